@@ -0,0 +1,458 @@
+package config
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	"modernc.org/sqlite"
+
+	"meu-provedor/engine/dialect"
+)
+
+// ============================================================================
+// CONNECTION MANAGER - Banco de dados por projeto
+// ============================================================================
+//
+// Por padrão as tabelas {code}_* de todos os projetos ficam no banco master.
+// Projetos com uma DSN cadastrada em project_databases usam um banco (ou
+// servidor) dedicado, com um pool *sql.DB aberto sob demanda e compartilhado
+// entre projetos que apontam para a mesma DSN. O formato da DSN define o
+// dialeto: MySQL (user:senha@tcp(host)/banco), PostgreSQL (postgres://...)
+// ou SQLite (sqlite:caminho/arquivo.db).
+//
+// Um pool que nenhum projeto usa mais não é fechado na hora: requisições em
+// andamento ainda podem estar com o *sql.DB. Ele fica aposentado por
+// PROJECT_POOL_RETIRE_SECONDS (no mínimo o dobro de QUERY_TIMEOUT_MS) e
+// volta a ser usado se algum projeto apontar de novo para a DSN.
+
+// projectConnTTL define por quanto tempo o roteamento de um projeto fica em cache
+const projectConnTTL = time.Minute
+
+type projectConn struct {
+	dsn    string // DSN normalizada; vazia = banco master
+	loaded time.Time
+}
+
+// retiredPool é um pool sem projetos aguardando o fechamento
+type retiredPool struct {
+	db        *sql.DB
+	retiredAt time.Time
+}
+
+var (
+	connMu       sync.Mutex
+	projectConns = map[int64]projectConn{}
+	projectPools = map[string]*sql.DB{}
+	retiredPools = map[string]retiredPool{}
+	catalogReady bool
+)
+
+// EnsureProjectDatabasesTable garante que a tabela project_databases existe
+func EnsureProjectDatabasesTable() error {
+	connMu.Lock()
+	ready := catalogReady
+	connMu.Unlock()
+	if ready {
+		return nil
+	}
+
+	_, err := MasterDB.Exec(`
+		CREATE TABLE IF NOT EXISTS project_databases (
+			project_id BIGINT UNSIGNED NOT NULL PRIMARY KEY,
+			dsn_encrypted TEXT NOT NULL,
+			updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+		)`)
+	if err != nil {
+		return fmt.Errorf("erro ao criar tabela project_databases: %w", err)
+	}
+
+	connMu.Lock()
+	catalogReady = true
+	connMu.Unlock()
+	return nil
+}
+
+// ProjectDB retorna o banco onde ficam as tabelas do projeto
+func ProjectDB(projectID int64) (*sql.DB, error) {
+	if MasterDB == nil {
+		return nil, fmt.Errorf("banco master não conectado")
+	}
+
+	dsn, err := projectDSN(projectID)
+	if err != nil {
+		return nil, err
+	}
+	if dsn == "" {
+		return MasterDB, nil
+	}
+	return projectPool(dsn)
+}
+
+// IsDedicated informa se o projeto usa um banco dedicado
+func IsDedicated(projectID int64) (bool, error) {
+	dsn, err := projectDSN(projectID)
+	return dsn != "", err
+}
+
+// DSNInfo descreve uma DSN sem a senha
+type DSNInfo struct {
+	Dialect  string
+	Host     string
+	Database string
+	User     string
+}
+
+// ProjectDSNInfo descreve o banco dedicado do projeto, ou nil quando o
+// projeto usa o banco master
+func ProjectDSNInfo(projectID int64) (*DSNInfo, error) {
+	dsn, err := projectDSN(projectID)
+	if err != nil || dsn == "" {
+		return nil, err
+	}
+	info := parseDSNInfo(dsn)
+	return &info, nil
+}
+
+// UsesDSN informa se o projeto já usa a DSN informada
+func UsesDSN(projectID int64, dsn string) (bool, error) {
+	normalized, err := NormalizeDSN(dsn)
+	if err != nil {
+		return false, err
+	}
+	current, err := projectDSN(projectID)
+	return current == normalized, err
+}
+
+// DialectOf retorna o dialeto SQL de um pool a partir do driver em uso
+func DialectOf(db *sql.DB) dialect.Dialect {
+	switch db.Driver().(type) {
+	case *pq.Driver:
+		return dialect.Postgres
+	case *sqlite.Driver:
+		return dialect.SQLite
+	default:
+		return dialect.MySQL
+	}
+}
+
+// SetProjectDSN grava (cifrada) a DSN dedicada do projeto
+func SetProjectDSN(projectID int64, dsn string) error {
+	if err := EnsureProjectDatabasesTable(); err != nil {
+		return err
+	}
+
+	normalized, err := NormalizeDSN(dsn)
+	if err != nil {
+		return err
+	}
+	if err := CheckProjectDSN(normalized); err != nil {
+		return err
+	}
+	encrypted, err := EncryptDSN(normalized)
+	if err != nil {
+		return err
+	}
+
+	_, err = MasterDB.Exec(`
+		INSERT INTO project_databases (project_id, dsn_encrypted)
+		VALUES (?, ?)
+		ON DUPLICATE KEY UPDATE dsn_encrypted = VALUES(dsn_encrypted)`,
+		projectID, encrypted,
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao gravar banco do projeto: %w", err)
+	}
+
+	ForgetProjectDB(projectID)
+	return bumpRoutingEpoch(projectID)
+}
+
+// RemoveProjectDSN volta o projeto para o banco master
+func RemoveProjectDSN(projectID int64) error {
+	if err := EnsureProjectDatabasesTable(); err != nil {
+		return err
+	}
+	if _, err := MasterDB.Exec(`DELETE FROM project_databases WHERE project_id = ?`, projectID); err != nil {
+		return fmt.Errorf("erro ao remover banco do projeto: %w", err)
+	}
+
+	ForgetProjectDB(projectID)
+	return bumpRoutingEpoch(projectID)
+}
+
+// RoutingCacheTTL é o tempo máximo em que um processo ainda lê pelo
+// roteamento anterior após uma troca de banco (escritas revalidam o epoch)
+func RoutingCacheTTL() time.Duration {
+	return projectConnTTL
+}
+
+// ForgetProjectDB descarta o roteamento em cache do projeto (sem efeito se
+// não houver) e aposenta pools que não são mais usados por nenhum projeto
+func ForgetProjectDB(projectID int64) {
+	connMu.Lock()
+	defer connMu.Unlock()
+
+	if _, ok := projectConns[projectID]; !ok {
+		return
+	}
+	delete(projectConns, projectID)
+	retireUnusedPools()
+}
+
+// NormalizeDSN valida a DSN e força as opções exigidas pelo engine
+func NormalizeDSN(dsn string) (string, error) {
+	dsn = strings.TrimSpace(dsn)
+	switch dialect.ForDSN(dsn) {
+	case dialect.Postgres:
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return "", fmt.Errorf("DSN inválida: %w", err)
+		}
+		if strings.Trim(u.Path, "/") == "" {
+			return "", fmt.Errorf("DSN inválida: banco de dados não informado")
+		}
+		return u.String(), nil
+	case dialect.SQLite:
+		if strings.TrimSpace(dsn[len("sqlite:"):]) == "" {
+			return "", fmt.Errorf("DSN inválida: arquivo do banco não informado")
+		}
+		return "sqlite:" + strings.TrimSpace(dsn[len("sqlite:"):]), nil
+	}
+
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return "", fmt.Errorf("DSN inválida: %w", err)
+	}
+	if cfg.DBName == "" {
+		return "", fmt.Errorf("DSN inválida: banco de dados não informado")
+	}
+	cfg.ParseTime = true
+	return cfg.FormatDSN(), nil
+}
+
+// OpenProjectDB abre e testa um pool para a DSN informada (restrita pela
+// política de destinos, ver CheckProjectDSN)
+func OpenProjectDB(dsn string) (*sql.DB, error) {
+	normalized, err := NormalizeDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckProjectDSN(normalized); err != nil {
+		return nil, err
+	}
+
+	d := dialect.ForDSN(normalized)
+	db, err := sql.Open(d.DriverName(), driverDSN(d, normalized))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir banco do projeto: %w", err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("erro ao pingar banco do projeto: %w", err)
+	}
+
+	db.SetMaxOpenConns(EnvPositiveInt("PROJECT_DB_MAX_OPEN_CONNS", 10))
+	db.SetMaxIdleConns(EnvPositiveInt("PROJECT_DB_MAX_IDLE_CONNS", 2))
+	db.SetConnMaxIdleTime(5 * time.Minute)
+	return db, nil
+}
+
+// closeProjectPools fecha todos os pools dedicados (chamado em CloseDB)
+func closeProjectPools() {
+	connMu.Lock()
+	defer connMu.Unlock()
+
+	for dsn, db := range projectPools {
+		db.Close()
+		delete(projectPools, dsn)
+	}
+	for dsn, retired := range retiredPools {
+		retired.db.Close()
+		delete(retiredPools, dsn)
+	}
+	projectConns = map[int64]projectConn{}
+}
+
+// ============================================================================
+// INTERNAL HELPERS
+// ============================================================================
+
+// projectDSN resolve (com cache) a DSN do projeto; vazia = banco master
+func projectDSN(projectID int64) (string, error) {
+	connMu.Lock()
+	entry, ok := projectConns[projectID]
+	connMu.Unlock()
+
+	if ok && time.Since(entry.loaded) < projectConnTTL {
+		return entry.dsn, nil
+	}
+
+	dsn, err := loadProjectDSN(projectID)
+	if err != nil {
+		return "", err
+	}
+
+	connMu.Lock()
+	projectConns[projectID] = projectConn{dsn: dsn, loaded: time.Now()}
+	if ok && entry.dsn != dsn {
+		retireUnusedPools()
+	}
+	connMu.Unlock()
+
+	return dsn, nil
+}
+
+// loadProjectDSN lê e decifra a DSN do catálogo master
+func loadProjectDSN(projectID int64) (string, error) {
+	if err := EnsureProjectDatabasesTable(); err != nil {
+		return "", err
+	}
+
+	var encrypted string
+	err := MasterDB.QueryRow(
+		`SELECT dsn_encrypted FROM project_databases WHERE project_id = ? LIMIT 1`,
+		projectID,
+	).Scan(&encrypted)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("erro ao buscar banco do projeto: %w", err)
+	}
+
+	dsn, err := DecryptDSN(encrypted)
+	if err != nil {
+		return "", err
+	}
+	return NormalizeDSN(dsn)
+}
+
+// projectPool retorna o pool da DSN (reaproveitando um aposentado), abrindo-o
+// fora do lock na primeira vez
+func projectPool(dsn string) (*sql.DB, error) {
+	connMu.Lock()
+	db, ok := projectPools[dsn]
+	if !ok {
+		if retired, found := retiredPools[dsn]; found {
+			delete(retiredPools, dsn)
+			projectPools[dsn] = retired.db
+			db, ok = retired.db, true
+		}
+	}
+	connMu.Unlock()
+	if ok {
+		return db, nil
+	}
+
+	opened, err := OpenProjectDB(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	connMu.Lock()
+	defer connMu.Unlock()
+	if db, ok := projectPools[dsn]; ok {
+		// Outra requisição abriu o pool primeiro
+		opened.Close()
+		return db, nil
+	}
+	projectPools[dsn] = opened
+	log.Println("✅ Pool dedicado aberto:", describeDSN(dsn))
+	return opened, nil
+}
+
+// retireUnusedPools aposenta pools sem projetos associados (connMu deve estar
+// travado). O pool é fechado após poolRetireGrace se não voltar a ser usado.
+func retireUnusedPools() {
+	used := map[string]bool{}
+	for _, entry := range projectConns {
+		used[entry.dsn] = true
+	}
+	grace := poolRetireGrace()
+	for dsn, db := range projectPools {
+		if used[dsn] {
+			continue
+		}
+		delete(projectPools, dsn)
+		retiredPools[dsn] = retiredPool{db: db, retiredAt: time.Now()}
+
+		dsn, db := dsn, db
+		time.AfterFunc(grace, func() {
+			connMu.Lock()
+			defer connMu.Unlock()
+
+			// Reaproveitado (ou aposentado de novo depois) nesse meio tempo
+			retired, ok := retiredPools[dsn]
+			if !ok || retired.db != db || time.Since(retired.retiredAt) < grace {
+				return
+			}
+			delete(retiredPools, dsn)
+			db.Close()
+			log.Println("🔒 Pool dedicado fechado:", describeDSN(dsn))
+		})
+	}
+}
+
+// poolRetireGrace é a espera antes de fechar um pool aposentado, maior que o
+// timeout das consultas para que as em andamento terminem
+func poolRetireGrace() time.Duration {
+	grace := time.Duration(EnvPositiveInt("PROJECT_POOL_RETIRE_SECONDS", 120)) * time.Second
+	if queries := 2 * time.Duration(EnvPositiveInt("QUERY_TIMEOUT_MS", 30000)) * time.Millisecond; grace < queries {
+		grace = queries
+	}
+	return grace
+}
+
+// driverDSN converte a DSN normalizada para o formato do driver. No SQLite as
+// FKs (ON DELETE CASCADE) precisam ser ligadas em cada conexão.
+func driverDSN(d dialect.Dialect, dsn string) string {
+	if d != dialect.SQLite {
+		return dsn
+	}
+	path := strings.TrimPrefix(dsn, "sqlite:")
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	return path + sep + "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)"
+}
+
+// parseDSNInfo extrai dialeto, host, banco e usuário de uma DSN normalizada
+func parseDSNInfo(dsn string) DSNInfo {
+	d := dialect.ForDSN(dsn)
+	info := DSNInfo{Dialect: d.Name()}
+
+	switch d {
+	case dialect.Postgres:
+		if u, err := url.Parse(dsn); err == nil {
+			info.Host = u.Host
+			info.Database = strings.Trim(u.Path, "/")
+			info.User = u.User.Username()
+		}
+	case dialect.SQLite:
+		info.Database = strings.TrimPrefix(dsn, "sqlite:")
+	default:
+		if cfg, err := mysql.ParseDSN(dsn); err == nil {
+			info.Host = cfg.Addr
+			info.Database = cfg.DBName
+			info.User = cfg.User
+		}
+	}
+	return info
+}
+
+// describeDSN omite a senha ao registrar a DSN em log
+func describeDSN(dsn string) string {
+	info := parseDSNInfo(dsn)
+	if info.Dialect == dialect.SQLite.Name() {
+		return "sqlite:" + info.Database
+	}
+	return fmt.Sprintf("%s@%s/%s", info.User, info.Host, info.Database)
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ============================================================================
+// DSN CRYPTO - Cifragem das DSNs guardadas no catálogo master
+// ============================================================================
+
+// dsnCipherPrefix identifica o formato do valor cifrado (permite rotação futura)
+const dsnCipherPrefix = "v1:"
+
+// dsnKey deriva a chave AES-256 de DSN_ENCRYPTION_KEY
+func dsnKey() ([]byte, error) {
+	secret := os.Getenv("DSN_ENCRYPTION_KEY")
+	if secret == "" {
+		return nil, fmt.Errorf("DSN_ENCRYPTION_KEY não configurada")
+	}
+	key := sha256.Sum256([]byte(secret))
+	return key[:], nil
+}
+
+// EncryptDSN cifra a DSN com AES-GCM: "v1:" + base64(nonce || ciphertext)
+func EncryptDSN(dsn string) (string, error) {
+	gcm, err := dsnCipher()
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", fmt.Errorf("erro ao gerar nonce: %w", err)
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(dsn), nil)
+	return dsnCipherPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptDSN decifra um valor gerado por EncryptDSN
+func DecryptDSN(encrypted string) (string, error) {
+	if !strings.HasPrefix(encrypted, dsnCipherPrefix) {
+		return "", fmt.Errorf("formato de DSN cifrada desconhecido")
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(encrypted, dsnCipherPrefix))
+	if err != nil {
+		return "", fmt.Errorf("DSN cifrada inválida: %w", err)
+	}
+
+	gcm, err := dsnCipher()
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", fmt.Errorf("DSN cifrada inválida")
+	}
+
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plain, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("erro ao decifrar DSN (chave incorreta?): %w", err)
+	}
+	return string(plain), nil
+}
+
+func dsnCipher() (cipher.AEAD, error) {
+	key, err := dsnKey()
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
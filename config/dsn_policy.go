@@ -0,0 +1,140 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+
+	"meu-provedor/engine/dialect"
+)
+
+// ============================================================================
+// DSN POLICY - Destinos permitidos para bancos dedicados
+// ============================================================================
+//
+// A DSN de um banco dedicado vem do cliente, então o destino é restrito antes
+// de qualquer conexão:
+//
+//   - SQLite: o arquivo precisa ficar dentro de PROJECT_SQLITE_DIR (sem o
+//     diretório configurado, SQLite é recusado)
+//   - MySQL/PostgreSQL: o host precisa constar em PROJECT_DB_ALLOWED_HOSTS,
+//     lista separada por vírgulas de hosts, host:porta ou faixas CIDR (CIDR
+//     vale apenas para hosts informados como IP). Sem a lista, nenhum host
+//     externo é aceito.
+
+// CheckProjectDSN valida o destino de uma DSN normalizada
+func CheckProjectDSN(dsn string) error {
+	switch dialect.ForDSN(dsn) {
+	case dialect.SQLite:
+		return checkSQLitePath(strings.TrimPrefix(dsn, "sqlite:"))
+
+	case dialect.Postgres:
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return fmt.Errorf("DSN inválida: %w", err)
+		}
+		// Parâmetros que trocariam o destino da conexão
+		for key := range u.Query() {
+			switch strings.ToLower(key) {
+			case "host", "hostaddr", "port", "service", "servicefile", "passfile", "sslrootcert", "sslcert", "sslkey":
+				return fmt.Errorf("DSN inválida: parâmetro '%s' não é permitido", key)
+			}
+		}
+		port := u.Port()
+		if port == "" {
+			port = "5432"
+		}
+		return checkDSNHost(u.Hostname(), port)
+	}
+
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return fmt.Errorf("DSN inválida: %w", err)
+	}
+	if cfg.Net != "tcp" {
+		return fmt.Errorf("DSN inválida: apenas conexões tcp são permitidas")
+	}
+	if cfg.AllowAllFiles {
+		return fmt.Errorf("DSN inválida: allowAllFiles não é permitido")
+	}
+	host, port, err := net.SplitHostPort(cfg.Addr)
+	if err != nil {
+		return fmt.Errorf("DSN inválida: %w", err)
+	}
+	return checkDSNHost(host, port)
+}
+
+// checkSQLitePath exige que o arquivo fique dentro de PROJECT_SQLITE_DIR
+func checkSQLitePath(path string) error {
+	root := strings.TrimSpace(os.Getenv("PROJECT_SQLITE_DIR"))
+	if root == "" {
+		return fmt.Errorf("DSN inválida: bancos SQLite não estão habilitados (PROJECT_SQLITE_DIR)")
+	}
+
+	path = strings.TrimPrefix(path, "file:")
+	if i := strings.Index(path, "?"); i >= 0 {
+		path = path[:i]
+	}
+	if path == "" || strings.HasPrefix(path, ":") {
+		return fmt.Errorf("DSN inválida: arquivo do banco não informado")
+	}
+	// Caminho relativo é aberto pelo driver a partir do diretório atual
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("DSN inválida: %w", err)
+	}
+
+	// Links simbólicos são resolvidos no diretório do arquivo (o arquivo pode
+	// ainda não existir)
+	dir, err := filepath.EvalSymlinks(filepath.Dir(filepath.Clean(path)))
+	if err != nil {
+		return fmt.Errorf("DSN inválida: diretório do banco inacessível")
+	}
+	rootDir, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return fmt.Errorf("DSN inválida: PROJECT_SQLITE_DIR inacessível")
+	}
+
+	rel, err := filepath.Rel(rootDir, filepath.Join(dir, filepath.Base(path)))
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("DSN inválida: o arquivo precisa ficar dentro de PROJECT_SQLITE_DIR")
+	}
+	return nil
+}
+
+// checkDSNHost exige o host (ou host:porta) em PROJECT_DB_ALLOWED_HOSTS
+func checkDSNHost(host, port string) error {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if host == "" {
+		return fmt.Errorf("DSN inválida: host não informado")
+	}
+	ip := net.ParseIP(host)
+
+	for _, entry := range strings.Split(os.Getenv("PROJECT_DB_ALLOWED_HOSTS"), ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			if ip != nil && network.Contains(ip) {
+				return nil
+			}
+			continue
+		}
+		if entryHost, entryPort, err := net.SplitHostPort(entry); err == nil {
+			if entryHost == host && entryPort == port {
+				return nil
+			}
+			continue
+		}
+		if strings.Trim(entry, "[]") == host {
+			return nil
+		}
+	}
+	return fmt.Errorf("DSN inválida: host '%s' não está em PROJECT_DB_ALLOWED_HOSTS", host)
+}
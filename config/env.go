@@ -1,40 +1,60 @@
-package config
-
-import (
-	"log"
-	"os"
-
-	"github.com/joho/godotenv"
-)
-
-// ============================================================================
-// ENVIRONMENT CONFIGURATION
-// ============================================================================
-
-// LoadEnv carrega variáveis de ambiente do arquivo .env
-func LoadEnv() {
-	err := godotenv.Load()
-	if err != nil {
-		log.Println("⚠️ Arquivo .env não encontrado, usando variáveis do sistema")
-	} else {
-		log.Println("✅ Variáveis de ambiente carregadas")
-	}
-}
-
-// GetEnv retorna valor de variável de ambiente obrigatória
-func GetEnv(key string) string {
-	value := os.Getenv(key)
-	if value == "" {
-		log.Fatalf("❌ ERRO: Variável de ambiente %s não definida", key)
-	}
-	return value
-}
-
-// GetEnvOrDefault retorna valor de variável ou default se não existir
-func GetEnvOrDefault(key, defaultValue string) string {
-	value := os.Getenv(key)
-	if value == "" {
-		return defaultValue
-	}
-	return value
-}
\ No newline at end of file
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/joho/godotenv"
+)
+
+// ============================================================================
+// ENVIRONMENT CONFIGURATION
+// ============================================================================
+
+// LoadEnv carrega variáveis de ambiente do arquivo .env
+func LoadEnv() {
+	err := godotenv.Load()
+	if err != nil {
+		log.Println("⚠️ Arquivo .env não encontrado, usando variáveis do sistema")
+	} else {
+		log.Println("✅ Variáveis de ambiente carregadas")
+	}
+}
+
+// GetEnv retorna valor de variável de ambiente obrigatória
+func GetEnv(key string) string {
+	value := os.Getenv(key)
+	if value == "" {
+		log.Fatalf("❌ ERRO: Variável de ambiente %s não definida", key)
+	}
+	return value
+}
+
+// GetEnvOrDefault retorna valor de variável ou default se não existir
+func GetEnvOrDefault(key, defaultValue string) string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
+
+// EnvInt retorna a variável como inteiro (default se ausente ou inválida).
+// Zero e negativos são aceitos: servem para desligar limites.
+func EnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(GetEnvOrDefault(key, strconv.Itoa(defaultValue)))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// EnvPositiveInt retorna a variável como inteiro positivo (default se
+// ausente, inválida ou <= 0), para intervalos e tamanhos que não podem zerar
+func EnvPositiveInt(key string, defaultValue int) int {
+	if value := EnvInt(key, defaultValue); value > 0 {
+		return value
+	}
+	return defaultValue
+}
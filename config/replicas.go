@@ -0,0 +1,245 @@
+package config
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"meu-provedor/engine/dialect"
+	"meu-provedor/models"
+)
+
+// ============================================================================
+// READ REPLICAS - Leituras do banco master em réplicas saudáveis
+// ============================================================================
+//
+// MYSQL_REPLICA_DSNS lista (separadas por vírgula) as réplicas do banco master.
+// SELECTs e agregações de projetos no banco master vão para as réplicas em
+// round-robin; escritas, transações e projetos com banco dedicado continuam
+// no primário. Uma réplica sai da rotação quando não responde ao ping, quando
+// a replicação está parada ou quando o atraso passa de REPLICA_MAX_LAG_SECONDS.
+//
+// Atraso desconhecido (sem privilégio REPLICATION CLIENT ou servidor sem
+// status de réplica) também tira a réplica da rotação, a menos que
+// REPLICA_ALLOW_UNKNOWN_LAG=true (ex.: endpoints de leitura gerenciados),
+// quando basta responder ao ping.
+
+// Níveis de consistência aceitos nas leituras
+const (
+	ConsistencyEventual = "eventual" // padrão: réplicas, se houver
+	ConsistencyStrong   = "strong"   // sempre o primário
+)
+
+type replica struct {
+	name    string // user@host/db (sem senha)
+	db      *sql.DB
+	healthy atomic.Bool
+	lag     atomic.Int64 // segundos; -1 = desconhecido
+}
+
+var (
+	replicasMu  sync.RWMutex
+	replicas    []*replica
+	replicaNext atomic.Uint64
+)
+
+// ConnectReplicas abre as réplicas configuradas e inicia a checagem de saúde
+func ConnectReplicas() error {
+	raw := strings.TrimSpace(GetEnvOrDefault("MYSQL_REPLICA_DSNS", ""))
+	if raw == "" {
+		return nil
+	}
+
+	var opened []*replica
+	for _, dsn := range strings.Split(raw, ",") {
+		dsn = strings.TrimSpace(dsn)
+		if dsn == "" {
+			continue
+		}
+		if dialect.ForDSN(dsn) != dialect.MySQL {
+			return fmt.Errorf("réplica: apenas réplicas MySQL do banco master são suportadas")
+		}
+		normalized, err := NormalizeDSN(dsn)
+		if err != nil {
+			return fmt.Errorf("réplica: %w", err)
+		}
+		db, err := sql.Open("mysql", normalized)
+		if err != nil {
+			return fmt.Errorf("erro ao abrir réplica %s: %w", describeDSN(normalized), err)
+		}
+		db.SetMaxOpenConns(25)
+		db.SetMaxIdleConns(5)
+
+		r := &replica{name: describeDSN(normalized), db: db}
+		r.lag.Store(-1)
+		opened = append(opened, r)
+	}
+
+	replicasMu.Lock()
+	replicas = opened
+	replicasMu.Unlock()
+
+	// Primeira checagem síncrona: nenhuma leitura vai para réplica não verificada
+	checkReplicas()
+	go func() {
+		ticker := time.NewTicker(replicaCheckInterval())
+		defer ticker.Stop()
+		for range ticker.C {
+			checkReplicas()
+		}
+	}()
+
+	log.Printf("✅ %d réplica(s) de leitura configurada(s)", len(opened))
+	return nil
+}
+
+// ReadDB retorna o banco para uma leitura do projeto: uma réplica saudável
+// (consistência eventual) ou o banco do projeto (strong / sem réplicas)
+func ReadDB(projectID int64, consistency string) (*sql.DB, error) {
+	switch consistency {
+	case "", ConsistencyEventual:
+	case ConsistencyStrong:
+		return ProjectDB(projectID)
+	default:
+		return nil, fmt.Errorf("%w: %s", models.ErrInvalidConsistency, consistency)
+	}
+
+	// As réplicas são do banco master; bancos dedicados leem do próprio banco
+	dedicated, err := IsDedicated(projectID)
+	if err != nil {
+		return nil, err
+	}
+	if !dedicated {
+		if db := nextReplica(); db != nil {
+			return db, nil
+		}
+	}
+	return ProjectDB(projectID)
+}
+
+// closeReplicas fecha as réplicas (chamado em CloseDB)
+func closeReplicas() {
+	replicasMu.Lock()
+	defer replicasMu.Unlock()
+
+	for _, r := range replicas {
+		r.db.Close()
+	}
+	replicas = nil
+}
+
+// ============================================================================
+// INTERNAL HELPERS
+// ============================================================================
+
+// nextReplica escolhe a próxima réplica saudável em round-robin (nil se nenhuma)
+func nextReplica() *sql.DB {
+	replicasMu.RLock()
+	defer replicasMu.RUnlock()
+
+	n := len(replicas)
+	if n == 0 {
+		return nil
+	}
+	start := replicaNext.Add(1)
+	for i := 0; i < n; i++ {
+		r := replicas[(start+uint64(i))%uint64(n)]
+		if r.healthy.Load() {
+			return r.db
+		}
+	}
+	return nil
+}
+
+// checkReplicas atualiza saúde e atraso de todas as réplicas
+func checkReplicas() {
+	replicasMu.RLock()
+	current := append([]*replica{}, replicas...)
+	replicasMu.RUnlock()
+
+	maxLag := int64(replicaMaxLag())
+	for _, r := range current {
+		lag, err := replicationLag(r.db)
+		healthy := err == nil && lag >= 0 && lag <= maxLag
+
+		if healthy != r.healthy.Load() {
+			if healthy {
+				log.Printf("✅ Réplica %s voltou à rotação (atraso %ds)", r.name, lag)
+			} else {
+				log.Printf("⚠️ Réplica %s fora da rotação (atraso %ds, erro: %v)", r.name, lag, err)
+			}
+		}
+		r.lag.Store(lag)
+		r.healthy.Store(healthy)
+	}
+}
+
+// replicationLag lê Seconds_Behind_Source (MySQL 8.0.22+) ou
+// Seconds_Behind_Master. Retorna -1 quando a replicação está parada ou o
+// atraso não pode ser lido (ver unknownLag).
+func replicationLag(db *sql.DB) (int64, error) {
+	if err := db.Ping(); err != nil {
+		return -1, err
+	}
+
+	rows, err := db.Query("SHOW REPLICA STATUS")
+	if err != nil {
+		rows, err = db.Query("SHOW SLAVE STATUS")
+	}
+	if err != nil {
+		return unknownLag(fmt.Errorf("status de réplica indisponível: %w", err))
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return -1, err
+	}
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return -1, err
+		}
+		return unknownLag(fmt.Errorf("servidor sem status de réplica"))
+	}
+
+	values := make([]sql.RawBytes, len(cols))
+	ptrs := make([]interface{}, len(cols))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	if err := rows.Scan(ptrs...); err != nil {
+		return -1, err
+	}
+
+	for i, col := range cols {
+		if col == "Seconds_Behind_Source" || col == "Seconds_Behind_Master" {
+			if values[i] == nil {
+				return -1, fmt.Errorf("replicação parada")
+			}
+			return strconv.ParseInt(string(values[i]), 10, 64)
+		}
+	}
+	return unknownLag(fmt.Errorf("status de réplica sem atraso"))
+}
+
+// unknownLag trata um atraso que não pôde ser lido: erro (réplica fora da
+// rotação) ou, com REPLICA_ALLOW_UNKNOWN_LAG=true, atraso zero
+func unknownLag(reason error) (int64, error) {
+	if strings.ToLower(GetEnvOrDefault("REPLICA_ALLOW_UNKNOWN_LAG", "false")) == "true" {
+		return 0, nil
+	}
+	return -1, reason
+}
+
+func replicaMaxLag() int {
+	return EnvPositiveInt("REPLICA_MAX_LAG_SECONDS", 5)
+}
+
+func replicaCheckInterval() time.Duration {
+	return time.Duration(EnvPositiveInt("REPLICA_CHECK_SECONDS", 5)) * time.Second
+}
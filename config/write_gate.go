@@ -0,0 +1,587 @@
+package config
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"meu-provedor/models"
+)
+
+// ============================================================================
+// WRITE GATE - Congelamento das escritas de um projeto
+// ============================================================================
+//
+// Toda escrita nas tabelas de um projeto passa pelo gate. Durante uma troca
+// de banco (migração de tenancy) o gate é congelado: novas escritas esperam
+// até PROJECT_FREEZE_WAIT_SECONDS pelo descongelamento e então falham com 503.
+//
+// O estado compartilhado fica no catálogo master, então vale para todos os
+// processos:
+//
+//   - frozen_until: congelamento com prazo (um migrador que cai não deixa o
+//     projeto congelado para sempre)
+//   - routing_epoch: incrementado a cada troca de banco; o processo que vê
+//     um epoch novo descarta o roteamento em cache
+//   - tracking: enquanto ligado, as escritas marcam as tabelas alteradas em
+//     project_write_marks (antes da escrita e após o commit)
+//   - project_write_leases: o processo com escritas em andamento no projeto
+//     mantém uma lease, renovada a cada PROJECT_GATE_REFRESH_MS e válida por
+//     PROJECT_WRITE_LEASE_SECONDS. O congelamento espera não restar lease
+//     válida, então escritas admitidas em outros processos terminam antes
+//     da troca de banco.
+//
+// Enquanto a lease vale, o processo admite escritas e decide as marcações
+// pelo estado lido na última renovação, sem ir ao catálogo a cada escrita.
+// A lease é publicada antes de o gate ser relido: ou a escrita vê o
+// congelamento, ou o congelamento vê a lease.
+
+type writeGate struct {
+	active   int
+	frozen   chan struct{} // fechado ao descongelar; nil = livre
+	leased   bool          // lease publicada no catálogo
+	renewed  time.Time     // última renovação da lease (e leitura do estado)
+	shared   sharedGate    // estado lido na última renovação
+	lastDone time.Time
+	kick     chan struct{} // acorda a renovação quando as escritas terminam
+	leaseMu  sync.Mutex    // serializa publicação e remoção da lease
+}
+
+// sharedGate é o estado do gate no catálogo master
+type sharedGate struct {
+	frozen   bool
+	tracking bool
+	epoch    int64
+}
+
+// freezePoll é o intervalo de espera por um congelamento de outro processo
+const freezePoll = 50 * time.Millisecond
+
+var (
+	gatesMu sync.Mutex
+	gates   = map[int64]*writeGate{}
+	// epochs guarda o último routing_epoch visto por este processo
+	epochs         = map[int64]int64{}
+	gateTableReady bool
+
+	// processID identifica as leases deste processo
+	processID = newProcessID()
+)
+
+// EnsureWriteGateTables garante as tabelas project_write_gates,
+// project_write_marks e project_write_leases
+func EnsureWriteGateTables() error {
+	gatesMu.Lock()
+	ready := gateTableReady
+	gatesMu.Unlock()
+	if ready {
+		return nil
+	}
+
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS project_write_gates (
+			project_id BIGINT UNSIGNED NOT NULL PRIMARY KEY,
+			frozen_until DATETIME(6) NULL,
+			tracking TINYINT(1) NOT NULL DEFAULT 0,
+			routing_epoch BIGINT NOT NULL DEFAULT 0
+		)`,
+		`CREATE TABLE IF NOT EXISTS project_write_marks (
+			project_id BIGINT UNSIGNED NOT NULL,
+			table_name VARCHAR(128) NOT NULL,
+			version BIGINT NOT NULL DEFAULT 1,
+			PRIMARY KEY (project_id, table_name)
+		)`,
+		`CREATE TABLE IF NOT EXISTS project_write_leases (
+			project_id BIGINT UNSIGNED NOT NULL,
+			process_id VARCHAR(32) NOT NULL,
+			expires_at DATETIME(6) NOT NULL,
+			PRIMARY KEY (project_id, process_id)
+		)`,
+	}
+	for _, stmt := range statements {
+		if _, err := MasterDB.Exec(stmt); err != nil {
+			return fmt.Errorf("erro ao criar tabelas do write gate: %w", err)
+		}
+	}
+
+	gatesMu.Lock()
+	gateTableReady = true
+	gatesMu.Unlock()
+	return nil
+}
+
+// BeginProjectWrite registra uma escrita em andamento. A função retornada
+// deve ser chamada ao fim da escrita (após o commit).
+func BeginProjectWrite(projectID int64) (func(), error) {
+	deadline := time.Now().Add(freezeWait())
+
+	for {
+		gatesMu.Lock()
+		g := projectGate(projectID)
+		if g.frozen == nil && g.fresh() && !g.shared.frozen {
+			g.active++
+			gatesMu.Unlock()
+			return g.doneFunc(), nil
+		}
+		wait := g.frozen
+		gatesMu.Unlock()
+
+		if wait == nil {
+			// Sem lease válida: publica a lease e relê o gate no catálogo
+			admitted, err := g.acquire(projectID)
+			if err != nil {
+				return nil, err
+			}
+			if admitted {
+				return g.doneFunc(), nil
+			}
+		}
+
+		if time.Now().After(deadline) {
+			return nil, models.ErrWritesFrozen
+		}
+		if wait == nil {
+			// Congelado por outro processo: consulta o catálogo de novo
+			wait = make(chan struct{})
+		}
+		select {
+		case <-wait:
+		case <-time.After(minDuration(freezePoll, time.Until(deadline))):
+		}
+	}
+}
+
+// WriteGateStaleness é o tempo máximo em que um processo ainda decide pelo
+// estado do gate em cache (congelamento, rastreamento) após uma mudança
+func WriteGateStaleness() time.Duration {
+	return writeLease() / 2
+}
+
+// FreezeProjectWrites bloqueia novas escritas em todos os processos e espera
+// as em andamento terminarem: enquanto algum processo mantém lease válida no
+// projeto, há escrita admitida antes do congelamento. O congelamento expira
+// em PROJECT_FREEZE_LEASE_SECONDS se não for renovado. Em caso de timeout o
+// gate é liberado e um erro é retornado.
+func FreezeProjectWrites(projectID int64, timeout time.Duration) error {
+	if err := EnsureWriteGateTables(); err != nil {
+		return err
+	}
+
+	gatesMu.Lock()
+	g := projectGate(projectID)
+	if g.frozen != nil {
+		gatesMu.Unlock()
+		return fmt.Errorf("escritas do projeto %d já estão congeladas", projectID)
+	}
+	g.frozen = make(chan struct{})
+	gatesMu.Unlock()
+
+	_, err := MasterDB.Exec(`
+		INSERT INTO project_write_gates (project_id, frozen_until)
+		VALUES (?, NOW(6) + INTERVAL ? SECOND)
+		ON DUPLICATE KEY UPDATE frozen_until = VALUES(frozen_until)`,
+		projectID, freezeLeaseSeconds(),
+	)
+	if err != nil {
+		UnfreezeProjectWrites(projectID)
+		return fmt.Errorf("erro ao congelar escritas do projeto: %w", err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		// A lease deste processo é liberada assim que as escritas terminam
+		g.wake()
+
+		var leases int
+		err := MasterDB.QueryRow(
+			`SELECT COUNT(*) FROM project_write_leases WHERE project_id = ? AND expires_at > NOW(6)`,
+			projectID,
+		).Scan(&leases)
+		if err != nil {
+			UnfreezeProjectWrites(projectID)
+			return fmt.Errorf("erro ao verificar escritas em andamento: %w", err)
+		}
+		if leases == 0 {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			UnfreezeProjectWrites(projectID)
+			return fmt.Errorf("timeout aguardando escritas em andamento em %d processo(s)", leases)
+		}
+		time.Sleep(freezePoll)
+	}
+}
+
+// RenewProjectFreeze estende o prazo do congelamento. Falha se o prazo já
+// expirou (escritas podem ter sido liberadas nos outros processos).
+func RenewProjectFreeze(projectID int64) error {
+	result, err := MasterDB.Exec(`
+		UPDATE project_write_gates
+		SET frozen_until = NOW(6) + INTERVAL ? SECOND
+		WHERE project_id = ? AND frozen_until > NOW(6)`,
+		freezeLeaseSeconds(), projectID,
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao renovar congelamento do projeto: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("o congelamento do projeto %d expirou", projectID)
+	}
+	return nil
+}
+
+// UnfreezeProjectWrites libera as escritas do projeto
+func UnfreezeProjectWrites(projectID int64) {
+	if err := EnsureWriteGateTables(); err == nil {
+		MasterDB.Exec(`UPDATE project_write_gates SET frozen_until = NULL WHERE project_id = ?`, projectID)
+	}
+
+	gatesMu.Lock()
+	defer gatesMu.Unlock()
+
+	g := projectGate(projectID)
+	if g.frozen != nil {
+		close(g.frozen)
+		g.frozen = nil
+	}
+}
+
+// TrackProjectWrites liga ou desliga a marcação das tabelas escritas. Ao
+// desligar, as marcas pendentes são descartadas.
+func TrackProjectWrites(projectID int64, enabled bool) error {
+	if err := EnsureWriteGateTables(); err != nil {
+		return err
+	}
+
+	_, err := MasterDB.Exec(`
+		INSERT INTO project_write_gates (project_id, tracking)
+		VALUES (?, ?)
+		ON DUPLICATE KEY UPDATE tracking = VALUES(tracking)`,
+		projectID, enabled,
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao atualizar rastreamento de escritas: %w", err)
+	}
+	if !enabled {
+		_, err = MasterDB.Exec(`DELETE FROM project_write_marks WHERE project_id = ?`, projectID)
+	}
+	return err
+}
+
+// MarkTableWrite marca a tabela física como escrita quando o projeto está
+// sendo rastreado. O estado é o da última renovação da lease (no máximo
+// WriteGateStaleness): uma escrita admitida antes do rastreamento ligar
+// ainda é marcada ao terminar.
+func MarkTableWrite(projectID int64, fullTable string) error {
+	shared, err := gateState(projectID)
+	if err != nil {
+		return err
+	}
+	if !shared.tracking {
+		return nil
+	}
+
+	_, err = MasterDB.Exec(`
+		INSERT INTO project_write_marks (project_id, table_name)
+		VALUES (?, ?)
+		ON DUPLICATE KEY UPDATE version = version + 1`,
+		projectID, fullTable,
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao marcar escrita em %s: %w", fullTable, err)
+	}
+	return nil
+}
+
+// ConsumeTableWrites retorna as tabelas marcadas desde a última chamada. Uma
+// marca só é removida se não foi incrementada depois de lida.
+func ConsumeTableWrites(projectID int64) ([]string, error) {
+	if err := EnsureWriteGateTables(); err != nil {
+		return nil, err
+	}
+
+	rows, err := MasterDB.Query(
+		`SELECT table_name, version FROM project_write_marks WHERE project_id = ?`, projectID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler escritas marcadas: %w", err)
+	}
+
+	versions := map[string]int64{}
+	for rows.Next() {
+		var table string
+		var version int64
+		if err := rows.Scan(&table, &version); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		versions[table] = version
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	tables := make([]string, 0, len(versions))
+	for table, version := range versions {
+		if _, err := MasterDB.Exec(
+			`DELETE FROM project_write_marks WHERE project_id = ? AND table_name = ? AND version = ?`,
+			projectID, table, version,
+		); err != nil {
+			return nil, fmt.Errorf("erro ao consumir escritas marcadas: %w", err)
+		}
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
+
+// bumpRoutingEpoch avisa os demais processos de que o banco do projeto mudou
+func bumpRoutingEpoch(projectID int64) error {
+	if err := EnsureWriteGateTables(); err != nil {
+		return err
+	}
+	_, err := MasterDB.Exec(`
+		INSERT INTO project_write_gates (project_id, routing_epoch)
+		VALUES (?, 1)
+		ON DUPLICATE KEY UPDATE routing_epoch = routing_epoch + 1`,
+		projectID,
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao publicar troca de banco do projeto: %w", err)
+	}
+	return nil
+}
+
+// loadSharedGate lê o estado do gate no catálogo master
+func loadSharedGate(projectID int64) (sharedGate, error) {
+	if err := EnsureWriteGateTables(); err != nil {
+		return sharedGate{}, err
+	}
+
+	var g sharedGate
+	err := MasterDB.QueryRow(`
+		SELECT COALESCE(frozen_until > NOW(6), 0), tracking, routing_epoch
+		FROM project_write_gates WHERE project_id = ?`, projectID,
+	).Scan(&g.frozen, &g.tracking, &g.epoch)
+	if err == sql.ErrNoRows {
+		return sharedGate{}, nil
+	}
+	if err != nil {
+		return sharedGate{}, fmt.Errorf("%w: %v", models.ErrDatabaseConnection, err)
+	}
+	return g, nil
+}
+
+// projectGate retorna o gate do projeto (gatesMu deve estar travado)
+func projectGate(projectID int64) *writeGate {
+	g, ok := gates[projectID]
+	if !ok {
+		g = &writeGate{kick: make(chan struct{}, 1)}
+		gates[projectID] = g
+	}
+	return g
+}
+
+// gateState retorna o estado do gate: o da última renovação enquanto a lease
+// do processo vale, senão o do catálogo
+func gateState(projectID int64) (sharedGate, error) {
+	gatesMu.Lock()
+	g := projectGate(projectID)
+	fresh, shared := g.fresh(), g.shared
+	gatesMu.Unlock()
+
+	if fresh {
+		return shared, nil
+	}
+	return loadSharedGate(projectID)
+}
+
+// fresh indica se a lease vale e o estado em cache pode ser usado (gatesMu
+// deve estar travado). A margem cobre renovações que falharam.
+func (g *writeGate) fresh() bool {
+	return g.leased && time.Since(g.renewed) < WriteGateStaleness()
+}
+
+// acquire publica a lease do processo e relê o gate. Com o gate livre, a
+// escrita é admitida (active já incrementado).
+func (g *writeGate) acquire(projectID int64) (bool, error) {
+	// Sem publicar lease enquanto congelado: o congelamento espera por elas
+	shared, err := loadSharedGate(projectID)
+	if err != nil || shared.frozen {
+		return false, err
+	}
+
+	g.leaseMu.Lock()
+	defer g.leaseMu.Unlock()
+
+	if err := renewLease(projectID); err != nil {
+		return false, err
+	}
+	if shared, err = loadSharedGate(projectID); err != nil {
+		return false, err
+	}
+
+	gatesMu.Lock()
+	stale := g.observe(projectID, shared)
+	if !g.leased {
+		g.leased = true
+		g.lastDone = time.Now()
+		go g.keepLease(projectID)
+	}
+	admitted := g.frozen == nil && !shared.frozen
+	if admitted {
+		g.active++
+	}
+	gatesMu.Unlock()
+
+	if stale {
+		ForgetProjectDB(projectID)
+	}
+	return admitted, nil
+}
+
+// observe guarda o estado lido do catálogo (gatesMu deve estar travado) e
+// indica se o roteamento em cache ficou velho (banco trocado por outro processo)
+func (g *writeGate) observe(projectID int64, shared sharedGate) bool {
+	g.shared = shared
+	g.renewed = time.Now()
+
+	previous, seen := epochs[projectID]
+	epochs[projectID] = shared.epoch
+	return !seen || previous != shared.epoch
+}
+
+// keepLease renova a lease e o estado do gate enquanto houver escritas no
+// projeto e remove a lease quando o processo fica ocioso (imediatamente se
+// o gate estiver congelado)
+func (g *writeGate) keepLease(projectID int64) {
+	ticker := time.NewTicker(gateRefresh())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-g.kick:
+		}
+
+		g.leaseMu.Lock()
+		gatesMu.Lock()
+		idle := g.active == 0 &&
+			(g.frozen != nil || g.shared.frozen || time.Since(g.lastDone) >= gateRefresh())
+		if idle {
+			g.leased = false
+		}
+		gatesMu.Unlock()
+
+		if idle {
+			// Se falhar, a lease expira sozinha
+			if _, err := MasterDB.Exec(
+				`DELETE FROM project_write_leases WHERE project_id = ? AND process_id = ?`, projectID, processID,
+			); err != nil {
+				log.Printf("⚠️ Erro ao liberar lease de escrita do projeto %d: %v", projectID, err)
+			}
+			g.leaseMu.Unlock()
+			return
+		}
+
+		err := renewLease(projectID)
+		var shared sharedGate
+		if err == nil {
+			shared, err = loadSharedGate(projectID)
+		}
+		g.leaseMu.Unlock()
+
+		if err != nil {
+			log.Printf("⚠️ Erro ao renovar lease de escrita do projeto %d: %v", projectID, err)
+			continue
+		}
+		gatesMu.Lock()
+		stale := g.observe(projectID, shared)
+		gatesMu.Unlock()
+		if stale {
+			ForgetProjectDB(projectID)
+		}
+	}
+}
+
+// doneFunc encerra uma escrita admitida
+func (g *writeGate) doneFunc() func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			gatesMu.Lock()
+			g.active--
+			g.lastDone = time.Now()
+			frozen := g.active == 0 && (g.frozen != nil || g.shared.frozen)
+			gatesMu.Unlock()
+
+			if frozen {
+				g.wake()
+			}
+		})
+	}
+}
+
+// wake acorda a renovação da lease sem esperar o próximo ciclo
+func (g *writeGate) wake() {
+	select {
+	case g.kick <- struct{}{}:
+	default:
+	}
+}
+
+// renewLease publica (ou estende) a lease deste processo no projeto
+func renewLease(projectID int64) error {
+	if err := EnsureWriteGateTables(); err != nil {
+		return err
+	}
+	_, err := MasterDB.Exec(`
+		INSERT INTO project_write_leases (project_id, process_id, expires_at)
+		VALUES (?, ?, NOW(6) + INTERVAL ? SECOND)
+		ON DUPLICATE KEY UPDATE expires_at = VALUES(expires_at)`,
+		projectID, processID, int(writeLease()/time.Second),
+	)
+	if err != nil {
+		return fmt.Errorf("%w: %v", models.ErrDatabaseConnection, err)
+	}
+	return nil
+}
+
+func newProcessID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func freezeWait() time.Duration {
+	return time.Duration(EnvPositiveInt("PROJECT_FREEZE_WAIT_SECONDS", 10)) * time.Second
+}
+
+// gateRefresh é o intervalo de renovação da lease e releitura do gate
+func gateRefresh() time.Duration {
+	return time.Duration(EnvPositiveInt("PROJECT_GATE_REFRESH_MS", 1000)) * time.Millisecond
+}
+
+// writeLease é a validade da lease sem renovação (no mínimo 4 renovações)
+func writeLease() time.Duration {
+	lease := time.Duration(EnvPositiveInt("PROJECT_WRITE_LEASE_SECONDS", 15)) * time.Second
+	if lease < 4*gateRefresh() {
+		lease = 4 * gateRefresh()
+	}
+	return lease
+}
+
+func freezeLeaseSeconds() int {
+	return EnvPositiveInt("PROJECT_FREEZE_LEASE_SECONDS", 60)
+}
+
+func minDuration(a, b time.Duration) time.Duration {
+	if a < b {
+		return a
+	}
+	return b
+}
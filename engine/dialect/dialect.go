@@ -0,0 +1,330 @@
+package dialect
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"meu-provedor/models"
+)
+
+// ============================================================================
+// DIALECT - Diferenças de SQL entre MySQL, PostgreSQL e SQLite
+// ============================================================================
+//
+// Os builders de engine/query e os serviços de tabelas geram SQL através do
+// dialeto do banco do projeto: placeholders, delimitação de identificadores,
+// paginação, tipos de DDL, upsert e introspecção do catálogo. Recursos sem
+// equivalente portátil (JSON_SET, MATCH ... AGAINST, FULLTEXT) continuam
+// exclusivos do MySQL e falham no banco quando usados em outro dialeto.
+
+// Queryer é satisfeito por *sql.DB, *sql.Tx e *sql.Conn
+type Queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// Index descreve um índice criado junto com a tabela
+type Index struct {
+	Name    string
+	Type    string // INDEX, UNIQUE ou FULLTEXT
+	Columns []string
+}
+
+// Dialect encapsula o SQL específico de um banco
+type Dialect interface {
+	// Name identifica o dialeto (mysql, postgres, sqlite)
+	Name() string
+	// DriverName é o driver database/sql usado nas conexões
+	DriverName() string
+
+	// Placeholder renderiza o n-ésimo parâmetro (começando em 1)
+	Placeholder(n int) string
+	// QuoteIdent delimita um identificador
+	QuoteIdent(name string) string
+	// LimitOffset renderiza a paginação com espaço inicial ("" sem limite)
+	LimitOffset(limit, offset int) string
+	// ForUpdate renderiza o lock de linhas de um SELECT ("" se não houver)
+	ForUpdate() string
+	// ExecutionTimeHint renderiza, com espaço final, o hint que limita o tempo
+	// de execução de um SELECT no servidor ("" sem limite ou sem suporte)
+	ExecutionTimeHint(ms int) string
+	// Literal renderiza um valor como literal SQL, para comandos que não
+	// aceitam parâmetros (ex.: o SELECT de um CREATE VIEW)
+	Literal(v interface{}) (string, error)
+
+	// AutoIncrementPK renderiza a coluna de chave primária autoincremental
+	AutoIncrementPK(column string) string
+	// BigIntType é o tipo inteiro de 64 bits de ids e versões
+	BigIntType() string
+	// DateTimeType é o tipo de data/hora sem fuso
+	DateTimeType() string
+	// JSONType é o tipo de documentos JSON
+	JSONType() string
+	// TimestampColumn renderiza uma coluna NOT NULL com default "agora".
+	// fsp é a precisão de frações de segundo; onUpdate só existe no MySQL.
+	TimestampColumn(column string, fsp int, onUpdate bool) string
+	// ForeignKeyCascade renderiza a constraint FK com ON DELETE CASCADE
+	ForeignKeyCascade(column, refTable, refColumn string) string
+	// CreateTable renderiza o CREATE TABLE e os índices (um ou mais comandos)
+	CreateTable(table string, ifNotExists bool, definitions []string, indexes []Index) ([]string, error)
+	// CreateIndex renderiza a criação de um índice em tabela existente
+	CreateIndex(table string, idx Index) (string, error)
+	// DropIndex renderiza a remoção de um índice
+	DropIndex(table, name string) string
+	// ModifyColumn renderiza a troca de tipo/nulidade de uma coluna
+	ModifyColumn(table, column, colType string, nullable bool) ([]string, error)
+
+	// InsertIgnore renderiza um INSERT de uma linha que ignora duplicatas
+	InsertIgnore(table string, columns []string) string
+	// Upsert renderiza um INSERT de uma linha que atualiza updates quando a
+	// chave (keys) já existe
+	Upsert(table string, columns, keys, updates []string) string
+	// Returning renderiza " RETURNING coluna" nos bancos que devolvem os ids
+	// de um INSERT multi-row ("" no MySQL, onde se usa LastInsertId por linha)
+	Returning(column string) string
+	// SyncSequence renderiza o ajuste do gerador de ids depois de um INSERT
+	// com id explícito ("" onde o banco já acompanha o maior id)
+	SyncSequence(table, column string) string
+
+	// Tables lista as tabelas (sem views) do banco com o prefixo informado
+	Tables(db Queryer, prefix string) ([]string, error)
+	// Views lista as views do banco com o prefixo informado
+	Views(db Queryer, prefix string) ([]string, error)
+	// Columns lê as colunas de uma tabela no formato do information_schema do MySQL
+	Columns(db Queryer, table string) ([]models.ColumnDetail, error)
+	// Indexes lê os índices de uma tabela
+	Indexes(db Queryer, table string) ([]models.IndexDetail, error)
+
+	// TranslateError normaliza um erro do driver deste dialeto (nil quando
+	// o erro é de outro driver ou não vem do banco)
+	TranslateError(err error) *DBError
+}
+
+// Dialetos disponíveis
+var (
+	MySQL    Dialect = mysqlDialect{}
+	Postgres Dialect = postgresDialect{}
+	SQLite   Dialect = sqliteDialect{}
+)
+
+// ForDSN identifica o dialeto pelo formato da DSN:
+// postgres://... ou postgresql://... (PostgreSQL), sqlite:arquivo.db (SQLite)
+// e user:senha@tcp(host)/banco (MySQL, padrão)
+func ForDSN(dsn string) Dialect {
+	lower := strings.ToLower(strings.TrimSpace(dsn))
+	switch {
+	case strings.HasPrefix(lower, "postgres://"), strings.HasPrefix(lower, "postgresql://"):
+		return Postgres
+	case strings.HasPrefix(lower, "sqlite:"):
+		return SQLite
+	default:
+		return MySQL
+	}
+}
+
+// OrDefault retorna d, ou MySQL quando d é nil
+func OrDefault(d Dialect) Dialect {
+	if d == nil {
+		return MySQL
+	}
+	return d
+}
+
+// Rebind adapta uma query escrita na forma do MySQL ao dialeto: troca os
+// placeholders "?" pelos do dialeto e os identificadores entre crases pelo
+// delimitador próprio. Literais entre aspas simples e duplas são preservados.
+func Rebind(d Dialect, query string) string {
+	d = OrDefault(d)
+	rebindPlaceholders := d.Placeholder(1) != "?" && strings.Contains(query, "?")
+	rebindIdents := d.QuoteIdent("x") != "`x`" && strings.Contains(query, "`")
+	if !rebindPlaceholders && !rebindIdents {
+		return query
+	}
+
+	var b strings.Builder
+	b.Grow(len(query) + 8)
+
+	n := 0
+	var quote rune
+	var ident strings.Builder
+	runes := []rune(query)
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		switch {
+		case quote == '`':
+			if c != '`' {
+				ident.WriteRune(c)
+				continue
+			}
+			// crase duplicada é uma crase literal dentro do identificador
+			if i+1 < len(runes) && runes[i+1] == '`' {
+				ident.WriteRune(c)
+				i++
+				continue
+			}
+			quote = 0
+			if rebindIdents {
+				b.WriteString(d.QuoteIdent(ident.String()))
+			} else {
+				b.WriteString("`" + strings.ReplaceAll(ident.String(), "`", "``") + "`")
+			}
+			ident.Reset()
+			continue
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '`':
+			quote = c
+			continue
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '?' && rebindPlaceholders:
+			n++
+			b.WriteString(d.Placeholder(n))
+			continue
+		}
+		b.WriteRune(c)
+	}
+	if quote == '`' {
+		b.WriteString("`" + ident.String())
+	}
+	return b.String()
+}
+
+// Inline substitui os placeholders "?" de uma query na forma do MySQL pelos
+// literais dos argumentos. Literais entre aspas são preservados.
+func Inline(d Dialect, query string, args []interface{}) (string, error) {
+	d = OrDefault(d)
+
+	var b strings.Builder
+	b.Grow(len(query) + 16*len(args))
+
+	n := 0
+	var quote rune
+	for _, c := range query {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '`' || c == '\'' || c == '"':
+			quote = c
+		case c == '?':
+			if n >= len(args) {
+				return "", fmt.Errorf("query com mais placeholders que argumentos (%d)", len(args))
+			}
+			lit, err := d.Literal(args[n])
+			if err != nil {
+				return "", err
+			}
+			n++
+			b.WriteString(lit)
+			continue
+		}
+		b.WriteRune(c)
+	}
+	if n != len(args) {
+		return "", fmt.Errorf("query com %d placeholders e %d argumentos", n, len(args))
+	}
+	return b.String(), nil
+}
+
+// ============================================================================
+// INTERNAL HELPERS
+// ============================================================================
+
+// literal renderiza valores escalares; escapeBackslash dobra a barra
+// invertida nas strings (o MySQL a trata como escape por padrão)
+func literal(v interface{}, escapeBackslash bool) (string, error) {
+	switch x := v.(type) {
+	case nil:
+		return "NULL", nil
+	case bool:
+		if x {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprint(x), nil
+	case float32:
+		return literal(float64(x), escapeBackslash)
+	case float64:
+		if math.IsNaN(x) || math.IsInf(x, 0) {
+			return "", fmt.Errorf("valor numérico inválido: %v", x)
+		}
+		return strconv.FormatFloat(x, 'g', -1, 64), nil
+	case time.Time:
+		return "'" + x.Format("2006-01-02 15:04:05") + "'", nil
+	case []byte:
+		return literal(string(x), escapeBackslash)
+	case string:
+		if strings.ContainsRune(x, 0) {
+			return "", fmt.Errorf("string com caractere nulo")
+		}
+		if escapeBackslash {
+			x = strings.ReplaceAll(x, `\`, `\\`)
+		}
+		return "'" + strings.ReplaceAll(x, "'", "''") + "'", nil
+	default:
+		return "", fmt.Errorf("tipo sem literal SQL: %T", v)
+	}
+}
+
+// placeholders renderiza "p1, p2, ..., pn" no formato do dialeto
+func placeholders(d Dialect, count int) string {
+	parts := make([]string, count)
+	for i := range parts {
+		parts[i] = d.Placeholder(i + 1)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// insertInto renderiza "INSERT INTO t (cols) VALUES (...)" com o verbo informado
+func insertInto(d Dialect, verb, table string, columns []string) string {
+	return verb + " " + table + " (" + strings.Join(columns, ", ") + ") VALUES (" +
+		placeholders(d, len(columns)) + ")"
+}
+
+// scanIndexes agrupa linhas (índice, coluna, único, tipo) na ordem das colunas
+func scanIndexes(rows *sql.Rows) ([]models.IndexDetail, error) {
+	defer rows.Close()
+
+	var indexes []models.IndexDetail
+	position := map[string]int{}
+	for rows.Next() {
+		var name, column, idxType string
+		if err := rows.Scan(&name, &column, &idxType); err != nil {
+			return nil, err
+		}
+		i, ok := position[name]
+		if !ok {
+			i = len(indexes)
+			position[name] = i
+			indexes = append(indexes, models.IndexDetail{Name: name, Columns: []string{}, Type: idxType})
+		}
+		indexes[i].Columns = append(indexes[i].Columns, column)
+	}
+	return indexes, rows.Err()
+}
+
+// scanTables lê nomes de tabelas mantendo apenas os que têm o prefixo (o "_"
+// do LIKE é curinga, então o filtro final é feito aqui)
+func scanTables(rows *sql.Rows, prefix string) ([]string, error) {
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(name, prefix) {
+			tables = append(tables, name)
+		}
+	}
+	return tables, rows.Err()
+}
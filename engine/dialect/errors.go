@@ -0,0 +1,331 @@
+package dialect
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	"modernc.org/sqlite"
+
+	"meu-provedor/models"
+)
+
+// ============================================================================
+// DB ERRORS - Erros dos drivers normalizados por dialeto
+// ============================================================================
+//
+// Cada dialeto reconhece o erro do próprio driver, mapeia o código nativo
+// para o erro tipado (tabelas abaixo) e extrai da mensagem o que houver de
+// coluna, constraint e valor. A mensagem para o cliente é montada pelos
+// serviços de dados, igual para todos os bancos.
+
+// DBError é um erro do driver normalizado. Campos sem informação no erro
+// ficam vazios; Err nil = código sem tradução.
+type DBError struct {
+	Err             *models.AppError
+	CodeKey         string      // chave do código nos detalhes (mysql_code, pg_code, sqlite_code)
+	Code            interface{} // código nativo do driver
+	Column          string
+	Constraint      string
+	Value           string
+	Table           string // tabela física (com prefixo do projeto)
+	ReferencedTable string // tabela física referenciada pela FK
+	ExpectedType    string
+	Row             int
+}
+
+// TranslateError normaliza o erro de qualquer um dos drivers suportados
+// (nil quando não é erro de driver)
+func TranslateError(err error) *DBError {
+	if err == nil {
+		return nil
+	}
+	for _, d := range []Dialect{MySQL, Postgres, SQLite} {
+		if dbErr := d.TranslateError(err); dbErr != nil {
+			return dbErr
+		}
+	}
+	return nil
+}
+
+// ============================================================================
+// MYSQL
+// ============================================================================
+
+// mysqlErrors mapeia números de erro do MySQL para os erros tipados
+var mysqlErrors = map[uint16]*models.AppError{
+	1048: models.ErrNotNullViolation,
+	1050: models.ErrTableExists,
+	1054: models.ErrUnknownColumn,
+	1060: models.ErrDuplicateColumn,
+	1061: models.ErrDuplicateIndex,
+	1062: models.ErrDuplicateKey,
+	1064: models.ErrSQLSyntax,
+	1091: models.ErrObjectNotFound,
+	1146: models.ErrTableNotFound,
+	1264: models.ErrValueOutOfRange,
+	1317: models.ErrQueryCanceled,
+	1366: models.ErrInvalidValue,
+	1406: models.ErrValueTooLong,
+	1451: models.ErrForeignKeyRestrict,
+	1452: models.ErrForeignKeyFailed,
+	3024: models.ErrQueryTimeout,
+}
+
+var (
+	reMySQLDuplicate   = regexp.MustCompile(`Duplicate entry '(.*)' for key '([^']+)'`)
+	reMySQLNotNull     = regexp.MustCompile(`Column '([^']+)' cannot be null`)
+	reMySQLForeignKey  = regexp.MustCompile("CONSTRAINT `([^`]+)` FOREIGN KEY \\(`([^`]+)`\\) REFERENCES `([^`]+)`")
+	reMySQLTooLong     = regexp.MustCompile(`Data too long for column '([^']+)' at row (\d+)`)
+	reMySQLIncorrect   = regexp.MustCompile("Incorrect (.+) value: '(.*)' for column (?:[`'][^`']*[`']\\.)*[`']([^`']+)[`'] at row (\\d+)")
+	reMySQLOutOfRange  = regexp.MustCompile(`Out of range value for column '([^']+)' at row (\d+)`)
+	reMySQLUnknown     = regexp.MustCompile(`Unknown column '([^']+)' in '([^']+)'`)
+	reMySQLTableExists = regexp.MustCompile(`Table '([^']+)' (?:doesn't exist|already exists)`)
+)
+
+// TranslateError reconhece *mysql.MySQLError
+func (mysqlDialect) TranslateError(err error) *DBError {
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return nil
+	}
+
+	dbErr := &DBError{Err: mysqlErrors[mysqlErr.Number], CodeKey: "mysql_code", Code: mysqlErr.Number}
+	msg := mysqlErr.Message
+
+	switch mysqlErr.Number {
+	case 1062:
+		// A mensagem traz o nome do índice, não as colunas: sem Column
+		if m := reMySQLDuplicate.FindStringSubmatch(msg); m != nil {
+			dbErr.Value = m[1]
+			dbErr.Constraint = lastIdentifier(m[2])
+		}
+
+	case 1048:
+		if m := reMySQLNotNull.FindStringSubmatch(msg); m != nil {
+			dbErr.Column = m[1]
+		}
+
+	case 1451, 1452:
+		if m := reMySQLForeignKey.FindStringSubmatch(msg); m != nil {
+			dbErr.Constraint = m[1]
+			dbErr.Column = m[2]
+			dbErr.ReferencedTable = m[3]
+		}
+
+	case 1406:
+		if m := reMySQLTooLong.FindStringSubmatch(msg); m != nil {
+			dbErr.Column = m[1]
+			dbErr.Row, _ = strconv.Atoi(m[2])
+		}
+
+	case 1366:
+		if m := reMySQLIncorrect.FindStringSubmatch(msg); m != nil {
+			dbErr.ExpectedType = m[1]
+			dbErr.Value = m[2]
+			dbErr.Column = m[3]
+			dbErr.Row, _ = strconv.Atoi(m[4])
+		}
+
+	case 1264:
+		if m := reMySQLOutOfRange.FindStringSubmatch(msg); m != nil {
+			dbErr.Column = m[1]
+			dbErr.Row, _ = strconv.Atoi(m[2])
+		}
+
+	case 1054:
+		if m := reMySQLUnknown.FindStringSubmatch(msg); m != nil {
+			dbErr.Column = lastIdentifier(m[1])
+		}
+
+	case 1050, 1146:
+		if m := reMySQLTableExists.FindStringSubmatch(msg); m != nil {
+			dbErr.Table = lastIdentifier(m[1])
+		}
+	}
+	return dbErr
+}
+
+// ============================================================================
+// POSTGRESQL
+// ============================================================================
+
+// postgresErrors mapeia SQLSTATEs do PostgreSQL para os erros tipados
+var postgresErrors = map[pq.ErrorCode]*models.AppError{
+	"23502": models.ErrNotNullViolation,
+	"23503": models.ErrForeignKeyFailed, // ou restrict, ver TranslateError
+	"23505": models.ErrDuplicateKey,
+	"23514": models.ErrInvalidValue,
+	"22001": models.ErrValueTooLong,
+	"22003": models.ErrValueOutOfRange,
+	"22007": models.ErrInvalidValue,
+	"22P02": models.ErrInvalidValue,
+	"42601": models.ErrSQLSyntax,
+	"42703": models.ErrUnknownColumn,
+	"42701": models.ErrDuplicateColumn,
+	"42704": models.ErrObjectNotFound,
+	"42P01": models.ErrTableNotFound,
+	"42P07": models.ErrTableExists,
+	"57014": models.ErrQueryCanceled,
+}
+
+var (
+	// Key (col1, col2)=(v1, v2) already exists / is not present / is still referenced
+	rePostgresKey       = regexp.MustCompile(`^Key \((.+?)\)=\((.*)\) `)
+	rePostgresTableIn   = regexp.MustCompile(`in table "([^"]+)"`)
+	rePostgresTableFrom = regexp.MustCompile(`from table "([^"]+)"`)
+	rePostgresColumn    = regexp.MustCompile(`column "([^"]+)"`)
+	rePostgresRelation  = regexp.MustCompile(`relation "([^"]+)"`)
+	rePostgresInput     = regexp.MustCompile(`invalid input (?:syntax|value) for (?:type )?([^:]+): "(.*)"`)
+)
+
+// TranslateError reconhece *pq.Error. O servidor informa tabela, coluna e
+// constraint em campos próprios; os valores vêm no Detail.
+func (postgresDialect) TranslateError(err error) *DBError {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return nil
+	}
+
+	dbErr := &DBError{
+		Err:        postgresErrors[pqErr.Code],
+		CodeKey:    "pg_code",
+		Code:       string(pqErr.Code),
+		Column:     pqErr.Column,
+		Constraint: pqErr.Constraint,
+		Table:      pqErr.Table,
+	}
+
+	switch pqErr.Code {
+	case "23505":
+		if m := rePostgresKey.FindStringSubmatch(pqErr.Detail); m != nil {
+			dbErr.Value = m[2]
+			if !strings.Contains(m[1], ",") {
+				dbErr.Column = m[1]
+			}
+		}
+
+	case "23503":
+		m := rePostgresKey.FindStringSubmatch(pqErr.Detail)
+		if t := rePostgresTableFrom.FindStringSubmatch(pqErr.Detail); t != nil {
+			// "is still referenced from table": a linha apagada tem filhos
+			dbErr.Err = models.ErrForeignKeyRestrict
+			dbErr.Table = t[1]
+			dbErr.Column = ""
+		} else if m != nil {
+			dbErr.Value = m[2]
+			dbErr.Column = m[1]
+			if t := rePostgresTableIn.FindStringSubmatch(pqErr.Detail); t != nil {
+				dbErr.ReferencedTable = t[1]
+			}
+		}
+
+	case "23502", "42703":
+		if dbErr.Column == "" {
+			if m := rePostgresColumn.FindStringSubmatch(pqErr.Message); m != nil {
+				dbErr.Column = m[1]
+			}
+		}
+
+	case "22P02", "22007":
+		if m := rePostgresInput.FindStringSubmatch(pqErr.Message); m != nil {
+			dbErr.ExpectedType = m[1]
+			dbErr.Value = m[2]
+		}
+
+	case "42P01", "42P07":
+		if m := rePostgresRelation.FindStringSubmatch(pqErr.Message); m != nil {
+			dbErr.Table = m[1]
+		}
+
+	case "57014":
+		// statement_timeout e cancelamento usam o mesmo SQLSTATE
+		if strings.Contains(pqErr.Message, "statement timeout") {
+			dbErr.Err = models.ErrQueryTimeout
+		}
+	}
+	return dbErr
+}
+
+// ============================================================================
+// SQLITE
+// ============================================================================
+
+// sqliteErrors mapeia os códigos estendidos do SQLite para os erros tipados.
+// O SQLite não distingue FK inexistente de FK restrita (787 nos dois casos).
+var sqliteErrors = map[int]*models.AppError{
+	275:  models.ErrInvalidValue,     // SQLITE_CONSTRAINT_CHECK
+	787:  models.ErrForeignKeyFailed, // SQLITE_CONSTRAINT_FOREIGNKEY
+	1299: models.ErrNotNullViolation, // SQLITE_CONSTRAINT_NOTNULL
+	1555: models.ErrDuplicateKey,     // SQLITE_CONSTRAINT_PRIMARYKEY
+	2067: models.ErrDuplicateKey,     // SQLITE_CONSTRAINT_UNIQUE
+	9:    models.ErrQueryCanceled,    // SQLITE_INTERRUPT
+}
+
+// sqliteMessages classifica o SQLITE_ERROR genérico (1) pela mensagem
+var sqliteMessages = []struct {
+	re  *regexp.Regexp
+	err *models.AppError
+	// field recebe o nome capturado
+	field func(*DBError, string)
+}{
+	{regexp.MustCompile(`no such table: (\S+)`), models.ErrTableNotFound, func(e *DBError, v string) { e.Table = v }},
+	{regexp.MustCompile(`table (\S+) already exists`), models.ErrTableExists, func(e *DBError, v string) { e.Table = v }},
+	{regexp.MustCompile(`no such column: (\S+)`), models.ErrUnknownColumn, func(e *DBError, v string) { e.Column = lastIdentifier(v) }},
+	{regexp.MustCompile(`table (\S+) has no column named (\S+)`), models.ErrUnknownColumn, func(e *DBError, v string) { e.Column = v }},
+	{regexp.MustCompile(`duplicate column name: (\S+)`), models.ErrDuplicateColumn, func(e *DBError, v string) { e.Column = v }},
+	{regexp.MustCompile(`index (\S+) already exists`), models.ErrDuplicateIndex, func(e *DBError, v string) { e.Constraint = v }},
+	{regexp.MustCompile(`no such index: (\S+)`), models.ErrObjectNotFound, func(e *DBError, v string) { e.Constraint = v }},
+	{regexp.MustCompile(`(syntax error)`), models.ErrSQLSyntax, func(*DBError, string) {}},
+}
+
+var reSQLiteConstraint = regexp.MustCompile(`(?:UNIQUE|NOT NULL) constraint failed: ([^ ]+(?:, [^ ]+)*)`)
+
+// TranslateError reconhece *sqlite.Error (modernc.org/sqlite)
+func (sqliteDialect) TranslateError(err error) *DBError {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return nil
+	}
+
+	code := sqliteErr.Code()
+	dbErr := &DBError{Err: sqliteErrors[code], CodeKey: "sqlite_code", Code: code}
+	msg := sqliteErr.Error()
+
+	switch code {
+	case 1299, 1555, 2067:
+		// "tabela.coluna" ou "tabela.c1, tabela.c2" (unique composto: sem Column)
+		if m := reSQLiteConstraint.FindStringSubmatch(msg); m != nil && !strings.Contains(m[1], ",") {
+			if i := strings.LastIndex(m[1], "."); i >= 0 {
+				dbErr.Table = m[1][:i]
+			}
+			dbErr.Column = lastIdentifier(m[1])
+		}
+
+	case 1:
+		// SQLITE_ERROR genérico: o tipo vem da primeira mensagem que casar
+		for _, rule := range sqliteMessages {
+			if m := rule.re.FindStringSubmatch(msg); m != nil {
+				dbErr.Err = rule.err
+				rule.field(dbErr, m[len(m)-1])
+				break
+			}
+		}
+	}
+	return dbErr
+}
+
+// ============================================================================
+// INTERNAL HELPERS
+// ============================================================================
+
+// lastIdentifier retorna a última parte de um nome qualificado (db.tabela.coluna)
+func lastIdentifier(name string) string {
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
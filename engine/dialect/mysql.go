@@ -0,0 +1,227 @@
+package dialect
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"meu-provedor/models"
+)
+
+// ============================================================================
+// MYSQL - Dialeto padrão (banco master e projetos sem DSN de outro tipo)
+// ============================================================================
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) Name() string       { return "mysql" }
+func (mysqlDialect) DriverName() string { return "mysql" }
+
+func (mysqlDialect) Placeholder(int) string { return "?" }
+
+func (mysqlDialect) QuoteIdent(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+func (mysqlDialect) LimitOffset(limit, offset int) string {
+	if limit <= 0 {
+		return ""
+	}
+	if offset > 0 {
+		return fmt.Sprintf(" LIMIT %d OFFSET %d", limit, offset)
+	}
+	return fmt.Sprintf(" LIMIT %d", limit)
+}
+
+func (mysqlDialect) ForUpdate() string { return " FOR UPDATE" }
+
+// ExecutionTimeHint: o servidor aborta o SELECT mesmo que a conexão do
+// cliente já tenha sido descartada pelo cancelamento do contexto
+func (mysqlDialect) ExecutionTimeHint(ms int) string {
+	if ms <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("/*+ MAX_EXECUTION_TIME(%d) */ ", ms)
+}
+
+func (mysqlDialect) Literal(v interface{}) (string, error) { return literal(v, true) }
+
+// ============================================================================
+// DDL
+// ============================================================================
+
+func (mysqlDialect) AutoIncrementPK(column string) string {
+	return column + " BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY"
+}
+
+func (mysqlDialect) BigIntType() string   { return "BIGINT UNSIGNED" }
+func (mysqlDialect) DateTimeType() string { return "DATETIME" }
+func (mysqlDialect) JSONType() string     { return "JSON" }
+
+func (mysqlDialect) TimestampColumn(column string, fsp int, onUpdate bool) string {
+	typ, now := "DATETIME", "CURRENT_TIMESTAMP"
+	if fsp > 0 {
+		typ = fmt.Sprintf("DATETIME(%d)", fsp)
+		now = fmt.Sprintf("CURRENT_TIMESTAMP(%d)", fsp)
+	}
+	def := fmt.Sprintf("%s %s NOT NULL DEFAULT %s", column, typ, now)
+	if onUpdate {
+		def += " ON UPDATE " + now
+	}
+	return def
+}
+
+func (mysqlDialect) ForeignKeyCascade(column, refTable, refColumn string) string {
+	return fmt.Sprintf("FOREIGN KEY (%s) REFERENCES %s(%s) ON DELETE CASCADE", column, refTable, refColumn)
+}
+
+// CreateTable declara os índices dentro do próprio CREATE TABLE
+func (mysqlDialect) CreateTable(table string, ifNotExists bool, definitions []string, indexes []Index) ([]string, error) {
+	defs := append([]string{}, definitions...)
+	for _, idx := range indexes {
+		cols := strings.Join(idx.Columns, ",")
+		switch strings.ToUpper(idx.Type) {
+		case "UNIQUE":
+			defs = append(defs, fmt.Sprintf("UNIQUE KEY %s (%s)", idx.Name, cols))
+		case "FULLTEXT":
+			defs = append(defs, fmt.Sprintf("FULLTEXT INDEX %s (%s)", idx.Name, cols))
+		default:
+			defs = append(defs, fmt.Sprintf("INDEX %s (%s)", idx.Name, cols))
+		}
+	}
+
+	create := "CREATE TABLE "
+	if ifNotExists {
+		create += "IF NOT EXISTS "
+	}
+	return []string{fmt.Sprintf("%s%s (%s)", create, table, strings.Join(defs, ","))}, nil
+}
+
+func (mysqlDialect) CreateIndex(table string, idx Index) (string, error) {
+	cols := strings.Join(idx.Columns, ",")
+	switch strings.ToUpper(idx.Type) {
+	case "UNIQUE":
+		return fmt.Sprintf("ALTER TABLE %s ADD UNIQUE INDEX %s (%s)", table, idx.Name, cols), nil
+	case "FULLTEXT":
+		return fmt.Sprintf("ALTER TABLE %s ADD FULLTEXT INDEX %s (%s)", table, idx.Name, cols), nil
+	default:
+		return fmt.Sprintf("ALTER TABLE %s ADD INDEX %s (%s)", table, idx.Name, cols), nil
+	}
+}
+
+func (mysqlDialect) DropIndex(table, name string) string {
+	return fmt.Sprintf("ALTER TABLE %s DROP INDEX %s", table, name)
+}
+
+func (mysqlDialect) ModifyColumn(table, column, colType string, nullable bool) ([]string, error) {
+	def := column + " " + colType
+	if !nullable {
+		def += " NOT NULL"
+	}
+	return []string{fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s", table, def)}, nil
+}
+
+// ============================================================================
+// INSERT
+// ============================================================================
+
+func (d mysqlDialect) InsertIgnore(table string, columns []string) string {
+	return insertInto(d, "INSERT IGNORE INTO", table, columns)
+}
+
+func (d mysqlDialect) Upsert(table string, columns, keys, updates []string) string {
+	sets := make([]string, len(updates))
+	for i, col := range updates {
+		sets[i] = fmt.Sprintf("%s = VALUES(%s)", col, col)
+	}
+	return insertInto(d, "INSERT INTO", table, columns) +
+		" ON DUPLICATE KEY UPDATE " + strings.Join(sets, ", ")
+}
+
+// Returning: sem RETURNING no MySQL; os ids de um INSERT multi-row não são
+// necessariamente consecutivos, então as linhas são inseridas uma a uma
+func (mysqlDialect) Returning(string) string { return "" }
+
+// SyncSequence: o AUTO_INCREMENT avança sozinho com ids explícitos maiores
+func (mysqlDialect) SyncSequence(string, string) string { return "" }
+
+// ============================================================================
+// CATALOG
+// ============================================================================
+
+func (mysqlDialect) Tables(db Queryer, prefix string) ([]string, error) {
+	rows, err := db.Query(`
+		SELECT table_name
+		FROM information_schema.tables
+		WHERE table_schema = DATABASE()
+		AND table_type = 'BASE TABLE'
+		AND table_name LIKE ?`, prefix+"%",
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanTables(rows, prefix)
+}
+
+func (mysqlDialect) Views(db Queryer, prefix string) ([]string, error) {
+	rows, err := db.Query(`
+		SELECT table_name
+		FROM information_schema.tables
+		WHERE table_schema = DATABASE()
+		AND table_type = 'VIEW'
+		AND table_name LIKE ?`, prefix+"%",
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanTables(rows, prefix)
+}
+
+func (mysqlDialect) Columns(db Queryer, table string) ([]models.ColumnDetail, error) {
+	rows, err := db.Query(`
+		SELECT COLUMN_NAME, COLUMN_TYPE, IS_NULLABLE, COLUMN_DEFAULT, COLUMN_KEY, EXTRA
+		FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?
+		ORDER BY ORDINAL_POSITION`, table,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []models.ColumnDetail
+	for rows.Next() {
+		var col models.ColumnDetail
+		var isNullable, colDefault, colKey, extra sql.NullString
+
+		if err := rows.Scan(&col.Name, &col.Type, &isNullable, &colDefault, &colKey, &extra); err != nil {
+			return nil, err
+		}
+
+		col.Nullable = (isNullable.String == "YES")
+		if colDefault.Valid {
+			col.Default = colDefault.String
+		}
+		col.Key = colKey.String
+		col.Extra = extra.String
+
+		columns = append(columns, col)
+	}
+	return columns, rows.Err()
+}
+
+func (mysqlDialect) Indexes(db Queryer, table string) ([]models.IndexDetail, error) {
+	rows, err := db.Query(`
+		SELECT INDEX_NAME, COLUMN_NAME,
+			CASE WHEN NON_UNIQUE = 0 THEN 'UNIQUE'
+			     WHEN INDEX_TYPE = 'FULLTEXT' THEN 'FULLTEXT'
+			     ELSE 'INDEX' END
+		FROM information_schema.STATISTICS
+		WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?
+		ORDER BY INDEX_NAME, SEQ_IN_INDEX`, table,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanIndexes(rows)
+}
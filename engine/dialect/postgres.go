@@ -0,0 +1,287 @@
+package dialect
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"meu-provedor/models"
+)
+
+// ============================================================================
+// POSTGRESQL - Projetos com DSN postgres://
+// ============================================================================
+
+type postgresDialect struct{}
+
+func (postgresDialect) Name() string       { return "postgres" }
+func (postgresDialect) DriverName() string { return "postgres" }
+
+func (postgresDialect) Placeholder(n int) string { return fmt.Sprintf("$%d", n) }
+
+func (postgresDialect) QuoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func (postgresDialect) LimitOffset(limit, offset int) string {
+	return MySQL.LimitOffset(limit, offset)
+}
+
+func (postgresDialect) ForUpdate() string { return " FOR UPDATE" }
+
+// ExecutionTimeHint: o lib/pq envia um cancel request ao servidor quando o
+// contexto expira, então não há hint
+func (postgresDialect) ExecutionTimeHint(int) string { return "" }
+
+// Literal: com standard_conforming_strings (padrão) a barra invertida é literal
+func (postgresDialect) Literal(v interface{}) (string, error) { return literal(v, false) }
+
+// ============================================================================
+// DDL
+// ============================================================================
+
+func (postgresDialect) AutoIncrementPK(column string) string {
+	return column + " BIGSERIAL PRIMARY KEY"
+}
+
+func (postgresDialect) BigIntType() string   { return "BIGINT" }
+func (postgresDialect) DateTimeType() string { return "TIMESTAMP" }
+func (postgresDialect) JSONType() string     { return "JSONB" }
+
+// TimestampColumn: sem ON UPDATE; updated_at é preenchido pelo serviço
+func (postgresDialect) TimestampColumn(column string, fsp int, onUpdate bool) string {
+	return fmt.Sprintf("%s TIMESTAMP(%d) NOT NULL DEFAULT CURRENT_TIMESTAMP", column, fsp)
+}
+
+func (postgresDialect) ForeignKeyCascade(column, refTable, refColumn string) string {
+	return MySQL.ForeignKeyCascade(column, refTable, refColumn)
+}
+
+func (d postgresDialect) CreateTable(table string, ifNotExists bool, definitions []string, indexes []Index) ([]string, error) {
+	return createTableWithIndexes(d, table, ifNotExists, definitions, indexes)
+}
+
+func (postgresDialect) CreateIndex(table string, idx Index) (string, error) {
+	return createIndex(table, idx, false)
+}
+
+func (postgresDialect) DropIndex(table, name string) string {
+	return "DROP INDEX " + name
+}
+
+func (postgresDialect) ModifyColumn(table, column, colType string, nullable bool) ([]string, error) {
+	nullability := "SET NOT NULL"
+	if nullable {
+		nullability = "DROP NOT NULL"
+	}
+	return []string{
+		fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s", table, column, colType),
+		fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s %s", table, column, nullability),
+	}, nil
+}
+
+// ============================================================================
+// INSERT
+// ============================================================================
+
+func (d postgresDialect) InsertIgnore(table string, columns []string) string {
+	return insertInto(d, "INSERT INTO", table, columns) + " ON CONFLICT DO NOTHING"
+}
+
+func (d postgresDialect) Upsert(table string, columns, keys, updates []string) string {
+	return insertInto(d, "INSERT INTO", table, columns) + onConflictUpdate(keys, updates)
+}
+
+// Returning: o lib/pq não implementa LastInsertId
+func (postgresDialect) Returning(column string) string { return " RETURNING " + column }
+
+// SyncSequence: o BIGSERIAL não enxerga ids explícitos e geraria o mesmo id
+// de novo. O nextval - 1 é o último valor entregue: a sequência só avança.
+func (d postgresDialect) SyncSequence(table, column string) string {
+	seq := fmt.Sprintf("pg_get_serial_sequence('%s', '%s')", d.QuoteIdent(table), column)
+	return fmt.Sprintf("SELECT setval(%s, GREATEST(nextval(%s) - 1, (SELECT MAX(%s) FROM %s)))",
+		seq, seq, d.QuoteIdent(column), d.QuoteIdent(table))
+}
+
+// ============================================================================
+// CATALOG
+// ============================================================================
+
+func (postgresDialect) Tables(db Queryer, prefix string) ([]string, error) {
+	rows, err := db.Query(`
+		SELECT table_name
+		FROM information_schema.tables
+		WHERE table_schema = current_schema()
+		AND table_type = 'BASE TABLE'
+		AND table_name LIKE $1`, prefix+"%",
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanTables(rows, prefix)
+}
+
+func (postgresDialect) Views(db Queryer, prefix string) ([]string, error) {
+	rows, err := db.Query(`
+		SELECT table_name
+		FROM information_schema.views
+		WHERE table_schema = current_schema()
+		AND table_name LIKE $1`, prefix+"%",
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanTables(rows, prefix)
+}
+
+// Columns converte o catálogo do PostgreSQL para os nomes de tipo do MySQL,
+// que é o formato interpretado pela validação de schema
+func (postgresDialect) Columns(db Queryer, table string) ([]models.ColumnDetail, error) {
+	rows, err := db.Query(`
+		SELECT a.attname,
+			format_type(a.atttypid, a.atttypmod),
+			NOT a.attnotnull,
+			pg_get_expr(ad.adbin, ad.adrelid),
+			COALESCE((
+				SELECT CASE WHEN i.indisprimary THEN 'PRI' WHEN i.indisunique THEN 'UNI' ELSE 'MUL' END
+				FROM pg_index i
+				WHERE i.indrelid = a.attrelid AND i.indkey[0] = a.attnum
+				ORDER BY i.indisprimary DESC, i.indisunique DESC
+				LIMIT 1
+			), ''),
+			a.attidentity <> ''
+		FROM pg_attribute a
+		LEFT JOIN pg_attrdef ad ON ad.adrelid = a.attrelid AND ad.adnum = a.attnum
+		WHERE a.attrelid = to_regclass($1) AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY a.attnum`, table,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []models.ColumnDetail
+	for rows.Next() {
+		var col models.ColumnDetail
+		var colDefault sql.NullString
+		var identity bool
+
+		if err := rows.Scan(&col.Name, &col.Type, &col.Nullable, &colDefault, &col.Key, &identity); err != nil {
+			return nil, err
+		}
+
+		col.Type = postgresColumnType(col.Type)
+		switch {
+		case identity || strings.HasPrefix(colDefault.String, "nextval("):
+			col.Extra = "auto_increment"
+		case colDefault.Valid:
+			col.Default = colDefault.String
+		}
+
+		columns = append(columns, col)
+	}
+	return columns, rows.Err()
+}
+
+func (postgresDialect) Indexes(db Queryer, table string) ([]models.IndexDetail, error) {
+	rows, err := db.Query(`
+		SELECT CASE WHEN ix.indisprimary THEN 'PRIMARY' ELSE ic.relname END,
+			a.attname,
+			CASE WHEN ix.indisunique THEN 'UNIQUE' ELSE 'INDEX' END
+		FROM pg_index ix
+		JOIN pg_class ic ON ic.oid = ix.indexrelid
+		CROSS JOIN LATERAL unnest(ix.indkey) WITH ORDINALITY AS k(attnum, ord)
+		JOIN pg_attribute a ON a.attrelid = ix.indrelid AND a.attnum = k.attnum
+		WHERE ix.indrelid = to_regclass($1)
+		ORDER BY ic.relname, k.ord`, table,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanIndexes(rows)
+}
+
+// ============================================================================
+// INTERNAL HELPERS
+// ============================================================================
+
+var postgresTypePattern = regexp.MustCompile(`^([a-z ]+?)(\(.*\))?( without time zone| with time zone)?$`)
+
+// postgresColumnType traduz format_type para o nome equivalente do MySQL
+func postgresColumnType(pgType string) string {
+	m := postgresTypePattern.FindStringSubmatch(pgType)
+	if m == nil {
+		return pgType
+	}
+
+	base, args := m[1], m[2]
+	switch base {
+	case "character varying":
+		return "varchar" + args
+	case "character":
+		return "char" + args
+	case "integer":
+		return "int"
+	case "double precision":
+		return "double"
+	case "boolean":
+		return "tinyint(1)"
+	case "jsonb":
+		return "json"
+	case "bytea":
+		return "blob"
+	case "timestamp":
+		if m[3] == " with time zone" {
+			return "timestamp"
+		}
+		return "datetime"
+	}
+	return pgType
+}
+
+// createTableWithIndexes renderiza CREATE TABLE seguido de CREATE INDEX
+// (dialetos sem índices inline)
+func createTableWithIndexes(d Dialect, table string, ifNotExists bool, definitions []string, indexes []Index) ([]string, error) {
+	create := "CREATE TABLE "
+	if ifNotExists {
+		create += "IF NOT EXISTS "
+	}
+	stmts := []string{fmt.Sprintf("%s%s (%s)", create, table, strings.Join(definitions, ","))}
+
+	for _, idx := range indexes {
+		stmt, err := createIndex(table, idx, ifNotExists)
+		if err != nil {
+			return nil, err
+		}
+		stmts = append(stmts, stmt)
+	}
+	return stmts, nil
+}
+
+// createIndex renderiza CREATE [UNIQUE] INDEX (FULLTEXT é exclusivo do MySQL)
+func createIndex(table string, idx Index, ifNotExists bool) (string, error) {
+	create := "CREATE INDEX "
+	switch strings.ToUpper(idx.Type) {
+	case "UNIQUE":
+		create = "CREATE UNIQUE INDEX "
+	case "FULLTEXT":
+		return "", fmt.Errorf("índices FULLTEXT só são suportados no MySQL")
+	}
+	if ifNotExists {
+		create += "IF NOT EXISTS "
+	}
+	return fmt.Sprintf("%s%s ON %s (%s)", create, idx.Name, table, strings.Join(idx.Columns, ",")), nil
+}
+
+// onConflictUpdate renderiza o ON CONFLICT ... DO UPDATE de PostgreSQL e SQLite
+func onConflictUpdate(keys, updates []string) string {
+	if len(updates) == 0 {
+		return fmt.Sprintf(" ON CONFLICT (%s) DO NOTHING", strings.Join(keys, ", "))
+	}
+	sets := make([]string, len(updates))
+	for i, col := range updates {
+		sets[i] = fmt.Sprintf("%s = excluded.%s", col, col)
+	}
+	return fmt.Sprintf(" ON CONFLICT (%s) DO UPDATE SET %s", strings.Join(keys, ", "), strings.Join(sets, ", "))
+}
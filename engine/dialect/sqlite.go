@@ -0,0 +1,190 @@
+package dialect
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"meu-provedor/models"
+)
+
+// ============================================================================
+// SQLITE - Projetos pequenos e testes sem servidor (DSN sqlite:arquivo.db)
+// ============================================================================
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) Name() string       { return "sqlite" }
+func (sqliteDialect) DriverName() string { return "sqlite" }
+
+func (sqliteDialect) Placeholder(int) string { return "?" }
+
+func (sqliteDialect) QuoteIdent(name string) string {
+	return Postgres.QuoteIdent(name)
+}
+
+func (sqliteDialect) LimitOffset(limit, offset int) string {
+	return MySQL.LimitOffset(limit, offset)
+}
+
+// ForUpdate: o SQLite serializa as escritas no banco inteiro
+func (sqliteDialect) ForUpdate() string { return "" }
+
+// ExecutionTimeHint: o SQLite roda no processo e é interrompido pelo contexto
+func (sqliteDialect) ExecutionTimeHint(int) string { return "" }
+
+func (sqliteDialect) Literal(v interface{}) (string, error) { return literal(v, false) }
+
+// ============================================================================
+// DDL
+// ============================================================================
+
+// AutoIncrementPK: só "INTEGER PRIMARY KEY" vira alias do rowid
+func (sqliteDialect) AutoIncrementPK(column string) string {
+	return column + " INTEGER PRIMARY KEY AUTOINCREMENT"
+}
+
+func (sqliteDialect) BigIntType() string   { return "BIGINT" }
+func (sqliteDialect) DateTimeType() string { return "DATETIME" }
+func (sqliteDialect) JSONType() string     { return "JSON" }
+
+// TimestampColumn: sem frações de segundo nem ON UPDATE no default do SQLite
+func (sqliteDialect) TimestampColumn(column string, fsp int, onUpdate bool) string {
+	return column + " DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP"
+}
+
+func (sqliteDialect) ForeignKeyCascade(column, refTable, refColumn string) string {
+	return MySQL.ForeignKeyCascade(column, refTable, refColumn)
+}
+
+func (d sqliteDialect) CreateTable(table string, ifNotExists bool, definitions []string, indexes []Index) ([]string, error) {
+	return createTableWithIndexes(d, table, ifNotExists, definitions, indexes)
+}
+
+func (sqliteDialect) CreateIndex(table string, idx Index) (string, error) {
+	return createIndex(table, idx, false)
+}
+
+func (sqliteDialect) DropIndex(table, name string) string {
+	return "DROP INDEX " + name
+}
+
+func (sqliteDialect) ModifyColumn(table, column, colType string, nullable bool) ([]string, error) {
+	return nil, fmt.Errorf("o SQLite não suporta alterar colunas existentes")
+}
+
+// ============================================================================
+// INSERT
+// ============================================================================
+
+func (d sqliteDialect) InsertIgnore(table string, columns []string) string {
+	return insertInto(d, "INSERT OR IGNORE INTO", table, columns)
+}
+
+func (d sqliteDialect) Upsert(table string, columns, keys, updates []string) string {
+	return insertInto(d, "INSERT INTO", table, columns) + onConflictUpdate(keys, updates)
+}
+
+// Returning: RETURNING disponível desde o SQLite 3.35
+func (sqliteDialect) Returning(column string) string { return " RETURNING " + column }
+
+// SyncSequence: AUTOINCREMENT atualiza o sqlite_sequence com ids explícitos
+func (sqliteDialect) SyncSequence(string, string) string { return "" }
+
+// ============================================================================
+// CATALOG
+// ============================================================================
+
+func (sqliteDialect) Tables(db Queryer, prefix string) ([]string, error) {
+	rows, err := db.Query(`
+		SELECT name
+		FROM sqlite_master
+		WHERE type = 'table'
+		AND name LIKE ?`, prefix+"%",
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanTables(rows, prefix)
+}
+
+func (sqliteDialect) Views(db Queryer, prefix string) ([]string, error) {
+	rows, err := db.Query(`
+		SELECT name
+		FROM sqlite_master
+		WHERE type = 'view'
+		AND name LIKE ?`, prefix+"%",
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanTables(rows, prefix)
+}
+
+// Columns lê pragma_table_info; UNIQUE vem dos índices de uma coluna só
+func (sqliteDialect) Columns(db Queryer, table string) ([]models.ColumnDetail, error) {
+	rows, err := db.Query(`
+		SELECT c.name, c.type, c."notnull", c.dflt_value, c.pk,
+			EXISTS (
+				SELECT 1 FROM pragma_index_list(?) il
+				WHERE il."unique" = 1
+				AND (SELECT COUNT(*) FROM pragma_index_info(il.name)) = 1
+				AND (SELECT name FROM pragma_index_info(il.name)) = c.name
+			)
+		FROM pragma_table_info(?) c
+		ORDER BY c.cid`, table, table,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []models.ColumnDetail
+	for rows.Next() {
+		var col models.ColumnDetail
+		var notNull, pk int
+		var colDefault sql.NullString
+		var unique bool
+
+		if err := rows.Scan(&col.Name, &col.Type, &notNull, &colDefault, &pk, &unique); err != nil {
+			return nil, err
+		}
+
+		col.Type = strings.ToLower(col.Type)
+		col.Nullable = notNull == 0 && pk == 0
+		if colDefault.Valid {
+			col.Default = strings.Trim(colDefault.String, "'")
+		}
+		switch {
+		case pk > 0:
+			col.Key = "PRI"
+			if col.Type == "integer" {
+				col.Extra = "auto_increment"
+			}
+		case unique:
+			col.Key = "UNI"
+		}
+
+		columns = append(columns, col)
+	}
+	return columns, rows.Err()
+}
+
+// Indexes inclui a chave primária, que no SQLite não aparece em pragma_index_list
+// quando é o rowid
+func (sqliteDialect) Indexes(db Queryer, table string) ([]models.IndexDetail, error) {
+	rows, err := db.Query(`
+		SELECT 'PRIMARY', name, 'UNIQUE'
+		FROM pragma_table_info(?)
+		WHERE pk > 0
+		UNION ALL
+		SELECT il.name, ii.name, CASE WHEN il."unique" = 1 THEN 'UNIQUE' ELSE 'INDEX' END
+		FROM pragma_index_list(?) il
+		JOIN pragma_index_info(il.name) ii
+		WHERE il.origin <> 'pk'`, table, table,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanIndexes(rows)
+}
@@ -1,74 +1,99 @@
-package query
-
-import (
-	"fmt"
-	"strings"
-)
-
-// ============================================================================
-// AGGREGATE BUILDER (COUNT, SUM, AVG, MIN, MAX, EXISTS)
-// ============================================================================
-
-// AggregateBuilder constrói queries de agregação
-type AggregateBuilder struct {
-	Table     string
-	Alias     string
-	Operation string
-	Column    string
-	Where     []string
-	Values    []interface{}
-}
-
-// NewAggregate cria um novo AggregateBuilder
-func NewAggregate(table, alias, operation, column string) *AggregateBuilder {
-	if alias == "" {
-		alias = table
-	}
-	return &AggregateBuilder{
-		Table:     table,
-		Alias:     alias,
-		Operation: NormalizeOperation(operation),
-		Column:    column,
-		Where:     []string{},
-		Values:    []interface{}{},
-	}
-}
-
-// AddWhere adiciona condição WHERE
-func (a *AggregateBuilder) AddWhere(condition string, args ...interface{}) *AggregateBuilder {
-	a.Where = append(a.Where, condition)
-	a.Values = append(a.Values, args...)
-	return a
-}
-
-// Build gera a query SQL final
-func (a *AggregateBuilder) Build() string {
-	target := "*"
-	if a.Column != "" {
-		target = a.Column
-	}
-
-	var selectExpr string
-	if a.Operation == "EXISTS" {
-		selectExpr = "EXISTS(SELECT 1"
-	} else {
-		selectExpr = fmt.Sprintf("%s(%s)", a.Operation, target)
-	}
-
-	query := fmt.Sprintf("SELECT %s FROM %s AS %s", selectExpr, a.Table, a.Alias)
-
-	if len(a.Where) > 0 {
-		query += " WHERE " + strings.Join(a.Where, " AND ")
-	}
-
-	if a.Operation == "EXISTS" {
-		query += ")"
-	}
-
-	return query
-}
-
-// GetValues retorna os valores dos parâmetros
-func (a *AggregateBuilder) GetValues() []interface{} {
-	return a.Values
+package query
+
+import (
+	"fmt"
+	"strings"
+
+	"meu-provedor/engine/dialect"
+)
+
+// ============================================================================
+// AGGREGATE BUILDER (COUNT, SUM, AVG, MIN, MAX, EXISTS)
+// ============================================================================
+
+// AggregateBuilder constrói queries de agregação
+type AggregateBuilder struct {
+	Table     string
+	Alias     string
+	Operation string
+	Column    string
+	Where     []string
+	Values    []interface{}
+	Dialect   dialect.Dialect
+	// MaxExecutionMs limita o tempo do SELECT no servidor (0 = sem limite)
+	MaxExecutionMs int
+}
+
+// NewAggregate cria um novo AggregateBuilder
+func NewAggregate(table, alias, operation, column string) *AggregateBuilder {
+	if alias == "" {
+		alias = table
+	}
+	return &AggregateBuilder{
+		Table:     table,
+		Alias:     alias,
+		Operation: NormalizeOperation(operation),
+		Column:    column,
+		Where:     []string{},
+		Values:    []interface{}{},
+	}
+}
+
+// AddWhere adiciona condição WHERE
+func (a *AggregateBuilder) AddWhere(condition string, args ...interface{}) *AggregateBuilder {
+	a.Where = append(a.Where, condition)
+	a.Values = append(a.Values, args...)
+	return a
+}
+
+// AddWhereEq adiciona "referência = ?" com a coluna delimitada
+func (a *AggregateBuilder) AddWhereEq(ref string, value interface{}) *AggregateBuilder {
+	return a.AddWhere(quoteExpr(ref)+" = ?", value)
+}
+
+// SetDialect define o dialeto SQL (MySQL quando não definido)
+func (a *AggregateBuilder) SetDialect(d dialect.Dialect) *AggregateBuilder {
+	a.Dialect = d
+	return a
+}
+
+// SetMaxExecutionTime define o tempo máximo de execução no servidor (ms)
+func (a *AggregateBuilder) SetMaxExecutionTime(ms int) *AggregateBuilder {
+	a.MaxExecutionMs = ms
+	return a
+}
+
+// Build gera a query SQL final
+func (a *AggregateBuilder) Build() string {
+	d := dialect.OrDefault(a.Dialect)
+
+	target := "*"
+	if a.Column != "" {
+		target = quoteExpr(a.Column)
+	}
+
+	var selectExpr string
+	if a.Operation == "EXISTS" {
+		selectExpr = "EXISTS(SELECT 1"
+	} else {
+		selectExpr = fmt.Sprintf("%s(%s)", a.Operation, target)
+	}
+
+	query := fmt.Sprintf("SELECT %s%s FROM %s AS %s",
+		d.ExecutionTimeHint(a.MaxExecutionMs), selectExpr, QuoteIdent(a.Table), QuoteIdent(a.Alias))
+
+	if len(a.Where) > 0 {
+		query += " WHERE " + strings.Join(a.Where, " AND ")
+	}
+
+	if a.Operation == "EXISTS" {
+		query += ")"
+	}
+
+	return dialect.Rebind(d, query)
+}
+
+// GetValues retorna os valores dos parâmetros
+func (a *AggregateBuilder) GetValues() []interface{} {
+	return a.Values
 }
\ No newline at end of file
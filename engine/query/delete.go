@@ -1,46 +1,60 @@
-package query
-
-import (
-	"fmt"
-	"strings"
-)
-
-// ============================================================================
-// DELETE BUILDER
-// ============================================================================
-
-// DeleteBuilder constrói queries DELETE
-type DeleteBuilder struct {
-	Table        string
-	WhereClauses []string
-	WhereValues  []interface{}
-}
-
-// NewDelete cria um novo DeleteBuilder
-func NewDelete(table string) *DeleteBuilder {
-	return &DeleteBuilder{
-		Table:        table,
-		WhereClauses: []string{},
-		WhereValues:  []interface{}{},
-	}
-}
-
-// Where adiciona condição WHERE
-func (d *DeleteBuilder) Where(condition string, args ...interface{}) *DeleteBuilder {
-	d.WhereClauses = append(d.WhereClauses, condition)
-	d.WhereValues = append(d.WhereValues, args...)
-	return d
-}
-
-// WhereRaw adiciona filtro customizado sem parâmetros
-func (d *DeleteBuilder) WhereRaw(raw string) *DeleteBuilder {
-	d.WhereClauses = append(d.WhereClauses, "("+raw+")")
-	return d
-}
-
-// Build gera a query SQL final
-func (d *DeleteBuilder) Build() (string, []interface{}) {
-	wherePart := strings.Join(d.WhereClauses, " AND ")
-	query := fmt.Sprintf("DELETE FROM %s WHERE %s", d.Table, wherePart)
-	return query, d.WhereValues
+package query
+
+import (
+	"fmt"
+	"strings"
+
+	"meu-provedor/engine/dialect"
+)
+
+// ============================================================================
+// DELETE BUILDER
+// ============================================================================
+
+// DeleteBuilder constrói queries DELETE
+type DeleteBuilder struct {
+	Table        string
+	WhereClauses []string
+	WhereValues  []interface{}
+	Dialect      dialect.Dialect
+}
+
+// NewDelete cria um novo DeleteBuilder
+func NewDelete(table string) *DeleteBuilder {
+	return &DeleteBuilder{
+		Table:        table,
+		WhereClauses: []string{},
+		WhereValues:  []interface{}{},
+	}
+}
+
+// Where adiciona condição WHERE
+func (d *DeleteBuilder) Where(condition string, args ...interface{}) *DeleteBuilder {
+	d.WhereClauses = append(d.WhereClauses, condition)
+	d.WhereValues = append(d.WhereValues, args...)
+	return d
+}
+
+// WhereEq adiciona "referência = ?" com a coluna delimitada
+func (d *DeleteBuilder) WhereEq(ref string, value interface{}) *DeleteBuilder {
+	return d.Where(quoteExpr(ref)+" = ?", value)
+}
+
+// WhereRaw adiciona filtro customizado sem parâmetros
+func (d *DeleteBuilder) WhereRaw(raw string) *DeleteBuilder {
+	d.WhereClauses = append(d.WhereClauses, "("+raw+")")
+	return d
+}
+
+// SetDialect define o dialeto SQL (MySQL quando não definido)
+func (d *DeleteBuilder) SetDialect(dl dialect.Dialect) *DeleteBuilder {
+	d.Dialect = dl
+	return d
+}
+
+// Build gera a query SQL final
+func (d *DeleteBuilder) Build() (string, []interface{}) {
+	wherePart := strings.Join(d.WhereClauses, " AND ")
+	query := fmt.Sprintf("DELETE FROM %s WHERE %s", QuoteIdent(d.Table), wherePart)
+	return dialect.Rebind(d.Dialect, query), d.WhereValues
 }
\ No newline at end of file
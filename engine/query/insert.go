@@ -1,81 +1,123 @@
-package query
-
-import (
-	"fmt"
-	"strings"
-)
-
-type InsertBuilder struct {
-	table      string
-	columns    []string
-	values     [][]interface{}
-	validated  bool
-}
-
-func NewInsert(table string) *InsertBuilder {
-	return &InsertBuilder{
-		table:     table,
-		columns:   []string{},
-		values:    [][]interface{}{},
-		validated: false,
-	}
-}
-
-// SetColumns define as colunas do INSERT
-func (b *InsertBuilder) SetColumns(cols []string) *InsertBuilder {
-	b.columns = cols
-	return b
-}
-
-// AddRow adiciona uma linha de valores
-func (b *InsertBuilder) AddRow(vals []interface{}) error {
-	// Validar que número de valores = número de colunas
-	if len(vals) != len(b.columns) {
-		return fmt.Errorf("número de valores (%d) diferente de colunas (%d)", 
-			len(vals), len(b.columns))
-	}
-	
-	b.values = append(b.values, vals)
-	return nil
-}
-
-// Build gera SQL MySQL com placeholders ?
-func (b *InsertBuilder) Build() (string, []interface{}, error) {
-	// Validações
-	if b.table == "" {
-		return "", nil, fmt.Errorf("tabela não definida")
-	}
-	if len(b.columns) == 0 {
-		return "", nil, fmt.Errorf("nenhuma coluna definida")
-	}
-	if len(b.values) == 0 {
-		return "", nil, fmt.Errorf("nenhum valor fornecido")
-	}
-	
-	// Montar placeholders
-	var placeholderGroups []string
-	var allValues []interface{}
-	
-	for _, row := range b.values {
-		// Para cada row: (?, ?, ?)
-		placeholders := make([]string, len(b.columns))
-		for i := range placeholders {
-			placeholders[i] = "?"
-		}
-		
-		placeholderGroups = append(placeholderGroups, 
-			"("+strings.Join(placeholders, ",")+")")
-		
-		allValues = append(allValues, row...)
-	}
-	
-	// Montar query final
-	query := fmt.Sprintf(
-		"INSERT INTO %s (%s) VALUES %s",
-		b.table,
-		strings.Join(b.columns, ","),
-		strings.Join(placeholderGroups, ","),
-	)
-	
-	return query, allValues, nil
-}
+package query
+
+import (
+	"fmt"
+	"strings"
+
+	"meu-provedor/engine/dialect"
+)
+
+type InsertBuilder struct {
+	table      string
+	columns    []string
+	values     [][]interface{}
+	validated  bool
+	dialect    dialect.Dialect
+	returning  string
+}
+
+func NewInsert(table string) *InsertBuilder {
+	return &InsertBuilder{
+		table:     table,
+		columns:   []string{},
+		values:    [][]interface{}{},
+		validated: false,
+	}
+}
+
+// SetColumns define as colunas do INSERT
+func (b *InsertBuilder) SetColumns(cols []string) *InsertBuilder {
+	b.columns = cols
+	return b
+}
+
+// SetDialect define o dialeto SQL (MySQL quando não definido)
+func (b *InsertBuilder) SetDialect(d dialect.Dialect) *InsertBuilder {
+	b.dialect = d
+	return b
+}
+
+// Returning pede a coluna de volta nos dialetos com RETURNING (PostgreSQL, SQLite)
+func (b *InsertBuilder) Returning(column string) *InsertBuilder {
+	b.returning = column
+	return b
+}
+
+// AddRow adiciona uma linha de valores
+func (b *InsertBuilder) AddRow(vals []interface{}) error {
+	// Validar que número de valores = número de colunas
+	if len(vals) != len(b.columns) {
+		return fmt.Errorf("número de valores (%d) diferente de colunas (%d)", 
+			len(vals), len(b.columns))
+	}
+	
+	b.values = append(b.values, vals)
+	return nil
+}
+
+// Build gera o SQL com os placeholders do dialeto
+func (b *InsertBuilder) Build() (string, []interface{}, error) {
+	// Validações
+	if b.table == "" {
+		return "", nil, fmt.Errorf("tabela não definida")
+	}
+	if len(b.columns) == 0 {
+		return "", nil, fmt.Errorf("nenhuma coluna definida")
+	}
+	if len(b.values) == 0 {
+		return "", nil, fmt.Errorf("nenhum valor fornecido")
+	}
+	
+	// Montar placeholders
+	var placeholderGroups []string
+	var allValues []interface{}
+	
+	for _, row := range b.values {
+		// Para cada row: (?, ?, ?)
+		placeholders := make([]string, len(b.columns))
+		for i := range placeholders {
+			placeholders[i] = "?"
+		}
+		
+		placeholderGroups = append(placeholderGroups, 
+			"("+strings.Join(placeholders, ",")+")")
+		
+		allValues = append(allValues, row...)
+	}
+	
+	quotedColumns := make([]string, len(b.columns))
+	for i, col := range b.columns {
+		quotedColumns[i] = QuoteIdent(col)
+	}
+	
+	// Montar query final
+	query := fmt.Sprintf(
+		"INSERT INTO %s (%s) VALUES %s",
+		QuoteIdent(b.table),
+		strings.Join(quotedColumns, ","),
+		strings.Join(placeholderGroups, ","),
+	)
+	
+	d := dialect.OrDefault(b.dialect)
+	if b.returning != "" {
+		query += d.Returning(b.returning)
+	}
+	
+	return dialect.Rebind(d, query), allValues, nil
+}
+
+// BuildRows gera o INSERT de uma única linha e os argumentos de cada linha,
+// para inserir as linhas uma a uma (um LastInsertId por linha)
+func (b *InsertBuilder) BuildRows() (string, [][]interface{}, error) {
+	if len(b.values) == 0 {
+		return "", nil, fmt.Errorf("nenhum valor fornecido")
+	}
+
+	single := *b
+	single.values = b.values[:1]
+	query, _, err := single.Build()
+	if err != nil {
+		return "", nil, err
+	}
+	return query, b.values, nil
+}
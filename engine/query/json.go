@@ -0,0 +1,51 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ============================================================================
+// JSON PATHS - Extração e atualização parcial de colunas JSON
+// ============================================================================
+
+// jsonPathPattern aceita caminhos simples: $, $.chave, $.a.b, $.lista[0]
+var jsonPathPattern = regexp.MustCompile(`^\$(\.[A-Za-z_][A-Za-z0-9_]*|\[[0-9]+\])*$`)
+
+// IsValidJSONPath valida um caminho JSON. Como o caminho é embutido no SQL
+// (o operador ->> exige literal), apenas a forma restrita acima é aceita.
+func IsValidJSONPath(path string) bool {
+	return jsonPathPattern.MatchString(path)
+}
+
+// JSONExtractExpr renderiza "coluna->>'$.caminho'" (valor sem aspas JSON)
+func JSONExtractExpr(column, path string) string {
+	return fmt.Sprintf("%s->>'%s'", column, path)
+}
+
+// ParseJSONPathKey separa uma chave "coluna->'$.caminho'" (ou ->>) em coluna e caminho
+func ParseJSONPathKey(key string) (column, path string, ok bool) {
+	idx := strings.Index(key, "->")
+	if idx <= 0 {
+		return "", "", false
+	}
+	column = strings.TrimSpace(key[:idx])
+	path = strings.TrimPrefix(key[idx+2:], ">")
+	path = strings.Trim(strings.TrimSpace(path), "'\"")
+	return column, path, true
+}
+
+// JSONPathAlias gera o nome padrão de uma projeção JSON: attrs + $.cor -> attrs_cor
+func JSONPathAlias(column, path string) string {
+	var b strings.Builder
+	b.WriteString(column)
+	for _, c := range strings.TrimPrefix(path, "$") {
+		if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '_' {
+			b.WriteRune(c)
+		} else if c == '.' || c == '[' {
+			b.WriteRune('_')
+		}
+	}
+	return b.String()
+}
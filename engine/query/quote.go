@@ -0,0 +1,73 @@
+package query
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ============================================================================
+// IDENTIFIER QUOTING - Escape único de tabelas, aliases e colunas
+// ============================================================================
+//
+// Os builders delimitam identificadores com crases (forma do MySQL). Nos
+// demais dialetos, dialect.Rebind converte as crases no delimitador próprio
+// ao montar a query, então QuoteIdent é o único ponto de escape.
+
+// QuoteIdent delimita um identificador, duplicando crases internas
+func QuoteIdent(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// QuoteRef delimita uma referência "coluna", "alias.coluna", "alias.*" ou "*"
+func QuoteRef(ref string) string {
+	parts := strings.Split(ref, ".")
+	for i, part := range parts {
+		if part != "*" {
+			parts[i] = QuoteIdent(part)
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// IsColumnRef indica se a string é uma referência simples ("coluna",
+// "alias.coluna", "alias.*" ou "*"), sem expressões
+func IsColumnRef(ref string) bool {
+	parts := strings.Split(ref, ".")
+	if len(parts) > 2 {
+		return false
+	}
+	for i, part := range parts {
+		if part == "*" && i == len(parts)-1 {
+			continue
+		}
+		if !IsValidIdentifier(part) {
+			return false
+		}
+	}
+	return true
+}
+
+// projectionAlias reconhece "referência AS nome" em colunas do SELECT
+var projectionAlias = regexp.MustCompile(`(?i)^\s*([A-Za-z0-9_.*]+)\s+AS\s+([A-Za-z0-9_]+)\s*$`)
+
+// quoteExpr delimita referências simples (com ou sem "AS nome"). Expressões
+// (funções, caminhos JSON, trechos já delimitados) são mantidas como vieram.
+func quoteExpr(expr string) string {
+	trimmed := strings.TrimSpace(expr)
+	if IsColumnRef(trimmed) {
+		return QuoteRef(trimmed)
+	}
+	if m := projectionAlias.FindStringSubmatch(trimmed); m != nil && IsColumnRef(m[1]) {
+		return QuoteRef(m[1]) + " AS " + QuoteIdent(m[2])
+	}
+	return expr
+}
+
+// quoteExprs aplica quoteExpr a uma lista de colunas
+func quoteExprs(exprs []string) []string {
+	out := make([]string, len(exprs))
+	for i, expr := range exprs {
+		out[i] = quoteExpr(expr)
+	}
+	return out
+}
@@ -0,0 +1,197 @@
+package query
+
+import (
+	"testing"
+	"time"
+
+	"meu-provedor/engine/dialect"
+)
+
+func TestQuoteIdent(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"simples", "nome", "`nome`"},
+		{"palavra reservada order", "order", "`order`"},
+		{"palavra reservada group", "group", "`group`"},
+		{"crase interna", "a`b", "`a``b`"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := QuoteIdent(tt.in); got != tt.want {
+				t.Errorf("QuoteIdent(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuoteExpr(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"coluna", "key", "`key`"},
+		{"alias.coluna", "o.order", "`o`.`order`"},
+		{"alias.*", "o.*", "`o`.*"},
+		{"asterisco", "*", "*"},
+		{"com AS", "o.select AS s", "`o`.`select` AS `s`"},
+		{"com as minúsculo", "group as g", "`group` AS `g`"},
+		{"função", "COUNT(*)", "COUNT(*)"},
+		{"já delimitada", "`o`.`order`", "`o`.`order`"},
+		{"três partes", "a.b.c", "a.b.c"},
+		{"expressão JSON", "attrs->>'$.cor'", "attrs->>'$.cor'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := quoteExpr(tt.in); got != tt.want {
+				t.Errorf("quoteExpr(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildersQuoteReservedWords(t *testing.T) {
+	deletedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		build func() string
+		want  string
+	}{
+		{
+			name: "select",
+			build: func() string {
+				return NewSelect("p_order", "o").
+					SetColumns([]string{"o.key", "group AS g"}).
+					AddWhereEq("o.order", 1).
+					Build()
+			},
+			want: "SELECT `o`.`key`, `group` AS `g` FROM `p_order` AS `o` WHERE `o`.`order` = ?",
+		},
+		{
+			name: "select com join",
+			build: func() string {
+				return NewSelect("p_order", "o").
+					AddJoin("LEFT", "p_group", "g", "`g`.`id` = `o`.`group`").
+					Build()
+			},
+			want: "SELECT * FROM `p_order` AS `o` LEFT JOIN `p_group` AS `g` ON `g`.`id` = `o`.`group`",
+		},
+		{
+			name: "select com limite por grupo",
+			build: func() string {
+				return NewSelect("p_order", "o").
+					AddWhereEq("o.key", 1).
+					SetOrderBy("`o`.`order` DESC").
+					SetLimitPerGroup("o.group", 2).
+					Build()
+			},
+			want: "SELECT * FROM (SELECT `o`.*, ROW_NUMBER() OVER (PARTITION BY `o`.`group` ORDER BY `o`.`order` DESC) AS `_group_row` FROM `p_order` AS `o` WHERE `o`.`key` = ?) AS `_grouped` WHERE `_group_row` <= 2 ORDER BY `_group_row`",
+		},
+		{
+			name: "join select",
+			build: func() string {
+				b := NewJoinSelect("p_order", "select").
+					AddColumns("select.*", "table.key").
+					AddJoin(JoinConfig{Type: "INNER", Table: "p_table", Alias: "table", On: "1 = 1"}).
+					AddWhereEq("table.group", 2)
+				sql, _ := b.Build()
+				return sql
+			},
+			want: "SELECT `select`.*, `table`.`key` FROM `p_order` AS `select` INNER JOIN `p_table` AS `table` ON 1 = 1 WHERE `table`.`group` = ?",
+		},
+		{
+			name: "aggregate",
+			build: func() string {
+				return NewAggregate("p_order", "", "SUM", "order").AddWhereEq("key", 1).Build()
+			},
+			want: "SELECT SUM(`order`) FROM `p_order` AS `p_order` WHERE `key` = ?",
+		},
+		{
+			name: "insert",
+			build: func() string {
+				b := NewInsert("p_order").SetColumns([]string{"order", "key"})
+				if err := b.AddRow([]interface{}{1, 2}); err != nil {
+					t.Fatal(err)
+				}
+				sql, _, err := b.Build()
+				if err != nil {
+					t.Fatal(err)
+				}
+				return sql
+			},
+			want: "INSERT INTO `p_order` (`order`,`key`) VALUES (?,?)",
+		},
+		{
+			name: "update",
+			build: func() string {
+				b := NewUpdate("p_order").Set("group", 1).WithVersion("version", 3).WhereEq("key", 2)
+				if err := b.SetOp("order", "increment", 1); err != nil {
+					t.Fatal(err)
+				}
+				sql, _ := b.Build()
+				return sql
+			},
+			want: "UPDATE `p_order` SET `group` = ?, `version` = `version` + 1, `order` = `order` + ? WHERE `version` = ? AND `key` = ?",
+		},
+		{
+			name: "delete",
+			build: func() string {
+				sql, _ := NewDelete("p_order").WhereEq("order", 1).Build()
+				return sql
+			},
+			want: "DELETE FROM `p_order` WHERE `order` = ?",
+		},
+		{
+			name: "soft delete",
+			build: func() string {
+				sql, _ := NewSoftDelete("p_order").AddWhereEq("key", 1).Build(deletedAt)
+				return sql
+			},
+			want: "UPDATE `p_order` SET `deleted_at` = ? WHERE `key` = ?",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.build(); got != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildersRebindIdentifiers(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect dialect.Dialect
+		want    string
+	}{
+		{"mysql", dialect.MySQL, "SELECT `o`.`key` FROM `p_order` AS `o` WHERE `o`.`order` = ? AND note = '`x`?'"},
+		{"postgres", dialect.Postgres, `SELECT "o"."key" FROM "p_order" AS "o" WHERE "o"."order" = $1 AND note = '` + "`x`" + `?'`},
+		{"sqlite", dialect.SQLite, `SELECT "o"."key" FROM "p_order" AS "o" WHERE "o"."order" = ? AND note = '` + "`x`" + `?'`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewSelect("p_order", "o").
+				SetColumns([]string{"o.key"}).
+				AddWhereEq("o.order", 1).
+				AddWhere("note = '`x`?'").
+				SetDialect(tt.dialect).
+				Build()
+			if got != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRebindEscapedBacktick(t *testing.T) {
+	got := dialect.Rebind(dialect.Postgres, "SELECT "+QuoteIdent(`a"b`+"`c"))
+	want := `SELECT "a""b` + "`" + `c"`
+	if got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
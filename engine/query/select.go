@@ -1,133 +1,209 @@
-package query
-
-import (
-	"fmt"
-	"strings"
-)
-
-// ============================================================================
-// SELECT BUILDER
-// ============================================================================
-
-// SelectBuilder constrói queries SELECT
-type SelectBuilder struct {
-	Table   string
-	Alias   string
-	Columns []string
-	Joins   []string
-	Where   []string
-	GroupBy string
-	Having  string
-	OrderBy string
-	Limit   int
-	Offset  int
-	Values  []interface{}
-}
-
-// NewSelect cria um novo SelectBuilder
-func NewSelect(table, alias string) *SelectBuilder {
-	if alias == "" {
-		alias = table
-	}
-	return &SelectBuilder{
-		Table:   table,
-		Alias:   alias,
-		Columns: []string{"*"},
-		Joins:   []string{},
-		Where:   []string{},
-		Values:  []interface{}{},
-	}
-}
-
-// SetColumns define as colunas a serem selecionadas
-func (s *SelectBuilder) SetColumns(cols []string) *SelectBuilder {
-	if len(cols) > 0 {
-		s.Columns = cols
-	}
-	return s
-}
-
-// AddJoin adiciona uma cláusula JOIN
-func (s *SelectBuilder) AddJoin(joinType, table, alias, on string) *SelectBuilder {
-	joinType = NormalizeJoinType(joinType)
-	if alias == "" {
-		alias = table
-	}
-	joinClause := fmt.Sprintf("%s JOIN %s AS %s ON %s", joinType, table, alias, on)
-	s.Joins = append(s.Joins, joinClause)
-	return s
-}
-
-// AddWhere adiciona condição WHERE
-func (s *SelectBuilder) AddWhere(condition string, args ...interface{}) *SelectBuilder {
-	s.Where = append(s.Where, condition)
-	s.Values = append(s.Values, args...)
-	return s
-}
-
-// SetGroupBy define cláusula GROUP BY
-func (s *SelectBuilder) SetGroupBy(group string) *SelectBuilder {
-	s.GroupBy = group
-	return s
-}
-
-// SetHaving define cláusula HAVING
-func (s *SelectBuilder) SetHaving(having string) *SelectBuilder {
-	s.Having = having
-	return s
-}
-
-// SetOrderBy define cláusula ORDER BY
-func (s *SelectBuilder) SetOrderBy(order string) *SelectBuilder {
-	s.OrderBy = order
-	return s
-}
-
-// SetLimitOffset define LIMIT e OFFSET
-func (s *SelectBuilder) SetLimitOffset(limit, offset int) *SelectBuilder {
-	s.Limit = limit
-	s.Offset = offset
-	return s
-}
-
-// Build gera a query SQL final
-func (s *SelectBuilder) Build() string {
-	query := fmt.Sprintf("SELECT %s FROM %s AS %s",
-		strings.Join(s.Columns, ", "),
-		s.Table,
-		s.Alias,
-	)
-
-	if len(s.Joins) > 0 {
-		query += " " + strings.Join(s.Joins, " ")
-	}
-
-	if len(s.Where) > 0 {
-		query += " WHERE " + strings.Join(s.Where, " AND ")
-	}
-
-	if s.GroupBy != "" {
-		query += " GROUP BY " + s.GroupBy
-		if s.Having != "" {
-			query += " HAVING " + s.Having
-		}
-	}
-
-	if s.OrderBy != "" {
-		query += " ORDER BY " + s.OrderBy
-	}
-
-	if s.Limit > 0 {
-		query += fmt.Sprintf(" LIMIT %d", s.Limit)
-		if s.Offset > 0 {
-			query += fmt.Sprintf(" OFFSET %d", s.Offset)
-		}
-	}
-
-	return query
-}
-
-// GetValues retorna os valores dos parâmetros
-func (s *SelectBuilder) GetValues() []interface{} {
-	return s.Values
+package query
+
+import (
+	"fmt"
+	"strings"
+
+	"meu-provedor/engine/dialect"
+)
+
+// ============================================================================
+// SELECT BUILDER
+// ============================================================================
+
+// SelectBuilder constrói queries SELECT
+type SelectBuilder struct {
+	Table   string
+	Alias   string
+	Columns []string
+	Joins   []string
+	Where   []string
+	GroupBy string
+	Having  string
+	OrderBy string
+	Limit   int
+	Offset  int
+	Values  []interface{}
+	Dialect dialect.Dialect
+	// MaxExecutionMs limita o tempo do SELECT no servidor (0 = sem limite)
+	MaxExecutionMs int
+	// GroupPartition/GroupLimit limitam as linhas por grupo (SetLimitPerGroup)
+	GroupPartition string
+	GroupLimit     int
+}
+
+// GroupRowColumn é a coluna com a posição da linha no grupo (SetLimitPerGroup)
+const GroupRowColumn = "_group_row"
+
+// NewSelect cria um novo SelectBuilder
+func NewSelect(table, alias string) *SelectBuilder {
+	if alias == "" {
+		alias = table
+	}
+	return &SelectBuilder{
+		Table:   table,
+		Alias:   alias,
+		Columns: []string{"*"},
+		Joins:   []string{},
+		Where:   []string{},
+		Values:  []interface{}{},
+	}
+}
+
+// SetColumns define as colunas a serem selecionadas
+func (s *SelectBuilder) SetColumns(cols []string) *SelectBuilder {
+	if len(cols) > 0 {
+		s.Columns = cols
+	}
+	return s
+}
+
+// AddJoin adiciona uma cláusula JOIN
+func (s *SelectBuilder) AddJoin(joinType, table, alias, on string) *SelectBuilder {
+	joinType = NormalizeJoinType(joinType)
+	if alias == "" {
+		alias = table
+	}
+	joinClause := fmt.Sprintf("%s JOIN %s AS %s ON %s", joinType, QuoteIdent(table), QuoteIdent(alias), on)
+	s.Joins = append(s.Joins, joinClause)
+	return s
+}
+
+// AddWhere adiciona condição WHERE
+func (s *SelectBuilder) AddWhere(condition string, args ...interface{}) *SelectBuilder {
+	s.Where = append(s.Where, condition)
+	s.Values = append(s.Values, args...)
+	return s
+}
+
+// AddWhereEq adiciona "referência = ?" com a coluna delimitada
+func (s *SelectBuilder) AddWhereEq(ref string, value interface{}) *SelectBuilder {
+	return s.AddWhere(quoteExpr(ref)+" = ?", value)
+}
+
+// SetGroupBy define cláusula GROUP BY
+func (s *SelectBuilder) SetGroupBy(group string) *SelectBuilder {
+	s.GroupBy = group
+	return s
+}
+
+// SetHaving define cláusula HAVING
+func (s *SelectBuilder) SetHaving(having string) *SelectBuilder {
+	s.Having = having
+	return s
+}
+
+// SetOrderBy define cláusula ORDER BY
+func (s *SelectBuilder) SetOrderBy(order string) *SelectBuilder {
+	s.OrderBy = order
+	return s
+}
+
+// SetLimitOffset define LIMIT e OFFSET
+func (s *SelectBuilder) SetLimitOffset(limit, offset int) *SelectBuilder {
+	s.Limit = limit
+	s.Offset = offset
+	return s
+}
+
+// SetLimitPerGroup limita a limit linhas por valor de partition (na ordem do
+// ORDER BY) com ROW_NUMBER() OVER (PARTITION BY ...). O resultado traz a
+// coluna GroupRowColumn e vem ordenado por ela; LIMIT/OFFSET valem sobre o total.
+func (s *SelectBuilder) SetLimitPerGroup(partition string, limit int) *SelectBuilder {
+	s.GroupPartition = partition
+	s.GroupLimit = limit
+	return s
+}
+
+// SetDialect define o dialeto SQL (MySQL quando não definido)
+func (s *SelectBuilder) SetDialect(d dialect.Dialect) *SelectBuilder {
+	s.Dialect = d
+	return s
+}
+
+// SetMaxExecutionTime define o tempo máximo de execução no servidor (ms)
+func (s *SelectBuilder) SetMaxExecutionTime(ms int) *SelectBuilder {
+	s.MaxExecutionMs = ms
+	return s
+}
+
+// Build gera a query SQL final
+func (s *SelectBuilder) Build() string {
+	d := dialect.OrDefault(s.Dialect)
+	if s.GroupLimit > 0 {
+		return dialect.Rebind(d, s.buildPerGroup(d))
+	}
+
+	query := fmt.Sprintf("SELECT %s%s FROM %s AS %s",
+		d.ExecutionTimeHint(s.MaxExecutionMs),
+		strings.Join(quoteExprs(s.Columns), ", "),
+		QuoteIdent(s.Table),
+		QuoteIdent(s.Alias),
+	)
+
+	if len(s.Joins) > 0 {
+		query += " " + strings.Join(s.Joins, " ")
+	}
+
+	if len(s.Where) > 0 {
+		query += " WHERE " + strings.Join(s.Where, " AND ")
+	}
+
+	if s.GroupBy != "" {
+		query += " GROUP BY " + s.GroupBy
+		if s.Having != "" {
+			query += " HAVING " + s.Having
+		}
+	}
+
+	if s.OrderBy != "" {
+		query += " ORDER BY " + s.OrderBy
+	}
+
+	query += d.LimitOffset(s.Limit, s.Offset)
+
+	return dialect.Rebind(d, query)
+}
+
+// buildPerGroup numera as linhas de cada grupo numa subquery e filtra por GroupLimit
+func (s *SelectBuilder) buildPerGroup(d dialect.Dialect) string {
+	columns := quoteExprs(s.Columns)
+	for i, col := range columns {
+		if col == "*" {
+			columns[i] = QuoteIdent(s.Alias) + ".*"
+		}
+	}
+
+	window := "PARTITION BY " + quoteExpr(s.GroupPartition)
+	if s.OrderBy != "" {
+		window += " ORDER BY " + s.OrderBy
+	}
+	columns = append(columns, fmt.Sprintf("ROW_NUMBER() OVER (%s) AS %s", window, QuoteIdent(GroupRowColumn)))
+
+	inner := fmt.Sprintf("SELECT %s FROM %s AS %s",
+		strings.Join(columns, ", "),
+		QuoteIdent(s.Table),
+		QuoteIdent(s.Alias),
+	)
+	if len(s.Joins) > 0 {
+		inner += " " + strings.Join(s.Joins, " ")
+	}
+	if len(s.Where) > 0 {
+		inner += " WHERE " + strings.Join(s.Where, " AND ")
+	}
+
+	return fmt.Sprintf("SELECT %s* FROM (%s) AS %s WHERE %s <= %d ORDER BY %s%s",
+		d.ExecutionTimeHint(s.MaxExecutionMs),
+		inner,
+		QuoteIdent("_grouped"),
+		QuoteIdent(GroupRowColumn),
+		s.GroupLimit,
+		QuoteIdent(GroupRowColumn),
+		d.LimitOffset(s.Limit, s.Offset),
+	)
+}
+
+// GetValues retorna os valores dos parâmetros
+func (s *SelectBuilder) GetValues() []interface{} {
+	return s.Values
 }
\ No newline at end of file
@@ -0,0 +1,121 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ============================================================================
+// STRUCTURED SPECS - Renderização de colunas, ordenação e condições de JOIN
+// ============================================================================
+
+// ColumnExpr renderiza "alias.coluna" (ou só "coluna" sem alias) delimitados
+func ColumnExpr(alias, column string) string {
+	if alias == "" {
+		return QuoteIdent(column)
+	}
+	return QuoteIdent(alias) + "." + QuoteIdent(column)
+}
+
+// ProjectionExpr renderiza "alias.coluna AS nome"
+func ProjectionExpr(alias, column, as string) string {
+	expr := ColumnExpr(alias, column)
+	if as != "" {
+		expr += " AS " + QuoteIdent(as)
+	}
+	return expr
+}
+
+// NormalizeDirection normaliza a direção de ordenação (ASC padrão)
+func NormalizeDirection(direction string) (string, error) {
+	switch strings.ToUpper(strings.TrimSpace(direction)) {
+	case "", "ASC":
+		return "ASC", nil
+	case "DESC":
+		return "DESC", nil
+	default:
+		return "", fmt.Errorf("direção inválida: %s", direction)
+	}
+}
+
+// SortExprs renderiza a ordenação de uma coluna. MySQL não suporta
+// NULLS FIRST/LAST, então a posição dos nulos é emulada com "col IS NULL".
+func SortExprs(column, direction, nulls string) ([]string, error) {
+	dir, err := NormalizeDirection(direction)
+	if err != nil {
+		return nil, err
+	}
+
+	var exprs []string
+	switch strings.ToLower(strings.TrimSpace(nulls)) {
+	case "":
+	case "first":
+		exprs = append(exprs, column+" IS NULL DESC")
+	case "last":
+		exprs = append(exprs, column+" IS NULL ASC")
+	default:
+		return nil, fmt.Errorf("posição de nulos inválida: %s", nulls)
+	}
+
+	return append(exprs, column+" "+dir), nil
+}
+
+// EqualityOn renderiza condições de JOIN "a = b AND c = d"
+func EqualityOn(pairs [][2]string) string {
+	parts := make([]string, len(pairs))
+	for i, p := range pairs {
+		parts[i] = p[0] + " = " + p[1]
+	}
+	return strings.Join(parts, " AND ")
+}
+
+// ComparisonExpr renderiza uma comparação parametrizada e a quantidade de
+// placeholders esperada (-1 para IN, que usa um por item)
+func ComparisonExpr(column, op string, count int) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(op)) {
+	case "", "eq":
+		return column + " = ?", nil
+	case "ne":
+		return column + " <> ?", nil
+	case "gt":
+		return column + " > ?", nil
+	case "gte":
+		return column + " >= ?", nil
+	case "lt":
+		return column + " < ?", nil
+	case "lte":
+		return column + " <= ?", nil
+	case "like":
+		return column + " LIKE ?", nil
+	case "in":
+		if count <= 0 {
+			return "", fmt.Errorf("operador in exige ao menos um valor")
+		}
+		return column + " IN " + BuildPlaceholders(count), nil
+	case "not_in":
+		if count <= 0 {
+			return "", fmt.Errorf("operador not_in exige ao menos um valor")
+		}
+		return column + " NOT IN " + BuildPlaceholders(count), nil
+	case "is_null":
+		return column + " IS NULL", nil
+	case "not_null":
+		return column + " IS NOT NULL", nil
+	default:
+		return "", fmt.Errorf("operador inválido: %s", op)
+	}
+}
+
+// MatchAgainst renderiza "MATCH(cols) AGAINST(? IN ... MODE)" para busca full-text
+func MatchAgainst(columns []string, mode string) (string, error) {
+	var modifier string
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "", "natural":
+		modifier = "IN NATURAL LANGUAGE MODE"
+	case "boolean":
+		modifier = "IN BOOLEAN MODE"
+	default:
+		return "", fmt.Errorf("modo de busca inválido: %s", mode)
+	}
+	return fmt.Sprintf("MATCH(%s) AGAINST(? %s)", strings.Join(columns, ", "), modifier), nil
+}
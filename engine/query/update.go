@@ -1,63 +1,174 @@
-package query
-
-import (
-	"fmt"
-	"strings"
-)
-
-// ============================================================================
-// UPDATE BUILDER
-// ============================================================================
-
-// UpdateBuilder constrói queries UPDATE
-type UpdateBuilder struct {
-	Table        string
-	Sets         []string
-	SetValues    []interface{}
-	WhereClauses []string
-	WhereValues  []interface{}
-}
-
-// NewUpdate cria um novo UpdateBuilder
-func NewUpdate(table string) *UpdateBuilder {
-	return &UpdateBuilder{
-		Table:        table,
-		Sets:         []string{},
-		SetValues:    []interface{}{},
-		WhereClauses: []string{},
-		WhereValues:  []interface{}{},
-	}
-}
-
-// Set adiciona uma coluna e valor para atualizar
-func (u *UpdateBuilder) Set(col string, val interface{}) *UpdateBuilder {
-	u.Sets = append(u.Sets, fmt.Sprintf("%s = ?", col))
-	u.SetValues = append(u.SetValues, val)
-	return u
-}
-
-// Where adiciona condição WHERE
-func (u *UpdateBuilder) Where(condition string, args ...interface{}) *UpdateBuilder {
-	u.WhereClauses = append(u.WhereClauses, condition)
-	u.WhereValues = append(u.WhereValues, args...)
-	return u
-}
-
-// WhereRaw adiciona filtro customizado sem parâmetros
-func (u *UpdateBuilder) WhereRaw(raw string) *UpdateBuilder {
-	u.WhereClauses = append(u.WhereClauses, "("+raw+")")
-	return u
-}
-
-// Build gera a query SQL final
-func (u *UpdateBuilder) Build() (string, []interface{}) {
-	setPart := strings.Join(u.Sets, ", ")
-	wherePart := strings.Join(u.WhereClauses, " AND ")
-
-	query := fmt.Sprintf("UPDATE %s SET %s WHERE %s", u.Table, setPart, wherePart)
-
-	// Combina valores do SET com valores do WHERE
-	allValues := append(u.SetValues, u.WhereValues...)
-
-	return query, allValues
+package query
+
+import (
+	"fmt"
+	"strings"
+
+	"meu-provedor/engine/dialect"
+)
+
+// ============================================================================
+// UPDATE BUILDER
+// ============================================================================
+
+// UpdateBuilder constrói queries UPDATE
+type UpdateBuilder struct {
+	Table        string
+	Sets         []string
+	SetValues    []interface{}
+	WhereClauses []string
+	WhereValues  []interface{}
+	Dialect      dialect.Dialect
+}
+
+// NewUpdate cria um novo UpdateBuilder
+func NewUpdate(table string) *UpdateBuilder {
+	return &UpdateBuilder{
+		Table:        table,
+		Sets:         []string{},
+		SetValues:    []interface{}{},
+		WhereClauses: []string{},
+		WhereValues:  []interface{}{},
+	}
+}
+
+// Set adiciona uma coluna e valor para atualizar
+func (u *UpdateBuilder) Set(col string, val interface{}) *UpdateBuilder {
+	u.Sets = append(u.Sets, fmt.Sprintf("%s = ?", QuoteRef(col)))
+	u.SetValues = append(u.SetValues, val)
+	return u
+}
+
+// Decimal é um valor DECIMAL exato em texto. Nos operadores aritméticos o
+// banco converte o texto com CAST para DECIMAL (sem passar por DOUBLE).
+// Precision 0 usa DECIMAL(65,30).
+type Decimal struct {
+	Value     string
+	Precision int
+	Scale     int
+}
+
+// placeholder retorna o parâmetro convertido para DECIMAL
+func (d Decimal) placeholder() string {
+	if d.Precision <= 0 {
+		return "CAST(? AS DECIMAL(65,30))"
+	}
+	return fmt.Sprintf("CAST(? AS DECIMAL(%d,%d))", d.Precision, d.Scale)
+}
+
+// SetOp adiciona uma atualização calculada pelo próprio banco (atômica):
+// increment, decrement, multiply, set_null, now, coalesce, least, greatest
+func (u *UpdateBuilder) SetOp(col, op string, val interface{}) error {
+	var expr string
+	withValue := true
+	col = QuoteRef(col)
+
+	operand := "?"
+	if d, ok := val.(Decimal); ok {
+		operand = d.placeholder()
+		val = d.Value
+	}
+
+	switch strings.ToLower(strings.TrimSpace(op)) {
+	case "increment":
+		expr = fmt.Sprintf("%s = %s + %s", col, col, operand)
+	case "decrement":
+		expr = fmt.Sprintf("%s = %s - %s", col, col, operand)
+	case "multiply":
+		expr = fmt.Sprintf("%s = %s * %s", col, col, operand)
+	case "coalesce":
+		expr = fmt.Sprintf("%s = COALESCE(%s, %s)", col, col, operand)
+	case "least":
+		expr = fmt.Sprintf("%s = LEAST(%s, %s)", col, col, operand)
+	case "greatest":
+		expr = fmt.Sprintf("%s = GREATEST(%s, %s)", col, col, operand)
+	case "set_null":
+		expr = fmt.Sprintf("%s = NULL", col)
+		withValue = false
+	case "now":
+		// CURRENT_TIMESTAMP existe em todos os dialetos (NOW() não existe no SQLite)
+		expr = fmt.Sprintf("%s = CURRENT_TIMESTAMP", col)
+		withValue = false
+	default:
+		return fmt.Errorf("operador de update inválido: %s", op)
+	}
+
+	u.Sets = append(u.Sets, expr)
+	if withValue {
+		u.SetValues = append(u.SetValues, val)
+	}
+	return nil
+}
+
+// SetJSON altera uma chave de uma coluna JSON (JSON_SET). doc é o valor já codificado em JSON.
+func (u *UpdateBuilder) SetJSON(col, path, doc string) *UpdateBuilder {
+	col = QuoteRef(col)
+	u.Sets = append(u.Sets, fmt.Sprintf("%s = JSON_SET(COALESCE(%s, JSON_OBJECT()), ?, CAST(? AS JSON))", col, col))
+	u.SetValues = append(u.SetValues, path, doc)
+	return u
+}
+
+// RemoveJSON remove chaves de uma coluna JSON (JSON_REMOVE)
+func (u *UpdateBuilder) RemoveJSON(col string, paths ...string) *UpdateBuilder {
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(paths)), ", ")
+	col = QuoteRef(col)
+	u.Sets = append(u.Sets, fmt.Sprintf("%s = JSON_REMOVE(%s, %s)", col, col, placeholders))
+	for _, path := range paths {
+		u.SetValues = append(u.SetValues, path)
+	}
+	return u
+}
+
+// MergeJSON aplica um patch (RFC 7396) em uma coluna JSON (JSON_MERGE_PATCH)
+func (u *UpdateBuilder) MergeJSON(col, doc string) *UpdateBuilder {
+	col = QuoteRef(col)
+	u.Sets = append(u.Sets, fmt.Sprintf("%s = JSON_MERGE_PATCH(COALESCE(%s, JSON_OBJECT()), CAST(? AS JSON))", col, col))
+	u.SetValues = append(u.SetValues, doc)
+	return u
+}
+
+// WithVersion exige a versão esperada no WHERE e incrementa a coluna de versão
+func (u *UpdateBuilder) WithVersion(col string, expected int64) *UpdateBuilder {
+	col = QuoteRef(col)
+	u.Sets = append(u.Sets, fmt.Sprintf("%s = %s + 1", col, col))
+	u.WhereClauses = append(u.WhereClauses, col+" = ?")
+	u.WhereValues = append(u.WhereValues, expected)
+	return u
+}
+
+// Where adiciona condição WHERE
+func (u *UpdateBuilder) Where(condition string, args ...interface{}) *UpdateBuilder {
+	u.WhereClauses = append(u.WhereClauses, condition)
+	u.WhereValues = append(u.WhereValues, args...)
+	return u
+}
+
+// WhereEq adiciona "referência = ?" com a coluna delimitada
+func (u *UpdateBuilder) WhereEq(ref string, value interface{}) *UpdateBuilder {
+	return u.Where(quoteExpr(ref)+" = ?", value)
+}
+
+// WhereRaw adiciona filtro customizado sem parâmetros
+func (u *UpdateBuilder) WhereRaw(raw string) *UpdateBuilder {
+	u.WhereClauses = append(u.WhereClauses, "("+raw+")")
+	return u
+}
+
+// SetDialect define o dialeto SQL (MySQL quando não definido)
+func (u *UpdateBuilder) SetDialect(d dialect.Dialect) *UpdateBuilder {
+	u.Dialect = d
+	return u
+}
+
+// Build gera a query SQL final
+func (u *UpdateBuilder) Build() (string, []interface{}) {
+	setPart := strings.Join(u.Sets, ", ")
+	wherePart := strings.Join(u.WhereClauses, " AND ")
+
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE %s", QuoteIdent(u.Table), setPart, wherePart)
+
+	// Combina valores do SET com valores do WHERE
+	allValues := append(u.SetValues, u.WhereValues...)
+
+	return dialect.Rebind(u.Dialect, query), allValues
 }
\ No newline at end of file
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"meu-provedor/services/data_service"
+	"meu-provedor/models"
+)
+
+// ============================================================================
+// SEARCH HANDLERS
+// ============================================================================
+
+// SearchHandler processa buscas full-text
+func SearchHandler(w http.ResponseWriter, r *http.Request) {
+	var req models.SearchRequest
+	
+	// Decodificar JSON
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondAppError(w, models.ErrInvalidJSON)
+		return
+	}
+
+	// Executar busca
+	result, err := services.ExecuteSearch(r.Context(), req)
+	if err != nil {
+		RespondAppError(w, err)
+		return
+	}
+
+	// Retornar resultado paginado
+	RespondSuccess(w, map[string]interface{}{
+		"success": true,
+		"data":    result.Rows,
+		"count":   len(result.Rows),
+		"total":   result.Total,
+		"limit":   result.Limit,
+		"offset":  req.Offset,
+	})
+}
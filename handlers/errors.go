@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"meu-provedor/engine/dialect"
+	"meu-provedor/models"
+	"meu-provedor/services/data_service"
+)
+
+// ============================================================================
+// ERROR MAPPING - Conversão de erros em status HTTP + envelope JSON
+// ============================================================================
+
+// kindStatus mapeia a categoria do erro para o status HTTP
+var kindStatus = map[models.ErrorKind]int{
+	models.KindValidation:    http.StatusBadRequest,
+	models.KindUnauthorized:  http.StatusUnauthorized,
+	models.KindForbidden:     http.StatusForbidden,
+	models.KindNotFound:      http.StatusNotFound,
+	models.KindConflict:      http.StatusConflict,
+	models.KindTooLarge:      http.StatusRequestEntityTooLarge,
+	models.KindUnprocessable: http.StatusUnprocessableEntity,
+	models.KindRateLimited:   http.StatusTooManyRequests,
+	models.KindUnavailable:   http.StatusServiceUnavailable,
+	models.KindCanceled:      http.StatusRequestTimeout,
+	models.KindTimeout:       http.StatusGatewayTimeout,
+	models.KindInternal:      http.StatusInternalServerError,
+}
+
+// ClassifyError converte qualquer erro em AppError + status HTTP
+func ClassifyError(err error) (*models.AppError, int) {
+	var appErr *models.AppError
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		// Contextos expirados fora do query guard (ex.: DDL do schema)
+		return ClassifyError(fmt.Errorf("%w: %v", models.ErrQueryTimeout, err))
+
+	case errors.Is(err, context.Canceled):
+		return ClassifyError(fmt.Errorf("%w: %v", models.ErrQueryCanceled, err))
+
+	case dialect.TranslateError(err) != nil:
+		// Erros crus do driver (ex.: DDL do schema) são traduzidos aqui
+		translated := services.TranslateDBError(err, "")
+		errors.As(translated, &appErr)
+		return classifyAppError(translated, appErr)
+
+	case errors.As(err, &appErr):
+		return classifyAppError(err, appErr)
+
+	case errors.Is(err, sql.ErrNoRows):
+		return models.NewError(models.KindNotFound, "NOT_FOUND", err.Error()), http.StatusNotFound
+
+	default:
+		log.Printf("❌ INTERNAL_ERROR: %v", err)
+		return models.NewError(models.KindInternal, "INTERNAL_ERROR", "erro interno do servidor"), http.StatusInternalServerError
+	}
+}
+
+// classifyAppError usa a mensagem completa do erro, exceto nos erros internos:
+// o erro encadeado pode trazer SQL e valores, então vai só para o log
+func classifyAppError(err error, appErr *models.AppError) (*models.AppError, int) {
+	classified := *appErr
+	status, ok := kindStatus[classified.Kind]
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+	if status == http.StatusInternalServerError {
+		log.Printf("❌ %s: %v", classified.Code, err)
+	} else {
+		classified.Message = err.Error()
+	}
+	return &classified, status
+}
+
+// RespondAppError envia o erro no envelope JSON padrão com o status mapeado
+func RespondAppError(w http.ResponseWriter, err error) {
+	appErr, status := ClassifyError(err)
+	writeErrorEnvelope(w, status, appErr.Code, appErr.Message, appErr.Details)
+}
+
+// writeErrorEnvelope escreve {"success": false, "error", "code", "details"}
+func writeErrorEnvelope(w http.ResponseWriter, status int, code, message string, details map[string]interface{}) {
+	body := map[string]interface{}{
+		"success": false,
+		"error":   message,
+		"code":    code,
+	}
+	if len(details) > 0 {
+		body["details"] = details
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// codeForStatus retorna um código genérico para erros sem tipo
+func codeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return "BAD_REQUEST"
+	case http.StatusUnauthorized:
+		return "UNAUTHORIZED"
+	case http.StatusForbidden:
+		return "FORBIDDEN"
+	case http.StatusNotFound:
+		return "NOT_FOUND"
+	case http.StatusConflict:
+		return "CONFLICT"
+	case http.StatusTooManyRequests:
+		return "RATE_LIMITED"
+	default:
+		return "INTERNAL_ERROR"
+	}
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	"meu-provedor/models"
+	"meu-provedor/security"
+	"meu-provedor/services/data_service"
+)
+
+// ============================================================================
+// DIAGNOSTIC HANDLERS - EXPLAIN e slow query log
+// ============================================================================
+
+// ExplainHandler retorna o plano de execução do SQL gerado para um select.
+// Aceita o corpo de /data/select ou de /data/join-select (identificado por "base").
+func ExplainHandler(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		RespondAppError(w, models.ErrInvalidJSON)
+		return
+	}
+
+	var probe map[string]json.RawMessage
+	if err := json.Unmarshal(body, &probe); err != nil {
+		RespondAppError(w, models.ErrInvalidJSON)
+		return
+	}
+
+	var result *models.ExplainResult
+	if _, isJoin := probe["base"]; isJoin {
+		var req models.AdvancedJoinSelectRequest
+		if err := json.NewDecoder(bytes.NewReader(body)).Decode(&req); err != nil {
+			RespondAppError(w, models.ErrInvalidJSON)
+			return
+		}
+		result, err = services.ExplainAdvancedJoinSelect(r.Context(), req)
+	} else {
+		var req models.AdvancedSelectRequest
+		if err := json.NewDecoder(bytes.NewReader(body)).Decode(&req); err != nil {
+			RespondAppError(w, models.ErrInvalidJSON)
+			return
+		}
+		result, err = services.ExplainAdvancedSelect(r.Context(), req)
+	}
+	if err != nil {
+		RespondAppError(w, err)
+		return
+	}
+
+	RespondSuccess(w, map[string]interface{}{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// ListSlowQueries retorna as consultas lentas mais recentes.
+// Filtros opcionais: project_id, id_instancia, limit. Chamadas com X-API-Key
+// só veem as consultas do projeto da key.
+func ListSlowQueries(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	var filter models.SlowQueryFilter
+	if v := q.Get("project_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id <= 0 {
+			RespondAppError(w, models.ErrInvalidProjectID)
+			return
+		}
+		filter.ProjectID = id
+	}
+	if v := q.Get("id_instancia"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id <= 0 {
+			RespondAppError(w, models.ErrInvalidInstanceID)
+			return
+		}
+		filter.InstanceID = id
+	}
+	if apiKey := r.Header.Get("X-API-Key"); apiKey != "" {
+		project, err := security.ValidateApiKey(apiKey)
+		if err != nil {
+			RespondAppError(w, err)
+			return
+		}
+		if filter.ProjectID > 0 && filter.ProjectID != int64(project.ID) {
+			RespondAppError(w, models.ErrInvalidAPIKey)
+			return
+		}
+		filter.ProjectID = int64(project.ID)
+	}
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			RespondAppError(w, models.NewValidationError("limit inválido"))
+			return
+		}
+		filter.Limit = limit
+	}
+
+	result := services.ListSlowQueries(filter)
+	RespondSuccess(w, map[string]interface{}{
+		"success": true,
+		"data":    result,
+		"count":   len(result),
+	})
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"meu-provedor/models"
+	"meu-provedor/security"
+	"meu-provedor/services/data_service"
+)
+
+// ============================================================================
+// HISTORY HANDLERS
+// ============================================================================
+
+// HistoryHandler lista o histórico de alterações de um registro
+func HistoryHandler(w http.ResponseWriter, r *http.Request) {
+	var req models.HistoryRequest
+	
+	// Decodificar JSON
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondAppError(w, models.ErrInvalidJSON)
+		return
+	}
+
+	entries, err := services.ListHistory(r.Context(), req)
+	if err != nil {
+		RespondAppError(w, err)
+		return
+	}
+
+	RespondSuccess(w, map[string]interface{}{
+		"success": true,
+		"data":    entries,
+		"count":   len(entries),
+	})
+}
+
+// RestoreHandler restaura um registro a partir de uma entrada do histórico
+func RestoreHandler(w http.ResponseWriter, r *http.Request) {
+	var req models.RestoreRequest
+	
+	// Decodificar JSON
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondAppError(w, models.ErrInvalidJSON)
+		return
+	}
+
+	req.Actor = security.CallerID(r)
+
+	row, err := services.RestoreFromHistory(r.Context(), req)
+	if err != nil {
+		RespondAppError(w, err)
+		return
+	}
+
+	RespondSuccess(w, map[string]interface{}{
+		"success": true,
+		"message": "Registro restaurado",
+		"data":    row,
+	})
+}
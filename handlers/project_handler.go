@@ -1,65 +1,247 @@
-package handlers
-
-import (
-	"encoding/json"
-	"net/http"
-	"strconv"
-	"github.com/gorilla/mux"
-	projectService "meu-provedor/services/project"
-	"meu-provedor/models"
-)
-
-func CreateProject(w http.ResponseWriter, r *http.Request) {
-	var req models.ProjectRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "invalid json", 400)
-		return
-	}
-
-	if err := projectService.Create(req); err != nil {
-		http.Error(w, err.Error(), 400)
-		return
-	}
-
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(map[string]string{
-		"message": "PROJECT CREATED",
-	})
-}
-
-func ListProjects(w http.ResponseWriter, r *http.Request) {
-	projects, err := projectService.List()
-	if err != nil {
-		http.Error(w, err.Error(), 500)
-		return
-	}
-	json.NewEncoder(w).Encode(projects)
-}
-
-func UpdateProject(w http.ResponseWriter, r *http.Request) {
-	id, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
-
-	var req models.ProjectUpdateRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "invalid json", 400)
-		return
-	}
-
-	if err := projectService.Update(id, req); err != nil {
-		http.Error(w, err.Error(), 400)
-		return
-	}
-
-	w.Write([]byte("PROJECT UPDATED"))
-}
-
-func DeleteProject(w http.ResponseWriter, r *http.Request) {
-	id, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
-
-	if err := projectService.Delete(id); err != nil {
-		http.Error(w, err.Error(), 400)
-		return
-	}
-
-	w.Write([]byte("PROJECT DELETED"))
-}
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"github.com/gorilla/mux"
+	projectService "meu-provedor/services/project"
+	"meu-provedor/services/data_service"
+	"meu-provedor/models"
+	"meu-provedor/security"
+)
+
+func CreateProject(w http.ResponseWriter, r *http.Request) {
+	var req models.ProjectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondAppError(w, models.ErrInvalidJSON)
+		return
+	}
+
+	if err := projectService.Create(req); err != nil {
+		RespondAppError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "PROJECT CREATED",
+	})
+}
+
+func ListProjects(w http.ResponseWriter, r *http.Request) {
+	projects, err := projectService.List()
+	if err != nil {
+		RespondAppError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(projects)
+}
+
+func UpdateProject(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+
+	var req models.ProjectUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondAppError(w, models.ErrInvalidJSON)
+		return
+	}
+
+	if err := projectService.Update(id, req); err != nil {
+		RespondAppError(w, err)
+		return
+	}
+
+	w.Write([]byte("PROJECT UPDATED"))
+}
+
+func DeleteProject(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+
+	if err := projectService.Delete(id); err != nil {
+		RespondAppError(w, err)
+		return
+	}
+
+	w.Write([]byte("PROJECT DELETED"))
+}
+
+func GetProjectRateLimit(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		RespondAppError(w, models.NewValidationError("invalid id"))
+		return
+	}
+
+	limit, err := projectService.GetRateLimit(id)
+	if err != nil {
+		RespondAppError(w, err)
+		return
+	}
+	if limit == nil {
+		RespondError(w, "rate limit not configured", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(limit)
+}
+
+func SetProjectRateLimit(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		RespondAppError(w, models.NewValidationError("invalid id"))
+		return
+	}
+
+	var req models.ProjectRateLimit
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondAppError(w, models.ErrInvalidJSON)
+		return
+	}
+
+	if err := projectService.SetRateLimit(id, req); err != nil {
+		RespondAppError(w, err)
+		return
+	}
+	security.ForgetProjectRule(id)
+
+	w.Write([]byte("RATE LIMIT UPDATED"))
+}
+
+func GetProjectQueryLimits(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		RespondAppError(w, models.NewValidationError("invalid id"))
+		return
+	}
+
+	limits, err := projectService.GetQueryLimits(id)
+	if err != nil {
+		RespondAppError(w, err)
+		return
+	}
+	if limits == nil {
+		RespondError(w, "query limits not configured", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(limits)
+}
+
+func SetProjectQueryLimits(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		RespondAppError(w, models.NewValidationError("invalid id"))
+		return
+	}
+
+	var req models.ProjectQueryLimits
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondAppError(w, models.ErrInvalidJSON)
+		return
+	}
+
+	if err := projectService.SetQueryLimits(id, req); err != nil {
+		RespondAppError(w, err)
+		return
+	}
+	services.ForgetQueryGuard(id)
+
+	w.Write([]byte("QUERY LIMITS UPDATED"))
+}
+
+func GetProjectDatabase(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		RespondAppError(w, models.NewValidationError("invalid id"))
+		return
+	}
+
+	info, err := projectService.GetDatabase(id)
+	if err != nil {
+		RespondAppError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(info)
+}
+
+func SetProjectDatabase(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		RespondAppError(w, models.NewValidationError("invalid id"))
+		return
+	}
+
+	var req models.ProjectDatabaseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondAppError(w, models.ErrInvalidJSON)
+		return
+	}
+
+	if err := projectService.SetDatabase(id, req); err != nil {
+		RespondAppError(w, err)
+		return
+	}
+
+	w.Write([]byte("PROJECT DATABASE UPDATED"))
+}
+
+func DeleteProjectDatabase(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		RespondAppError(w, models.NewValidationError("invalid id"))
+		return
+	}
+
+	if err := projectService.RemoveDatabase(id); err != nil {
+		RespondAppError(w, err)
+		return
+	}
+
+	w.Write([]byte("PROJECT DATABASE REMOVED"))
+}
+
+func StartProjectMigration(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		RespondAppError(w, models.NewValidationError("invalid id"))
+		return
+	}
+
+	var req models.TenancyMigrationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondAppError(w, models.ErrInvalidJSON)
+		return
+	}
+
+	migration, err := projectService.StartMigration(id, req)
+	if err != nil {
+		RespondAppError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(migration)
+}
+
+func GetProjectMigration(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		RespondAppError(w, models.NewValidationError("invalid id"))
+		return
+	}
+
+	migration, err := projectService.GetMigration(id)
+	if err != nil {
+		RespondAppError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(migration)
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"meu-provedor/models"
+	"meu-provedor/security"
+	"meu-provedor/services/data_service"
+	projectService "meu-provedor/services/project"
+)
+
+// ============================================================================
+// SAVED QUERY HANDLERS - Consultas nomeadas
+// ============================================================================
+
+// RunSavedQueryHandler executa /data/query/{name}. Chamadas com X-API-Key só
+// executam versões aprovadas de consultas do próprio projeto da key.
+func RunSavedQueryHandler(w http.ResponseWriter, r *http.Request) {
+	var req models.RunSavedQueryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondAppError(w, models.ErrInvalidJSON)
+		return
+	}
+
+	approvedOnly := false
+	if apiKey := r.Header.Get("X-API-Key"); apiKey != "" {
+		project, err := security.ValidateApiKey(apiKey)
+		if err != nil {
+			RespondAppError(w, err)
+			return
+		}
+		if int64(project.ID) != req.ProjectID {
+			RespondAppError(w, models.ErrInvalidAPIKey)
+			return
+		}
+		approvedOnly = true
+	}
+
+	result, saved, err := services.ExecuteSavedQuery(r.Context(), mux.Vars(r)["name"], req, approvedOnly)
+	if err != nil {
+		RespondAppError(w, err)
+		return
+	}
+
+	RespondSuccess(w, map[string]interface{}{
+		"success": true,
+		"data":    result,
+		"count":   len(result),
+		"version": saved.Version,
+	})
+}
+
+// ListSavedQueries lista a versão mais recente de cada consulta do projeto
+func ListSavedQueries(w http.ResponseWriter, r *http.Request) {
+	projectID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		RespondAppError(w, models.ErrInvalidProjectID)
+		return
+	}
+
+	queries, err := projectService.ListSavedQueries(projectID)
+	if err != nil {
+		RespondAppError(w, err)
+		return
+	}
+
+	RespondSuccess(w, map[string]interface{}{
+		"success": true,
+		"data":    queries,
+		"count":   len(queries),
+	})
+}
+
+// GetSavedQueryVersions lista todas as versões de uma consulta nomeada
+func GetSavedQueryVersions(w http.ResponseWriter, r *http.Request) {
+	projectID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		RespondAppError(w, models.ErrInvalidProjectID)
+		return
+	}
+
+	versions, err := projectService.ListSavedQueryVersions(projectID, mux.Vars(r)["name"])
+	if err != nil {
+		RespondAppError(w, err)
+		return
+	}
+
+	RespondSuccess(w, map[string]interface{}{
+		"success": true,
+		"data":    versions,
+		"count":   len(versions),
+	})
+}
+
+// SaveQuery grava uma nova versão da consulta nomeada
+func SaveQuery(w http.ResponseWriter, r *http.Request) {
+	projectID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		RespondAppError(w, models.ErrInvalidProjectID)
+		return
+	}
+
+	var req models.SavedQueryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondAppError(w, models.ErrInvalidJSON)
+		return
+	}
+
+	saved, err := projectService.SaveQuery(projectID, mux.Vars(r)["name"], req, security.CallerID(r))
+	if err != nil {
+		RespondAppError(w, err)
+		return
+	}
+
+	RespondCreated(w, saved)
+}
+
+// ApproveSavedQuery aprova ou revoga uma versão para chamadas com API key
+func ApproveSavedQuery(w http.ResponseWriter, r *http.Request) {
+	projectID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		RespondAppError(w, models.ErrInvalidProjectID)
+		return
+	}
+
+	var req models.SavedQueryApproval
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondAppError(w, models.ErrInvalidJSON)
+		return
+	}
+
+	if err := projectService.ApproveSavedQuery(projectID, mux.Vars(r)["name"], req); err != nil {
+		RespondAppError(w, err)
+		return
+	}
+
+	w.Write([]byte("SAVED QUERY APPROVAL UPDATED"))
+}
+
+// DeleteSavedQuery remove a consulta nomeada com todas as versões
+func DeleteSavedQuery(w http.ResponseWriter, r *http.Request) {
+	projectID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		RespondAppError(w, models.ErrInvalidProjectID)
+		return
+	}
+
+	if err := projectService.DeleteSavedQuery(projectID, mux.Vars(r)["name"]); err != nil {
+		RespondAppError(w, err)
+		return
+	}
+
+	w.Write([]byte("SAVED QUERY DELETED"))
+}
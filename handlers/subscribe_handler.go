@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"meu-provedor/engine/query"
+	"meu-provedor/models"
+	"meu-provedor/services/realtime"
+)
+
+// ============================================================================
+// SUBSCRIBE HANDLER (Server-Sent Events)
+// ============================================================================
+
+// heartbeatInterval mantém a conexão aberta em proxies
+const heartbeatInterval = 25 * time.Second
+
+// SubscribeHandler envia em tempo real os eventos de uma tabela para uma instância.
+// Parâmetros: project_id, id_instancia, table, operations (opcional, separadas por vírgula).
+// Para retomar, o cliente envia o header Last-Event-ID (ou ?last_event_id).
+func SubscribeHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	projectID, err := strconv.ParseInt(q.Get("project_id"), 10, 64)
+	if err != nil || projectID <= 0 {
+		RespondAppError(w, models.ErrInvalidProjectID)
+		return
+	}
+	instanceID, err := strconv.ParseInt(q.Get("id_instancia"), 10, 64)
+	if err != nil || instanceID <= 0 {
+		RespondAppError(w, models.ErrInvalidInstanceID)
+		return
+	}
+	table := q.Get("table")
+	if table == "" {
+		RespondAppError(w, models.ErrTableRequired)
+		return
+	}
+	if !query.IsValidTableName(table) {
+		RespondAppError(w, fmt.Errorf("%w: %s", models.ErrInvalidIdentifier, table))
+		return
+	}
+
+	var operations []string
+	if ops := q.Get("operations"); ops != "" {
+		operations = strings.Split(ops, ",")
+	}
+
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = q.Get("last_event_id")
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		RespondError(w, "streaming não suportado", http.StatusInternalServerError)
+		return
+	}
+
+	sub := realtime.Subscribe(realtime.Filter{
+		ProjectID:  projectID,
+		InstanceID: instanceID,
+		Table:      table,
+		Operations: operations,
+	}, lastID)
+	defer realtime.Unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "retry: 3000\n\n")
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+			flusher.Flush()
+
+		case msg, open := <-sub.C:
+			if !open {
+				// Assinatura encerrada pelo broker: o cliente reconecta e retoma
+				return
+			}
+			if msg.Reset {
+				fmt.Fprintf(w, "id: %s\nevent: reset\ndata: {}\n\n", msg.ID())
+				flusher.Flush()
+				continue
+			}
+
+			data, err := json.Marshal(msg.Event)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", msg.ID(), msg.Event.Operation, data)
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"meu-provedor/models"
+	tableService "meu-provedor/services/table"
+)
+
+// ============================================================================
+// SUMMARY HANDLERS - Resumos materializados (consultados por /data/select)
+// ============================================================================
+
+func CreateSummary(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateSummaryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondAppError(w, models.ErrInvalidJSON)
+		return
+	}
+
+	if req.ProjectID <= 0 {
+		RespondAppError(w, models.ErrInvalidProjectID)
+		return
+	}
+
+	summary, err := tableService.CreateSummary(req)
+	if err != nil {
+		RespondAppError(w, err)
+		return
+	}
+
+	RespondCreated(w, summary)
+}
+
+func DeleteSummary(w http.ResponseWriter, r *http.Request) {
+	projectIDStr := r.URL.Query().Get("project_id")
+	name := r.URL.Query().Get("name")
+
+	if projectIDStr == "" || name == "" {
+		RespondAppError(w, models.NewValidationError("project_id and name are required"))
+		return
+	}
+
+	projectID, err := strconv.ParseInt(projectIDStr, 10, 64)
+	if err != nil {
+		RespondAppError(w, models.ErrInvalidProjectID)
+		return
+	}
+
+	if err := tableService.DropSummary(projectID, name); err != nil {
+		RespondAppError(w, err)
+		return
+	}
+
+	w.Write([]byte("SUMMARY DELETED"))
+}
+
+// RefreshSummary recalcula o resumo imediatamente, fora do agendamento
+func RefreshSummary(w http.ResponseWriter, r *http.Request) {
+	var req models.SummaryRefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondAppError(w, models.ErrInvalidJSON)
+		return
+	}
+
+	if req.ProjectID <= 0 {
+		RespondAppError(w, models.ErrInvalidProjectID)
+		return
+	}
+
+	summary, err := tableService.RefreshProjectSummary(req.ProjectID, req.Name)
+	if err != nil {
+		RespondAppError(w, err)
+		return
+	}
+
+	RespondSuccess(w, summary)
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"meu-provedor/models"
+	"meu-provedor/services/data_service"
+)
+
+// ============================================================================
+// VIEW HANDLERS - Views do projeto (somente leitura, consultadas por /data/select)
+// ============================================================================
+
+func CreateProjectView(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateViewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondAppError(w, models.ErrInvalidJSON)
+		return
+	}
+
+	viewName, err := services.CreateProjectView(req)
+	if err != nil {
+		RespondAppError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "VIEW CREATED",
+		"view":    viewName,
+	})
+}
+
+func DeleteProjectView(w http.ResponseWriter, r *http.Request) {
+	projectIDStr := r.URL.Query().Get("project_id")
+	viewName := r.URL.Query().Get("view")
+
+	if projectIDStr == "" || viewName == "" {
+		RespondAppError(w, models.NewValidationError("project_id and view are required"))
+		return
+	}
+
+	projectID, err := strconv.ParseInt(projectIDStr, 10, 64)
+	if err != nil {
+		RespondAppError(w, models.ErrInvalidProjectID)
+		return
+	}
+
+	if err := services.DropProjectView(projectID, viewName); err != nil {
+		RespondAppError(w, err)
+		return
+	}
+
+	w.Write([]byte("VIEW DELETED"))
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"meu-provedor/models"
+	webhookService "meu-provedor/services/webhook"
+)
+
+// ============================================================================
+// WEBHOOK HANDLERS
+// ============================================================================
+
+func CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	projectID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		RespondAppError(w, models.ErrInvalidProjectID)
+		return
+	}
+
+	var req models.WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondAppError(w, models.ErrInvalidJSON)
+		return
+	}
+
+	hook, err := webhookService.Create(projectID, req)
+	if err != nil {
+		RespondAppError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(hook)
+}
+
+func ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	projectID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		RespondAppError(w, models.ErrInvalidProjectID)
+		return
+	}
+
+	hooks, err := webhookService.List(projectID)
+	if err != nil {
+		RespondAppError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(hooks)
+}
+
+func DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	projectID, webhookID, ok := webhookPathIDs(w, r)
+	if !ok {
+		return
+	}
+
+	if err := webhookService.Delete(projectID, webhookID); err != nil {
+		RespondAppError(w, err)
+		return
+	}
+
+	w.Write([]byte("WEBHOOK DELETED"))
+}
+
+// ListWebhookDeliveries lista as entregas (?status=pending|delivered|dead)
+func ListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	projectID, webhookID, ok := webhookPathIDs(w, r)
+	if !ok {
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+	deliveries, err := webhookService.ListDeliveries(projectID, webhookID, r.URL.Query().Get("status"), limit, offset)
+	if err != nil {
+		RespondAppError(w, err)
+		return
+	}
+
+	RespondSuccess(w, map[string]interface{}{
+		"success": true,
+		"data":    deliveries,
+		"count":   len(deliveries),
+	})
+}
+
+// RetryWebhookDelivery recoloca uma entrega na fila de envio
+func RetryWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	projectID, webhookID, ok := webhookPathIDs(w, r)
+	if !ok {
+		return
+	}
+	deliveryID, err := strconv.ParseInt(mux.Vars(r)["delivery_id"], 10, 64)
+	if err != nil {
+		RespondAppError(w, models.NewValidationError("invalid delivery_id"))
+		return
+	}
+
+	if err := webhookService.RetryDelivery(projectID, webhookID, deliveryID); err != nil {
+		RespondAppError(w, err)
+		return
+	}
+
+	w.Write([]byte("DELIVERY REQUEUED"))
+}
+
+func webhookPathIDs(w http.ResponseWriter, r *http.Request) (int64, int64, bool) {
+	vars := mux.Vars(r)
+	projectID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		RespondAppError(w, models.ErrInvalidProjectID)
+		return 0, 0, false
+	}
+	webhookID, err := strconv.ParseInt(vars["webhook_id"], 10, 64)
+	if err != nil {
+		RespondAppError(w, models.NewValidationError("invalid webhook_id"))
+		return 0, 0, false
+	}
+	return projectID, webhookID, true
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// ExplainResult - Plano de execução do SQL gerado para um select
+type ExplainResult struct {
+	SQL         string            `json:"sql"`
+	Plan        interface{}       `json:"plan"`
+	Suggestions []IndexSuggestion `json:"suggestions"`
+}
+
+// IndexSuggestion - Índice sugerido para evitar um full table scan
+type IndexSuggestion struct {
+	Table        string   `json:"table"`
+	Columns      []string `json:"columns"`
+	RowsExamined int64    `json:"rows_examined,omitempty"`
+	Reason       string   `json:"reason"`
+}
+
+// SlowQuery - Consulta que excedeu o limite do slow query log
+type SlowQuery struct {
+	SQL        string        `json:"sql"`
+	Args       []interface{} `json:"args"` // somente os tipos (valores redigidos)
+	DurationMs float64       `json:"duration_ms"`
+	ProjectID  int64         `json:"project_id"`
+	InstanceID int64         `json:"id_instancia"`
+	Error      string        `json:"error,omitempty"` // somente o código do erro
+	At         time.Time     `json:"at"`
+}
+
+// SlowQueryFilter - Filtros da consulta ao slow query log (zero = todos)
+type SlowQueryFilter struct {
+	ProjectID  int64
+	InstanceID int64
+	Limit      int
+}
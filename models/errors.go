@@ -1,37 +1,172 @@
-package models
-
-import "errors"
-
-// ============================================================================
-// ERROR DEFINITIONS
-// ============================================================================
-
-var (
-	// Erros de validação de requisição
-	ErrInvalidProjectID   = errors.New("project_id inválido ou não informado")
-	ErrInvalidInstanceID  = errors.New("id_instancia inválido ou não informado")
-	ErrTableRequired      = errors.New("nome da tabela é obrigatório")
-	ErrNoDataProvided     = errors.New("nenhum dado fornecido")
-	ErrOperationRequired  = errors.New("operação de agregação é obrigatória")
-	ErrInvalidColumn      = errors.New("nome de coluna inválido")
-	ErrInvalidIdentifier  = errors.New("identificador contém caracteres inválidos")
-
-	// Erros de projeto
-	ErrProjectNotFound    = errors.New("projeto não encontrado")
-	ErrInvalidAPIKey      = errors.New("API key inválida")
-	ErrAPIKeyNotProvided  = errors.New("API key não fornecida")
-
-	// Erros de operação
-	ErrQueryFailed        = errors.New("falha ao executar query")
-	ErrNoResultsFound     = errors.New("nenhum resultado encontrado")
-	ErrInsertFailed       = errors.New("falha ao inserir dados")
-	ErrUpdateFailed       = errors.New("falha ao atualizar dados")
-	ErrDeleteFailed       = errors.New("falha ao deletar dados")
-
-	// Erros de conexão
-	ErrDatabaseConnection = errors.New("erro de conexão com banco de dados")
-	// erro para projetos
-	ErrInvalidProjectData = errors.New("dados do projeto inválidos")
-	ErrProjectCodeExists  = errors.New("project code já existe")
-
-)
+package models
+
+// ============================================================================
+// ERROR TYPES
+// ============================================================================
+
+// ErrorKind classifica o erro para o mapeamento de status HTTP
+type ErrorKind string
+
+const (
+	KindValidation    ErrorKind = "validation"
+	KindUnauthorized  ErrorKind = "unauthorized"
+	KindForbidden     ErrorKind = "forbidden"
+	KindNotFound      ErrorKind = "not_found"
+	KindConflict      ErrorKind = "conflict"
+	KindTooLarge      ErrorKind = "too_large"
+	KindUnprocessable ErrorKind = "unprocessable"
+	KindRateLimited   ErrorKind = "rate_limited"
+	KindUnavailable   ErrorKind = "unavailable"
+	KindCanceled      ErrorKind = "canceled"
+	KindTimeout       ErrorKind = "timeout"
+	KindInternal      ErrorKind = "internal"
+)
+
+// AppError - Erro tipado com código estável para os clientes
+type AppError struct {
+	Kind    ErrorKind              `json:"-"`
+	Code    string                 `json:"code"`
+	Message string                 `json:"message"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// Error implementa a interface error
+func (e *AppError) Error() string {
+	return e.Message
+}
+
+// NewError cria um AppError
+func NewError(kind ErrorKind, code, message string) *AppError {
+	return &AppError{Kind: kind, Code: code, Message: message}
+}
+
+// Is permite comparar cópias detalhadas com o erro sentinela (mesmo código)
+func (e *AppError) Is(target error) bool {
+	t, ok := target.(*AppError)
+	return ok && t.Code == e.Code
+}
+
+// WithDetails retorna uma cópia do erro com mensagem e detalhes específicos
+func (e *AppError) WithDetails(message string, details map[string]interface{}) *AppError {
+	return &AppError{Kind: e.Kind, Code: e.Code, Message: message, Details: details}
+}
+
+// NewValidationError cria um erro de validação genérico
+func NewValidationError(message string) *AppError {
+	return NewError(KindValidation, "VALIDATION_ERROR", message)
+}
+
+// ============================================================================
+// ERROR DEFINITIONS
+// ============================================================================
+
+var (
+	// Erros de validação de requisição
+	ErrInvalidProjectID   = NewError(KindValidation, "INVALID_PROJECT_ID", "project_id inválido ou não informado")
+	ErrInvalidInstanceID  = NewError(KindValidation, "INVALID_INSTANCE_ID", "id_instancia inválido ou não informado")
+	ErrTableRequired      = NewError(KindValidation, "TABLE_REQUIRED", "nome da tabela é obrigatório")
+	ErrInvalidTableName   = NewError(KindValidation, "INVALID_TABLE_NAME", "nome de tabela inválido")
+	ErrNoDataProvided     = NewError(KindValidation, "NO_DATA_PROVIDED", "nenhum dado fornecido")
+	ErrOperationRequired  = NewError(KindValidation, "OPERATION_REQUIRED", "operação de agregação é obrigatória")
+	ErrInvalidColumn      = NewError(KindValidation, "INVALID_COLUMN", "nome de coluna inválido")
+	ErrInvalidIdentifier  = NewError(KindValidation, "INVALID_IDENTIFIER", "identificador contém caracteres inválidos")
+	ErrInvalidJSON        = NewError(KindValidation, "INVALID_JSON", "JSON inválido")
+	ErrUnknownAlias       = NewError(KindValidation, "UNKNOWN_ALIAS", "alias de tabela desconhecido")
+	ErrInvalidSort        = NewError(KindValidation, "INVALID_SORT", "ordenação inválida")
+	ErrInvalidJSONPath    = NewError(KindValidation, "INVALID_JSON_PATH", "caminho JSON inválido")
+	ErrInvalidUpdateOp    = NewError(KindValidation, "INVALID_UPDATE_OP", "operador de update inválido")
+	ErrInvalidFilter      = NewError(KindValidation, "INVALID_FILTER", "filtro inválido")
+	ErrInvalidSearchMode  = NewError(KindValidation, "INVALID_SEARCH_MODE", "modo de busca inválido; use natural ou boolean")
+	ErrInvalidConsistency = NewError(KindValidation, "INVALID_CONSISTENCY", "consistency inválida; use eventual ou strong")
+	ErrRawSQLNotAllowed   = NewError(KindForbidden, "RAW_SQL_NOT_ALLOWED", "SQL livre não permitido; use os campos estruturados")
+
+	// Erros de projeto
+	ErrProjectNotFound    = NewError(KindNotFound, "PROJECT_NOT_FOUND", "projeto não encontrado")
+	ErrInvalidAPIKey      = NewError(KindUnauthorized, "INVALID_API_KEY", "API key inválida")
+	ErrAPIKeyNotProvided  = NewError(KindUnauthorized, "API_KEY_REQUIRED", "API key não fornecida")
+
+	// Erros de operação
+	ErrQueryFailed        = NewError(KindInternal, "QUERY_FAILED", "falha ao executar query")
+	ErrNoResultsFound     = NewError(KindNotFound, "NO_RESULTS", "nenhum resultado encontrado")
+	ErrInsertFailed       = NewError(KindInternal, "INSERT_FAILED", "falha ao inserir dados")
+	ErrUpdateFailed       = NewError(KindInternal, "UPDATE_FAILED", "falha ao atualizar dados")
+	ErrDeleteFailed       = NewError(KindInternal, "DELETE_FAILED", "falha ao deletar dados")
+	ErrQueryCanceled      = NewError(KindCanceled, "QUERY_CANCELED", "consulta cancelada (o cliente encerrou a requisição)")
+	ErrQueryTimeout       = NewError(KindTimeout, "QUERY_TIMEOUT", "consulta excedeu o tempo máximo de execução")
+	ErrInvalidQueryLimit  = NewError(KindValidation, "INVALID_QUERY_LIMITS", "limites de consulta inválidos")
+	ErrExplainUnsupported = NewError(KindValidation, "EXPLAIN_UNSUPPORTED", "EXPLAIN disponível apenas para bancos MySQL")
+
+	// Erros de webhooks
+	ErrInvalidWebhookURL  = NewError(KindValidation, "INVALID_WEBHOOK_URL", "url do webhook inválida (use http ou https)")
+	ErrWebhookURLBlocked  = NewError(KindValidation, "WEBHOOK_URL_BLOCKED", "url do webhook aponta para um endereço interno")
+	ErrWebhookNotFound    = NewError(KindNotFound, "WEBHOOK_NOT_FOUND", "webhook não encontrado")
+	ErrDeliveryNotFound   = NewError(KindNotFound, "DELIVERY_NOT_FOUND", "entrega de webhook não encontrada")
+
+	// Erros de histórico de alterações
+	ErrHistoryDisabled    = NewError(KindValidation, "HISTORY_DISABLED", "histórico não habilitado para a tabela")
+	ErrHistoryNotFound    = NewError(KindNotFound, "HISTORY_NOT_FOUND", "registro de histórico não encontrado")
+	ErrHistoryRestore     = NewError(KindValidation, "HISTORY_RESTORE_INVALID", "esta entrada de histórico não pode ser restaurada")
+
+	// Erros de views
+	ErrInvalidView        = NewError(KindValidation, "INVALID_VIEW", "definição de view inválida")
+	ErrViewNotFound       = NewError(KindNotFound, "VIEW_NOT_FOUND", "view não encontrada")
+	ErrViewReadOnly       = NewError(KindValidation, "VIEW_READ_ONLY", "views são somente leitura")
+
+	// Erros de resumos materializados
+	ErrInvalidSummary     = NewError(KindValidation, "INVALID_SUMMARY", "definição de resumo materializado inválida")
+	ErrSummaryNotFound    = NewError(KindNotFound, "SUMMARY_NOT_FOUND", "resumo materializado não encontrado")
+	ErrSummaryReadOnly    = NewError(KindValidation, "SUMMARY_READ_ONLY", "resumos materializados são atualizados apenas pelo engine")
+
+	// Erros de consultas nomeadas
+	ErrSavedQueryNotFound = NewError(KindNotFound, "SAVED_QUERY_NOT_FOUND", "consulta nomeada não encontrada")
+	ErrInvalidSavedQuery  = NewError(KindValidation, "INVALID_SAVED_QUERY", "definição de consulta nomeada inválida")
+	ErrInvalidQueryParam  = NewError(KindValidation, "INVALID_QUERY_PARAM", "parâmetro da consulta inválido")
+	ErrQueryNotApproved   = NewError(KindForbidden, "QUERY_NOT_APPROVED", "consulta não aprovada para chamadas com API key")
+
+	// Erros de conexão
+	ErrDatabaseConnection = NewError(KindUnavailable, "DATABASE_UNAVAILABLE", "erro de conexão com banco de dados")
+	// erro para projetos
+	ErrInvalidProjectData = NewError(KindValidation, "INVALID_PROJECT_DATA", "dados do projeto inválidos")
+	ErrProjectCodeExists  = NewError(KindConflict, "PROJECT_CODE_EXISTS", "project code já existe")
+	ErrInvalidProjectCode = NewError(KindValidation, "INVALID_PROJECT_CODE", "code deve ter de 1 a 32 letras ou dígitos (sem '_')")
+	ErrInvalidDSN         = NewError(KindValidation, "INVALID_DSN", "DSN inválida ou banco inacessível")
+	ErrProjectHasTables   = NewError(KindConflict, "PROJECT_HAS_TABLES", "o projeto já possui tabelas no banco atual")
+
+	// Erros de migração de tenancy
+	ErrMigrationRunning   = NewError(KindConflict, "MIGRATION_RUNNING", "já existe uma migração em andamento para o projeto")
+	ErrMigrationNotFound  = NewError(KindNotFound, "MIGRATION_NOT_FOUND", "nenhuma migração registrada para o projeto")
+	ErrInvalidTenancyMode = NewError(KindValidation, "INVALID_TENANCY_MODE", "modo inválido; use dedicated ou shared")
+	ErrWritesFrozen       = NewError(KindUnavailable, "WRITES_FROZEN", "escritas do projeto temporariamente congeladas (migração em andamento)")
+
+	// Erros de cota
+	ErrQuotaExceeded      = NewError(KindForbidden, "QUOTA_EXCEEDED", "cota da instância excedida")
+	ErrInstanceSettings   = NewError(KindInternal, "INSTANCE_SETTINGS_INVALID", "limites configurados da instância são inválidos")
+	ErrBatchTooLarge      = NewError(KindTooLarge, "BATCH_TOO_LARGE", "lote excede o tamanho máximo permitido")
+	ErrRateLimitExceeded  = NewError(KindRateLimited, "RATE_LIMITED", "limite de requisições excedido")
+	ErrRequestTooLarge    = NewError(KindTooLarge, "REQUEST_TOO_LARGE", "corpo da requisição excede o tamanho máximo permitido")
+
+	// Erros de concorrência (tabelas versionadas)
+	ErrVersionConflict    = NewError(KindConflict, "VERSION_CONFLICT", "o registro foi alterado por outra requisição")
+	ErrVersionRequired    = NewError(KindValidation, "VERSION_REQUIRED", "expected_version é obrigatório nesta tabela")
+	ErrVersionManaged     = NewError(KindValidation, "VERSION_MANAGED", "a coluna version é gerenciada pelo engine")
+	ErrAuditManaged       = NewError(KindValidation, "AUDIT_COLUMN_MANAGED", "coluna de auditoria é preenchida pelo engine")
+
+	// Erros do banco de dados (MySQL)
+	ErrNotNullViolation   = NewError(KindUnprocessable, "NOT_NULL_VIOLATION", "coluna obrigatória não pode ser nula")
+	ErrTableExists        = NewError(KindConflict, "TABLE_EXISTS", "tabela já existe")
+	ErrUnknownColumn      = NewError(KindValidation, "UNKNOWN_COLUMN", "coluna desconhecida")
+	ErrDuplicateColumn    = NewError(KindConflict, "DUPLICATE_COLUMN", "coluna já existe")
+	ErrDuplicateIndex     = NewError(KindConflict, "DUPLICATE_INDEX", "índice já existe")
+	ErrDuplicateKey       = NewError(KindConflict, "DUPLICATE_KEY", "registro duplicado")
+	ErrSQLSyntax          = NewError(KindValidation, "SQL_SYNTAX_ERROR", "erro de sintaxe SQL")
+	ErrObjectNotFound     = NewError(KindNotFound, "COLUMN_OR_INDEX_NOT_FOUND", "coluna ou índice não existe")
+	ErrTableNotFound      = NewError(KindNotFound, "TABLE_NOT_FOUND", "tabela não existe")
+	ErrValueOutOfRange    = NewError(KindUnprocessable, "VALUE_OUT_OF_RANGE", "valor fora do intervalo permitido")
+	ErrInvalidValue       = NewError(KindUnprocessable, "INVALID_VALUE", "valor incorreto para a coluna")
+	ErrValueTooLong       = NewError(KindUnprocessable, "VALUE_TOO_LONG", "valor excede o tamanho da coluna")
+	ErrForeignKeyRestrict = NewError(KindConflict, "FOREIGN_KEY_RESTRICT", "registro referenciado por outra tabela")
+	ErrForeignKeyFailed   = NewError(KindUnprocessable, "FOREIGN_KEY_VIOLATION", "referência a registro inexistente")
+	ErrDatabase           = NewError(KindInternal, "DATABASE_ERROR", "erro no banco de dados")
+	ErrSchemaValidation   = NewError(KindUnprocessable, "SCHEMA_VALIDATION_FAILED", "dados não conferem com o schema da tabela")
+
+)
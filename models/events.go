@@ -0,0 +1,65 @@
+package models
+
+import "time"
+
+// ============================================================================
+// CHANGE EVENTS - Alterações de dados emitidas pelos data services
+// ============================================================================
+
+// ChangeEvent - Alteração confirmada (após commit) em uma tabela do projeto
+type ChangeEvent struct {
+	ID         string                 `json:"id"`
+	ProjectID  int64                  `json:"project_id"`
+	Table      string                 `json:"table"`
+	Operation  string                 `json:"operation"` // insert, update, delete, soft_delete, restore
+	InstanceID int64                  `json:"id_instancia"`
+	RowIDs     []int64                `json:"row_ids,omitempty"`
+	Count      int64                  `json:"count"`
+	Where      map[string]interface{} `json:"where,omitempty"`
+	Actor      string                 `json:"actor,omitempty"`
+	OccurredAt time.Time              `json:"occurred_at"`
+}
+
+// Webhook - Endpoint externo notificado sobre alterações do projeto
+type Webhook struct {
+	ID         int64     `json:"id"`
+	ProjectID  int64     `json:"project_id"`
+	URL        string    `json:"url"`
+	Secret     string    `json:"secret,omitempty"`     // retornado apenas na criação
+	Tables     []string  `json:"tables,omitempty"`     // vazio = todas
+	Operations []string  `json:"operations,omitempty"` // vazio = todas
+	InstanceID *int64    `json:"id_instancia,omitempty"`
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// WebhookRequest - Cadastro de webhook
+type WebhookRequest struct {
+	URL        string   `json:"url"`
+	Secret     string   `json:"secret,omitempty"` // gerado se não informado
+	Tables     []string `json:"tables,omitempty"`
+	Operations []string `json:"operations,omitempty"`
+	InstanceID *int64   `json:"id_instancia,omitempty"`
+}
+
+// Estados de uma entrega de webhook
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead"
+)
+
+// WebhookDelivery - Entrega de um evento a um webhook (registro do outbox)
+type WebhookDelivery struct {
+	ID            int64       `json:"id"`
+	WebhookID     int64       `json:"webhook_id"`
+	EventID       string      `json:"event_id"`
+	Event         ChangeEvent `json:"event"`
+	Status        string      `json:"status"`
+	Attempts      int         `json:"attempts"`
+	LastStatus    int         `json:"last_status,omitempty"`
+	LastError     string      `json:"last_error,omitempty"`
+	NextAttemptAt time.Time   `json:"next_attempt_at"`
+	CreatedAt     time.Time   `json:"created_at"`
+	DeliveredAt   *time.Time  `json:"delivered_at,omitempty"`
+}
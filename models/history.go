@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// Operações gravadas no histórico
+const (
+	HistoryInsert     = "insert"
+	HistoryUpdate     = "update"
+	HistoryDelete     = "delete"
+	HistorySoftDelete = "soft_delete"
+	HistoryRestore    = "restore"
+)
+
+// HistoryEntry - Alteração de um registro de tabela do projeto
+type HistoryEntry struct {
+	ID         int64                  `json:"id"`
+	Table      string                 `json:"table"`
+	RowID      int64                  `json:"row_id"`
+	InstanceID int64                  `json:"id_instancia"`
+	Operation  string                 `json:"operation"`
+	Before     map[string]interface{} `json:"before,omitempty"`
+	After      map[string]interface{} `json:"after,omitempty"`
+	Diff       map[string]interface{} `json:"diff,omitempty"`
+	Actor      string                 `json:"actor,omitempty"`
+	CreatedAt  time.Time              `json:"created_at"`
+}
@@ -1,19 +1,29 @@
-package models
-
-
-type InstanceRequest struct {
-	ProjectID   int64                  `json:"project_id"`
-	ClientName  string                 `json:"client_name"`
-	Email       string                 `json:"email"`
-	Phone       string                 `json:"phone"`
-	Price       float64                `json:"price"`
-	PaymentDay  int                    `json:"payment_day"`
-	Name        string                 `json:"name"`
-	Code        string                 `json:"code"`
-	Description string                 `json:"description"`
-	Status      string                 `json:"status"`
-	Settings    map[string]interface{} `json:"settings"`
-}
-
-
-
+package models
+
+
+type InstanceRequest struct {
+	ProjectID   int64                  `json:"project_id"`
+	ClientName  string                 `json:"client_name"`
+	Email       string                 `json:"email"`
+	Phone       string                 `json:"phone"`
+	Price       float64                `json:"price"`
+	PaymentDay  int                    `json:"payment_day"`
+	Name        string                 `json:"name"`
+	Code        string                 `json:"code"`
+	Description string                 `json:"description"`
+	Status      string                 `json:"status"`
+	Settings    map[string]interface{} `json:"settings"`
+}
+
+
+
+
+// InstanceLimits - Cotas configuráveis da instância (settings.limits)
+// Valores zero significam "usar o padrão do servidor"
+type InstanceLimits struct {
+	MaxRowsPerTable      int64 `json:"max_rows_per_table,omitempty"`
+	MaxTotalRows         int64 `json:"max_total_rows,omitempty"`
+	MaxRequestsPerMinute int   `json:"max_requests_per_minute,omitempty"`
+	MaxResultSize        int   `json:"max_result_size,omitempty"`
+	MaxBatchSize         int   `json:"max_batch_size,omitempty"`
+}
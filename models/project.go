@@ -1,40 +1,122 @@
-package models
-
-import "time"
-
-// ============================================================================
-// ENTITY MODELS - Estruturas de entidades do domínio
-// ============================================================================
-
-// Project - Representa um projeto no sistema
-type Project struct {
-	ID          int64     `json:"id"`
-	Name        string    `json:"name"`
-	Code        string    `json:"code"`         // Prefixo único para tabelas (IMUTÁVEL)
-	Description string    `json:"description"`
-	ApiKey      string    `json:"api_key"`
-	Type        string    `json:"type"`
-	Version     string    `json:"version"`
-	Status      string    `json:"status"`       // active, inactive, blocked
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
-}
-
-// ProjectRequest - Request para criação de projeto
-type ProjectRequest struct {
-	Name    string `json:"name"`
-	Code    string `json:"code"`      // Obrigatório apenas na criação
-	ApiKey  string `json:"api_key"`
-	Type    string `json:"type"`
-	Version string `json:"version"`
-	Status  string `json:"status"`
-}
-
-// ProjectUpdateRequest - Request para atualização de projeto (SEM code)
-type ProjectUpdateRequest struct {
-	Name    string `json:"name"`
-	ApiKey  string `json:"api_key"`
-	Type    string `json:"type"`
-	Version string `json:"version"`
-	Status  string `json:"status"`
-}
+package models
+
+import "time"
+
+// ============================================================================
+// ENTITY MODELS - Estruturas de entidades do domínio
+// ============================================================================
+
+// Project - Representa um projeto no sistema
+type Project struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	Code        string    `json:"code"`         // Prefixo único para tabelas (IMUTÁVEL)
+	Description string    `json:"description"`
+	ApiKey      string    `json:"api_key"`
+	Type        string    `json:"type"`
+	Version     string    `json:"version"`
+	Status      string    `json:"status"`       // active, inactive, blocked
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ProjectRequest - Request para criação de projeto
+type ProjectRequest struct {
+	Name    string `json:"name"`
+	Code    string `json:"code"`      // Obrigatório apenas na criação
+	ApiKey  string `json:"api_key"`
+	Type    string `json:"type"`
+	Version string `json:"version"`
+	Status  string `json:"status"`
+}
+
+// ProjectUpdateRequest - Request para atualização de projeto (SEM code)
+type ProjectUpdateRequest struct {
+	Name    string `json:"name"`
+	ApiKey  string `json:"api_key"`
+	Type    string `json:"type"`
+	Version string `json:"version"`
+	Status  string `json:"status"`
+}
+
+// ProjectRateLimit - Limite de requisições configurado para o projeto
+type ProjectRateLimit struct {
+	ProjectID         int64 `json:"project_id"`
+	RequestsPerMinute int   `json:"requests_per_minute"`
+	Burst             int   `json:"burst"`
+}
+
+// ProjectQueryLimits - Limites de execução das consultas do projeto
+// (zero = usar o padrão do servidor). APIKeyRawSQL libera os campos de SQL
+// livre para chamadas com X-API-Key (desligado por padrão).
+type ProjectQueryLimits struct {
+	ProjectID          int64 `json:"project_id"`
+	StatementTimeoutMs int   `json:"statement_timeout_ms"`
+	MaxRows            int   `json:"max_rows"`
+	APIKeyRawSQL       bool  `json:"api_key_raw_sql"`
+}
+
+// Modos de tenancy do projeto
+const (
+	TenancyShared    = "shared"    // tabelas {code}_* no banco master
+	TenancyDedicated = "dedicated" // tabelas {code}_* em banco próprio
+)
+
+// ProjectDatabaseRequest - DSN do banco dedicado do projeto (gravada cifrada)
+type ProjectDatabaseRequest struct {
+	DSN string `json:"dsn"`
+}
+
+// ProjectDatabase - Onde ficam as tabelas do projeto (a senha nunca é exposta)
+type ProjectDatabase struct {
+	ProjectID int64  `json:"project_id"`
+	Mode      string `json:"mode"`
+	Dialect   string `json:"dialect,omitempty"`
+	Host      string `json:"host,omitempty"`
+	Database  string `json:"database,omitempty"`
+	User      string `json:"user,omitempty"`
+}
+
+// Estados e fases da migração de tenancy
+const (
+	MigrationRunning   = "running"
+	MigrationCompleted = "completed"
+	MigrationFailed    = "failed"
+
+	MigrationPhaseCopy    = "copy"    // cópia online em chunks
+	MigrationPhaseFreeze  = "freeze"  // escritas congeladas, reaplicando diferenças
+	MigrationPhaseVerify  = "verify"  // contagem e checksum por tabela
+	MigrationPhaseSwitch  = "switch"  // troca do roteamento
+	MigrationPhaseCleanup = "cleanup" // remoção das tabelas de origem (drop_source)
+	MigrationPhaseDone    = "done"
+)
+
+// TenancyMigrationRequest - Move as tabelas do projeto para outro banco
+type TenancyMigrationRequest struct {
+	Mode       string `json:"mode"`                  // dedicated ou shared
+	DSN        string `json:"dsn,omitempty"`         // obrigatória para dedicated
+	DropSource bool   `json:"drop_source,omitempty"` // remove as tabelas de origem ao final
+}
+
+// TenancyMigration - Andamento de uma migração de tenancy
+type TenancyMigration struct {
+	ProjectID  int64                  `json:"project_id"`
+	From       string                 `json:"from"`
+	To         string                 `json:"to"`
+	Status     string                 `json:"status"`
+	Phase      string                 `json:"phase"`
+	Tables     []TableMigrationStatus `json:"tables"`
+	FrozenMs   int64                  `json:"frozen_ms,omitempty"`
+	Error      string                 `json:"error,omitempty"`
+	StartedAt  time.Time              `json:"started_at"`
+	FinishedAt *time.Time             `json:"finished_at,omitempty"`
+}
+
+// TableMigrationStatus - Andamento de uma tabela na migração
+type TableMigrationStatus struct {
+	Table        string `json:"table"`
+	Rows         int64  `json:"rows"`
+	ChunksCopied int    `json:"chunks_copied"`
+	Checksum     string `json:"checksum,omitempty"`
+	Verified     bool   `json:"verified"`
+}
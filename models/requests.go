@@ -1,286 +1,469 @@
-package models
-import "errors"
-
-// Column representa uma coluna com seu valor
-type Column struct {
-	Name  string      `json:"name"`
-	Value interface{} `json:"value"`
-}
-
-// InsertRequest - Requisição refatorada com especificação clara
-type InsertRequest struct {
-	ProjectID  int64    `json:"project_id"`
-	InstanceID int64    `json:"id_instancia"`
-	Table      string   `json:"table"`
-	Columns    []Column `json:"columns"` // ✅ Agora é explícito: nome + valor
-}
-
-// BatchInsertRequest - Múltiplos inserts com mesma estrutura
-type BatchInsertRequest struct {
-	ProjectID  int64      `json:"project_id"`
-	InstanceID int64      `json:"id_instancia"`
-	Table      string     `json:"table"`
-	Rows       [][]Column `json:"rows"` // ✅ Array de rows, cada row tem suas colunas
-}
-
-// Validate valida InsertRequest
-func (r *InsertRequest) Validate() error {
-	if r.ProjectID <= 0 {
-		return errors.New("project_id inválido")
-	}
-	if r.InstanceID <= 0 {
-		return errors.New("id_instancia inválido")
-	}
-	if r.Table == "" {
-		return errors.New("table é obrigatória")
-	}
-	if len(r.Columns) == 0 {
-		return errors.New("nenhuma coluna fornecida")
-	}
-	
-	// Validar nomes das colunas
-	for _, col := range r.Columns {
-		if col.Name == "" {
-			return errors.New("coluna com nome vazio")
-		}
-		if !IsValidColumnName(col.Name) {
-			return errors.New("nome de coluna inválido: " + col.Name)
-		}
-	}
-	
-	return nil
-}
-
-// Validate valida BatchInsertRequest
-func (r *BatchInsertRequest) Validate() error {
-	if r.ProjectID <= 0 {
-		return errors.New("project_id inválido")
-	}
-	if r.InstanceID <= 0 {
-		return errors.New("id_instancia inválido")
-	}
-	if r.Table == "" {
-		return errors.New("table é obrigatória")
-	}
-	if len(r.Rows) == 0 {
-		return errors.New("nenhuma linha fornecida")
-	}
-	
-	// Validar estrutura de cada row
-	firstRowLen := len(r.Rows[0])
-	for i, row := range r.Rows {
-		if len(row) == 0 {
-			return errors.New("linha vazia")
-		}
-		if len(row) != firstRowLen {
-			return errors.New("linhas com número diferente de colunas")
-		}
-		
-		// Validar cada coluna
-		for _, col := range row {
-			if col.Name == "" {
-				return errors.New("coluna com nome vazio na linha " + string(rune(i)))
-			}
-			if !IsValidColumnName(col.Name) {
-				return errors.New("nome de coluna inválido: " + col.Name)
-			}
-		}
-	}
-	
-	return nil
-}
-
-// IsValidColumnName valida nome de coluna
-func IsValidColumnName(name string) bool {
-	if name == "" || len(name) > 64 {
-		return false
-	}
-	
-	// Aceita: letras, números, underscore
-	for _, char := range name {
-		if !((char >= 'a' && char <= 'z') || 
-			(char >= 'A' && char <= 'Z') || 
-			(char >= '0' && char <= '9') || 
-			char == '_') {
-			return false
-		}
-	}
-	
-	return true
-}
-
-
-// ============================================================================
-// REQUEST MODELS - Estruturas de requisição HTTP
-// ============================================================================
-
-// DeleteRequest - Requisição para deletar registros
-type DeleteRequest struct {
-	ProjectID  int64                  `json:"project_id"`
-	InstanceID int64                  `json:"id_instancia"`
-	Table      string                 `json:"table"`
-	Where      map[string]interface{} `json:"where,omitempty"`
-	WhereRaw   string                 `json:"where_raw,omitempty"`
-	Mode       string                 `json:"mode,omitempty"` // "hard" ou "soft"
-}
-
-// AdvancedSelectRequest - Requisição para SELECT avançado
-type AdvancedSelectRequest struct {
-	ProjectID  int64                  `json:"project_id"`
-	InstanceID int64                  `json:"id_instancia"`
-	Table      string                 `json:"table"`
-	Alias      string                 `json:"alias,omitempty"`
-	Select     []string               `json:"select,omitempty"`
-	Joins      []Join                 `json:"joins,omitempty"`
-	Where      map[string]interface{} `json:"where,omitempty"`
-	WhereRaw   string                 `json:"where_raw,omitempty"`
-	GroupBy    string                 `json:"group_by,omitempty"`
-	Having     string                 `json:"having,omitempty"`
-	OrderBy    string                 `json:"order_by,omitempty"`
-	Limit      int                    `json:"limit,omitempty"`
-	Offset     int                    `json:"offset,omitempty"`
-}
-
-// Join - Configuração de JOIN
-type Join struct {
-	Type  string `json:"type"`  // INNER, LEFT, RIGHT
-	Table string `json:"table"`
-	Alias string `json:"alias,omitempty"`
-	On    string `json:"on"`
-}
-
-// AdvancedJoinSelectRequest - Requisição para SELECT com múltiplos JOINs
-type AdvancedJoinSelectRequest struct {
-	ProjectID  int64                  `json:"project_id"`
-	InstanceID int64                  `json:"id_instancia"`
-	Base       JoinBase               `json:"base"`
-	Joins      []JoinItem             `json:"joins,omitempty"`
-	Where      map[string]interface{} `json:"where,omitempty"`
-	WhereRaw   []string               `json:"where_raw,omitempty"`
-	GroupBy    string                 `json:"group_by,omitempty"`
-	Having     string                 `json:"having,omitempty"`
-	OrderBy    string                 `json:"order_by,omitempty"`
-	Limit      int                    `json:"limit,omitempty"`
-	Offset     int                    `json:"offset,omitempty"`
-}
-
-// JoinBase - Tabela base para JOIN
-type JoinBase struct {
-	Table   string   `json:"table"`
-	Alias   string   `json:"alias,omitempty"`
-	Columns []string `json:"columns,omitempty"`
-}
-
-// JoinItem - Item de JOIN
-type JoinItem struct {
-	Type    string   `json:"type"`
-	Table   string   `json:"table"`
-	Alias   string   `json:"alias,omitempty"`
-	On      string   `json:"on"`
-	Columns []string `json:"columns,omitempty"`
-}
-
-
-
-// UpdateRequest - Requisição para UPDATE
-type UpdateRequest struct {
-	ProjectID  int64                  `json:"project_id"`
-	InstanceID int64                  `json:"id_instancia"`
-	Table      string                 `json:"table"`
-	Data       map[string]interface{} `json:"data"`
-	Where      map[string]interface{} `json:"where,omitempty"`
-	WhereRaw   string                 `json:"where_raw,omitempty"`
-}
-
-// BatchUpdateRequest - Requisição para UPDATE em lote
-type BatchUpdateRequest struct {
-	ProjectID  int64               `json:"project_id"`
-	InstanceID int64               `json:"id_instancia"`
-	Table      string              `json:"table"`
-	Updates    []UpdateItem        `json:"updates"`
-}
-
-// UpdateItem - Item individual de update em lote
-type UpdateItem struct {
-	Data  map[string]interface{} `json:"data"`
-	Where map[string]interface{} `json:"where"`
-}
-
-// AggregateRequest - Requisição para operações de agregação
-type AggregateRequest struct {
-	ProjectID  int64                  `json:"project_id"`
-	InstanceID int64                  `json:"id_instancia"`
-	Table      string                 `json:"table"`
-	Operation  string                 `json:"operation"` // COUNT, SUM, AVG, MIN, MAX, EXISTS
-	Column     string                 `json:"column,omitempty"`
-	Where      map[string]interface{} `json:"where,omitempty"`
-}
-
-// ============================================================================
-// VALIDATION METHODS
-// ============================================================================
-
-// Validate - Valida DeleteRequest
-func (r *DeleteRequest) Validate() error {
-	if r.ProjectID <= 0 {
-		return ErrInvalidProjectID
-	}
-	if r.InstanceID <= 0 {
-		return ErrInvalidInstanceID
-	}
-	if r.Table == "" {
-		return ErrTableRequired
-	}
-	return nil
-}
-
-// Validate - Valida AdvancedSelectRequest
-func (r *AdvancedSelectRequest) Validate() error {
-	if r.ProjectID <= 0 {
-		return ErrInvalidProjectID
-	}
-	if r.InstanceID <= 0 {
-		return ErrInvalidInstanceID
-	}
-	if r.Table == "" {
-		return ErrTableRequired
-	}
-	return nil
-}
-
-
-
-// Validate - Valida UpdateRequest
-func (r *UpdateRequest) Validate() error {
-	if r.ProjectID <= 0 {
-		return ErrInvalidProjectID
-	}
-	if r.InstanceID <= 0 {
-		return ErrInvalidInstanceID
-	}
-	if r.Table == "" {
-		return ErrTableRequired
-	}
-	if len(r.Data) == 0 {
-		return ErrNoDataProvided
-	}
-	return nil
-}
-
-// Validate - Valida AggregateRequest
-func (r *AggregateRequest) Validate() error {
-	if r.ProjectID <= 0 {
-		return ErrInvalidProjectID
-	}
-	if r.InstanceID <= 0 {
-		return ErrInvalidInstanceID
-	}
-	if r.Table == "" {
-		return ErrTableRequired
-	}
-	if r.Operation == "" {
-		return ErrOperationRequired
-	}
-	return nil
-
-}
+package models
+
+// Column representa uma coluna com seu valor
+type Column struct {
+	Name  string      `json:"name"`
+	Value interface{} `json:"value"`
+}
+
+// InsertRequest - Requisição refatorada com especificação clara
+type InsertRequest struct {
+	ProjectID  int64    `json:"project_id"`
+	InstanceID int64    `json:"id_instancia"`
+	Table      string   `json:"table"`
+	Columns    []Column `json:"columns"` // ✅ Agora é explícito: nome + valor
+	Actor      string   `json:"-"`       // quem fez a requisição (preenchido pelo handler)
+}
+
+// BatchInsertRequest - Múltiplos inserts com mesma estrutura
+type BatchInsertRequest struct {
+	ProjectID  int64      `json:"project_id"`
+	InstanceID int64      `json:"id_instancia"`
+	Table      string     `json:"table"`
+	Rows       [][]Column `json:"rows"` // ✅ Array de rows, cada row tem suas colunas
+	Actor      string     `json:"-"`    // quem fez a requisição (preenchido pelo handler)
+}
+
+// Validate valida InsertRequest
+func (r *InsertRequest) Validate() error {
+	if r.ProjectID <= 0 {
+		return NewValidationError("project_id inválido")
+	}
+	if r.InstanceID <= 0 {
+		return NewValidationError("id_instancia inválido")
+	}
+	if r.Table == "" {
+		return NewValidationError("table é obrigatória")
+	}
+	if len(r.Columns) == 0 {
+		return NewValidationError("nenhuma coluna fornecida")
+	}
+	
+	// Validar nomes das colunas
+	for _, col := range r.Columns {
+		if col.Name == "" {
+			return NewValidationError("coluna com nome vazio")
+		}
+		if !IsValidColumnName(col.Name) {
+			return NewValidationError("nome de coluna inválido: " + col.Name)
+		}
+	}
+	
+	return nil
+}
+
+// Validate valida BatchInsertRequest
+func (r *BatchInsertRequest) Validate() error {
+	if r.ProjectID <= 0 {
+		return NewValidationError("project_id inválido")
+	}
+	if r.InstanceID <= 0 {
+		return NewValidationError("id_instancia inválido")
+	}
+	if r.Table == "" {
+		return NewValidationError("table é obrigatória")
+	}
+	if len(r.Rows) == 0 {
+		return NewValidationError("nenhuma linha fornecida")
+	}
+	
+	// Validar estrutura de cada row
+	firstRowLen := len(r.Rows[0])
+	for i, row := range r.Rows {
+		if len(row) == 0 {
+			return NewValidationError("linha vazia")
+		}
+		if len(row) != firstRowLen {
+			return NewValidationError("linhas com número diferente de colunas")
+		}
+		
+		// Validar cada coluna
+		for _, col := range row {
+			if col.Name == "" {
+				return NewValidationError("coluna com nome vazio na linha " + string(rune(i)))
+			}
+			if !IsValidColumnName(col.Name) {
+				return NewValidationError("nome de coluna inválido: " + col.Name)
+			}
+		}
+	}
+	
+	return nil
+}
+
+// IsValidColumnName valida nome de coluna
+func IsValidColumnName(name string) bool {
+	if name == "" || len(name) > 64 {
+		return false
+	}
+	
+	// Aceita: letras, números, underscore
+	for _, char := range name {
+		if !((char >= 'a' && char <= 'z') || 
+			(char >= 'A' && char <= 'Z') || 
+			(char >= '0' && char <= '9') || 
+			char == '_') {
+			return false
+		}
+	}
+	
+	return true
+}
+
+
+// ============================================================================
+// REQUEST MODELS - Estruturas de requisição HTTP
+// ============================================================================
+
+// DeleteRequest - Requisição para deletar registros
+type DeleteRequest struct {
+	ProjectID  int64                  `json:"project_id"`
+	InstanceID int64                  `json:"id_instancia"`
+	Table      string                 `json:"table"`
+	Where      map[string]interface{} `json:"where,omitempty"`
+	WhereRaw   string                 `json:"where_raw,omitempty"`
+	Mode       string                 `json:"mode,omitempty"` // "hard" ou "soft"
+	Actor      string                 `json:"-"`              // quem fez a requisição (preenchido pelo handler)
+}
+
+// HistoryRequest - Lista o histórico de alterações de um registro
+type HistoryRequest struct {
+	ProjectID  int64  `json:"project_id"`
+	InstanceID int64  `json:"id_instancia"`
+	Table      string `json:"table"`
+	RowID      int64  `json:"row_id"`
+	Limit      int    `json:"limit,omitempty"`
+	Offset     int    `json:"offset,omitempty"`
+}
+
+// RestoreRequest - Restaura um registro para o estado de uma entrada do histórico
+type RestoreRequest struct {
+	ProjectID  int64  `json:"project_id"`
+	InstanceID int64  `json:"id_instancia"`
+	Table      string `json:"table"`
+	HistoryID  int64  `json:"history_id"`
+	Actor      string `json:"-"` // quem fez a requisição (preenchido pelo handler)
+}
+
+// AdvancedSelectRequest - Requisição para SELECT avançado
+type AdvancedSelectRequest struct {
+	ProjectID   int64                  `json:"project_id"`
+	InstanceID  int64                  `json:"id_instancia"`
+	Table       string                 `json:"table"`
+	Alias       string                 `json:"alias,omitempty"`
+	Select      []string               `json:"select,omitempty"`
+	Projection  []ProjectionSpec       `json:"projection,omitempty"`
+	Joins       []Join                 `json:"joins,omitempty"`
+	Where       map[string]interface{} `json:"where,omitempty"`
+	Filters     []FilterSpec           `json:"filters,omitempty"`
+	WhereRaw    string                 `json:"where_raw,omitempty"`
+	GroupBy     string                 `json:"group_by,omitempty"`
+	Group       []ColumnRef            `json:"group,omitempty"`
+	Having      string                 `json:"having,omitempty"`
+	OrderBy     string                 `json:"order_by,omitempty"`
+	Sort        []SortSpec             `json:"sort,omitempty"`
+	Limit       int                    `json:"limit,omitempty"`
+	Offset      int                    `json:"offset,omitempty"`
+	Include     []IncludeSpec          `json:"include,omitempty"`
+	Consistency string                 `json:"consistency,omitempty"` // eventual (padrão, réplicas) ou strong (primário)
+}
+
+// IncludeSpec - Relacionamento embutido no resultado do SELECT
+// (linhas de Table onde ForeignColumn = valor de ParentColumn do pai)
+type IncludeSpec struct {
+	Table         string                 `json:"table"`
+	As            string                 `json:"as,omitempty"`            // nome do campo embutido (padrão: table)
+	ForeignColumn string                 `json:"foreign_column"`          // coluna na tabela incluída
+	ParentColumn  string                 `json:"parent_column,omitempty"` // coluna no pai (padrão: id)
+	Single        bool                   `json:"single,omitempty"`        // objeto em vez de array
+	Projection    []ProjectionSpec       `json:"projection,omitempty"`
+	Where         map[string]interface{} `json:"where,omitempty"`
+	Sort          []SortSpec             `json:"sort,omitempty"`
+	Limit         int                    `json:"limit,omitempty"` // máximo por pai
+	Include       []IncludeSpec          `json:"include,omitempty"`
+}
+
+// Join - Configuração de JOIN
+type Join struct {
+	Type       string          `json:"type"`  // INNER, LEFT, RIGHT
+	Table      string          `json:"table"`
+	Alias      string          `json:"alias,omitempty"`
+	On         string          `json:"on,omitempty"`
+	Conditions []JoinCondition `json:"conditions,omitempty"` // alternativa estruturada ao On
+}
+
+// AdvancedJoinSelectRequest - Requisição para SELECT com múltiplos JOINs
+type AdvancedJoinSelectRequest struct {
+	ProjectID   int64                  `json:"project_id"`
+	InstanceID  int64                  `json:"id_instancia"`
+	Base        JoinBase               `json:"base"`
+	Joins       []JoinItem             `json:"joins,omitempty"`
+	Where       map[string]interface{} `json:"where,omitempty"`
+	WhereRaw    []string               `json:"where_raw,omitempty"`
+	GroupBy     string                 `json:"group_by,omitempty"`
+	Group       []ColumnRef            `json:"group,omitempty"`
+	Having      string                 `json:"having,omitempty"`
+	OrderBy     string                 `json:"order_by,omitempty"`
+	Sort        []SortSpec             `json:"sort,omitempty"`
+	Limit       int                    `json:"limit,omitempty"`
+	Offset      int                    `json:"offset,omitempty"`
+	Consistency string                 `json:"consistency,omitempty"` // eventual (padrão) ou strong
+}
+
+// JoinBase - Tabela base para JOIN
+type JoinBase struct {
+	Table      string           `json:"table"`
+	Alias      string           `json:"alias,omitempty"`
+	Columns    []string         `json:"columns,omitempty"`
+	Projection []ProjectionSpec `json:"projection,omitempty"`
+}
+
+// JoinItem - Item de JOIN
+type JoinItem struct {
+	Type       string           `json:"type"`
+	Table      string           `json:"table"`
+	Alias      string           `json:"alias,omitempty"`
+	On         string           `json:"on,omitempty"`
+	Conditions []JoinCondition  `json:"conditions,omitempty"` // alternativa estruturada ao On
+	Columns    []string         `json:"columns,omitempty"`
+	Projection []ProjectionSpec `json:"projection,omitempty"`
+}
+
+// SearchRequest - Requisição de busca full-text (MATCH ... AGAINST)
+type SearchRequest struct {
+	ProjectID   int64                  `json:"project_id"`
+	InstanceID  int64                  `json:"id_instancia"`
+	Table       string                 `json:"table"`
+	Columns     []string               `json:"columns"`               // colunas do índice FULLTEXT
+	Query       string                 `json:"query"`
+	Mode        string                 `json:"mode,omitempty"`        // natural (padrão) ou boolean
+	Projection  []ProjectionSpec       `json:"projection,omitempty"`
+	Where       map[string]interface{} `json:"where,omitempty"`
+	Highlight   bool                   `json:"highlight,omitempty"`
+	Limit       int                    `json:"limit,omitempty"`
+	Offset      int                    `json:"offset,omitempty"`
+	Consistency string                 `json:"consistency,omitempty"` // eventual (padrão) ou strong
+}
+
+// ============================================================================
+// STRUCTURED SPECS - Alternativas seguras aos campos SQL livres
+// ============================================================================
+
+// ColumnRef - Referência a uma coluna (table_alias opcional)
+type ColumnRef struct {
+	TableAlias string `json:"table_alias,omitempty"`
+	Column     string `json:"column"`
+	Path       string `json:"path,omitempty"` // caminho em coluna JSON ($.cor)
+}
+
+// ProjectionSpec - Coluna projetada no SELECT
+type ProjectionSpec struct {
+	ColumnRef
+	Alias string `json:"alias,omitempty"`
+}
+
+// SortSpec - Ordenação estruturada
+type SortSpec struct {
+	ColumnRef
+	Direction string `json:"direction,omitempty"` // asc, desc
+	Nulls     string `json:"nulls,omitempty"`     // first, last
+}
+
+// FilterSpec - Comparação estruturada (op: eq, ne, gt, gte, lt, lte, like, in, not_in, is_null, not_null)
+type FilterSpec struct {
+	ColumnRef
+	Op    string      `json:"op,omitempty"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// JoinCondition - Igualdade entre colunas (left = right)
+type JoinCondition struct {
+	Left  ColumnRef `json:"left"`
+	Right ColumnRef `json:"right"`
+}
+
+
+
+// UpdateRequest - Requisição para UPDATE
+type UpdateRequest struct {
+	ProjectID  int64                  `json:"project_id"`
+	InstanceID int64                  `json:"id_instancia"`
+	Table      string                 `json:"table"`
+	Data       map[string]interface{} `json:"data"`
+	JSON       []JSONUpdate           `json:"json,omitempty"`
+	Ops        []UpdateOperation      `json:"ops,omitempty"`
+	Where      map[string]interface{} `json:"where,omitempty"`
+	WhereRaw   string                 `json:"where_raw,omitempty"`
+	// ExpectedVersion é obrigatório em tabelas versionadas
+	ExpectedVersion *int64 `json:"expected_version,omitempty"`
+	Actor           string `json:"-"` // quem fez a requisição (preenchido pelo handler)
+}
+
+// BatchUpdateRequest - Requisição para UPDATE em lote
+type BatchUpdateRequest struct {
+	ProjectID  int64               `json:"project_id"`
+	InstanceID int64               `json:"id_instancia"`
+	Table      string              `json:"table"`
+	Updates    []UpdateItem        `json:"updates"`
+	Actor      string              `json:"-"` // quem fez a requisição (preenchido pelo handler)
+}
+
+// UpdateItem - Item individual de update em lote
+type UpdateItem struct {
+	Data  map[string]interface{} `json:"data"`
+	JSON  []JSONUpdate           `json:"json,omitempty"`
+	Ops   []UpdateOperation      `json:"ops,omitempty"`
+	Where map[string]interface{} `json:"where"`
+	// ExpectedVersion é obrigatório em tabelas versionadas
+	ExpectedVersion *int64 `json:"expected_version,omitempty"`
+}
+
+// UpdateOperation - Atualização atômica de coluna calculada pelo banco
+// (op: increment, decrement, multiply, set_null, now, coalesce, least, greatest)
+type UpdateOperation struct {
+	Column string      `json:"column"`
+	Op     string      `json:"op"`
+	Value  interface{} `json:"value,omitempty"`
+}
+
+// JSONUpdate - Atualização parcial de coluna JSON (op: set, remove, merge)
+type JSONUpdate struct {
+	Column string      `json:"column"`
+	Op     string      `json:"op"`
+	Path   string      `json:"path,omitempty"`  // set e remove
+	Value  interface{} `json:"value,omitempty"` // set e merge (objeto)
+}
+
+// AggregateRequest - Requisição para operações de agregação
+type AggregateRequest struct {
+	ProjectID   int64                  `json:"project_id"`
+	InstanceID  int64                  `json:"id_instancia"`
+	Table       string                 `json:"table"`
+	Operation   string                 `json:"operation"`             // COUNT, SUM, AVG, MIN, MAX, EXISTS
+	Column      string                 `json:"column,omitempty"`
+	Where       map[string]interface{} `json:"where,omitempty"`
+	Consistency string                 `json:"consistency,omitempty"` // eventual (padrão) ou strong
+}
+
+// ============================================================================
+// VALIDATION METHODS
+// ============================================================================
+
+// Validate - Valida DeleteRequest
+func (r *DeleteRequest) Validate() error {
+	if r.ProjectID <= 0 {
+		return ErrInvalidProjectID
+	}
+	if r.InstanceID <= 0 {
+		return ErrInvalidInstanceID
+	}
+	if r.Table == "" {
+		return ErrTableRequired
+	}
+	return nil
+}
+
+// Validate - Valida AdvancedSelectRequest
+func (r *AdvancedSelectRequest) Validate() error {
+	if r.ProjectID <= 0 {
+		return ErrInvalidProjectID
+	}
+	if r.InstanceID <= 0 {
+		return ErrInvalidInstanceID
+	}
+	if r.Table == "" {
+		return ErrTableRequired
+	}
+	return nil
+}
+
+
+
+// Validate - Valida UpdateRequest
+func (r *UpdateRequest) Validate() error {
+	if r.ProjectID <= 0 {
+		return ErrInvalidProjectID
+	}
+	if r.InstanceID <= 0 {
+		return ErrInvalidInstanceID
+	}
+	if r.Table == "" {
+		return ErrTableRequired
+	}
+	if len(r.Data) == 0 && len(r.JSON) == 0 && len(r.Ops) == 0 {
+		return ErrNoDataProvided
+	}
+	return nil
+}
+
+// Validate - Valida AggregateRequest
+func (r *AggregateRequest) Validate() error {
+	if r.ProjectID <= 0 {
+		return ErrInvalidProjectID
+	}
+	if r.InstanceID <= 0 {
+		return ErrInvalidInstanceID
+	}
+	if r.Table == "" {
+		return ErrTableRequired
+	}
+	if r.Operation == "" {
+		return ErrOperationRequired
+	}
+	return nil
+
+}
+
+// Validate - Valida SearchRequest
+func (r *SearchRequest) Validate() error {
+	if r.ProjectID <= 0 {
+		return ErrInvalidProjectID
+	}
+	if r.InstanceID <= 0 {
+		return ErrInvalidInstanceID
+	}
+	if r.Table == "" {
+		return ErrTableRequired
+	}
+	if len(r.Columns) == 0 {
+		return NewValidationError("columns é obrigatório para a busca")
+	}
+	if r.Query == "" {
+		return NewValidationError("query é obrigatória para a busca")
+	}
+	return nil
+}
+
+// Validate - Valida HistoryRequest
+func (r *HistoryRequest) Validate() error {
+	if r.ProjectID <= 0 {
+		return ErrInvalidProjectID
+	}
+	if r.InstanceID <= 0 {
+		return ErrInvalidInstanceID
+	}
+	if r.Table == "" {
+		return ErrTableRequired
+	}
+	if r.RowID <= 0 {
+		return NewValidationError("row_id inválido")
+	}
+	return nil
+}
+
+// Validate - Valida RestoreRequest
+func (r *RestoreRequest) Validate() error {
+	if r.ProjectID <= 0 {
+		return ErrInvalidProjectID
+	}
+	if r.InstanceID <= 0 {
+		return ErrInvalidInstanceID
+	}
+	if r.Table == "" {
+		return ErrTableRequired
+	}
+	if r.HistoryID <= 0 {
+		return NewValidationError("history_id inválido")
+	}
+	return nil
+}
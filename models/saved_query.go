@@ -0,0 +1,60 @@
+package models
+
+import "time"
+
+// Tipos aceitos nos parâmetros de consultas nomeadas
+const (
+	ParamString   = "string"
+	ParamInt      = "int"
+	ParamFloat    = "float"
+	ParamBool     = "bool"
+	ParamDatetime = "datetime" // RFC 3339 ou "2006-01-02 15:04:05"
+)
+
+// SavedQuery - Versão de uma consulta nomeada do projeto. O template é um
+// join-select cujos valores em "where" podem referenciar parâmetros no
+// formato {"param": "nome"}.
+type SavedQuery struct {
+	ProjectID   int64                     `json:"project_id"`
+	Name        string                    `json:"name"`
+	Version     int                       `json:"version"`
+	Description string                    `json:"description,omitempty"`
+	Template    AdvancedJoinSelectRequest `json:"template"`
+	Params      []QueryParam              `json:"params"`
+	Approved    bool                      `json:"approved"`
+	CreatedBy   string                    `json:"created_by,omitempty"`
+	CreatedAt   time.Time                 `json:"created_at"`
+}
+
+// QueryParam - Parâmetro tipado de uma consulta nomeada (opcional sem valor
+// nem default: o filtro que o referencia é omitido)
+type QueryParam struct {
+	Name     string      `json:"name"`
+	Type     string      `json:"type"`
+	Required bool        `json:"required,omitempty"`
+	Default  interface{} `json:"default,omitempty"`
+}
+
+// SavedQueryRequest - Cria uma nova versão da consulta nomeada
+type SavedQueryRequest struct {
+	Description string                    `json:"description,omitempty"`
+	Template    AdvancedJoinSelectRequest `json:"template"`
+	Params      []QueryParam              `json:"params,omitempty"`
+}
+
+// SavedQueryApproval - Aprova (ou revoga) uma versão para chamadas com API key
+type SavedQueryApproval struct {
+	Version  int  `json:"version"`
+	Approved bool `json:"approved"`
+}
+
+// RunSavedQueryRequest - Execução de uma consulta nomeada via /data/query/{name}
+type RunSavedQueryRequest struct {
+	ProjectID   int64                  `json:"project_id"`
+	InstanceID  int64                  `json:"id_instancia"`
+	Version     int                    `json:"version,omitempty"` // 0 = mais recente
+	Params      map[string]interface{} `json:"params,omitempty"`
+	Limit       int                    `json:"limit,omitempty"`
+	Offset      int                    `json:"offset,omitempty"`
+	Consistency string                 `json:"consistency,omitempty"`
+}
@@ -0,0 +1,63 @@
+package models
+
+import "time"
+
+// SummaryPrefix é o prefixo lógico das tabelas de resumos materializados
+// ({code}_mv_nome); tabelas e views comuns não podem usá-lo
+const SummaryPrefix = "mv_"
+
+// Modos de atualização de um resumo materializado
+const (
+	RefreshScheduled = "scheduled" // recalcula tudo a cada interval_seconds
+	RefreshOnWrite   = "on_write"  // recalcula a instância após escritas na origem
+)
+
+// Estados da atualização de um resumo materializado
+const (
+	SummaryPending    = "pending"
+	SummaryRefreshing = "refreshing"
+	SummaryReady      = "ready"
+	SummaryFailed     = "failed"
+)
+
+// SummaryAggregate - Agregação calculada pelo resumo
+type SummaryAggregate struct {
+	Function string `json:"function"`         // COUNT, SUM, AVG, MIN, MAX
+	Column   string `json:"column,omitempty"` // vazio (ou *) apenas em COUNT
+	As       string `json:"as"`
+}
+
+// SummaryDefinition - Agregação agrupada por id_instancia (e pelas colunas de group_by)
+type SummaryDefinition struct {
+	Source          string                 `json:"source"`
+	GroupBy         []string               `json:"group_by,omitempty"`
+	Aggregates      []SummaryAggregate     `json:"aggregates"`
+	Where           map[string]interface{} `json:"where,omitempty"`
+	RefreshMode     string                 `json:"refresh_mode"`
+	IntervalSeconds int                    `json:"interval_seconds,omitempty"` // apenas scheduled
+}
+
+// CreateSummaryRequest - Declara o resumo {code}_mv_{name}
+type CreateSummaryRequest struct {
+	ProjectID int64  `json:"project_id"`
+	Name      string `json:"name"`
+	SummaryDefinition
+}
+
+// SummaryRefreshRequest - Atualização manual de um resumo
+type SummaryRefreshRequest struct {
+	ProjectID int64  `json:"project_id"`
+	Name      string `json:"name"`
+}
+
+// MaterializedSummary - Resumo materializado e o estado da última atualização
+type MaterializedSummary struct {
+	ProjectID int64  `json:"project_id"`
+	Name      string `json:"name"`
+	Table     string `json:"table"` // nome lógico (mv_nome), consultável como tabela
+	SummaryDefinition
+	Status         string     `json:"status"`
+	LastRefreshAt  *time.Time `json:"last_refresh_at,omitempty"`
+	LastDurationMs int64      `json:"last_duration_ms,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+}
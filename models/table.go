@@ -1,43 +1,96 @@
-package models
-
-type ColumnRequest struct {
-	Name     string `json:"name"`
-	Type     string `json:"type"`
-	Nullable bool   `json:"nullable"`
-	Unique   bool   `json:"unique"`
-}
-
-type IndexRequest struct {
-	Name    string   `json:"name"`
-	Columns []string `json:"columns"`
-	Type    string   `json:"type"` // UNIQUE ou INDEX
-}
-
-// CreateTableRequest - Agora usa project_id ao invés de project_code
-type CreateTableRequest struct {
-	ProjectID int64           `json:"project_id"`
-	TableName string          `json:"table_name"`
-	Columns   []ColumnRequest `json:"columns"`
-	Indexes   []IndexRequest  `json:"indexes,omitempty"`
-}
-
-type ColumnDetail struct {
-	Name     string      `json:"name"`
-	Type     string      `json:"type"`
-	Nullable bool        `json:"nullable"`
-	Default  interface{} `json:"default"`
-	Key      string      `json:"key"`
-	Extra    string      `json:"extra"`
-}
-
-type IndexDetail struct {
-	Name    string   `json:"name"`
-	Columns []string `json:"columns"`
-	Type    string   `json:"type"`
-}
-
-type TableDetail struct {
-	Name    string         `json:"name"`
-	Columns []ColumnDetail `json:"columns"`
-	Indexes []IndexDetail  `json:"indexes"`
-}
+package models
+
+// VersionColumn é a coluna de versão gerenciada pelo engine (concorrência otimista)
+const VersionColumn = "version"
+
+type ColumnRequest struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Nullable bool   `json:"nullable"`
+	Unique   bool   `json:"unique"`
+}
+
+type IndexRequest struct {
+	Name    string   `json:"name"`
+	Columns []string `json:"columns"`
+	Type    string   `json:"type"` // UNIQUE, INDEX ou FULLTEXT
+}
+
+// CreateTableRequest - Agora usa project_id ao invés de project_code
+type CreateTableRequest struct {
+	ProjectID  int64           `json:"project_id"`
+	TableName  string          `json:"table_name"`
+	Columns    []ColumnRequest `json:"columns"`
+	Indexes    []IndexRequest  `json:"indexes,omitempty"`
+	Versioned  bool            `json:"versioned,omitempty"`  // adiciona a coluna version
+	Timestamps bool            `json:"timestamps,omitempty"` // adiciona created_at/updated_at
+	TrackUser  bool            `json:"track_user,omitempty"` // adiciona created_by/updated_by
+	History    bool            `json:"history,omitempty"`    // grava histórico de alterações
+
+	// AuditOverride aceita valores do cliente nas colunas de auditoria
+	// (importações); sem ele, enviá-los é erro
+	AuditOverride bool `json:"audit_override,omitempty"`
+}
+
+type ColumnDetail struct {
+	Name     string      `json:"name"`
+	Type     string      `json:"type"`
+	Nullable bool        `json:"nullable"`
+	Default  interface{} `json:"default"`
+	Key      string      `json:"key"`
+	Extra    string      `json:"extra"`
+}
+
+type IndexDetail struct {
+	Name    string   `json:"name"`
+	Columns []string `json:"columns"`
+	Type    string   `json:"type"`
+}
+
+// Tipos de objeto do schema de um projeto
+const (
+	TableTypeTable   = "table"
+	TableTypeView    = "view"
+	TableTypeSummary = "materialized"
+)
+
+// TableSummary - Item da listagem de tabelas do projeto
+type TableSummary struct {
+	Name string `json:"name"`
+	Type string `json:"type"` // table, view ou materialized
+}
+
+type TableDetail struct {
+	Name       string                     `json:"name"`
+	Type       string                     `json:"type"`
+	Columns    []ColumnDetail             `json:"columns"`
+	Indexes    []IndexDetail              `json:"indexes"`
+	Definition *AdvancedJoinSelectRequest `json:"definition,omitempty"` // apenas views
+	Summary    *MaterializedSummary       `json:"summary,omitempty"`    // apenas resumos materializados
+}
+
+// CreateViewRequest - View do projeto descrita pelo spec estruturado do
+// join-select (base.projection, joins[].conditions/projection, where, group,
+// sort). Campos de SQL livre não são aceitos; project_id, id_instancia,
+// limit e offset da definição são ignorados.
+type CreateViewRequest struct {
+	ProjectID  int64                     `json:"project_id"`
+	ViewName   string                    `json:"view_name"`
+	Definition AdvancedJoinSelectRequest `json:"definition"`
+}
+
+// FieldError - Erro de validação de uma coluna específica
+type FieldError struct {
+	Column  string      `json:"column"`
+	Code    string      `json:"code"`
+	Message string      `json:"message"`
+	Value   interface{} `json:"value,omitempty"`
+	Row     *int        `json:"row,omitempty"`
+}
+
+// TableHistoryRequest - Liga ou desliga o histórico de alterações da tabela
+type TableHistoryRequest struct {
+	ProjectID int64  `json:"project_id"`
+	TableName string `json:"table_name"`
+	Enabled   bool   `json:"enabled"`
+}
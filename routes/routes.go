@@ -1,115 +1,183 @@
-package routes
-
-import (
-	"log"
-	"net/http"
-
-	"github.com/gorilla/mux"
-	"meu-provedor/handlers"
-	"meu-provedor/security"
-)
-
-// ============================================================================
-// ROUTER SETUP
-// ============================================================================
-
-// SetupRouter configura todas as rotas da API
-func SetupRouter() *mux.Router {
-	r := mux.NewRouter()
-
-	// Aplicar CORS globalmente
-	r.Use(security.CORS)
-
-	// Criar subrouter protegido
-	protected := r.PathPrefix("/").Subrouter()
-	protected.Use(security.InternalOnly)
-
-	// ========================================
-	// DATA ENGINE ROUTES
-	// ========================================
-
-	// SELECT InsertDebugHandler
-	protected.HandleFunc("/data/select", handlers.AdvancedSelectHandler).Methods("POST")
-	protected.HandleFunc("/data/join-select", handlers.AdvancedJoinSelectHandler).Methods("POST")
-
-	// INSERT
-	protected.HandleFunc("/data/insert", handlers.InsertHandler).Methods("POST")
-	protected.HandleFunc("/data/batch-insert", handlers.BatchInsertHandler).Methods("POST")
-
-	// UPDATE
-	protected.HandleFunc("/data/update", handlers.UpdateHandler).Methods("POST")
-	protected.HandleFunc("/data/batch-update", handlers.BatchUpdateHandler).Methods("POST")
-
-	// DELETE
-	protected.HandleFunc("/data/delete", handlers.DeleteHandler).Methods("POST")
-
-	// AGGREGATE
-	protected.HandleFunc("/data/aggregate", handlers.AggregateHandler).Methods("POST")
-
-
-	/*
-	====================================================
-	PROJETOS
-	====================================================
-	*/
-
-	protected.HandleFunc("/projects", handlers.ListProjects).Methods("GET")
-	protected.HandleFunc("/projects", handlers.CreateProject).Methods("POST")
-	protected.HandleFunc("/projects/{id}", handlers.UpdateProject).Methods("PUT")
-	protected.HandleFunc("/projects/{id}", handlers.DeleteProject).Methods("DELETE")
-
-	/*
-	====================================================
-	INSTÂNCIAS
-	====================================================
-	*/
-
-	protected.HandleFunc("/instances", handlers.ListInstances).Methods("GET")
-	protected.HandleFunc("/instances", handlers.CreateInstance).Methods("POST")
-	protected.HandleFunc("/instances/{id}", handlers.UpdateInstance).Methods("PUT")
-	protected.HandleFunc("/instances/{id}", handlers.DeleteInstance).Methods("DELETE")
-
-	/*
-	====================================================
-	SCHEMA – TABELAS
-	====================================================
-	*/
-
-	protected.HandleFunc("/schema/table", handlers.CreateProjectTable).Methods("POST")
-	protected.HandleFunc("/schema/tables", handlers.ListProjectTables).Methods("GET")
-	protected.HandleFunc("/schema/table/details", handlers.GetTableDetails).Methods("GET")
-	protected.HandleFunc("/schema/table", handlers.DeleteProjectTable).Methods("DELETE")
-
-	/*
-	====================================================
-	SCHEMA – COLUNAS
-	====================================================
-	*/
-
-	protected.HandleFunc("/schema/column", handlers.AddColumn).Methods("POST")
-	protected.HandleFunc("/schema/column", handlers.ModifyColumn).Methods("PUT")
-	protected.HandleFunc("/schema/column", handlers.DropColumn).Methods("DELETE")
-
-	/*
-	====================================================
-	SCHEMA – ÍNDICES
-	====================================================
-	*/
-
-	protected.HandleFunc("/schema/index", handlers.AddIndex).Methods("POST")
-	protected.HandleFunc("/schema/index", handlers.DropIndex).Methods("DELETE")
-
-	return r
-}
-
-func StartServer(port string) {
-	r := SetupRouter()
-	log.Println("🚀 Servidor iniciado na porta", port)
-
-	if err := http.ListenAndServe("0.0.0.0:"+port, r); err != nil {
-		log.Fatal("❌ Erro ao iniciar servidor:", err)
-	}
-}
-
-
-
+package routes
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"meu-provedor/handlers"
+	"meu-provedor/security"
+)
+
+// ============================================================================
+// ROUTER SETUP
+// ============================================================================
+
+// SetupRouter configura todas as rotas da API
+func SetupRouter() *mux.Router {
+	r := mux.NewRouter()
+
+	// Aplicar CORS globalmente
+	r.Use(security.CORS)
+
+	// Criar subrouter protegido
+	protected := r.PathPrefix("/").Subrouter()
+	protected.Use(security.InternalOnly)
+	protected.Use(security.RateLimit)
+	protected.Use(security.SavedQueriesOnly)
+	protected.Use(security.APIKeyCaller)
+
+	// ========================================
+	// DATA ENGINE ROUTES
+	// ========================================
+
+	// SELECT InsertDebugHandler
+	protected.HandleFunc("/data/select", handlers.AdvancedSelectHandler).Methods("POST")
+	protected.HandleFunc("/data/join-select", handlers.AdvancedJoinSelectHandler).Methods("POST")
+
+	// INSERT
+	protected.HandleFunc("/data/insert", handlers.InsertHandler).Methods("POST")
+	protected.HandleFunc("/data/batch-insert", handlers.BatchInsertHandler).Methods("POST")
+
+	// UPDATE
+	protected.HandleFunc("/data/update", handlers.UpdateHandler).Methods("POST")
+	protected.HandleFunc("/data/batch-update", handlers.BatchUpdateHandler).Methods("POST")
+
+	// DELETE
+	protected.HandleFunc("/data/delete", handlers.DeleteHandler).Methods("POST")
+
+	// AGGREGATE
+	protected.HandleFunc("/data/aggregate", handlers.AggregateHandler).Methods("POST")
+
+	// SEARCH (full-text)
+	protected.HandleFunc("/data/search", handlers.SearchHandler).Methods("POST")
+
+	// SUBSCRIBE (Server-Sent Events)
+	protected.HandleFunc("/data/subscribe", handlers.SubscribeHandler).Methods("GET")
+
+	// HISTORY (trilha de alterações)
+	protected.HandleFunc("/data/history", handlers.HistoryHandler).Methods("POST")
+	protected.HandleFunc("/data/history/restore", handlers.RestoreHandler).Methods("POST")
+
+	// EXPLAIN (plano de execução e sugestão de índices)
+	protected.HandleFunc("/data/explain", handlers.ExplainHandler).Methods("POST")
+
+	// CONSULTAS NOMEADAS
+	protected.HandleFunc("/data/query/{name}", handlers.RunSavedQueryHandler).Methods("POST")
+
+
+	/*
+	====================================================
+	PROJETOS
+	====================================================
+	*/
+
+	protected.HandleFunc("/projects", handlers.ListProjects).Methods("GET")
+	protected.HandleFunc("/projects", handlers.CreateProject).Methods("POST")
+	protected.HandleFunc("/projects/{id}", handlers.UpdateProject).Methods("PUT")
+	protected.HandleFunc("/projects/{id}", handlers.DeleteProject).Methods("DELETE")
+	protected.HandleFunc("/projects/{id}/rate-limit", handlers.GetProjectRateLimit).Methods("GET")
+	protected.HandleFunc("/projects/{id}/rate-limit", handlers.SetProjectRateLimit).Methods("PUT")
+	protected.HandleFunc("/projects/{id}/query-limits", handlers.GetProjectQueryLimits).Methods("GET")
+	protected.HandleFunc("/projects/{id}/query-limits", handlers.SetProjectQueryLimits).Methods("PUT")
+	protected.HandleFunc("/projects/{id}/queries", handlers.ListSavedQueries).Methods("GET")
+	protected.HandleFunc("/projects/{id}/queries/{name}", handlers.GetSavedQueryVersions).Methods("GET")
+	protected.HandleFunc("/projects/{id}/queries/{name}", handlers.SaveQuery).Methods("PUT")
+	protected.HandleFunc("/projects/{id}/queries/{name}", handlers.DeleteSavedQuery).Methods("DELETE")
+	protected.HandleFunc("/projects/{id}/queries/{name}/approval", handlers.ApproveSavedQuery).Methods("PUT")
+	protected.HandleFunc("/projects/{id}/database", handlers.GetProjectDatabase).Methods("GET")
+	protected.HandleFunc("/projects/{id}/database", handlers.SetProjectDatabase).Methods("PUT")
+	protected.HandleFunc("/projects/{id}/database", handlers.DeleteProjectDatabase).Methods("DELETE")
+	protected.HandleFunc("/projects/{id}/database/migrate", handlers.StartProjectMigration).Methods("POST")
+	protected.HandleFunc("/projects/{id}/database/migration", handlers.GetProjectMigration).Methods("GET")
+
+	// Webhooks
+	protected.HandleFunc("/projects/{id}/webhooks", handlers.ListWebhooks).Methods("GET")
+	protected.HandleFunc("/projects/{id}/webhooks", handlers.CreateWebhook).Methods("POST")
+	protected.HandleFunc("/projects/{id}/webhooks/{webhook_id}", handlers.DeleteWebhook).Methods("DELETE")
+	protected.HandleFunc("/projects/{id}/webhooks/{webhook_id}/deliveries", handlers.ListWebhookDeliveries).Methods("GET")
+	protected.HandleFunc("/projects/{id}/webhooks/{webhook_id}/deliveries/{delivery_id}/retry", handlers.RetryWebhookDelivery).Methods("POST")
+
+	/*
+	====================================================
+	INSTÂNCIAS
+	====================================================
+	*/
+
+	protected.HandleFunc("/instances", handlers.ListInstances).Methods("GET")
+	protected.HandleFunc("/instances", handlers.CreateInstance).Methods("POST")
+	protected.HandleFunc("/instances/{id}", handlers.UpdateInstance).Methods("PUT")
+	protected.HandleFunc("/instances/{id}", handlers.DeleteInstance).Methods("DELETE")
+
+	/*
+	====================================================
+	SCHEMA – TABELAS
+	====================================================
+	*/
+
+	protected.HandleFunc("/schema/table", handlers.CreateProjectTable).Methods("POST")
+	protected.HandleFunc("/schema/tables", handlers.ListProjectTables).Methods("GET")
+	protected.HandleFunc("/schema/table/details", handlers.GetTableDetails).Methods("GET")
+	protected.HandleFunc("/schema/table", handlers.DeleteProjectTable).Methods("DELETE")
+	protected.HandleFunc("/schema/table/history", handlers.SetTableHistory).Methods("PUT")
+
+	/*
+	====================================================
+	SCHEMA – VIEWS
+	====================================================
+	*/
+
+	protected.HandleFunc("/schema/view", handlers.CreateProjectView).Methods("POST")
+	protected.HandleFunc("/schema/view", handlers.DeleteProjectView).Methods("DELETE")
+
+	/*
+	====================================================
+	SCHEMA – RESUMOS MATERIALIZADOS
+	====================================================
+	*/
+
+	protected.HandleFunc("/schema/summary", handlers.CreateSummary).Methods("POST")
+	protected.HandleFunc("/schema/summary", handlers.DeleteSummary).Methods("DELETE")
+	protected.HandleFunc("/schema/summary/refresh", handlers.RefreshSummary).Methods("POST")
+
+	/*
+	====================================================
+	SCHEMA – COLUNAS
+	====================================================
+	*/
+
+	protected.HandleFunc("/schema/column", handlers.AddColumn).Methods("POST")
+	protected.HandleFunc("/schema/column", handlers.ModifyColumn).Methods("PUT")
+	protected.HandleFunc("/schema/column", handlers.DropColumn).Methods("DELETE")
+
+	/*
+	====================================================
+	SCHEMA – ÍNDICES
+	====================================================
+	*/
+
+	protected.HandleFunc("/schema/index", handlers.AddIndex).Methods("POST")
+	protected.HandleFunc("/schema/index", handlers.DropIndex).Methods("DELETE")
+
+	/*
+	====================================================
+	ADMIN
+	====================================================
+	*/
+
+	protected.HandleFunc("/admin/slow-queries", handlers.ListSlowQueries).Methods("GET")
+
+	return r
+}
+
+func StartServer(port string) {
+	r := SetupRouter()
+	log.Println("🚀 Servidor iniciado na porta", port)
+
+	if err := http.ListenAndServe("0.0.0.0:"+port, r); err != nil {
+		log.Fatal("❌ Erro ao iniciar servidor:", err)
+	}
+}
+
+
+
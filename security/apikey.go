@@ -1,22 +1,22 @@
-package security
-
-import (
-    "errors"
-    "log"
-    "meu-provedor/config"
-)
-
-// Valida a API KEY recebida e retorna o projeto correspondente
-func ValidateApiKey(apiKey string) (*config.Project, error) {
-    if apiKey == "" {
-        return nil, errors.New("⚠️ API KEY não fornecida")
-    }
-
-    project, err := config.GetProjectByApiKey(apiKey)
-    if err != nil {
-        log.Println("❌ API KEY inválida:", apiKey)
-        return nil, errors.New("API KEY inválida")
-    }
-
-    return project, nil
-}
+package security
+
+import (
+    "log"
+    "meu-provedor/config"
+    "meu-provedor/models"
+)
+
+// Valida a API KEY recebida e retorna o projeto correspondente
+func ValidateApiKey(apiKey string) (*config.Project, error) {
+    if apiKey == "" {
+        return nil, models.ErrAPIKeyNotProvided
+    }
+
+    project, err := config.GetProjectByApiKey(apiKey)
+    if err != nil {
+        log.Println("❌ API KEY inválida:", apiKey)
+        return nil, models.ErrInvalidAPIKey
+    }
+
+    return project, nil
+}
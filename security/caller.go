@@ -0,0 +1,41 @@
+package security
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// ============================================================================
+// CALLER IDENTITY
+// ============================================================================
+
+// maxCallerLength limita o tamanho da identidade gravada nas tabelas
+const maxCallerLength = 191
+
+// CallerID identifica quem fez a requisição: o header X-Actor-ID (usuário
+// final repassado pelo backend), a API key (hash) ou "internal"
+func CallerID(r *http.Request) string {
+	if actor := strings.TrimSpace(r.Header.Get("X-Actor-ID")); actor != "" {
+		return truncateUTF8(actor, maxCallerLength)
+	}
+	if apiKey := r.Header.Get("X-API-Key"); apiKey != "" {
+		sum := sha256.Sum256([]byte(apiKey))
+		return "apikey:" + hex.EncodeToString(sum[:])[:16]
+	}
+	return "internal"
+}
+
+// truncateUTF8 corta s em até max bytes sem partir um caractere multibyte
+func truncateUTF8(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	cut := max
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut]
+}
@@ -1,44 +1,86 @@
-package security
-
-import (
-	"net/http"
-	"os"
-)
-
-// ============================================================================
-// SECURITY MIDDLEWARE
-// ============================================================================
-
-// InternalOnly valida token interno antes de processar requisição
-func InternalOnly(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		internalToken := os.Getenv("INTERNAL_TOKEN")
-		requestToken := r.Header.Get("X-Internal-Token")
-
-		// Validar token
-		if requestToken == "" || requestToken != internalToken {
-			http.Error(w, "Acesso negado - token inválido ou ausente", http.StatusForbidden)
-			return
-		}
-
-		// Token válido, prosseguir
-		next.ServeHTTP(w, r)
-	})
-}
-
-// CORS adiciona headers CORS à resposta
-func CORS(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, X-Internal-Token")
-
-		// Tratar preflight request
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
-
-		next.ServeHTTP(w, r)
-	})
-}
\ No newline at end of file
+package security
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+
+	"meu-provedor/services/data_service"
+)
+
+// ============================================================================
+// SECURITY MIDDLEWARE
+// ============================================================================
+
+// InternalOnly valida token interno antes de processar requisição
+func InternalOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		internalToken := os.Getenv("INTERNAL_TOKEN")
+		requestToken := r.Header.Get("X-Internal-Token")
+
+		// Validar token
+		if requestToken == "" || requestToken != internalToken {
+			writeError(w, http.StatusForbidden, "FORBIDDEN", "Acesso negado - token inválido ou ausente")
+			return
+		}
+
+		// Token válido, prosseguir
+		next.ServeHTTP(w, r)
+	})
+}
+
+// APIKeyCaller marca o contexto das chamadas com X-API-Key: os serviços de
+// dados só aceitam SQL livre dessas chamadas se o projeto liberou
+func APIKeyCaller(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-API-Key") != "" {
+			r = r.WithContext(services.WithAPIKeyCaller(r.Context()))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// SavedQueriesOnly restringe chamadas com X-API-Key às consultas nomeadas
+// (/data/query/{name}) quando APIKEY_SAVED_QUERIES_ONLY=true
+func SavedQueriesOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-API-Key") == "" ||
+			strings.ToLower(os.Getenv("APIKEY_SAVED_QUERIES_ONLY")) != "true" ||
+			strings.HasPrefix(r.URL.Path, "/data/query/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		writeError(w, http.StatusForbidden, "SAVED_QUERIES_ONLY", "Chamadas com API key só podem executar consultas nomeadas aprovadas")
+	})
+}
+
+// CORS adiciona headers CORS à resposta
+func CORS(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, X-Internal-Token, X-API-Key, X-Actor-ID")
+		w.Header().Set("Access-Control-Expose-Headers", "X-RateLimit-Limit, X-RateLimit-Remaining, X-RateLimit-Reset, Retry-After")
+
+		// Tratar preflight request
+		if r.Method == "OPTIONS" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// writeError escreve o envelope de erro JSON padrão da API
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": false,
+		"error":   message,
+		"code":    code,
+	})
+}
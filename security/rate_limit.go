@@ -0,0 +1,341 @@
+package security
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"meu-provedor/config"
+	"meu-provedor/models"
+	"meu-provedor/services/data_service"
+	projectService "meu-provedor/services/project"
+)
+
+// ============================================================================
+// RATE LIMIT - Token bucket por API key, projeto e instância
+// ============================================================================
+
+// RateLimitRule define a taxa de um bucket
+type RateLimitRule struct {
+	RequestsPerMinute int
+	Burst             int
+}
+
+// RateLimitDecision é o resultado da tentativa de consumir um token
+type RateLimitDecision struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration
+	Reset      time.Duration
+}
+
+// RateLimitBucket identifica um bucket e a regra aplicada a ele
+type RateLimitBucket struct {
+	Key  string
+	Rule RateLimitRule
+}
+
+// RateLimitStore armazena o estado dos buckets
+// (em memória por processo; pode ser trocado por um backend compartilhado)
+type RateLimitStore interface {
+	// Take consome um token de cada bucket somente se todos permitirem a
+	// requisição; uma requisição recusada não gasta token de nenhum bucket
+	Take(buckets []RateLimitBucket) []RateLimitDecision
+}
+
+// rateLimitStore é o store usado pelo middleware
+var rateLimitStore RateLimitStore = NewMemoryRateLimitStore()
+
+// SetRateLimitStore troca o store usado pelo middleware
+func SetRateLimitStore(store RateLimitStore) {
+	rateLimitStore = store
+}
+
+// RateLimit aplica os limites de requisição antes de processar a rota
+func RateLimit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		projectID, instanceID, err := rateLimitTargets(w, r)
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				writeError(w, http.StatusRequestEntityTooLarge, models.ErrRequestTooLarge.Code, models.ErrRequestTooLarge.Message)
+				return
+			}
+			writeError(w, http.StatusBadRequest, models.ErrInvalidJSON.Code, models.ErrInvalidJSON.Message)
+			return
+		}
+
+		var buckets []RateLimitBucket
+
+		if apiKey := r.Header.Get("X-API-Key"); apiKey != "" {
+			sum := sha256.Sum256([]byte(apiKey))
+			buckets = append(buckets, RateLimitBucket{Key: "apikey:" + hex.EncodeToString(sum[:]), Rule: apiKeyRule()})
+		}
+		if projectID > 0 {
+			buckets = append(buckets, RateLimitBucket{Key: fmt.Sprintf("project:%d", projectID), Rule: projectRule(projectID)})
+		}
+		if instanceID > 0 {
+			buckets = append(buckets, RateLimitBucket{Key: fmt.Sprintf("instance:%d", instanceID), Rule: instanceRule(instanceID)})
+		}
+
+		if len(buckets) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		decisions := rateLimitStore.Take(buckets)
+
+		// Reportar o bucket mais restritivo
+		current := decisions[0]
+		for _, d := range decisions[1:] {
+			if !d.Allowed && current.Allowed || d.Allowed == current.Allowed && d.Remaining < current.Remaining {
+				current = d
+			}
+		}
+
+		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(current.Limit))
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(current.Remaining))
+		w.Header().Set("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(current.Reset)))
+
+		if !current.Allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(current.RetryAfter)))
+			writeError(w, http.StatusTooManyRequests, models.ErrRateLimitExceeded.Code, models.ErrRateLimitExceeded.Message)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ============================================================================
+// MEMORY STORE
+// ============================================================================
+
+type tokenBucket struct {
+	tokens   float64
+	updated  time.Time
+	lastSeen time.Time
+}
+
+// MemoryRateLimitStore mantém os buckets em memória no processo atual
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+	now       func() time.Time // relógio (trocado nos testes)
+}
+
+// NewMemoryRateLimitStore cria um store em memória
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		buckets:   map[string]*tokenBucket{},
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+// Take consome um token de cada bucket se todos tiverem token disponível
+func (s *MemoryRateLimitStore) Take(buckets []RateLimitBucket) []RateLimitDecision {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	// Reabastecer e verificar todos os buckets antes de consumir
+	states := make([]*tokenBucket, len(buckets))
+	allowed := true
+	for i, bucket := range buckets {
+		capacity, perSecond := bucketRates(bucket.Rule)
+
+		b, ok := s.buckets[bucket.Key]
+		if !ok {
+			b = &tokenBucket{tokens: capacity, updated: now}
+			s.buckets[bucket.Key] = b
+		}
+
+		// Reabastecer proporcionalmente ao tempo decorrido
+		elapsed := now.Sub(b.updated).Seconds()
+		b.tokens = math.Min(capacity, b.tokens+elapsed*perSecond)
+		b.updated = now
+		b.lastSeen = now
+
+		states[i] = b
+		if b.tokens < 1 {
+			allowed = false
+		}
+	}
+
+	decisions := make([]RateLimitDecision, len(buckets))
+	for i, bucket := range buckets {
+		capacity, perSecond := bucketRates(bucket.Rule)
+		b := states[i]
+
+		decision := RateLimitDecision{Limit: int(capacity), Allowed: allowed}
+		if allowed {
+			b.tokens--
+		} else if b.tokens < 1 {
+			decision.RetryAfter = secondsToDuration((1 - b.tokens) / perSecond)
+		}
+		decision.Remaining = int(b.tokens)
+		decision.Reset = secondsToDuration((capacity - b.tokens) / perSecond)
+		decisions[i] = decision
+	}
+
+	return decisions
+}
+
+// bucketRates retorna a capacidade e a taxa de reabastecimento (tokens/s) da regra
+func bucketRates(rule RateLimitRule) (float64, float64) {
+	capacity := float64(rule.Burst)
+	if capacity <= 0 {
+		capacity = float64(rule.RequestsPerMinute)
+	}
+	return capacity, float64(rule.RequestsPerMinute) / 60
+}
+
+// sweep remove buckets ociosos (executa no máximo uma vez por minuto)
+func (s *MemoryRateLimitStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	for key, b := range s.buckets {
+		if now.Sub(b.lastSeen) > 10*time.Minute {
+			delete(s.buckets, key)
+		}
+	}
+	s.lastSweep = now
+}
+
+// ============================================================================
+// REGRAS
+// ============================================================================
+
+type cachedRule struct {
+	rule    RateLimitRule
+	expires time.Time
+}
+
+var (
+	ruleCacheMu sync.Mutex
+	ruleCache   = map[string]cachedRule{}
+)
+
+const ruleCacheTTL = time.Minute
+
+func apiKeyRule() RateLimitRule {
+	return RateLimitRule{RequestsPerMinute: config.EnvPositiveInt("RATE_LIMIT_API_KEY_RPM", 600)}
+}
+
+func projectRule(projectID int64) RateLimitRule {
+	return cachedRuleFor(fmt.Sprintf("project:%d", projectID), func() RateLimitRule {
+		rule := RateLimitRule{RequestsPerMinute: config.EnvPositiveInt("RATE_LIMIT_PROJECT_RPM", 1200)}
+		limit, err := projectService.GetRateLimit(projectID)
+		if err != nil {
+			log.Printf("⚠️ Erro ao carregar rate limit do projeto %d: %v", projectID, err)
+			return rule
+		}
+		if limit != nil {
+			rule = RateLimitRule{RequestsPerMinute: limit.RequestsPerMinute, Burst: limit.Burst}
+		}
+		return rule
+	})
+}
+
+func instanceRule(instanceID int64) RateLimitRule {
+	return cachedRuleFor(fmt.Sprintf("instance:%d", instanceID), func() RateLimitRule {
+		rule := RateLimitRule{RequestsPerMinute: config.EnvPositiveInt("RATE_LIMIT_INSTANCE_RPM", 300)}
+		limits, err := services.GetInstanceLimits(instanceID)
+		if err != nil {
+			log.Printf("⚠️ Erro ao carregar limites da instância %d: %v", instanceID, err)
+			return rule
+		}
+		if limits.MaxRequestsPerMinute > 0 {
+			rule.RequestsPerMinute = limits.MaxRequestsPerMinute
+		}
+		return rule
+	})
+}
+
+// ForgetProjectRule descarta a regra em cache do projeto (após alteração)
+func ForgetProjectRule(projectID int64) {
+	ruleCacheMu.Lock()
+	delete(ruleCache, fmt.Sprintf("project:%d", projectID))
+	ruleCacheMu.Unlock()
+}
+
+func cachedRuleFor(key string, load func() RateLimitRule) RateLimitRule {
+	ruleCacheMu.Lock()
+	cached, ok := ruleCache[key]
+	ruleCacheMu.Unlock()
+
+	if ok && time.Now().Before(cached.expires) {
+		return cached.rule
+	}
+
+	rule := load()
+
+	ruleCacheMu.Lock()
+	ruleCache[key] = cachedRule{rule: rule, expires: time.Now().Add(ruleCacheTTL)}
+	ruleCacheMu.Unlock()
+
+	return rule
+}
+
+// ============================================================================
+// INTERNAL HELPERS
+// ============================================================================
+
+// rateLimitTargets extrai project_id e id_instancia da query string ou do corpo
+// JSON. O corpo é lido até RATE_LIMIT_MAX_BODY_BYTES; acima disso a
+// requisição é recusada.
+func rateLimitTargets(w http.ResponseWriter, r *http.Request) (int64, int64, error) {
+	var target struct {
+		ProjectID  int64 `json:"project_id"`
+		InstanceID int64 `json:"id_instancia"`
+	}
+
+	if r.Body != nil && r.Method != http.MethodGet {
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes()))
+		r.Body.Close()
+		if err != nil {
+			return 0, 0, err
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		if len(body) > 0 {
+			_ = json.Unmarshal(body, &target)
+		}
+	}
+
+	if pid := r.URL.Query().Get("project_id"); pid != "" {
+		target.ProjectID, _ = strconv.ParseInt(pid, 10, 64)
+	}
+	if iid := r.URL.Query().Get("id_instancia"); iid != "" {
+		target.InstanceID, _ = strconv.ParseInt(iid, 10, 64)
+	}
+
+	return target.ProjectID, target.InstanceID, nil
+}
+
+func maxBodyBytes() int64 {
+	return int64(config.EnvPositiveInt("RATE_LIMIT_MAX_BODY_BYTES", 10<<20))
+}
+
+func secondsToDuration(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,138 @@
+package security
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"meu-provedor/models"
+)
+
+// testClock é um relógio manual para o store em memória
+type testClock struct{ now time.Time }
+
+func (c *testClock) Now() time.Time          { return c.now }
+func (c *testClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+// newTestStore cria o store em memória no relógio do teste
+func newTestStore(clock *testClock) *MemoryRateLimitStore {
+	store := NewMemoryRateLimitStore()
+	store.now = clock.Now
+	store.lastSweep = clock.now
+	return store
+}
+
+func TestMemoryRateLimitStoreRefill(t *testing.T) {
+	clock := &testClock{now: time.Unix(1700000000, 0)}
+	store := newTestStore(clock)
+
+	// 60/min com burst 2: capacidade 2, um token por segundo
+	bucket := []RateLimitBucket{{Key: "apikey:a", Rule: RateLimitRule{RequestsPerMinute: 60, Burst: 2}}}
+
+	steps := []struct {
+		name       string
+		advance    time.Duration
+		allowed    bool
+		remaining  int
+		retryAfter time.Duration
+		reset      time.Duration
+	}{
+		{"primeira", 0, true, 1, 0, time.Second},
+		{"esgota o burst", 0, true, 0, 0, 2 * time.Second},
+		{"sem token", 0, false, 0, time.Second, 2 * time.Second},
+		{"meio token", 500 * time.Millisecond, false, 0, 500 * time.Millisecond, 1500 * time.Millisecond},
+		{"token reabastecido", 500 * time.Millisecond, true, 0, 0, 2 * time.Second},
+		{"reabastece até a capacidade", time.Minute, true, 1, 0, time.Second},
+	}
+	for _, step := range steps {
+		clock.Advance(step.advance)
+		got := store.Take(bucket)[0]
+		if got.Allowed != step.allowed || got.Remaining != step.remaining || got.Limit != 2 ||
+			got.RetryAfter != step.retryAfter || got.Reset != step.reset {
+			t.Errorf("%s: decisão = %+v", step.name, got)
+		}
+	}
+}
+
+func TestMemoryRateLimitStoreAllOrNothing(t *testing.T) {
+	clock := &testClock{now: time.Unix(1700000000, 0)}
+	store := newTestStore(clock)
+
+	project := RateLimitBucket{Key: "project:1", Rule: RateLimitRule{RequestsPerMinute: 60, Burst: 1}}
+	instance := RateLimitBucket{Key: "instance:7", Rule: RateLimitRule{RequestsPerMinute: 60, Burst: 5}}
+
+	tests := []struct {
+		name      string
+		buckets   []RateLimitBucket
+		allowed   bool
+		remaining []int
+	}{
+		{"os dois com token", []RateLimitBucket{project, instance}, true, []int{0, 4}},
+		// O projeto recusa: a instância não gasta token
+		{"projeto sem token", []RateLimitBucket{project, instance}, false, []int{0, 4}},
+		{"só a instância", []RateLimitBucket{instance}, true, []int{3}},
+	}
+	for _, tt := range tests {
+		decisions := store.Take(tt.buckets)
+		for i, d := range decisions {
+			if d.Allowed != tt.allowed || d.Remaining != tt.remaining[i] {
+				t.Errorf("%s: decisão %d = %+v", tt.name, i, d)
+			}
+		}
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	clock := &testClock{now: time.Unix(1700000000, 0)}
+	previous := rateLimitStore
+	SetRateLimitStore(newTestStore(clock))
+	t.Cleanup(func() { SetRateLimitStore(previous) })
+
+	// 2/min por API key: um token a cada 30s
+	t.Setenv("RATE_LIMIT_API_KEY_RPM", "2")
+
+	calls := 0
+	handler := RateLimit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name       string
+		advance    time.Duration
+		status     int
+		remaining  string
+		reset      string
+		retryAfter string
+	}{
+		{"primeira", 0, http.StatusOK, "1", "30", ""},
+		{"segunda", 0, http.StatusOK, "0", "60", ""},
+		{"excedido", 0, http.StatusTooManyRequests, "0", "60", "30"},
+		{"excedido após 10s", 10 * time.Second, http.StatusTooManyRequests, "0", "50", "20"},
+		{"token reabastecido", 20 * time.Second, http.StatusOK, "0", "60", ""},
+	}
+	for _, tt := range tests {
+		clock.Advance(tt.advance)
+		req := httptest.NewRequest(http.MethodGet, "/data/select", nil)
+		req.Header.Set("X-API-Key", "chave-de-teste")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		h := rec.Header()
+		if rec.Code != tt.status || h.Get("X-RateLimit-Limit") != "2" || h.Get("X-RateLimit-Remaining") != tt.remaining ||
+			h.Get("X-RateLimit-Reset") != tt.reset || h.Get("Retry-After") != tt.retryAfter {
+			t.Errorf("%s: status %d, headers %v", tt.name, rec.Code, h)
+		}
+		if rec.Code == http.StatusTooManyRequests {
+			var body map[string]interface{}
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil || body["code"] != models.ErrRateLimitExceeded.Code {
+				t.Errorf("%s: corpo = %v (%v)", tt.name, body, err)
+			}
+		}
+	}
+	if calls != 3 {
+		t.Errorf("handler chamado %d vezes, esperado 3", calls)
+	}
+}
@@ -1,91 +1,200 @@
-package services
-
-import (
-	"fmt"
-
-	"meu-provedor/config"
-	"meu-provedor/engine/query"
-	"meu-provedor/models"
-)
-
-/*
-====================================================
-EXECUTOR – ADVANCED JOIN SELECT
-====================================================
-*/
-
-func ExecuteAdvancedJoinSelect(req models.AdvancedJoinSelectRequest) ([]map[string]interface{}, error) {
-	// resolve projeto
-	project, err := config.GetProjectByID(int(req.ProjectID)) // retorna *config.Project
-	if err != nil {
-		return nil, fmt.Errorf("falha ao buscar projeto: %w", err)
-	}
-
-	// tabela base com prefixo
-	baseTable := config.BuildTableName(project, req.Base.Table)
-
-	builder := query.NewJoinSelect(baseTable, req.Base.Alias)
-
-	// colunas da tabela base
-	if len(req.Base.Columns) > 0 {
-		builder.AddColumns(req.Base.Columns...)
-	}
-
-	// JOINS
-	for _, j := range req.Joins {
-		joinTable := config.BuildTableName(project, j.Table)
-
-		builder.AddJoin(query.JoinConfig{
-			Type:    j.Type,
-			Table:   joinTable,
-			Alias:   j.Alias,
-			On:      j.On,
-			Columns: j.Columns,
-		})
-
-		if len(j.Columns) > 0 {
-			builder.AddColumns(j.Columns...)
-		}
-	}
-
-	// isolamento por instância (SEMPRE na tabela base)
-	baseAlias := req.Base.Alias
-	if baseAlias == "" {
-		baseAlias = baseTable
-	}
-	builder.AddWhere(fmt.Sprintf("%s.id_instancia = ?", baseAlias), req.InstanceID)
-
-	// WHERE simples
-	for k, v := range req.Where {
-		builder.AddWhere(fmt.Sprintf("%s = ?", k), v)
-	}
-
-	// WHERE RAW
-	for _, raw := range req.WhereRaw {
-		builder.AddRawWhere(raw)
-	}
-
-	// GROUP / HAVING / ORDER
-	builder.GroupBy = req.GroupBy
-	builder.Having = req.Having
-	builder.OrderBy = req.OrderBy
-	builder.Limit = req.Limit
-	builder.Offset = req.Offset
-
-	// build final
-	sqlQuery, args := builder.Build()
-
-	rows, err := config.MasterDB.Query(sqlQuery, args...)
-	if err != nil {
-		return nil, fmt.Errorf("erro ao executar query: %w", err)
-	}
-	defer rows.Close()
-
-	result, err := config.RowsToMap(rows)
-	if err != nil {
-	    return nil, err
-	}
-	return result, nil
-
-}
-
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"meu-provedor/config"
+	"meu-provedor/engine/query"
+	"meu-provedor/models"
+)
+
+/*
+====================================================
+EXECUTOR – ADVANCED JOIN SELECT
+====================================================
+*/
+
+func ExecuteAdvancedJoinSelect(ctx context.Context, req models.AdvancedJoinSelectRequest) ([]map[string]interface{}, error) {
+	return executeAdvancedJoinSelect(ctx, req, false)
+}
+
+// executeAdvancedJoinSelect executa o join-select; com isolateJoins, cada JOIN
+// também exige o mesmo id_instancia da tabela base (consultas nomeadas)
+func executeAdvancedJoinSelect(ctx context.Context, req models.AdvancedJoinSelectRequest, isolateJoins bool) (result []map[string]interface{}, err error) {
+	// timeout e cancelamento da consulta
+	ctx, guard, finish := guardQuery(ctx, req.ProjectID, req.InstanceID)
+	defer func() { err = finish(err) }()
+
+	prepared, err := prepareAdvancedJoinSelect(req, guard, isolateJoins)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err = queryMaps(ctx, prepared.db, prepared.sql, prepared.args...)
+	if err != nil {
+		return nil, wrapDBError(models.ErrQueryFailed, err, prepared.projectCode)
+	}
+	return result, nil
+}
+
+// prepareAdvancedJoinSelect valida a requisição e monta o SQL (usado também pelo EXPLAIN)
+func prepareAdvancedJoinSelect(req models.AdvancedJoinSelectRequest, guard queryGuard, isolateJoins bool) (*preparedQuery, error) {
+	// rejeita SQL livre quando desabilitado
+	if err := guard.checkRawSQL(
+		rawField{"base.columns", len(req.Base.Columns) > 0},
+		rawField{"where_raw", len(req.WhereRaw) > 0},
+		rawField{"group_by", req.GroupBy != ""},
+		rawField{"having", req.Having != ""},
+		rawField{"order_by", req.OrderBy != ""},
+	); err != nil {
+		return nil, err
+	}
+	for _, j := range req.Joins {
+		if err := guard.checkRawSQL(rawField{"joins.on", j.On != ""}, rawField{"joins.columns", len(j.Columns) > 0}); err != nil {
+			return nil, err
+		}
+	}
+
+	// resolve projeto
+	project, err := config.GetProjectByID(int(req.ProjectID)) // retorna *config.Project
+	if err != nil {
+		return nil, fmt.Errorf("falha ao buscar projeto: %w", err)
+	}
+	db, err := readDB(req.ProjectID, req.Consistency)
+	if err != nil {
+		return nil, err
+	}
+
+	// limites da instância
+	limits, err := GetInstanceLimits(req.InstanceID)
+	if err != nil {
+		return nil, err
+	}
+
+	// tabela base com prefixo
+	baseTable, err := BuildTableName(project.Code, req.Base.Table)
+	if err != nil {
+		return nil, err
+	}
+
+	builder := query.NewJoinSelect(baseTable, req.Base.Alias).
+		SetDialect(config.DialectOf(db)).
+		SetMaxExecutionTime(guard.executionMs())
+
+	// escopo de aliases para validar os campos estruturados
+	baseAlias := req.Base.Alias
+	if baseAlias == "" {
+		baseAlias = baseTable
+	}
+	scope := newAliasScope(db)
+	if err := scope.add(baseAlias, baseTable, req.Base.Table); err != nil {
+		return nil, err
+	}
+
+	// colunas da tabela base
+	if len(req.Base.Projection) > 0 {
+		cols, err := scope.projection(withDefaultAlias(req.Base.Projection, baseAlias))
+		if err != nil {
+			return nil, err
+		}
+		builder.AddColumns(cols...)
+	} else if len(req.Base.Columns) > 0 {
+		builder.AddColumns(req.Base.Columns...)
+	}
+
+	// JOINS
+	for _, j := range req.Joins {
+		joinTable, err := BuildTableName(project.Code, j.Table)
+		if err != nil {
+			return nil, err
+		}
+		joinAlias := j.Alias
+		if joinAlias == "" {
+			joinAlias = joinTable
+		}
+		if err := scope.add(joinAlias, joinTable, j.Table); err != nil {
+			return nil, err
+		}
+
+		on := j.On
+		if len(j.Conditions) > 0 {
+			if on, err = scope.joinOn(j.Conditions); err != nil {
+				return nil, err
+			}
+		}
+		if isolateJoins {
+			sameInstance := query.EqualityOn([][2]string{{
+				query.ColumnExpr(joinAlias, "id_instancia"),
+				query.ColumnExpr(baseAlias, "id_instancia"),
+			}})
+			if on == "" {
+				on = sameInstance
+			} else {
+				on = "(" + on + ") AND " + sameInstance
+			}
+		}
+
+		builder.AddJoin(query.JoinConfig{
+			Type:    j.Type,
+			Table:   joinTable,
+			Alias:   joinAlias,
+			On:      on,
+			Columns: j.Columns,
+		})
+
+		if len(j.Projection) > 0 {
+			cols, err := scope.projection(withDefaultAlias(j.Projection, joinAlias))
+			if err != nil {
+				return nil, err
+			}
+			builder.AddColumns(cols...)
+		} else if len(j.Columns) > 0 {
+			builder.AddColumns(j.Columns...)
+		}
+	}
+
+	// isolamento por instância (SEMPRE na tabela base)
+	builder.AddWhereEq(baseAlias+".id_instancia", req.InstanceID)
+
+	// WHERE simples (coluna ou alias.coluna)
+	for k, v := range req.Where {
+		if !isValidColumnRef(k) {
+			return nil, fmt.Errorf("%w: %s", models.ErrInvalidColumn, k)
+		}
+		builder.AddWhereEq(k, v)
+	}
+
+	// WHERE RAW
+	for _, raw := range req.WhereRaw {
+		builder.AddRawWhere(raw)
+	}
+
+	// GROUP / HAVING / ORDER
+	builder.GroupBy = req.GroupBy
+	if len(req.Group) > 0 {
+		if builder.GroupBy, err = scope.group(req.Group); err != nil {
+			return nil, err
+		}
+	}
+	builder.Having = req.Having
+	builder.OrderBy = req.OrderBy
+	if len(req.Sort) > 0 {
+		if builder.OrderBy, err = scope.sort(req.Sort); err != nil {
+			return nil, err
+		}
+	}
+	builder.Limit = guard.capRows(ClampLimit(req.Limit, limits))
+	builder.Offset = req.Offset
+
+	// build final
+	sqlQuery, args := builder.Build()
+
+	return &preparedQuery{
+		db:          db,
+		projectCode: project.Code,
+		sql:         sqlQuery,
+		args:        args,
+		scope:       scope,
+	}, nil
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"meu-provedor/engine/dialect"
+	"meu-provedor/models"
+)
+
+// ============================================================================
+// DB ERRORS - Tradução de erros dos drivers em erros por campo
+// ============================================================================
+//
+// O reconhecimento de cada driver (códigos e mensagens) fica no dialeto; aqui
+// são montados os detalhes e a mensagem para o cliente, iguais em todos os
+// bancos.
+
+// TranslateDBError converte um erro do MySQL, PostgreSQL ou SQLite em
+// AppError com coluna, constraint e valor nos detalhes. Outros erros são
+// devolvidos sem alteração. projectCode é usado para remover o prefixo
+// físico dos nomes de tabela.
+func TranslateDBError(err error, projectCode string) error {
+	dbErr := dialect.TranslateError(err)
+	if dbErr == nil {
+		return err
+	}
+
+	details := map[string]interface{}{dbErr.CodeKey: dbErr.Code}
+	if dbErr.Err == nil {
+		return fmt.Errorf("%w: %v", models.ErrDatabase.WithDetails(models.ErrDatabase.Message, details), err)
+	}
+
+	table := stripProjectPrefix(dbErr.Table, projectCode)
+	referenced := stripProjectPrefix(dbErr.ReferencedTable, projectCode)
+	setDetail(details, "value", dbErr.Value)
+	setDetail(details, "constraint", dbErr.Constraint)
+	setDetail(details, "column", dbErr.Column)
+	setDetail(details, "table", table)
+	setDetail(details, "referenced_table", referenced)
+	setDetail(details, "expected_type", dbErr.ExpectedType)
+	if dbErr.Row > 0 {
+		details["row"] = dbErr.Row
+	}
+
+	message := dbErr.Err.Message
+	switch dbErr.Err {
+	case models.ErrDuplicateKey:
+		key := dbErr.Constraint
+		if key == "" {
+			key = dbErr.Column
+		}
+		switch {
+		case dbErr.Value != "" && key != "":
+			message = fmt.Sprintf("valor '%s' já existe (%s)", dbErr.Value, key)
+		case dbErr.Column != "":
+			message = fmt.Sprintf("valor já existe na coluna '%s'", dbErr.Column)
+		}
+
+	case models.ErrNotNullViolation:
+		if dbErr.Column != "" {
+			message = fmt.Sprintf("coluna '%s' é obrigatória", dbErr.Column)
+		}
+
+	case models.ErrForeignKeyFailed:
+		if dbErr.Column != "" && referenced != "" {
+			message = fmt.Sprintf("coluna '%s' referencia um registro inexistente em '%s'", dbErr.Column, referenced)
+		}
+
+	case models.ErrForeignKeyRestrict:
+		switch {
+		case dbErr.Column != "":
+			message = fmt.Sprintf("registro referenciado pela coluna '%s' (%s)", dbErr.Column, dbErr.Constraint)
+		case table != "":
+			message = fmt.Sprintf("registro referenciado pela tabela '%s' (%s)", table, dbErr.Constraint)
+		}
+
+	case models.ErrValueTooLong:
+		if dbErr.Column != "" {
+			message = fmt.Sprintf("valor excede o tamanho da coluna '%s'", dbErr.Column)
+		}
+
+	case models.ErrInvalidValue:
+		switch {
+		case dbErr.Column != "" && dbErr.Value != "":
+			message = fmt.Sprintf("valor '%s' inválido para a coluna '%s' (%s)", dbErr.Value, dbErr.Column, dbErr.ExpectedType)
+		case dbErr.Value != "":
+			message = fmt.Sprintf("valor '%s' inválido (%s)", dbErr.Value, dbErr.ExpectedType)
+		}
+
+	case models.ErrValueOutOfRange:
+		if dbErr.Column != "" {
+			message = fmt.Sprintf("valor fora do intervalo para a coluna '%s'", dbErr.Column)
+		}
+
+	case models.ErrUnknownColumn:
+		if dbErr.Column != "" {
+			message = fmt.Sprintf("coluna '%s' não existe", dbErr.Column)
+		}
+
+	case models.ErrTableNotFound:
+		if table != "" {
+			message = fmt.Sprintf("tabela '%s' não existe", table)
+		}
+
+	case models.ErrTableExists:
+		if table != "" {
+			message = fmt.Sprintf("tabela '%s' já existe", table)
+		}
+	}
+
+	return dbErr.Err.WithDetails(message, details)
+}
+
+// wrapDBError traduz o erro do driver ou o encapsula no erro da operação
+func wrapDBError(op *models.AppError, err error, projectCode string) error {
+	if translated := TranslateDBError(err, projectCode); translated != err {
+		return translated
+	}
+	return fmt.Errorf("%w: %w", op, err)
+}
+
+// ============================================================================
+// INTERNAL HELPERS
+// ============================================================================
+
+func setDetail(details map[string]interface{}, key, value string) {
+	if value != "" {
+		details[key] = value
+	}
+}
+
+func stripProjectPrefix(table, projectCode string) string {
+	if projectCode == "" {
+		return table
+	}
+	return strings.TrimPrefix(table, projectCode+"_")
+}
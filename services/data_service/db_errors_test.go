@@ -0,0 +1,287 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+
+	"meu-provedor/models"
+)
+
+func TestTranslateDBError(t *testing.T) {
+	tests := []struct {
+		name    string
+		number  uint16
+		message string
+		want    *models.AppError
+		details map[string]interface{}
+	}{
+		{
+			"duplicado (MySQL 8, índice qualificado)", 1062,
+			"Duplicate entry 'a@b.com' for key 'ab_clientes.uq_email'",
+			models.ErrDuplicateKey,
+			map[string]interface{}{"mysql_code": uint16(1062), "value": "a@b.com", "constraint": "uq_email"},
+		},
+		{
+			"duplicado (MySQL 5.7)", 1062,
+			"Duplicate entry '7-x' for key 'uq_pedido_item'",
+			models.ErrDuplicateKey,
+			map[string]interface{}{"mysql_code": uint16(1062), "value": "7-x", "constraint": "uq_pedido_item"},
+		},
+		{
+			"not null", 1048,
+			"Column 'nome' cannot be null",
+			models.ErrNotNullViolation,
+			map[string]interface{}{"mysql_code": uint16(1048), "column": "nome"},
+		},
+		{
+			"fk inexistente", 1452,
+			"Cannot add or update a child row: a foreign key constraint fails (`db`.`ab_itens`, CONSTRAINT `fk_pedido` FOREIGN KEY (`pedido_id`) REFERENCES `ab_pedidos` (`id`))",
+			models.ErrForeignKeyFailed,
+			map[string]interface{}{"mysql_code": uint16(1452), "constraint": "fk_pedido", "column": "pedido_id", "referenced_table": "pedidos"},
+		},
+		{
+			"fk restrita", 1451,
+			"Cannot delete or update a parent row: a foreign key constraint fails (`db`.`ab_itens`, CONSTRAINT `fk_pedido` FOREIGN KEY (`pedido_id`) REFERENCES `ab_pedidos` (`id`))",
+			models.ErrForeignKeyRestrict,
+			map[string]interface{}{"mysql_code": uint16(1451), "constraint": "fk_pedido", "column": "pedido_id", "referenced_table": "pedidos"},
+		},
+		{
+			"texto longo", 1406,
+			"Data too long for column 'nome' at row 3",
+			models.ErrValueTooLong,
+			map[string]interface{}{"mysql_code": uint16(1406), "column": "nome", "row": 3},
+		},
+		{
+			"valor incorreto", 1366,
+			"Incorrect integer value: 'abc' for column `db`.`ab_itens`.`qtd` at row 1",
+			models.ErrInvalidValue,
+			map[string]interface{}{"mysql_code": uint16(1366), "expected_type": "integer", "value": "abc", "column": "qtd", "row": 1},
+		},
+		{
+			"valor incorreto (MySQL 5.7)", 1366,
+			"Incorrect decimal value: 'x' for column 'preco' at row 2",
+			models.ErrInvalidValue,
+			map[string]interface{}{"mysql_code": uint16(1366), "expected_type": "decimal", "value": "x", "column": "preco", "row": 2},
+		},
+		{
+			"fora do intervalo", 1264,
+			"Out of range value for column 'idade' at row 1",
+			models.ErrValueOutOfRange,
+			map[string]interface{}{"mysql_code": uint16(1264), "column": "idade", "row": 1},
+		},
+		{
+			"coluna desconhecida", 1054,
+			"Unknown column 'ab_itens.cor' in 'where clause'",
+			models.ErrUnknownColumn,
+			map[string]interface{}{"mysql_code": uint16(1054), "column": "cor"},
+		},
+		{
+			"tabela inexistente", 1146,
+			"Table 'db.ab_pedidos' doesn't exist",
+			models.ErrTableNotFound,
+			map[string]interface{}{"mysql_code": uint16(1146), "table": "pedidos"},
+		},
+		{
+			"tabela existente", 1050,
+			"Table 'ab_pedidos' already exists",
+			models.ErrTableExists,
+			map[string]interface{}{"mysql_code": uint16(1050), "table": "pedidos"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := TranslateDBError(&mysql.MySQLError{Number: tt.number, Message: tt.message}, "ab")
+			assertTranslated(t, err, tt.want, tt.details)
+		})
+	}
+}
+
+func TestTranslateDBErrorPostgres(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     *pq.Error
+		want    *models.AppError
+		details map[string]interface{}
+	}{
+		{
+			"duplicado",
+			&pq.Error{Code: "23505", Table: "ab_clientes", Constraint: "uq_email",
+				Detail: "Key (email)=(a@b.com) already exists."},
+			models.ErrDuplicateKey,
+			map[string]interface{}{"pg_code": "23505", "value": "a@b.com", "constraint": "uq_email", "column": "email", "table": "clientes"},
+		},
+		{
+			"duplicado composto",
+			&pq.Error{Code: "23505", Table: "ab_itens", Constraint: "uq_pedido_item",
+				Detail: "Key (pedido_id, sku)=(7, x) already exists."},
+			models.ErrDuplicateKey,
+			map[string]interface{}{"pg_code": "23505", "value": "7, x", "constraint": "uq_pedido_item", "table": "itens"},
+		},
+		{
+			"not null",
+			&pq.Error{Code: "23502", Table: "ab_clientes", Column: "nome",
+				Message: `null value in column "nome" of relation "ab_clientes" violates not-null constraint`},
+			models.ErrNotNullViolation,
+			map[string]interface{}{"pg_code": "23502", "column": "nome", "table": "clientes"},
+		},
+		{
+			"fk inexistente",
+			&pq.Error{Code: "23503", Table: "ab_itens", Constraint: "fk_pedido",
+				Detail: `Key (pedido_id)=(9) is not present in table "ab_pedidos".`},
+			models.ErrForeignKeyFailed,
+			map[string]interface{}{"pg_code": "23503", "value": "9", "constraint": "fk_pedido", "column": "pedido_id",
+				"table": "itens", "referenced_table": "pedidos"},
+		},
+		{
+			"fk restrita",
+			&pq.Error{Code: "23503", Table: "ab_pedidos", Constraint: "fk_pedido",
+				Detail: `Key (id)=(9) is still referenced from table "ab_itens".`},
+			models.ErrForeignKeyRestrict,
+			map[string]interface{}{"pg_code": "23503", "constraint": "fk_pedido", "table": "itens"},
+		},
+		{
+			"valor inválido",
+			&pq.Error{Code: "22P02", Message: `invalid input syntax for type integer: "abc"`},
+			models.ErrInvalidValue,
+			map[string]interface{}{"pg_code": "22P02", "expected_type": "integer", "value": "abc"},
+		},
+		{
+			"tabela inexistente",
+			&pq.Error{Code: "42P01", Message: `relation "ab_pedidos" does not exist`},
+			models.ErrTableNotFound,
+			map[string]interface{}{"pg_code": "42P01", "table": "pedidos"},
+		},
+		{
+			"statement timeout",
+			&pq.Error{Code: "57014", Message: "canceling statement due to statement timeout"},
+			models.ErrQueryTimeout,
+			map[string]interface{}{"pg_code": "57014"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertTranslated(t, TranslateDBError(tt.err, "ab"), tt.want, tt.details)
+		})
+	}
+}
+
+// TestTranslateDBErrorSQLite usa os erros reais do driver (códigos
+// estendidos e mensagens do modernc.org/sqlite)
+func TestTranslateDBErrorSQLite(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	defer db.Close()
+
+	for _, stmt := range []string{
+		`PRAGMA foreign_keys = ON`,
+		`CREATE TABLE ab_pedidos (id INTEGER PRIMARY KEY)`,
+		`CREATE TABLE ab_clientes (id INTEGER PRIMARY KEY, email TEXT UNIQUE, nome TEXT NOT NULL, idade INTEGER CHECK (idade >= 0))`,
+		`CREATE TABLE ab_itens (id INTEGER PRIMARY KEY, pedido_id INTEGER REFERENCES ab_pedidos (id))`,
+		`INSERT INTO ab_pedidos (id) VALUES (1)`,
+		`INSERT INTO ab_clientes (id, email, nome) VALUES (1, 'a@b.com', 'a')`,
+		`INSERT INTO ab_itens (id, pedido_id) VALUES (1, 1)`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		stmt    string
+		want    *models.AppError
+		details map[string]interface{}
+	}{
+		{
+			"duplicado",
+			`INSERT INTO ab_clientes (email, nome) VALUES ('a@b.com', 'b')`,
+			models.ErrDuplicateKey,
+			map[string]interface{}{"sqlite_code": 2067, "column": "email", "table": "clientes"},
+		},
+		{
+			"chave primária duplicada",
+			`INSERT INTO ab_clientes (id, nome) VALUES (1, 'b')`,
+			models.ErrDuplicateKey,
+			map[string]interface{}{"sqlite_code": 1555, "column": "id", "table": "clientes"},
+		},
+		{
+			"not null",
+			`INSERT INTO ab_clientes (email) VALUES ('c@d.com')`,
+			models.ErrNotNullViolation,
+			map[string]interface{}{"sqlite_code": 1299, "column": "nome", "table": "clientes"},
+		},
+		{
+			"check",
+			`INSERT INTO ab_clientes (nome, idade) VALUES ('b', -1)`,
+			models.ErrInvalidValue,
+			map[string]interface{}{"sqlite_code": 275},
+		},
+		{
+			"fk inexistente",
+			`INSERT INTO ab_itens (pedido_id) VALUES (9)`,
+			models.ErrForeignKeyFailed,
+			map[string]interface{}{"sqlite_code": 787},
+		},
+		{
+			"coluna desconhecida",
+			`SELECT cor FROM ab_itens`,
+			models.ErrUnknownColumn,
+			map[string]interface{}{"sqlite_code": 1, "column": "cor"},
+		},
+		{
+			"coluna desconhecida no insert",
+			`INSERT INTO ab_itens (cor) VALUES (1)`,
+			models.ErrUnknownColumn,
+			map[string]interface{}{"sqlite_code": 1, "column": "cor"},
+		},
+		{
+			"tabela inexistente",
+			`SELECT * FROM ab_nada`,
+			models.ErrTableNotFound,
+			map[string]interface{}{"sqlite_code": 1, "table": "nada"},
+		},
+		{
+			"tabela existente",
+			`CREATE TABLE ab_pedidos (id INTEGER)`,
+			models.ErrTableExists,
+			map[string]interface{}{"sqlite_code": 1, "table": "pedidos"},
+		},
+		{
+			"sintaxe",
+			`SELEC 1`,
+			models.ErrSQLSyntax,
+			map[string]interface{}{"sqlite_code": 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := db.Exec(tt.stmt)
+			if err == nil {
+				t.Fatalf("%s: esperado erro", tt.stmt)
+			}
+			assertTranslated(t, TranslateDBError(err, "ab"), tt.want, tt.details)
+		})
+	}
+}
+
+func assertTranslated(t *testing.T, err error, want *models.AppError, details map[string]interface{}) {
+	t.Helper()
+	if !errors.Is(err, want) {
+		t.Fatalf("erro = %v, want %s", err, want.Code)
+	}
+	var appErr *models.AppError
+	if !errors.As(err, &appErr) {
+		t.Fatalf("erro não é AppError: %v", err)
+	}
+	if !reflect.DeepEqual(appErr.Details, details) {
+		t.Errorf("details = %#v, want %#v", appErr.Details, details)
+	}
+}
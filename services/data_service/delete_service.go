@@ -1,126 +1,195 @@
-package services
-
-import (
-	"fmt"
-	"time"
-	"meu-provedor/config"
-	"meu-provedor/engine/query"
-	"meu-provedor/models"
-)
-
-// ============================================================================
-// DELETE SERVICE
-// ============================================================================
-
-// ExecuteHardDelete executa um DELETE físico (remove do banco)
-func ExecuteHardDelete(req models.DeleteRequest) (int64, error) {
-	// Validar requisição
-	if err := req.Validate(); err != nil {
-		return 0, err
-	}
-
-	// Obter código do projeto
-	projectCode, err := GetProjectCodeByID(req.ProjectID)
-	if err != nil {
-		return 0, err
-	}
-
-	// Construir nome da tabela
-	table, err := BuildTableName(projectCode, req.Table)
-	if err != nil {
-		return 0, err
-	}
-
-	// Criar DeleteBuilder
-	builder := query.NewDelete(table)
-
-	// Filtro obrigatório: id_instancia
-	builder.Where("id_instancia = ?", req.InstanceID)
-
-	// Adicionar filtros simples
-	for col, val := range req.Where {
-		if !query.IsValidColumnName(col) {
-			return 0, fmt.Errorf("%w: %s", models.ErrInvalidColumn, col)
-		}
-		builder.Where(col+" = ?", val)
-	}
-
-	// Filtro raw opcional
-	if req.WhereRaw != "" {
-		builder.WhereRaw(req.WhereRaw)
-	}
-
-	// Executar DELETE
-	sqlQuery, args := builder.Build()
-	result, err := config.MasterDB.Exec(sqlQuery, args...)
-	if err != nil {
-		return 0, fmt.Errorf("%w: %v", models.ErrDeleteFailed, err)
-	}
-
-	// Retornar quantidade de linhas afetadas
-	count, err := result.RowsAffected()
-	if err != nil {
-		return 0, err
-	}
-
-	return count, nil
-}
-
-// ExecuteSoftDelete executa um soft delete (marca como deletado)
-func ExecuteSoftDelete(req models.DeleteRequest) (int64, error) {
-	// Validar requisição
-	if err := req.Validate(); err != nil {
-		return 0, err
-	}
-
-	// Obter código do projeto
-	projectCode, err := GetProjectCodeByID(req.ProjectID)
-	if err != nil {
-		return 0, err
-	}
-
-	// Construir nome da tabela
-	table, err := BuildTableName(projectCode, req.Table)
-	if err != nil {
-		return 0, err
-	}
-
-	// Garantir que a coluna deleted_at existe
-	if err := EnsureSoftDeleteColumn(config.MasterDB, table); err != nil {
-		return 0, err
-	}
-
-	// Criar SoftDeleteBuilder
-	builder := query.NewSoftDelete(table)
-
-	// Filtro obrigatório: id_instancia
-	builder.AddWhere("id_instancia = ?", req.InstanceID)
-
-	// Adicionar filtros simples
-	for col, val := range req.Where {
-		if !query.IsValidColumnName(col) {
-			return 0, fmt.Errorf("%w: %s", models.ErrInvalidColumn, col)
-		}
-		builder.AddWhere(col+" = ?", val)
-	}
-
-	// Filtro raw opcional
-	if req.WhereRaw != "" {
-		builder.AddRawWhere(req.WhereRaw)
-	}
-
-	// Executar soft delete
-	sqlQuery, args := builder.Build(time.Now())
-	result, err := config.MasterDB.Exec(sqlQuery, args...)
-	if err != nil {
-		return 0, fmt.Errorf("%w: %v", models.ErrDeleteFailed, err)
-	}
-
-	// Retornar quantidade de linhas afetadas
-	count, err := result.RowsAffected()
-	if err != nil {
-		return 0, err
-	}
-
-	return count, nil
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"meu-provedor/engine/query"
+	"meu-provedor/models"
+)
+
+// ============================================================================
+// DELETE SERVICE
+// ============================================================================
+
+// ExecuteHardDelete executa um DELETE físico (remove do banco)
+func ExecuteHardDelete(ctx context.Context, req models.DeleteRequest) (affected int64, err error) {
+	// Validar requisição
+	if err := req.Validate(); err != nil {
+		return 0, err
+	}
+
+	// Timeout e cancelamento da escrita
+	ctx, guard, finish := guardQuery(ctx, req.ProjectID, req.InstanceID)
+	defer func() { err = finish(err) }()
+
+	// Rejeitar SQL livre quando não permitido
+	if err := guard.checkRawSQL(rawField{"where_raw", req.WhereRaw != ""}); err != nil {
+		return 0, err
+	}
+
+	// Obter código do projeto
+	projectCode, err := GetProjectCodeByID(req.ProjectID)
+	if err != nil {
+		return 0, err
+	}
+	db, done, err := writeDB(req.ProjectID)
+	if err != nil {
+		return 0, err
+	}
+	defer done()
+
+	// Construir nome da tabela
+	table, err := BuildTableName(projectCode, req.Table)
+	if err != nil {
+		return 0, err
+	}
+
+	// Criar DeleteBuilder
+	builder := query.NewDelete(table)
+
+	// Filtro obrigatório: id_instancia
+	builder.WhereEq("id_instancia", req.InstanceID)
+
+	// Adicionar filtros simples
+	for col, val := range req.Where {
+		if !query.IsValidColumnName(col) {
+			return 0, fmt.Errorf("%w: %s", models.ErrInvalidColumn, col)
+		}
+		builder.WhereEq(col, val)
+	}
+
+	// Filtro raw opcional
+	if req.WhereRaw != "" {
+		builder.WhereRaw(req.WhereRaw)
+	}
+
+	// Histórico: travar e capturar as linhas antes do DELETE
+	hist, err := beginHistory(ctx, db, req.ProjectID, projectCode, req.Table, table, req.InstanceID, req.Actor)
+	if err != nil {
+		return 0, err
+	}
+	defer hist.rollback()
+
+	before, err := hist.snapshot(builder.WhereClauses, builder.WhereValues)
+	if err != nil {
+		return 0, err
+	}
+
+	// Executar DELETE
+	sqlQuery, args := builder.Build()
+	result, err := hist.exec(sqlQuery, args...)
+	if err != nil {
+		return 0, wrapDBError(models.ErrDeleteFailed, err, projectCode)
+	}
+
+	// Retornar quantidade de linhas afetadas
+	count, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	if err := hist.recordChanges(models.HistoryDelete, before); err != nil {
+		return 0, err
+	}
+	if err := hist.commitChange(models.HistoryDelete, snapshotIDs(before), count, req.Where); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// ExecuteSoftDelete executa um soft delete (marca como deletado)
+func ExecuteSoftDelete(ctx context.Context, req models.DeleteRequest) (affected int64, err error) {
+	// Validar requisição
+	if err := req.Validate(); err != nil {
+		return 0, err
+	}
+
+	// Timeout e cancelamento da escrita
+	ctx, guard, finish := guardQuery(ctx, req.ProjectID, req.InstanceID)
+	defer func() { err = finish(err) }()
+
+	// Rejeitar SQL livre quando não permitido
+	if err := guard.checkRawSQL(rawField{"where_raw", req.WhereRaw != ""}); err != nil {
+		return 0, err
+	}
+
+	// Obter código do projeto
+	projectCode, err := GetProjectCodeByID(req.ProjectID)
+	if err != nil {
+		return 0, err
+	}
+	db, done, err := writeDB(req.ProjectID)
+	if err != nil {
+		return 0, err
+	}
+	defer done()
+
+	// Construir nome da tabela
+	table, err := BuildTableName(projectCode, req.Table)
+	if err != nil {
+		return 0, err
+	}
+
+	// Garantir que a coluna deleted_at existe (nunca em views)
+	if err := ensureWritable(req.Table, table); err != nil {
+		return 0, err
+	}
+	if err := EnsureSoftDeleteColumn(db, table); err != nil {
+		return 0, err
+	}
+
+	// Criar SoftDeleteBuilder
+	builder := query.NewSoftDelete(table)
+
+	// Filtro obrigatório: id_instancia
+	builder.AddWhereEq("id_instancia", req.InstanceID)
+
+	// Adicionar filtros simples
+	for col, val := range req.Where {
+		if !query.IsValidColumnName(col) {
+			return 0, fmt.Errorf("%w: %s", models.ErrInvalidColumn, col)
+		}
+		builder.AddWhereEq(col, val)
+	}
+
+	// Filtro raw opcional
+	if req.WhereRaw != "" {
+		builder.AddRawWhere(req.WhereRaw)
+	}
+
+	// Histórico: travar e capturar as linhas antes do soft delete
+	hist, err := beginHistory(ctx, db, req.ProjectID, projectCode, req.Table, table, req.InstanceID, req.Actor)
+	if err != nil {
+		return 0, err
+	}
+	defer hist.rollback()
+
+	before, err := hist.snapshot(append(append([]string{}, builder.Where...), builder.RawWhere...), builder.Values)
+	if err != nil {
+		return 0, err
+	}
+
+	// Executar soft delete
+	sqlQuery, args := builder.Build(time.Now())
+	result, err := hist.exec(sqlQuery, args...)
+	if err != nil {
+		return 0, wrapDBError(models.ErrDeleteFailed, err, projectCode)
+	}
+
+	// Retornar quantidade de linhas afetadas
+	count, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	if err := hist.recordChanges(models.HistorySoftDelete, before); err != nil {
+		return 0, err
+	}
+	if err := hist.commitChange(models.HistorySoftDelete, snapshotIDs(before), count, req.Where); err != nil {
+		return 0, err
+	}
+
+	return count, nil
 }
\ No newline at end of file
@@ -0,0 +1,19 @@
+package services
+
+// ============================================================================
+// CHANGE EVENTS - Emissão de eventos após o commit das escritas
+// ============================================================================
+//
+// O evento é montado e publicado por historyWriter.commitChange; com webhooks
+// ativos ele também é gravado na transação da escrita (ver services/webhook).
+
+// snapshotIDs extrai os ids das linhas capturadas pelo histórico
+func snapshotIDs(rows []map[string]interface{}) []int64 {
+	var ids []int64
+	for _, row := range rows {
+		if id, ok := toInt64(row["id"]); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,201 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+
+	"meu-provedor/config"
+	"meu-provedor/engine/dialect"
+	"meu-provedor/models"
+)
+
+// ============================================================================
+// EXPLAIN SERVICE - Plano de execução e sugestão de índices
+// ============================================================================
+
+// preparedQuery é um SELECT validado e montado, pronto para executar ou explicar
+type preparedQuery struct {
+	db          *sql.DB
+	projectCode string
+	sql         string
+	args        []interface{}
+	scope       *aliasScope
+}
+
+// conditionColumn reconhece `alias`.`coluna` (com schema opcional) nas
+// condições que o MySQL anexa ao plano
+var conditionColumn = regexp.MustCompile("(?:`[^`]+`\\.)?`([^`]+)`\\.`([^`]+)`")
+
+// ExplainAdvancedSelect retorna o EXPLAIN do SQL gerado para /data/select
+func ExplainAdvancedSelect(ctx context.Context, req models.AdvancedSelectRequest) (result *models.ExplainResult, err error) {
+	ctx, guard, finish := guardQuery(ctx, req.ProjectID, req.InstanceID)
+	defer func() { err = finish(err) }()
+
+	prepared, err := prepareAdvancedSelect(req, guard)
+	if err != nil {
+		return nil, err
+	}
+	return explainPrepared(ctx, prepared)
+}
+
+// ExplainAdvancedJoinSelect retorna o EXPLAIN do SQL gerado para /data/join-select
+func ExplainAdvancedJoinSelect(ctx context.Context, req models.AdvancedJoinSelectRequest) (result *models.ExplainResult, err error) {
+	ctx, guard, finish := guardQuery(ctx, req.ProjectID, req.InstanceID)
+	defer func() { err = finish(err) }()
+
+	prepared, err := prepareAdvancedJoinSelect(req, guard, false)
+	if err != nil {
+		return nil, err
+	}
+	return explainPrepared(ctx, prepared)
+}
+
+// ============================================================================
+// INTERNAL HELPERS
+// ============================================================================
+
+// explainPrepared roda EXPLAIN FORMAT=JSON com os mesmos argumentos da consulta
+func explainPrepared(ctx context.Context, prepared *preparedQuery) (*models.ExplainResult, error) {
+	if config.DialectOf(prepared.db) != dialect.MySQL {
+		return nil, models.ErrExplainUnsupported
+	}
+
+	var raw string
+	if err := prepared.db.QueryRowContext(ctx, "EXPLAIN FORMAT=JSON "+prepared.sql, prepared.args...).Scan(&raw); err != nil {
+		return nil, wrapDBError(models.ErrQueryFailed, err, prepared.projectCode)
+	}
+
+	var plan map[string]interface{}
+	if err := json.Unmarshal([]byte(raw), &plan); err != nil {
+		return nil, fmt.Errorf("%w: plano inválido: %v", models.ErrQueryFailed, err)
+	}
+
+	suggestions, err := suggestIndexes(prepared, plan)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.ExplainResult{
+		SQL:         prepared.sql,
+		Plan:        plan,
+		Suggestions: suggestions,
+	}, nil
+}
+
+// suggestIndexes procura full table scans com condição anexada e sugere um
+// índice (id_instancia, coluna) para cada coluna filtrada ainda sem índice
+func suggestIndexes(prepared *preparedQuery, plan map[string]interface{}) ([]models.IndexSuggestion, error) {
+	suggestions := []models.IndexSuggestion{}
+	seen := map[string]bool{}
+
+	for _, node := range planTables(plan) {
+		if node["access_type"] != "ALL" {
+			continue
+		}
+		condition, _ := node["attached_condition"].(string)
+		alias, _ := node["table_name"].(string)
+		physical, ok := prepared.scope.physical[alias]
+		if condition == "" || !ok {
+			continue
+		}
+
+		indexes, err := config.DialectOf(prepared.db).Indexes(prepared.db, physical)
+		if err != nil {
+			return nil, err
+		}
+
+		rows, _ := node["rows_examined_per_scan"].(float64)
+		for _, column := range filteredColumns(condition, alias) {
+			columns := []string{"id_instancia"}
+			if column != "id_instancia" {
+				columns = append(columns, column)
+			}
+
+			key := physical + "|" + fmt.Sprint(columns)
+			if seen[key] || indexCovers(indexes, columns) {
+				continue
+			}
+			seen[key] = true
+
+			suggestions = append(suggestions, models.IndexSuggestion{
+				Table:        logicalName(prepared.scope, alias),
+				Columns:      columns,
+				RowsExamined: int64(rows),
+				Reason:       fmt.Sprintf("full table scan em '%s' filtrando por '%s'", alias, column),
+			})
+		}
+	}
+	return suggestions, nil
+}
+
+// planTables percorre o plano JSON e coleta todos os nós "table"
+func planTables(node interface{}) []map[string]interface{} {
+	var out []map[string]interface{}
+	switch v := node.(type) {
+	case map[string]interface{}:
+		if table, ok := v["table"].(map[string]interface{}); ok {
+			out = append(out, table)
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			out = append(out, planTables(v[key])...)
+		}
+	case []interface{}:
+		for _, item := range v {
+			out = append(out, planTables(item)...)
+		}
+	}
+	return out
+}
+
+// filteredColumns extrai as colunas do alias citadas na condição anexada
+func filteredColumns(condition, alias string) []string {
+	var columns []string
+	seen := map[string]bool{}
+	for _, match := range conditionColumn.FindAllStringSubmatch(condition, -1) {
+		if match[1] != alias || seen[match[2]] {
+			continue
+		}
+		seen[match[2]] = true
+		columns = append(columns, match[2])
+	}
+	return columns
+}
+
+// indexCovers indica se algum índice existente começa pelas colunas informadas
+func indexCovers(indexes []models.IndexDetail, columns []string) bool {
+	for _, index := range indexes {
+		if len(index.Columns) < len(columns) {
+			continue
+		}
+		covered := true
+		for i, column := range columns {
+			if index.Columns[i] != column {
+				covered = false
+				break
+			}
+		}
+		if covered {
+			return true
+		}
+	}
+	return false
+}
+
+// logicalName devolve o nome lógico da tabela de um alias (ou o próprio alias)
+func logicalName(scope *aliasScope, alias string) string {
+	for logical, a := range scope.logical {
+		if a == alias {
+			return logical
+		}
+	}
+	return alias
+}
@@ -1,107 +1,176 @@
-package services
-
-import (
-	"database/sql"
-	"fmt"
-	"meu-provedor/config"
-	"meu-provedor/models"
-)
-
-// ============================================================================
-// HELPER FUNCTIONS - Funções compartilhadas entre services
-// ============================================================================
-
-// GetProjectCodeByID busca o código do projeto pelo ID
-func GetProjectCodeByID(projectID int64) (string, error) {
-	var code string
-	query := "SELECT code FROM projects WHERE id = ? LIMIT 1"
-	
-	err := config.MasterDB.QueryRow(query, projectID).Scan(&code)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return "", models.ErrProjectNotFound
-		}
-		return "", fmt.Errorf("erro ao buscar projeto: %w", err)
-	}
-	
-	if code == "" {
-		return "", models.ErrProjectNotFound
-	}
-	
-	return code, nil
-}
-
-// BuildTableName constrói o nome físico da tabela com prefixo do projeto
-func BuildTableName(projectCode, table string) (string, error) {
-	if table == "" {
-		return "", models.ErrTableRequired
-	}
-	return fmt.Sprintf("%s_%s", projectCode, table), nil
-}
-
-// RowsToMap converte sql.Rows para []map[string]interface{}
-func RowsToMap(rows *sql.Rows) ([]map[string]interface{}, error) {
-	cols, err := rows.Columns()
-	if err != nil {
-		return nil, err
-	}
-	
-	var result []map[string]interface{}
-	for rows.Next() {
-		values := make([]interface{}, len(cols))
-		ptrs := make([]interface{}, len(cols))
-		
-		for i := range values {
-			ptrs[i] = &values[i]
-		}
-		
-		if err := rows.Scan(ptrs...); err != nil {
-			return nil, err
-		}
-		
-		row := make(map[string]interface{})
-		for i, col := range cols {
-			val := values[i]
-			
-			if b, ok := val.([]byte); ok {
-				row[col] = string(b)
-			} else {
-				row[col] = val
-			}
-		}
-		
-		result = append(result, row)
-	}
-	
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	
-	return result, nil
-}
-
-// EnsureSoftDeleteColumn garante que a coluna deleted_at existe na tabela
-func EnsureSoftDeleteColumn(db *sql.DB, table string) error {
-	var exists int
-	queryCheck := `
-		SELECT COUNT(*)
-		FROM information_schema.COLUMNS
-		WHERE TABLE_SCHEMA = DATABASE()
-		  AND TABLE_NAME = ?
-		  AND COLUMN_NAME = 'deleted_at'
-	`
-	
-	if err := db.QueryRow(queryCheck, table).Scan(&exists); err != nil {
-		return fmt.Errorf("erro ao verificar coluna deleted_at: %w", err)
-	}
-	
-	if exists == 0 {
-		alter := fmt.Sprintf("ALTER TABLE %s ADD COLUMN deleted_at DATETIME NULL", table)
-		if _, err := db.Exec(alter); err != nil {
-			return fmt.Errorf("erro ao criar coluna deleted_at: %w", err)
-		}
-	}
-	
-	return nil
-}
-
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"meu-provedor/config"
+	"meu-provedor/models"
+	tableService "meu-provedor/services/table"
+)
+
+// ============================================================================
+// HELPER FUNCTIONS - Funções compartilhadas entre services
+// ============================================================================
+
+// GetProjectCodeByID busca o código do projeto pelo ID
+func GetProjectCodeByID(projectID int64) (string, error) {
+	var code string
+	query := "SELECT code FROM projects WHERE id = ? LIMIT 1"
+	
+	err := config.MasterDB.QueryRow(query, projectID).Scan(&code)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", models.ErrProjectNotFound
+		}
+		return "", fmt.Errorf("erro ao buscar projeto: %w", err)
+	}
+	
+	if code == "" {
+		return "", models.ErrProjectNotFound
+	}
+	
+	return code, nil
+}
+
+// projectDB retorna o banco onde ficam as tabelas do projeto
+// (master ou banco dedicado, conforme o connection manager)
+func projectDB(projectID int64) (*sql.DB, error) {
+	db, err := config.ProjectDB(projectID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", models.ErrDatabaseConnection, err)
+	}
+	return db, nil
+}
+
+// writeDB retorna o banco do projeto para uma escrita, respeitando o
+// congelamento durante migrações. done deve ser chamada ao fim da escrita.
+func writeDB(projectID int64) (*sql.DB, func(), error) {
+	done, err := config.BeginProjectWrite(projectID)
+	if err != nil {
+		return nil, nil, err
+	}
+	db, err := projectDB(projectID)
+	if err != nil {
+		done()
+		return nil, nil, err
+	}
+	return db, done, nil
+}
+
+// readDB retorna o banco para leituras: uma réplica saudável do master
+// ou, com consistency "strong", o banco primário do projeto
+func readDB(projectID int64, consistency string) (*sql.DB, error) {
+	db, err := config.ReadDB(projectID, consistency)
+	if errors.Is(err, models.ErrInvalidConsistency) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", models.ErrDatabaseConnection, err)
+	}
+	return db, nil
+}
+
+// BuildTableName constrói o nome físico da tabela com prefixo do projeto.
+// Nomes iniciados por "_" são tabelas internas (ex.: {code}__history) e não
+// são acessíveis pelos serviços de dados.
+func BuildTableName(projectCode, table string) (string, error) {
+	if table == "" {
+		return "", models.ErrTableRequired
+	}
+	if strings.HasPrefix(table, "_") {
+		return "", fmt.Errorf("%w: nomes iniciados por '_' são reservados", models.ErrInvalidTableName)
+	}
+	return fmt.Sprintf("%s_%s", projectCode, table), nil
+}
+
+// ensureWritable rejeita escritas em views e resumos materializados do projeto
+func ensureWritable(table, fullTable string) error {
+	isView, err := tableService.IsView(fullTable)
+	if err != nil {
+		return fmt.Errorf("erro ao verificar tabela %s: %w", fullTable, err)
+	}
+	if isView {
+		return fmt.Errorf("%w: %s", models.ErrViewReadOnly, table)
+	}
+	if strings.HasPrefix(table, models.SummaryPrefix) {
+		isSummary, err := tableService.IsSummary(fullTable)
+		if err != nil {
+			return fmt.Errorf("erro ao verificar tabela %s: %w", fullTable, err)
+		}
+		if isSummary {
+			return fmt.Errorf("%w: %s", models.ErrSummaryReadOnly, table)
+		}
+	}
+	return nil
+}
+
+// RowsToMap converte sql.Rows para []map[string]interface{}
+func RowsToMap(rows *sql.Rows) ([]map[string]interface{}, error) {
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	
+	var result []map[string]interface{}
+	for rows.Next() {
+		values := make([]interface{}, len(cols))
+		ptrs := make([]interface{}, len(cols))
+		
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		
+		row := make(map[string]interface{})
+		for i, col := range cols {
+			val := values[i]
+			
+			if b, ok := val.([]byte); ok {
+				row[col] = string(b)
+			} else {
+				row[col] = val
+			}
+		}
+		
+		result = append(result, row)
+	}
+	
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	
+	return result, nil
+}
+
+// EnsureSoftDeleteColumn garante que a coluna deleted_at existe na tabela (no banco do projeto)
+func EnsureSoftDeleteColumn(db *sql.DB, table string) error {
+	d := config.DialectOf(db)
+	columns, err := d.Columns(db, table)
+	if err != nil {
+		return fmt.Errorf("erro ao verificar coluna deleted_at: %w", err)
+	}
+	
+	exists := false
+	for _, col := range columns {
+		if col.Name == "deleted_at" {
+			exists = true
+			break
+		}
+	}
+	
+	if !exists {
+		alter := fmt.Sprintf("ALTER TABLE %s ADD COLUMN deleted_at %s NULL", table, d.DateTimeType())
+		if _, err := db.Exec(alter); err != nil {
+			return fmt.Errorf("erro ao criar coluna deleted_at: %w", err)
+		}
+		tableService.InvalidateColumns(table)
+	}
+	
+	return nil
+}
+
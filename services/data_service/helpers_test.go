@@ -0,0 +1,40 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"meu-provedor/models"
+)
+
+func TestBuildTableName(t *testing.T) {
+	tests := []struct {
+		name    string
+		table   string
+		want    string
+		wantErr error
+	}{
+		{"tabela comum", "clientes", "ab_clientes", nil},
+		{"resumo materializado", "mv_vendas", "ab_mv_vendas", nil},
+		{"vazia", "", "", models.ErrTableRequired},
+		{"histórico", "_history", "", models.ErrInvalidTableName},
+		{"prefixo reservado", "_interna", "", models.ErrInvalidTableName},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := BuildTableName("ab", tt.table)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("BuildTableName(%q) erro = %v, want %v", tt.table, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("BuildTableName(%q) erro inesperado: %v", tt.table, err)
+			}
+			if got != tt.want {
+				t.Errorf("BuildTableName(%q) = %q, want %q", tt.table, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,712 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"meu-provedor/config"
+	"meu-provedor/engine/dialect"
+	"meu-provedor/engine/query"
+	"meu-provedor/models"
+	"meu-provedor/services/events"
+	tableService "meu-provedor/services/table"
+	"meu-provedor/services/webhook"
+)
+
+// ============================================================================
+// HISTORY SERVICE - Trilha de alterações (opt-in por tabela)
+// ============================================================================
+
+// historyWriter executa a escrita e grava o histórico na mesma transação.
+// Com o histórico desabilitado, executa direto no banco do projeto e não grava nada.
+type historyWriter struct {
+	ctx          context.Context
+	db           *sql.DB
+	dialect      dialect.Dialect
+	enabled      bool
+	tx           *sql.Tx
+	projectID    int64
+	projectCode  string
+	table        string // nome lógico
+	fullTable    string
+	historyTable string
+	instanceID   int64
+	actor        string
+	events       bool   // grava o evento da alteração na transação (webhooks)
+	quotaTable   string // tabela de travas de cota, quando a escrita verificou cotas
+}
+
+// beginHistory abre a transação quando a tabela grava histórico
+func beginHistory(ctx context.Context, db *sql.DB, projectID int64, projectCode, table, fullTable string, instanceID int64, actor string) (*historyWriter, error) {
+	h := &historyWriter{
+		ctx:          ctx,
+		db:           db,
+		dialect:      config.DialectOf(db),
+		projectID:    projectID,
+		projectCode:  projectCode,
+		table:        table,
+		fullTable:    fullTable,
+		historyTable: tableService.HistoryTableName(projectCode),
+		instanceID:   instanceID,
+		actor:        actor,
+	}
+
+	// toda escrita passa por aqui: views são somente leitura
+	if err := ensureWritable(table, fullTable); err != nil {
+		return nil, err
+	}
+
+	enabled, err := tableService.HistoryEnabled(fullTable)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao verificar histórico de %s: %w", fullTable, err)
+	}
+	h.enabled = enabled
+
+	// Com webhooks, o evento é gravado na mesma transação dos dados
+	if h.events, err = webhook.PrepareEvents(db, projectID, projectCode); err != nil {
+		return nil, err
+	}
+
+	// durante uma migração, a tabela é marcada antes e depois da escrita
+	if err := h.markWritten(); err != nil {
+		return nil, err
+	}
+	if !enabled && !h.events {
+		return h, nil
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, wrapDBError(models.ErrQueryFailed, err, projectCode)
+	}
+	h.tx = tx
+	return h, nil
+}
+
+// markWritten marca as tabelas físicas tocadas pela escrita (as de histórico,
+// de eventos e de cotas só quando usadas)
+func (h *historyWriter) markWritten() error {
+	tables := []string{h.fullTable}
+	if h.enabled {
+		tables = append(tables, h.historyTable)
+	}
+	if h.events {
+		tables = append(tables, webhook.EventTableName(h.projectCode))
+	}
+	if h.quotaTable != "" {
+		tables = append(tables, h.quotaTable)
+	}
+	for _, table := range tables {
+		if err := config.MarkTableWrite(h.projectID, table); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exec executa a escrita (na transação, se houver)
+func (h *historyWriter) exec(sqlQuery string, args ...interface{}) (result sql.Result, err error) {
+	sqlQuery = dialect.Rebind(h.dialect, sqlQuery)
+	defer func(start time.Time) { observeQuery(h.ctx, sqlQuery, args, start, err) }(time.Now())
+
+	if h.tx != nil {
+		return h.tx.ExecContext(h.ctx, sqlQuery, args...)
+	}
+	return h.db.ExecContext(h.ctx, sqlQuery, args...)
+}
+
+// begin abre a transação mesmo sem histórico (INSERT de várias linhas no MySQL)
+func (h *historyWriter) begin() error {
+	if h.tx != nil {
+		return nil
+	}
+	tx, err := h.db.BeginTx(h.ctx, nil)
+	if err != nil {
+		return wrapDBError(models.ErrQueryFailed, err, h.projectCode)
+	}
+	h.tx = tx
+	return nil
+}
+
+// insert executa o INSERT do builder e retorna os ids gerados, em ordem
+// crescente. Com RETURNING (PostgreSQL, SQLite) os ids vêm do próprio INSERT;
+// no MySQL as linhas são inseridas uma a uma na transação, pois os ids de um
+// INSERT multi-row não são necessariamente consecutivos
+// (innodb_autoinc_lock_mode=2, auto_increment_increment > 1).
+func (h *historyWriter) insert(builder *query.InsertBuilder) ([]int64, error) {
+	if h.dialect.Returning("id") == "" {
+		sqlQuery, rowsArgs, err := builder.BuildRows()
+		if err != nil {
+			return nil, fmt.Errorf("erro ao construir SQL: %w", err)
+		}
+		if len(rowsArgs) > 1 {
+			if err := h.begin(); err != nil {
+				return nil, err
+			}
+		}
+
+		ids := make([]int64, 0, len(rowsArgs))
+		for _, args := range rowsArgs {
+			result, err := h.exec(sqlQuery, args...)
+			if err != nil {
+				return nil, wrapDBError(models.ErrInsertFailed, err, h.projectCode)
+			}
+			id, err := result.LastInsertId()
+			if err != nil {
+				return nil, fmt.Errorf("erro ao obter ID: %w", err)
+			}
+			ids = append(ids, id)
+		}
+		return ids, nil
+	}
+
+	sqlQuery, args, err := builder.Returning("id").Build()
+	if err != nil {
+		return nil, fmt.Errorf("erro ao construir SQL: %w", err)
+	}
+
+	var rows *sql.Rows
+	if h.tx != nil {
+		rows, err = h.tx.QueryContext(h.ctx, sqlQuery, args...)
+	} else {
+		rows, err = h.db.QueryContext(h.ctx, sqlQuery, args...)
+	}
+	if err != nil {
+		return nil, wrapDBError(models.ErrInsertFailed, err, h.projectCode)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("erro ao obter ID: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, wrapDBError(models.ErrInsertFailed, err, h.projectCode)
+	}
+	// a ordem do RETURNING não é garantida
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
+func (h *historyWriter) commit() error {
+	if h.tx != nil {
+		if err := h.tx.Commit(); err != nil {
+			return wrapDBError(models.ErrQueryFailed, err, h.projectCode)
+		}
+	}
+	// a marca anterior pode ter sido consumida com a escrita em andamento
+	if err := h.markWritten(); err != nil {
+		log.Printf("⚠️ %v", err)
+	}
+	return nil
+}
+
+// commitChange confirma a escrita e publica a alteração (nada é publicado
+// quando nenhuma linha mudou). Com webhooks o evento é gravado antes do
+// commit, na mesma transação: não se perde se o processo cair em seguida.
+func (h *historyWriter) commitChange(operation string, rowIDs []int64, count int64, where map[string]interface{}) error {
+	event := models.ChangeEvent{
+		ProjectID:  h.projectID,
+		Table:      h.table,
+		Operation:  operation,
+		InstanceID: h.instanceID,
+		RowIDs:     rowIDs,
+		Count:      count,
+		Where:      where,
+		Actor:      h.actor,
+	}
+	events.Stamp(&event)
+
+	if h.events && count > 0 {
+		if err := h.stageEvent(event); err != nil {
+			return err
+		}
+	}
+	if err := h.commit(); err != nil {
+		return err
+	}
+	if count > 0 {
+		events.Emit(event)
+	}
+	return nil
+}
+
+// stageEvent grava o evento na tabela de eventos do projeto (relay de webhooks)
+func (h *historyWriter) stageEvent(event models.ChangeEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("erro ao serializar evento: %w", err)
+	}
+	_, err = h.exec(
+		fmt.Sprintf("INSERT INTO %s (event_id, payload) VALUES (?, ?)", webhook.EventTableName(h.projectCode)),
+		event.ID, string(payload),
+	)
+	if err != nil {
+		return wrapDBError(models.ErrInsertFailed, err, h.projectCode)
+	}
+	return nil
+}
+
+// rollback desfaz a transação (sem efeito após commit)
+func (h *historyWriter) rollback() {
+	if h.tx != nil {
+		h.tx.Rollback()
+	}
+}
+
+// snapshot trava e lê as linhas que serão alteradas (apenas com histórico)
+func (h *historyWriter) snapshot(where []string, args []interface{}) ([]map[string]interface{}, error) {
+	if !h.enabled {
+		return nil, nil
+	}
+	return h.lockRows(where, args)
+}
+
+// lockRows lê as linhas do WHERE, travando-as quando há transação
+func (h *historyWriter) lockRows(where []string, args []interface{}) ([]map[string]interface{}, error) {
+	sqlQuery := fmt.Sprintf("SELECT * FROM %s WHERE %s", query.QuoteIdent(h.fullTable), strings.Join(where, " AND "))
+	if h.enabled {
+		sqlQuery += h.dialect.ForUpdate()
+	}
+	return h.query(sqlQuery, args...)
+}
+
+// rowsByID lê o estado atual das linhas, indexado pelo id
+func (h *historyWriter) rowsByID(ids []interface{}) (map[string]map[string]interface{}, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	sqlQuery := fmt.Sprintf("SELECT * FROM %s WHERE id_instancia = ? AND id IN %s",
+		query.QuoteIdent(h.fullTable), query.BuildPlaceholders(len(ids)))
+
+	rows, err := h.query(sqlQuery, append([]interface{}{h.instanceID}, ids...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]map[string]interface{}, len(rows))
+	for _, row := range rows {
+		byID[fmt.Sprint(row["id"])] = row
+	}
+	return byID, nil
+}
+
+func (h *historyWriter) query(sqlQuery string, args ...interface{}) ([]map[string]interface{}, error) {
+	sqlQuery = dialect.Rebind(h.dialect, sqlQuery)
+
+	var q rowQueryer = h.db
+	if h.tx != nil {
+		q = h.tx
+	}
+	result, err := queryMaps(h.ctx, q, sqlQuery, args...)
+	if err != nil {
+		return nil, wrapDBError(models.ErrQueryFailed, err, h.projectCode)
+	}
+	return result, nil
+}
+
+// recordInserted grava as linhas de um INSERT, lidas na própria transação
+func (h *historyWriter) recordInserted(insertedIDs []int64) error {
+	if !h.enabled || len(insertedIDs) == 0 {
+		return nil
+	}
+
+	ids := make([]interface{}, len(insertedIDs))
+	for i, id := range insertedIDs {
+		ids[i] = id
+	}
+	after, err := h.rowsByID(ids)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		if row, ok := after[fmt.Sprint(id)]; ok {
+			if err := h.record(models.HistoryInsert, nil, row); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// recordChanges grava before/after das linhas capturadas por snapshot
+func (h *historyWriter) recordChanges(operation string, before []map[string]interface{}) error {
+	if !h.enabled || len(before) == 0 {
+		return nil
+	}
+
+	var after map[string]map[string]interface{}
+	if operation != models.HistoryDelete {
+		ids := make([]interface{}, len(before))
+		for i, row := range before {
+			ids[i] = row["id"]
+		}
+		var err error
+		if after, err = h.rowsByID(ids); err != nil {
+			return err
+		}
+	}
+
+	for _, row := range before {
+		if err := h.record(operation, row, after[fmt.Sprint(row["id"])]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// record grava uma entrada no histórico do projeto
+func (h *historyWriter) record(operation string, before, after map[string]interface{}) error {
+	if !h.enabled {
+		return nil
+	}
+	row := after
+	if row == nil {
+		row = before
+	}
+	if row == nil {
+		return nil
+	}
+
+	rowID, err := strconv.ParseInt(fmt.Sprint(row["id"]), 10, 64)
+	if err != nil {
+		return fmt.Errorf("histórico: id inválido em %s: %v", h.fullTable, row["id"])
+	}
+
+	_, err = h.exec(
+		fmt.Sprintf(`INSERT INTO %s
+			(table_name, row_id, id_instancia, operation, before_data, after_data, diff, actor)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`, h.historyTable),
+		h.table, rowID, h.instanceID, operation,
+		jsonOrNil(before), jsonOrNil(after), jsonOrNil(historyDiff(before, after)), nullableActor(h.actor),
+	)
+	if err != nil {
+		return wrapDBError(models.ErrInsertFailed, err, h.projectCode)
+	}
+	return nil
+}
+
+// ============================================================================
+// LIST / RESTORE
+// ============================================================================
+
+// ListHistory lista as alterações de um registro (mais recentes primeiro)
+func ListHistory(ctx context.Context, req models.HistoryRequest) (entries []models.HistoryEntry, err error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	ctx, guard, finish := guardQuery(ctx, req.ProjectID, req.InstanceID)
+	defer func() { err = finish(err) }()
+
+	projectCode, err := GetProjectCodeByID(req.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	db, err := projectDB(req.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	if err := tableService.EnsureHistoryTable(db, projectCode); err != nil {
+		return nil, err
+	}
+
+	limits, err := GetInstanceLimits(req.InstanceID)
+	if err != nil {
+		return nil, err
+	}
+	limit := guard.capRows(ClampLimit(req.Limit, limits))
+	if limit <= 0 {
+		limit = 100
+	}
+
+	rows, err := db.QueryContext(ctx,
+		dialect.Rebind(config.DialectOf(db), fmt.Sprintf(`SELECT id, table_name, row_id, id_instancia, operation,
+			before_data, after_data, diff, actor, created_at
+			FROM %s
+			WHERE table_name = ? AND row_id = ? AND id_instancia = ?
+			ORDER BY id DESC
+			LIMIT ? OFFSET ?`, tableService.HistoryTableName(projectCode))),
+		req.Table, req.RowID, req.InstanceID, limit, req.Offset,
+	)
+	if err != nil {
+		return nil, wrapDBError(models.ErrQueryFailed, err, projectCode)
+	}
+	defer rows.Close()
+
+	entries = []models.HistoryEntry{}
+	for rows.Next() {
+		entry, err := scanHistoryEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, *entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// RestoreFromHistory volta o registro ao estado gravado na entrada do histórico
+// (o "depois" da alteração, ou o "antes" no caso de um delete)
+func RestoreFromHistory(ctx context.Context, req models.RestoreRequest) (result map[string]interface{}, err error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	ctx, _, finish := guardQuery(ctx, req.ProjectID, req.InstanceID)
+	defer func() { err = finish(err) }()
+
+	projectCode, err := GetProjectCodeByID(req.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	db, done, err := writeDB(req.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	table, err := BuildTableName(projectCode, req.Table)
+	if err != nil {
+		return nil, err
+	}
+	if err := tableService.EnsureHistoryTable(db, projectCode); err != nil {
+		return nil, err
+	}
+
+	// Carregar a entrada (sempre da mesma tabela e instância)
+	row := db.QueryRowContext(ctx,
+		dialect.Rebind(config.DialectOf(db), fmt.Sprintf(`SELECT id, table_name, row_id, id_instancia, operation,
+			before_data, after_data, diff, actor, created_at
+			FROM %s WHERE id = ? AND table_name = ? AND id_instancia = ?`, tableService.HistoryTableName(projectCode))),
+		req.HistoryID, req.Table, req.InstanceID,
+	)
+	entry, err := scanHistoryEntry(row)
+	if err == sql.ErrNoRows {
+		return nil, models.ErrHistoryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	state := entry.After
+	if entry.Operation == models.HistoryDelete {
+		state = entry.Before
+	}
+	if state == nil {
+		return nil, models.ErrHistoryRestore
+	}
+
+	schema, err := loadTableSchema(db, table)
+	if err != nil {
+		return nil, err
+	}
+	audit, err := loadAuditColumns(schema, table)
+	if err != nil {
+		return nil, err
+	}
+	versioned, err := loadVersioning(schema, table)
+	if err != nil {
+		return nil, err
+	}
+
+	h, err := beginHistory(ctx, db, req.ProjectID, projectCode, req.Table, table, req.InstanceID, req.Actor)
+	if err != nil {
+		return nil, err
+	}
+	defer h.rollback()
+
+	current, err := h.lockRows([]string{"id_instancia = ?", "id = ?"}, []interface{}{req.InstanceID, entry.RowID})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(current) > 0 {
+		err = restoreUpdate(h, schema, audit, versioned, entry.RowID, state)
+	} else {
+		err = restoreInsert(h, schema, entry.RowID, state)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	restored, err := h.rowsByID([]interface{}{entry.RowID})
+	if err != nil {
+		return nil, err
+	}
+	after := restored[strconv.FormatInt(entry.RowID, 10)]
+
+	var before map[string]interface{}
+	if len(current) > 0 {
+		before = current[0]
+	}
+	if err := h.record(models.HistoryRestore, before, after); err != nil {
+		return nil, err
+	}
+	if err := h.commitChange(models.HistoryRestore, []int64{entry.RowID}, 1, nil); err != nil {
+		return nil, err
+	}
+	return after, nil
+}
+
+// restoreUpdate sobrescreve o registro existente com o estado salvo
+func restoreUpdate(h *historyWriter, schema tableSchema, audit auditColumns, versioned versioning, rowID int64, state map[string]interface{}) error {
+	builder := query.NewUpdate(h.fullTable)
+
+	for col, spec := range schema {
+		value, ok := state[col]
+		if !ok || col == "id" || (bool(versioned) && col == models.VersionColumn) || serviceManagedColumns[col] || audit.manages(col) {
+			continue
+		}
+		coerced, fieldErr := spec.coerce(value)
+		if fieldErr != nil {
+			return fmt.Errorf("%w: %s: %s", models.ErrHistoryRestore, col, fieldErr.Message)
+		}
+		builder.Set(col, coerced)
+	}
+
+	if versioned {
+		builder.SetOp(models.VersionColumn, "increment", 1)
+	}
+	audit.applyUpdate(builder, h.actor)
+
+	builder.WhereEq("id_instancia", h.instanceID)
+	builder.WhereEq("id", rowID)
+
+	sqlQuery, args := builder.Build()
+	if _, err := h.exec(sqlQuery, args...); err != nil {
+		return wrapDBError(models.ErrUpdateFailed, err, h.projectCode)
+	}
+	return nil
+}
+
+// restoreInsert recria um registro removido, mantendo o id original
+func restoreInsert(h *historyWriter, schema tableSchema, rowID int64, state map[string]interface{}) error {
+	columns := []string{"id", "id_instancia"}
+	values := []interface{}{rowID, h.instanceID}
+
+	for col, spec := range schema {
+		value, ok := state[col]
+		if !ok || col == "id" || serviceManagedColumns[col] {
+			continue
+		}
+		coerced, fieldErr := spec.coerce(value)
+		if fieldErr != nil {
+			return fmt.Errorf("%w: %s: %s", models.ErrHistoryRestore, col, fieldErr.Message)
+		}
+		columns = append(columns, col)
+		values = append(values, coerced)
+	}
+
+	builder := query.NewInsert(h.fullTable).SetColumns(columns).SetDialect(h.dialect)
+	if err := builder.AddRow(values); err != nil {
+		return fmt.Errorf("erro ao adicionar row: %w", err)
+	}
+	sqlQuery, args, err := builder.Build()
+	if err != nil {
+		return fmt.Errorf("erro ao construir SQL: %w", err)
+	}
+	if _, err := h.exec(sqlQuery, args...); err != nil {
+		return wrapDBError(models.ErrInsertFailed, err, h.projectCode)
+	}
+
+	// Id explícito: o gerador de ids não pode voltar a entregá-lo
+	if stmt := h.dialect.SyncSequence(h.fullTable, "id"); stmt != "" {
+		if _, err := h.exec(stmt); err != nil {
+			return wrapDBError(models.ErrInsertFailed, err, h.projectCode)
+		}
+	}
+	return nil
+}
+
+// ============================================================================
+// INTERNAL HELPERS
+// ============================================================================
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanHistoryEntry(s rowScanner) (*models.HistoryEntry, error) {
+	var entry models.HistoryEntry
+	var before, after, diff, actor sql.NullString
+
+	err := s.Scan(&entry.ID, &entry.Table, &entry.RowID, &entry.InstanceID, &entry.Operation,
+		&before, &after, &diff, &actor, &entry.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	entry.Actor = actor.String
+	if entry.Before, err = decodeHistoryJSON(before); err != nil {
+		return nil, err
+	}
+	if entry.After, err = decodeHistoryJSON(after); err != nil {
+		return nil, err
+	}
+	if entry.Diff, err = decodeHistoryJSON(diff); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// decodeHistoryJSON preserva números grandes (json.Number) ao decodificar
+func decodeHistoryJSON(value sql.NullString) (map[string]interface{}, error) {
+	if !value.Valid || value.String == "" {
+		return nil, nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader([]byte(value.String)))
+	decoder.UseNumber()
+
+	var out map[string]interface{}
+	if err := decoder.Decode(&out); err != nil {
+		return nil, fmt.Errorf("histórico com JSON inválido: %w", err)
+	}
+	return out, nil
+}
+
+// historyDiff lista as colunas alteradas: {"coluna": {"from": x, "to": y}}
+func historyDiff(before, after map[string]interface{}) map[string]interface{} {
+	if before == nil || after == nil {
+		return nil
+	}
+
+	diff := map[string]interface{}{}
+	for col, value := range after {
+		old, ok := before[col]
+		if !ok || fmt.Sprint(old) != fmt.Sprint(value) {
+			diff[col] = map[string]interface{}{"from": old, "to": value}
+		}
+	}
+	for col, old := range before {
+		if _, ok := after[col]; !ok {
+			diff[col] = map[string]interface{}{"from": old, "to": nil}
+		}
+	}
+	return diff
+}
+
+func jsonOrNil(value map[string]interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+	return string(encoded)
+}
@@ -0,0 +1,298 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	"meu-provedor/engine/dialect"
+	"meu-provedor/engine/query"
+	"meu-provedor/models"
+	tableService "meu-provedor/services/table"
+)
+
+// noReturning é o SQLite sem RETURNING: força o caminho do MySQL (uma linha
+// por vez, LastInsertId de cada uma)
+type noReturning struct{ dialect.Dialect }
+
+func (noReturning) Returning(string) string { return "" }
+
+// newTestWriter abre um SQLite em memória com a tabela ab_itens e monta o
+// historyWriter direto (beginHistory depende do catálogo master). Com uma
+// única conexão, qualquer leitura fora da transação aberta trava e estoura
+// o contexto.
+func newTestWriter(t *testing.T, d dialect.Dialect) *historyWriter {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	statements, err := dialect.SQLite.CreateTable("ab_itens", false, []string{
+		dialect.SQLite.AutoIncrementPK("id"),
+		"id_instancia BIGINT NOT NULL",
+		"nome VARCHAR(64) NOT NULL",
+	}, nil)
+	if err != nil {
+		t.Fatalf("CreateTable: %v", err)
+	}
+	for _, stmt := range statements {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+
+	return &historyWriter{
+		ctx:          ctx,
+		db:           db,
+		dialect:      d,
+		projectCode:  "ab",
+		table:        "itens",
+		fullTable:    "ab_itens",
+		historyTable: tableService.HistoryTableName("ab"),
+		instanceID:   7,
+	}
+}
+
+func newItemsInsert(d dialect.Dialect, names ...string) *query.InsertBuilder {
+	builder := query.NewInsert("ab_itens").SetColumns([]string{"id_instancia", "nome"}).SetDialect(d)
+	for _, name := range names {
+		builder.AddRow([]interface{}{7, name})
+	}
+	return builder
+}
+
+// storedIDs lê os ids gravados por nome (após o commit)
+func storedIDs(t *testing.T, db *sql.DB) map[string]int64 {
+	t.Helper()
+	rows, err := db.Query(`SELECT id, nome FROM ab_itens WHERE id_instancia = 7`)
+	if err != nil {
+		t.Fatalf("SELECT: %v", err)
+	}
+	defer rows.Close()
+
+	ids := map[string]int64{}
+	for rows.Next() {
+		var id int64
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			t.Fatalf("Scan: %v", err)
+		}
+		ids[name] = id
+	}
+	return ids
+}
+
+func TestHistoryWriterInsertReturning(t *testing.T) {
+	h := newTestWriter(t, dialect.SQLite)
+
+	ids, err := h.insert(newItemsInsert(dialect.SQLite, "a", "b", "c"))
+	if err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	if h.tx != nil {
+		t.Fatalf("RETURNING não deveria abrir transação")
+	}
+
+	stored := storedIDs(t, h.db)
+	want := []int64{stored["a"], stored["b"], stored["c"]}
+	if !reflect.DeepEqual(ids, want) {
+		t.Fatalf("ids = %v, gravados %v", ids, want)
+	}
+}
+
+func TestHistoryWriterInsertRowByRow(t *testing.T) {
+	h := newTestWriter(t, noReturning{dialect.SQLite})
+
+	// Um id já ocupado: os ids gerados não começam em 1
+	if _, err := h.db.Exec(`INSERT INTO ab_itens (id, id_instancia, nome) VALUES (41, 1, 'x')`); err != nil {
+		t.Fatalf("INSERT: %v", err)
+	}
+
+	ids, err := h.insert(newItemsInsert(noReturning{dialect.SQLite}, "a", "b"))
+	if err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	if h.tx == nil {
+		t.Fatalf("INSERT de várias linhas deveria abrir transação")
+	}
+	if err := h.tx.Commit(); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+
+	stored := storedIDs(t, h.db)
+	want := []int64{stored["a"], stored["b"]}
+	if !reflect.DeepEqual(ids, want) || ids[0] != 42 {
+		t.Fatalf("ids = %v, gravados %v", ids, want)
+	}
+}
+
+func TestHistoryWriterRecordsInsert(t *testing.T) {
+	h := newTestWriter(t, dialect.SQLite)
+	if err := tableService.EnsureHistoryTable(h.db, "ab"); err != nil {
+		t.Fatalf("EnsureHistoryTable: %v", err)
+	}
+	h.enabled = true
+	if err := h.begin(); err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+
+	// Inserção, leitura (rowsByID com IN) e histórico na mesma transação
+	ids, err := h.insert(newItemsInsert(dialect.SQLite, "a", "b"))
+	if err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	if err := h.recordInserted(ids); err != nil {
+		t.Fatalf("recordInserted: %v", err)
+	}
+	if err := h.tx.Commit(); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+
+	entries, err := queryMaps(context.Background(), h.db,
+		`SELECT row_id, id_instancia, operation, after_data FROM ab__history ORDER BY row_id`)
+	if err != nil {
+		t.Fatalf("SELECT histórico: %v", err)
+	}
+	if len(entries) != len(ids) {
+		t.Fatalf("%d entradas de histórico, esperado %d", len(entries), len(ids))
+	}
+	for i, entry := range entries {
+		if fmt.Sprint(entry["row_id"]) != fmt.Sprint(ids[i]) || fmt.Sprint(entry["id_instancia"]) != "7" ||
+			fmt.Sprint(entry["operation"]) != models.HistoryInsert {
+			t.Errorf("entrada %d = %v", i, entry)
+		}
+		if !strings.Contains(fmt.Sprint(entry["after_data"]), `"nome":"`) {
+			t.Errorf("entrada %d sem after_data: %v", i, entry["after_data"])
+		}
+	}
+}
+
+func TestHistoryWriterExecRebinds(t *testing.T) {
+	h := newTestWriter(t, dialect.SQLite)
+	if _, err := h.insert(newItemsInsert(dialect.SQLite, "a")); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+
+	// Crases do MySQL e o '?' dentro do literal não podem virar parâmetro
+	stmt := "UPDATE `ab_itens` SET `nome` = ? || '?' WHERE `id_instancia` = ?"
+	want := `UPDATE "ab_itens" SET "nome" = $1 || '?' WHERE "id_instancia" = $2`
+	if got := dialect.Rebind(dialect.Postgres, stmt); got != want {
+		t.Errorf("Rebind(postgres) = %s, want %s", got, want)
+	}
+
+	result, err := h.exec(stmt, "b", 7)
+	if err != nil {
+		t.Fatalf("exec: %v", err)
+	}
+	if n, _ := result.RowsAffected(); n != 1 {
+		t.Fatalf("%d linhas alteradas, esperado 1", n)
+	}
+	if _, ok := storedIDs(t, h.db)["b?"]; !ok {
+		t.Fatalf("UPDATE não gravou o valor esperado: %v", storedIDs(t, h.db))
+	}
+}
+
+func TestInsertBuilderPlaceholders(t *testing.T) {
+	tests := []struct {
+		name      string
+		d         dialect.Dialect
+		returning bool
+		want      string
+	}{
+		{"mysql", dialect.MySQL, false,
+			"INSERT INTO `ab_itens` (`id_instancia`,`nome`) VALUES (?,?),(?,?)"},
+		{"postgres", dialect.Postgres, true,
+			`INSERT INTO "ab_itens" ("id_instancia","nome") VALUES ($1,$2),($3,$4) RETURNING id`},
+		{"sqlite", dialect.SQLite, true,
+			`INSERT INTO "ab_itens" ("id_instancia","nome") VALUES (?,?),(?,?) RETURNING id`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builder := newItemsInsert(tt.d, "a", "b")
+			if tt.returning {
+				builder.Returning("id")
+			}
+			got, args, err := builder.Build()
+			if err != nil {
+				t.Fatalf("Build: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Build() = %s, want %s", got, tt.want)
+			}
+			if len(args) != 4 {
+				t.Errorf("%d argumentos, esperado 4", len(args))
+			}
+
+			// BuildRows: uma linha por statement, argumentos de cada linha
+			single, rows, err := newItemsInsert(tt.d, "a", "b").BuildRows()
+			if err != nil {
+				t.Fatalf("BuildRows: %v", err)
+			}
+			if strings.Count(single, "(") != 2 || len(rows) != 2 || !reflect.DeepEqual(rows[1], []interface{}{7, "b"}) {
+				t.Errorf("BuildRows() = %s, %v", single, rows)
+			}
+		})
+	}
+}
+
+// syncRecorder registra os pedidos de ajuste da sequência de ids
+type syncRecorder struct {
+	dialect.Dialect
+	synced []string
+}
+
+func (r *syncRecorder) SyncSequence(table, column string) string {
+	r.synced = append(r.synced, table+"."+column)
+	return r.Dialect.SyncSequence(table, column)
+}
+
+func TestRestoreInsertKeepsID(t *testing.T) {
+	recorder := &syncRecorder{Dialect: dialect.SQLite}
+	h := newTestWriter(t, recorder)
+	if _, err := h.insert(newItemsInsert(dialect.SQLite, "a")); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+
+	schema := tableSchema{}
+	for _, col := range []models.ColumnDetail{
+		{Name: "id", Type: "bigint"},
+		{Name: "id_instancia", Type: "bigint"},
+		{Name: "nome", Type: "varchar(64)"},
+	} {
+		schema[col.Name] = parseColumnSpec(col)
+	}
+	if err := restoreInsert(h, schema, 9, map[string]interface{}{"id": 9, "nome": "restaurado"}); err != nil {
+		t.Fatalf("restoreInsert: %v", err)
+	}
+	if !reflect.DeepEqual(recorder.synced, []string{"ab_itens.id"}) {
+		t.Errorf("SyncSequence chamado com %v", recorder.synced)
+	}
+
+	// O próximo id gerado passa do id restaurado
+	if _, err := h.insert(newItemsInsert(dialect.SQLite, "b")); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	stored := storedIDs(t, h.db)
+	if stored["restaurado"] != 9 || stored["b"] <= 9 {
+		t.Fatalf("ids gravados = %v", stored)
+	}
+
+	want := `SELECT setval(pg_get_serial_sequence('"ab_itens"', 'id'), ` +
+		`GREATEST(nextval(pg_get_serial_sequence('"ab_itens"', 'id')) - 1, (SELECT MAX("id") FROM "ab_itens")))`
+	if got := dialect.Postgres.SyncSequence("ab_itens", "id"); got != want {
+		t.Errorf("SyncSequence(postgres) = %s, want %s", got, want)
+	}
+}
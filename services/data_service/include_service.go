@@ -0,0 +1,200 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"meu-provedor/config"
+	"meu-provedor/engine/query"
+	"meu-provedor/models"
+)
+
+// ============================================================================
+// INCLUDE SERVICE - Embutir relacionamentos nos resultados do SELECT
+// ============================================================================
+
+const (
+	// maxIncludeDepth limita o aninhamento de includes
+	maxIncludeDepth = 3
+	// includeBatchSize é o máximo de valores por IN (...)
+	includeBatchSize = 500
+)
+
+// resolveIncludes busca os relacionamentos das linhas pai em consultas
+// IN (...) em lote (sempre filtradas por id_instancia) e embute os filhos.
+// O limite por pai é aplicado no SQL e o total de filhos de cada include
+// respeita o teto de linhas do guard.
+func resolveIncludes(ctx context.Context, db *sql.DB, guard queryGuard, projectCode string, instanceID int64, parents []map[string]interface{}, includes []models.IncludeSpec, depth int) error {
+	if len(includes) == 0 || len(parents) == 0 {
+		return nil
+	}
+	if depth > maxIncludeDepth {
+		return models.NewValidationError(fmt.Sprintf("include excede a profundidade máxima de %d", maxIncludeDepth))
+	}
+
+	for _, inc := range includes {
+		if err := resolveInclude(ctx, db, guard, projectCode, instanceID, parents, inc, depth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func resolveInclude(ctx context.Context, db *sql.DB, guard queryGuard, projectCode string, instanceID int64, parents []map[string]interface{}, inc models.IncludeSpec, depth int) error {
+	parentColumn := inc.ParentColumn
+	if parentColumn == "" {
+		parentColumn = "id"
+	}
+	field := inc.As
+	if field == "" {
+		field = inc.Table
+	}
+	if !query.IsValidIdentifier(field) {
+		return fmt.Errorf("%w: %s", models.ErrInvalidIdentifier, field)
+	}
+
+	table, err := BuildTableName(projectCode, inc.Table)
+	if err != nil {
+		return err
+	}
+
+	scope := newAliasScope(db)
+	if err := scope.add(table, table, inc.Table); err != nil {
+		return err
+	}
+	foreign, err := scope.column(models.ColumnRef{Column: inc.ForeignColumn})
+	if err != nil {
+		return err
+	}
+
+	// Valores distintos da coluna do pai
+	var keys []interface{}
+	seen := map[string]bool{}
+	for _, parent := range parents {
+		value, ok := parent[parentColumn]
+		if !ok {
+			return models.NewValidationError(fmt.Sprintf(
+				"include '%s': coluna '%s' precisa estar no resultado do pai", field, parentColumn))
+		}
+		if value == nil {
+			continue
+		}
+		key := fmt.Sprint(value)
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, value)
+		}
+	}
+
+	// Buscar filhos em lotes, até o teto de linhas
+	var children []map[string]interface{}
+	remaining := guard.capRows(0)
+	for start := 0; start < len(keys); start += includeBatchSize {
+		end := start + includeBatchSize
+		if end > len(keys) {
+			end = len(keys)
+		}
+		batch, err := selectIncludeBatch(ctx, projectCode, table, scope, foreign, instanceID, inc, keys[start:end], remaining)
+		if err != nil {
+			return err
+		}
+		children = append(children, batch...)
+
+		if remaining > 0 {
+			if remaining -= len(batch); remaining <= 0 {
+				break
+			}
+		}
+	}
+
+	// Includes aninhados são resolvidos sobre todos os filhos de uma vez
+	if err := resolveIncludes(ctx, db, guard, projectCode, instanceID, children, inc.Include, depth+1); err != nil {
+		return err
+	}
+
+	// Agrupar filhos pelo valor da coluna estrangeira
+	grouped := map[string][]map[string]interface{}{}
+	for _, child := range children {
+		key := fmt.Sprint(child[inc.ForeignColumn])
+		grouped[key] = append(grouped[key], child)
+	}
+
+	// Embutir nos pais
+	for _, parent := range parents {
+		var related []map[string]interface{}
+		if value := parent[parentColumn]; value != nil {
+			related = grouped[fmt.Sprint(value)]
+		}
+
+		if inc.Single {
+			if len(related) > 0 {
+				parent[field] = related[0]
+			} else {
+				parent[field] = nil
+			}
+			continue
+		}
+
+		if related == nil {
+			related = []map[string]interface{}{}
+		}
+		parent[field] = related
+	}
+
+	return nil
+}
+
+func selectIncludeBatch(ctx context.Context, projectCode, table string, scope *aliasScope, foreign string, instanceID int64, inc models.IncludeSpec, keys []interface{}, maxRows int) ([]map[string]interface{}, error) {
+	builder := query.NewSelect(table, table).SetDialect(config.DialectOf(scope.db))
+
+	if len(inc.Projection) > 0 {
+		cols, err := scope.projection(inc.Projection)
+		if err != nil {
+			return nil, err
+		}
+		// A coluna estrangeira é necessária para agrupar os filhos
+		cols = append(cols, foreign+" AS "+query.QuoteIdent(inc.ForeignColumn))
+		builder.SetColumns(cols)
+	}
+
+	builder.AddWhereEq(table+".id_instancia", instanceID)
+	builder.AddWhere(fmt.Sprintf("%s IN %s", foreign, query.BuildPlaceholders(len(keys))), keys...)
+
+	for col, val := range inc.Where {
+		expr, err := scope.column(models.ColumnRef{Column: col})
+		if err != nil {
+			return nil, err
+		}
+		builder.AddWhereEq(expr, val)
+	}
+
+	if len(inc.Sort) > 0 {
+		order, err := scope.sort(inc.Sort)
+		if err != nil {
+			return nil, err
+		}
+		builder.SetOrderBy(order)
+	}
+
+	// Limite por pai (single = 1) aplicado no banco
+	perParent := inc.Limit
+	if inc.Single {
+		perParent = 1
+	}
+	if perParent > 0 {
+		builder.SetLimitPerGroup(foreign, perParent)
+	}
+	builder.SetLimitOffset(maxRows, 0)
+
+	result, err := queryMaps(ctx, scope.db, builder.Build(), builder.GetValues()...)
+	if err != nil {
+		return nil, wrapDBError(models.ErrQueryFailed, err, projectCode)
+	}
+	if perParent > 0 {
+		for _, row := range result {
+			delete(row, query.GroupRowColumn)
+		}
+	}
+	return result, nil
+}
@@ -1,138 +1,307 @@
-package services
-
-import (
-	"fmt"
-	"log"
-	"meu-provedor/config"
-	"meu-provedor/engine/query"
-	"meu-provedor/models"
-)
-
-// ExecuteInsert executa INSERT único com validação completa
-func ExecuteInsert(req models.InsertRequest) (int64, error) {
-	// ✅ PASSO 1: Validar requisição
-	if err := req.Validate(); err != nil {
-		return 0, fmt.Errorf("validação falhou: %w", err)
-	}
-	
-	// ✅ PASSO 2: Buscar código do projeto
-	projectCode, err := config.GetProjectCodeByID(int(req.ProjectID))
-	if err != nil {
-		return 0, fmt.Errorf("projeto não encontrado: %w", err)
-	}
-	
-	// ✅ PASSO 3: Construir nome da tabela
-	tableName := fmt.Sprintf("%s_%s", projectCode, req.Table)
-	
-	// ✅ PASSO 4: Preparar colunas e valores
-	columns := make([]string, 0, len(req.Columns)+1)
-	values := make([]interface{}, 0, len(req.Columns)+1)
-	
-	// Adicionar id_instancia primeiro
-	columns = append(columns, "id_instancia")
-	values = append(values, req.InstanceID)
-	
-	// Adicionar colunas do request
-	for _, col := range req.Columns {
-		columns = append(columns, col.Name)
-		values = append(values, col.Value)
-	}
-	
-	// ✅ PASSO 5: Construir query
-	builder := query.NewInsert(tableName).SetColumns(columns)
-	if err := builder.AddRow(values); err != nil {
-		return 0, fmt.Errorf("erro ao adicionar row: %w", err)
-	}
-	
-	sqlQuery, args, err := builder.Build()
-	if err != nil {
-		return 0, fmt.Errorf("erro ao construir SQL: %w", err)
-	}
-	
-	// ✅ PASSO 6: Log para debug
-	log.Printf("📝 SQL: %s", sqlQuery)
-	log.Printf("📊 Args: %v", args)
-	
-	// ✅ PASSO 7: Executar
-	result, err := config.MasterDB.Exec(sqlQuery, args...)
-	if err != nil {
-		return 0, fmt.Errorf("erro ao executar INSERT: %w", err)
-	}
-	
-	lastID, err := result.LastInsertId()
-	if err != nil {
-		return 0, fmt.Errorf("erro ao obter ID: %w", err)
-	}
-	
-	log.Printf("✅ Registro inserido com ID: %d", lastID)
-	return lastID, nil
-}
-
-// ExecuteBatchInsert executa múltiplos INSERTs em uma única query
-func ExecuteBatchInsert(req models.BatchInsertRequest) (int, error) {
-	// ✅ PASSO 1: Validar requisição
-	if err := req.Validate(); err != nil {
-		return 0, fmt.Errorf("validação falhou: %w", err)
-	}
-	
-	// ✅ PASSO 2: Buscar código do projeto
-	projectCode, err := config.GetProjectCodeByID(int(req.ProjectID))
-	if err != nil {
-		return 0, fmt.Errorf("projeto não encontrado: %w", err)
-	}
-	
-	// ✅ PASSO 3: Construir nome da tabela
-	tableName := fmt.Sprintf("%s_%s", projectCode, req.Table)
-	
-	// ✅ PASSO 4: Extrair nomes das colunas da primeira row
-	// Assumindo que todas as rows têm as mesmas colunas
-	firstRow := req.Rows[0]
-	columns := make([]string, 0, len(firstRow)+1)
-	
-	// Adicionar id_instancia primeiro
-	columns = append(columns, "id_instancia")
-	
-	// Adicionar colunas da primeira row
-	for _, col := range firstRow {
-		columns = append(columns, col.Name)
-	}
-	
-	// ✅ PASSO 5: Construir query
-	builder := query.NewInsert(tableName).SetColumns(columns)
-	
-	// Adicionar cada row
-	for _, row := range req.Rows {
-		values := make([]interface{}, 0, len(row)+1)
-		
-		// Adicionar id_instancia
-		values = append(values, req.InstanceID)
-		
-		// Adicionar valores da row
-		for _, col := range row {
-			values = append(values, col.Value)
-		}
-		
-		if err := builder.AddRow(values); err != nil {
-			return 0, fmt.Errorf("erro ao adicionar row: %w", err)
-		}
-	}
-	
-	sqlQuery, args, err := builder.Build()
-	if err != nil {
-		return 0, fmt.Errorf("erro ao construir SQL: %w", err)
-	}
-	
-	// ✅ PASSO 6: Log para debug
-	log.Printf("📝 BATCH SQL: %s", sqlQuery)
-	log.Printf("📊 BATCH Args: %v", args)
-	
-	// ✅ PASSO 7: Executar
-	_, err = config.MasterDB.Exec(sqlQuery, args...)
-	if err != nil {
-		return 0, fmt.Errorf("erro ao executar BATCH INSERT: %w", err)
-	}
-	
-	log.Printf("✅ %d registros inseridos", len(req.Rows))
-	return len(req.Rows), nil
-}
-
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"meu-provedor/config"
+	"meu-provedor/engine/query"
+	"meu-provedor/models"
+)
+
+// ExecuteInsert executa INSERT único com validação completa
+func ExecuteInsert(ctx context.Context, req models.InsertRequest) (id int64, err error) {
+	// ✅ PASSO 1: Validar requisição
+	if err := req.Validate(); err != nil {
+		return 0, fmt.Errorf("validação falhou: %w", err)
+	}
+	
+	// ✅ PASSO 1.1: Timeout e cancelamento da escrita
+	ctx, _, finish := guardQuery(ctx, req.ProjectID, req.InstanceID)
+	defer func() { err = finish(err) }()
+
+	// ✅ PASSO 2: Buscar código do projeto
+	projectCode, err := config.GetProjectCodeByID(int(req.ProjectID))
+	if err != nil {
+		return 0, fmt.Errorf("projeto não encontrado: %w", err)
+	}
+	db, done, err := writeDB(req.ProjectID)
+	if err != nil {
+		return 0, err
+	}
+	defer done()
+	
+	// ✅ PASSO 3: Construir nome da tabela
+	tableName, err := BuildTableName(projectCode, req.Table)
+	if err != nil {
+		return 0, err
+	}
+	
+	// ✅ PASSO 3.1: Carregar cotas da instância (verificadas na transação)
+	limits, err := GetInstanceLimits(req.InstanceID)
+	if err != nil {
+		return 0, err
+	}
+	
+	// ✅ PASSO 3.2: Validar valores contra o schema da tabela
+	schema, err := loadTableSchema(db, tableName)
+	if err != nil {
+		return 0, err
+	}
+	versioned, err := loadVersioning(schema, tableName)
+	if err != nil {
+		return 0, err
+	}
+	rowValues, fieldErrs := schema.validateInsertRow(req.Columns, nil)
+	if err := schemaValidationError(fieldErrs); err != nil {
+		return 0, err
+	}
+	if err := versioned.checkNotProvided(columnNames(req.Columns)...); err != nil {
+		return 0, err
+	}
+	audit, err := loadAuditColumns(schema, tableName)
+	if err != nil {
+		return 0, err
+	}
+	if err := audit.checkNotProvided(columnNames(req.Columns)...); err != nil {
+		return 0, err
+	}
+	
+	// ✅ PASSO 4: Preparar colunas e valores
+	columns := make([]string, 0, len(req.Columns)+1)
+	values := make([]interface{}, 0, len(req.Columns)+1)
+	
+	// Adicionar id_instancia primeiro
+	columns = append(columns, "id_instancia")
+	values = append(values, req.InstanceID)
+	
+	// Adicionar colunas do request (valores já convertidos)
+	for i, col := range req.Columns {
+		columns = append(columns, col.Name)
+		values = append(values, rowValues[i])
+	}
+	
+	// Tabelas versionadas começam na versão 1
+	if versioned {
+		columns = append(columns, models.VersionColumn)
+		values = append(values, 1)
+	}
+	
+	// Colunas de auditoria (created_at, created_by, ...)
+	auditCols, auditValues := audit.insertValues(req.Actor, columnNames(req.Columns)...)
+	columns = append(columns, auditCols...)
+	values = append(values, auditValues...)
+	
+	// ✅ PASSO 5: Construir query
+	builder := query.NewInsert(tableName).SetColumns(columns).
+		SetDialect(config.DialectOf(db))
+	if err := builder.AddRow(values); err != nil {
+		return 0, fmt.Errorf("erro ao adicionar row: %w", err)
+	}
+	
+	sqlQuery, args, err := builder.Build()
+	if err != nil {
+		return 0, fmt.Errorf("erro ao construir SQL: %w", err)
+	}
+	
+	// ✅ PASSO 6: Log para debug (só a quantidade de valores: são dados do cliente)
+	log.Printf("📝 SQL: %s", sqlQuery)
+	log.Printf("📊 Args: %d valores", len(args))
+	
+	// ✅ PASSO 7: Executar (com histórico, se habilitado na tabela)
+	hist, err := beginHistory(ctx, db, req.ProjectID, projectCode, req.Table, tableName, req.InstanceID, req.Actor)
+	if err != nil {
+		return 0, err
+	}
+	defer hist.rollback()
+	
+	if err := hist.reserveQuota(limits, 1); err != nil {
+		return 0, err
+	}
+	
+	ids, err := hist.insert(builder)
+	if err != nil {
+		return 0, err
+	}
+	lastID := ids[0]
+	
+	if err := hist.recordInserted(ids); err != nil {
+		return 0, err
+	}
+	if err := hist.commitChange(models.HistoryInsert, []int64{lastID}, 1, nil); err != nil {
+		return 0, err
+	}
+	
+	log.Printf("✅ Registro inserido com ID: %d", lastID)
+	return lastID, nil
+}
+
+// ExecuteBatchInsert executa múltiplos INSERTs em uma única query
+func ExecuteBatchInsert(ctx context.Context, req models.BatchInsertRequest) (inserted int, err error) {
+	// ✅ PASSO 1: Validar requisição
+	if err := req.Validate(); err != nil {
+		return 0, fmt.Errorf("validação falhou: %w", err)
+	}
+	
+	// ✅ PASSO 1.1: Timeout e cancelamento da escrita
+	ctx, _, finish := guardQuery(ctx, req.ProjectID, req.InstanceID)
+	defer func() { err = finish(err) }()
+
+	// ✅ PASSO 2: Buscar código do projeto
+	projectCode, err := config.GetProjectCodeByID(int(req.ProjectID))
+	if err != nil {
+		return 0, fmt.Errorf("projeto não encontrado: %w", err)
+	}
+	db, done, err := writeDB(req.ProjectID)
+	if err != nil {
+		return 0, err
+	}
+	defer done()
+	
+	// ✅ PASSO 3: Construir nome da tabela
+	tableName, err := BuildTableName(projectCode, req.Table)
+	if err != nil {
+		return 0, err
+	}
+	
+	// ✅ PASSO 3.1: Verificar tamanho do lote e cotas da instância
+	limits, err := GetInstanceLimits(req.InstanceID)
+	if err != nil {
+		return 0, err
+	}
+	if err := CheckBatchSize(len(req.Rows), limits); err != nil {
+		return 0, err
+	}
+	
+	// ✅ PASSO 4: Extrair nomes das colunas da primeira row
+	// Todas as rows devem ter as mesmas colunas, na mesma ordem
+	firstRow := req.Rows[0]
+	columns := make([]string, 0, len(firstRow)+1)
+	
+	// Adicionar id_instancia primeiro
+	columns = append(columns, "id_instancia")
+	
+	// Adicionar colunas da primeira row
+	for _, col := range firstRow {
+		columns = append(columns, col.Name)
+	}
+	
+	// ✅ PASSO 4.1: Validar todas as rows contra o schema da tabela
+	schema, err := loadTableSchema(db, tableName)
+	if err != nil {
+		return 0, err
+	}
+	versioned, err := loadVersioning(schema, tableName)
+	if err != nil {
+		return 0, err
+	}
+	
+	var fieldErrs []models.FieldError
+	rowsValues := make([][]interface{}, len(req.Rows))
+	for i, row := range req.Rows {
+		rowIndex := i
+		for j, col := range row {
+			if col.Name != firstRow[j].Name {
+				fieldErrs = append(fieldErrs, models.FieldError{
+					Column:  col.Name,
+					Code:    "COLUMN_MISMATCH",
+					Message: fmt.Sprintf("esperada coluna '%s' na posição %d", firstRow[j].Name, j),
+					Row:     &rowIndex,
+				})
+			}
+		}
+		
+		values, errs := schema.validateInsertRow(row, &rowIndex)
+		fieldErrs = append(fieldErrs, errs...)
+		rowsValues[i] = values
+	}
+	if err := schemaValidationError(fieldErrs); err != nil {
+		return 0, err
+	}
+	if err := versioned.checkNotProvided(columnNames(firstRow)...); err != nil {
+		return 0, err
+	}
+	audit, err := loadAuditColumns(schema, tableName)
+	if err != nil {
+		return 0, err
+	}
+	if err := audit.checkNotProvided(columnNames(firstRow)...); err != nil {
+		return 0, err
+	}
+	
+	// Tabelas versionadas começam na versão 1
+	if versioned {
+		columns = append(columns, models.VersionColumn)
+	}
+	
+	// Colunas de auditoria (mesmos valores para todo o lote)
+	auditCols, auditValues := audit.insertValues(req.Actor, columnNames(firstRow)...)
+	columns = append(columns, auditCols...)
+	
+	// ✅ PASSO 5: Construir query
+	builder := query.NewInsert(tableName).SetColumns(columns).
+		SetDialect(config.DialectOf(db))
+	
+	// Adicionar cada row
+	for _, rowValues := range rowsValues {
+		values := make([]interface{}, 0, len(rowValues)+1)
+		
+		// Adicionar id_instancia
+		values = append(values, req.InstanceID)
+		
+		// Adicionar valores da row (já convertidos)
+		values = append(values, rowValues...)
+		
+		if versioned {
+			values = append(values, 1)
+		}
+		values = append(values, auditValues...)
+		
+		if err := builder.AddRow(values); err != nil {
+			return 0, fmt.Errorf("erro ao adicionar row: %w", err)
+		}
+	}
+	
+	sqlQuery, args, err := builder.Build()
+	if err != nil {
+		return 0, fmt.Errorf("erro ao construir SQL: %w", err)
+	}
+	
+	// ✅ PASSO 6: Log para debug (só a quantidade de valores: são dados do cliente)
+	log.Printf("📝 BATCH SQL: %s", sqlQuery)
+	log.Printf("📊 BATCH Args: %d valores", len(args))
+	
+	// ✅ PASSO 7: Executar (com histórico, se habilitado na tabela)
+	hist, err := beginHistory(ctx, db, req.ProjectID, projectCode, req.Table, tableName, req.InstanceID, req.Actor)
+	if err != nil {
+		return 0, err
+	}
+	defer hist.rollback()
+	
+	if err := hist.reserveQuota(limits, len(req.Rows)); err != nil {
+		return 0, err
+	}
+	
+	ids, err := hist.insert(builder)
+	if err != nil {
+		return 0, err
+	}
+	if err := hist.recordInserted(ids); err != nil {
+		return 0, err
+	}
+	if err := hist.commitChange(models.HistoryInsert, ids, int64(len(ids)), nil); err != nil {
+		return 0, err
+	}
+	
+	log.Printf("✅ %d registros inseridos", len(req.Rows))
+	return len(req.Rows), nil
+}
+
+// columnNames extrai os nomes das colunas de uma row
+func columnNames(cols []models.Column) []string {
+	names := make([]string, len(cols))
+	for i, col := range cols {
+		names[i] = col.Name
+	}
+	return names
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"meu-provedor/config"
+	"meu-provedor/models"
+	projectService "meu-provedor/services/project"
+)
+
+// ============================================================================
+// QUERY GUARD - Timeout, cancelamento e teto de linhas das consultas
+// ============================================================================
+//
+// Toda operação de dados roda sob o contexto da requisição HTTP: se o cliente
+// desconecta, a query em andamento é cancelada. O guard acrescenta o timeout
+// de statement do projeto (QUERY_TIMEOUT_MS por padrão), repassado também ao
+// MySQL como hint MAX_EXECUTION_TIME, e o teto de linhas dos SELECTs
+// (QUERY_MAX_ROWS por padrão), que vale mesmo acima dos limites da instância.
+//
+// O guard também decide se os campos de SQL livre (where_raw, having, ...)
+// são aceitos: DATA_ALLOW_RAW_SQL=false os desliga para todos; chamadas com
+// X-API-Key só os usam se o projeto liberou (api_key_raw_sql).
+
+// queryGuard são os limites de execução efetivos de um projeto
+type queryGuard struct {
+	timeout      time.Duration
+	maxRows      int
+	apiKeyRawSQL bool
+	rawSQL       bool // SQL livre permitido para o chamador atual
+}
+
+// apiKeyCallerKey marca no contexto as requisições feitas com X-API-Key
+type apiKeyCallerKey struct{}
+
+// WithAPIKeyCaller marca o contexto como de uma chamada com API key
+func WithAPIKeyCaller(ctx context.Context) context.Context {
+	return context.WithValue(ctx, apiKeyCallerKey{}, true)
+}
+
+// isAPIKeyCaller indica se a requisição veio com API key
+func isAPIKeyCaller(ctx context.Context) bool {
+	marked, _ := ctx.Value(apiKeyCallerKey{}).(bool)
+	return marked
+}
+
+type cachedGuard struct {
+	guard   queryGuard
+	expires time.Time
+}
+
+var (
+	guardCacheMu sync.Mutex
+	guardCache   = map[int64]cachedGuard{}
+)
+
+const guardCacheTTL = time.Minute
+
+// guardQuery aplica o timeout do projeto ao contexto da requisição e marca o
+// contexto com projeto e instância (para o slow query log). finish libera o
+// contexto e converte o erro da operação em ErrQueryTimeout (504) ou
+// ErrQueryCanceled (408) quando o contexto terminou antes dela.
+func guardQuery(ctx context.Context, projectID, instanceID int64) (context.Context, queryGuard, func(error) error) {
+	guard := projectGuard(projectID)
+	guard.rawSQL = strings.ToLower(config.GetEnvOrDefault("DATA_ALLOW_RAW_SQL", "true")) != "false" &&
+		(!isAPIKeyCaller(ctx) || guard.apiKeyRawSQL)
+	ctx = withQueryTrace(ctx, projectID, instanceID)
+
+	cancel := context.CancelFunc(func() {})
+	if guard.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, guard.timeout)
+	}
+
+	finish := func(err error) error {
+		ctxErr := ctx.Err()
+		cancel()
+		if err == nil || ctxErr == nil {
+			return err
+		}
+		if ctxErr == context.DeadlineExceeded {
+			return models.ErrQueryTimeout.WithDetails(
+				fmt.Sprintf("consulta excedeu o tempo máximo de %s", guard.timeout),
+				map[string]interface{}{"timeout_ms": guard.timeout.Milliseconds()},
+			)
+		}
+		return fmt.Errorf("%w: %v", models.ErrQueryCanceled, ctxErr)
+	}
+	return ctx, guard, finish
+}
+
+// executionMs é o tempo máximo repassado ao servidor nos SELECTs
+func (g queryGuard) executionMs() int {
+	return int(g.timeout.Milliseconds())
+}
+
+// capRows aplica o teto de linhas do servidor ao LIMIT já limitado pela instância
+func (g queryGuard) capRows(limit int) int {
+	if g.maxRows <= 0 {
+		return limit
+	}
+	if limit <= 0 || limit > g.maxRows {
+		return g.maxRows
+	}
+	return limit
+}
+
+// checkRawSQL rejeita campos de SQL livre quando o chamador não pode usá-los
+func (g queryGuard) checkRawSQL(fields ...rawField) error {
+	if g.rawSQL {
+		return nil
+	}
+	for _, f := range fields {
+		if f.used {
+			return fmt.Errorf("%w: %s", models.ErrRawSQLNotAllowed, f.name)
+		}
+	}
+	return nil
+}
+
+// ForgetQueryGuard descarta os limites em cache do projeto (após alteração)
+func ForgetQueryGuard(projectID int64) {
+	guardCacheMu.Lock()
+	delete(guardCache, projectID)
+	guardCacheMu.Unlock()
+}
+
+// projectGuard carrega (do cache) os limites do projeto sobre os padrões do servidor
+func projectGuard(projectID int64) queryGuard {
+	guardCacheMu.Lock()
+	cached, ok := guardCache[projectID]
+	guardCacheMu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.guard
+	}
+
+	guard := queryGuard{
+		timeout: time.Duration(config.EnvInt("QUERY_TIMEOUT_MS", 30000)) * time.Millisecond,
+		maxRows: config.EnvInt("QUERY_MAX_ROWS", 10000),
+	}
+	limits, err := projectService.GetQueryLimits(projectID)
+	if err != nil {
+		log.Printf("⚠️ Erro ao carregar limites de consulta do projeto %d: %v", projectID, err)
+	} else if limits != nil {
+		if limits.StatementTimeoutMs > 0 {
+			guard.timeout = time.Duration(limits.StatementTimeoutMs) * time.Millisecond
+		}
+		if limits.MaxRows > 0 {
+			guard.maxRows = limits.MaxRows
+		}
+		guard.apiKeyRawSQL = limits.APIKeyRawSQL
+	}
+
+	guardCacheMu.Lock()
+	guardCache[projectID] = cachedGuard{guard: guard, expires: time.Now().Add(guardCacheTTL)}
+	guardCacheMu.Unlock()
+	return guard
+}
@@ -0,0 +1,252 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"meu-provedor/engine/query"
+	"meu-provedor/models"
+)
+
+// ============================================================================
+// QUERY SPECS - Validação de projeção, ordenação, agrupamento e JOINs
+// ============================================================================
+
+// aliasScope mantém as tabelas conhecidas de uma consulta, indexadas por alias
+type aliasScope struct {
+	db       *sql.DB // banco do projeto (schemas e consultas)
+	base     string
+	tables   map[string]tableSchema
+	logical  map[string]string // nome lógico da tabela -> alias
+	physical map[string]string // alias -> nome físico da tabela
+}
+
+func newAliasScope(db *sql.DB) *aliasScope {
+	return &aliasScope{
+		db:      db,
+		tables:   map[string]tableSchema{},
+		logical:  map[string]string{},
+		physical: map[string]string{},
+	}
+}
+
+// add registra uma tabela no escopo (a primeira é a tabela base)
+func (s *aliasScope) add(alias, fullTable, logicalName string) error {
+	if _, exists := s.tables[alias]; exists {
+		return models.NewValidationError(fmt.Sprintf("alias duplicado: %s", alias))
+	}
+
+	schema, err := loadTableSchema(s.db, fullTable)
+	if err != nil {
+		return err
+	}
+
+	s.tables[alias] = schema
+	s.physical[alias] = fullTable
+	if _, exists := s.logical[logicalName]; !exists {
+		s.logical[logicalName] = alias
+	}
+	if s.base == "" {
+		s.base = alias
+	}
+	return nil
+}
+
+// column resolve uma referência validando alias e existência da coluna
+func (s *aliasScope) column(ref models.ColumnRef) (string, error) {
+	alias, schema, err := s.lookup(ref.TableAlias)
+	if err != nil {
+		return "", err
+	}
+	if !query.IsValidColumnName(ref.Column) {
+		return "", fmt.Errorf("%w: %s", models.ErrInvalidColumn, ref.Column)
+	}
+	spec, ok := schema[ref.Column]
+	if !ok {
+		return "", models.ErrUnknownColumn.WithDetails(
+			fmt.Sprintf("coluna '%s' não existe em '%s'", ref.Column, alias),
+			map[string]interface{}{"column": ref.Column, "table_alias": alias},
+		)
+	}
+	if ref.Path != "" {
+		return jsonPathExpr(spec, query.ColumnExpr(alias, ref.Column), ref.Path)
+	}
+	return query.ColumnExpr(alias, ref.Column), nil
+}
+
+// filterKey resolve uma chave do mapa where: "coluna" ou "coluna->'$.caminho'"
+func (s *aliasScope) filterKey(key string) (string, error) {
+	if column, path, ok := query.ParseJSONPathKey(key); ok {
+		return s.column(models.ColumnRef{Column: column, Path: path})
+	}
+	if !query.IsValidColumnName(key) {
+		return "", fmt.Errorf("%w: %s", models.ErrInvalidColumn, key)
+	}
+	return query.QuoteIdent(key), nil
+}
+
+// filter renderiza uma comparação estruturada e seus argumentos
+func (s *aliasScope) filter(spec models.FilterSpec) (string, []interface{}, error) {
+	col, err := s.column(spec.ColumnRef)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var args []interface{}
+	switch strings.ToLower(strings.TrimSpace(spec.Op)) {
+	case "is_null", "not_null":
+	case "in", "not_in":
+		list, ok := spec.Value.([]interface{})
+		if !ok {
+			return "", nil, fmt.Errorf("%w: %s exige uma lista", models.ErrInvalidFilter, spec.Op)
+		}
+		args = list
+	default:
+		args = []interface{}{spec.Value}
+	}
+
+	expr, err := query.ComparisonExpr(col, spec.Op, len(args))
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: %v", models.ErrInvalidFilter, err)
+	}
+	return expr, args, nil
+}
+
+// projection renderiza as colunas do SELECT ("*" seleciona todas do alias)
+func (s *aliasScope) projection(specs []models.ProjectionSpec) ([]string, error) {
+	cols := make([]string, 0, len(specs))
+	for _, spec := range specs {
+		if spec.Alias != "" && !query.IsValidIdentifier(spec.Alias) {
+			return nil, fmt.Errorf("%w: %s", models.ErrInvalidIdentifier, spec.Alias)
+		}
+
+		if spec.Column == "*" {
+			alias, _, err := s.lookup(spec.TableAlias)
+			if err != nil {
+				return nil, err
+			}
+			cols = append(cols, query.QuoteIdent(alias)+".*")
+			continue
+		}
+
+		expr, err := s.column(spec.ColumnRef)
+		if err != nil {
+			return nil, err
+		}
+		if spec.Alias != "" {
+			expr += " AS " + query.QuoteIdent(spec.Alias)
+		} else if spec.Path != "" {
+			expr += " AS " + query.QuoteIdent(query.JSONPathAlias(spec.Column, spec.Path))
+		}
+		cols = append(cols, expr)
+	}
+	return cols, nil
+}
+
+// sort renderiza o ORDER BY estruturado
+func (s *aliasScope) sort(specs []models.SortSpec) (string, error) {
+	var parts []string
+	for _, spec := range specs {
+		col, err := s.column(spec.ColumnRef)
+		if err != nil {
+			return "", err
+		}
+		exprs, err := query.SortExprs(col, spec.Direction, spec.Nulls)
+		if err != nil {
+			return "", fmt.Errorf("%w: %v", models.ErrInvalidSort, err)
+		}
+		parts = append(parts, exprs...)
+	}
+	return strings.Join(parts, ", "), nil
+}
+
+// group renderiza o GROUP BY estruturado
+func (s *aliasScope) group(refs []models.ColumnRef) (string, error) {
+	parts := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		col, err := s.column(ref)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, col)
+	}
+	return strings.Join(parts, ", "), nil
+}
+
+// joinOn renderiza as condições de igualdade de um JOIN
+func (s *aliasScope) joinOn(conditions []models.JoinCondition) (string, error) {
+	pairs := make([][2]string, 0, len(conditions))
+	for _, cond := range conditions {
+		left, err := s.column(cond.Left)
+		if err != nil {
+			return "", err
+		}
+		right, err := s.column(cond.Right)
+		if err != nil {
+			return "", err
+		}
+		pairs = append(pairs, [2]string{left, right})
+	}
+	return query.EqualityOn(pairs), nil
+}
+
+func (s *aliasScope) lookup(alias string) (string, tableSchema, error) {
+	if alias == "" {
+		alias = s.base
+	}
+	if schema, ok := s.tables[alias]; ok {
+		return alias, schema, nil
+	}
+	if resolved, ok := s.logical[alias]; ok {
+		return resolved, s.tables[resolved], nil
+	}
+	return "", nil, fmt.Errorf("%w: %s", models.ErrUnknownAlias, alias)
+}
+
+// jsonPathExpr valida coluna JSON e caminho, e renderiza a extração
+func jsonPathExpr(spec columnSpec, column, path string) (string, error) {
+	if spec.base != "json" {
+		return "", fmt.Errorf("%w: coluna '%s' não é JSON", models.ErrInvalidJSONPath, spec.detail.Name)
+	}
+	if !query.IsValidJSONPath(path) {
+		return "", fmt.Errorf("%w: %s", models.ErrInvalidJSONPath, path)
+	}
+	return query.JSONExtractExpr(column, path), nil
+}
+
+// withDefaultAlias preenche table_alias vazio com o alias da tabela dona da projeção
+func withDefaultAlias(specs []models.ProjectionSpec, alias string) []models.ProjectionSpec {
+	out := make([]models.ProjectionSpec, len(specs))
+	for i, spec := range specs {
+		if spec.TableAlias == "" {
+			spec.TableAlias = alias
+		}
+		out[i] = spec
+	}
+	return out
+}
+
+// isValidColumnRef aceita "coluna" ou "alias.coluna"
+func isValidColumnRef(ref string) bool {
+	parts := strings.Split(ref, ".")
+	if len(parts) > 2 {
+		return false
+	}
+	for _, part := range parts {
+		if !query.IsValidIdentifier(part) {
+			return false
+		}
+	}
+	return true
+}
+
+// ============================================================================
+// RAW SQL
+// ============================================================================
+
+// rawField indica se um campo de SQL livre foi usado na requisição
+type rawField struct {
+	name string
+	used bool
+}
@@ -0,0 +1,273 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"meu-provedor/config"
+	"meu-provedor/engine/dialect"
+	"meu-provedor/engine/query"
+	"meu-provedor/models"
+)
+
+// ============================================================================
+// QUOTA SERVICE - Cotas e limites por instância
+// ============================================================================
+
+// DefaultInstanceLimits retorna os limites padrão do servidor
+// (DEFAULT_MAX_RESULT_SIZE e DEFAULT_MAX_BATCH_SIZE, 0 = ilimitado)
+func DefaultInstanceLimits() models.InstanceLimits {
+	return models.InstanceLimits{
+		MaxResultSize: config.EnvInt("DEFAULT_MAX_RESULT_SIZE", 1000),
+		MaxBatchSize:  config.EnvInt("DEFAULT_MAX_BATCH_SIZE", 1000),
+	}
+}
+
+// GetInstanceLimits retorna os limites da instância (cache de 1 minuto)
+func GetInstanceLimits(instanceID int64) (models.InstanceLimits, error) {
+	limitsMu.Lock()
+	cached, ok := limitsCache[instanceID]
+	limitsMu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.limits, nil
+	}
+
+	limits, err := loadInstanceLimits(instanceID)
+	if err != nil {
+		return DefaultInstanceLimits(), err
+	}
+
+	limitsMu.Lock()
+	limitsCache[instanceID] = cachedLimits{limits: limits, expires: time.Now().Add(limitsCacheTTL)}
+	limitsMu.Unlock()
+	return limits, nil
+}
+
+// ForgetInstanceLimits descarta os limites em cache da instância (após alteração)
+func ForgetInstanceLimits(instanceID int64) {
+	limitsMu.Lock()
+	delete(limitsCache, instanceID)
+	limitsMu.Unlock()
+}
+
+// ClampLimit aplica o tamanho máximo de resultado ao LIMIT do SELECT
+func ClampLimit(limit int, limits models.InstanceLimits) int {
+	if limits.MaxResultSize <= 0 {
+		return limit
+	}
+	if limit <= 0 || limit > limits.MaxResultSize {
+		return limits.MaxResultSize
+	}
+	return limit
+}
+
+// CheckBatchSize rejeita lotes maiores que o permitido
+func CheckBatchSize(size int, limits models.InstanceLimits) error {
+	if limits.MaxBatchSize > 0 && size > limits.MaxBatchSize {
+		return fmt.Errorf("%w: %d linhas (máximo %d)", models.ErrBatchTooLarge, size, limits.MaxBatchSize)
+	}
+	return nil
+}
+
+// reserveQuota verifica as cotas da instância dentro da transação da escrita.
+// A linha da instância em {code}__quota é travada antes da contagem, então
+// inserções concorrentes da mesma instância esperam o commit da anterior e
+// contam as linhas dela.
+func (h *historyWriter) reserveQuota(limits models.InstanceLimits, newRows int) error {
+	if limits.MaxRowsPerTable <= 0 && limits.MaxTotalRows <= 0 {
+		return nil
+	}
+
+	// DDL fora da transação (no MySQL faria commit implícito)
+	table, err := ensureQuotaTable(h.db, h.projectCode)
+	if err != nil {
+		return err
+	}
+	h.quotaTable = table
+	if err := config.MarkTableWrite(h.projectID, table); err != nil {
+		return err
+	}
+	if err := h.begin(); err != nil {
+		return err
+	}
+
+	lock := h.dialect.Upsert(query.QuoteIdent(table), []string{"id_instancia", "locked_at"}, []string{"id_instancia"}, []string{"locked_at"})
+	if _, err := h.exec(lock, h.instanceID, time.Now().UTC()); err != nil {
+		return wrapDBError(models.ErrInsertFailed, err, h.projectCode)
+	}
+
+	if limits.MaxRowsPerTable > 0 {
+		count, err := h.countInstanceRows(h.fullTable)
+		if err != nil {
+			return err
+		}
+		if count+int64(newRows) > limits.MaxRowsPerTable {
+			return fmt.Errorf("%w: tabela %s atingiu %d de %d linhas",
+				models.ErrQuotaExceeded, h.table, count, limits.MaxRowsPerTable)
+		}
+	}
+
+	if limits.MaxTotalRows > 0 {
+		tables, err := listQuotaTables(h.tx, h.dialect, h.projectCode)
+		if err != nil {
+			return err
+		}
+
+		var total int64
+		for _, t := range tables {
+			count, err := h.countInstanceRows(t)
+			if err != nil {
+				return err
+			}
+			total += count
+		}
+
+		if total+int64(newRows) > limits.MaxTotalRows {
+			return fmt.Errorf("%w: instância atingiu %d de %d linhas",
+				models.ErrQuotaExceeded, total, limits.MaxTotalRows)
+		}
+	}
+
+	return nil
+}
+
+// ============================================================================
+// INTERNAL HELPERS
+// ============================================================================
+
+type cachedLimits struct {
+	limits  models.InstanceLimits
+	expires time.Time
+}
+
+var (
+	limitsMu    sync.Mutex
+	limitsCache = map[int64]cachedLimits{}
+
+	quotaTablesMu sync.Mutex
+	quotaTables   = map[*sql.DB]map[string]bool{}
+)
+
+const limitsCacheTTL = time.Minute
+
+// loadInstanceLimits lê settings.limits da instância sobre os padrões do servidor
+func loadInstanceLimits(instanceID int64) (models.InstanceLimits, error) {
+	limits := DefaultInstanceLimits()
+
+	var settings []byte
+	err := config.MasterDB.QueryRow(
+		"SELECT settings FROM instancias_projetion WHERE id = ? LIMIT 1",
+		instanceID,
+	).Scan(&settings)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return limits, nil
+		}
+		return limits, fmt.Errorf("erro ao buscar limites da instância: %w", err)
+	}
+
+	if len(settings) == 0 {
+		return limits, nil
+	}
+
+	var parsed struct {
+		Limits models.InstanceLimits `json:"limits"`
+	}
+	if err := json.Unmarshal(settings, &parsed); err != nil {
+		// Sem os limites configurados a instância ficaria sem cota
+		return limits, fmt.Errorf("%w: instância %d: %v", models.ErrInstanceSettings, instanceID, err)
+	}
+
+	// Sobrescrever apenas os valores configurados
+	custom := parsed.Limits
+	if custom.MaxRowsPerTable > 0 {
+		limits.MaxRowsPerTable = custom.MaxRowsPerTable
+	}
+	if custom.MaxTotalRows > 0 {
+		limits.MaxTotalRows = custom.MaxTotalRows
+	}
+	if custom.MaxRequestsPerMinute > 0 {
+		limits.MaxRequestsPerMinute = custom.MaxRequestsPerMinute
+	}
+	if custom.MaxResultSize > 0 {
+		limits.MaxResultSize = custom.MaxResultSize
+	}
+	if custom.MaxBatchSize > 0 {
+		limits.MaxBatchSize = custom.MaxBatchSize
+	}
+
+	return limits, nil
+}
+
+// quotaTableName retorna a tabela de travas de cota do projeto ("__": interna)
+func quotaTableName(projectCode string) string {
+	return projectCode + "__quota"
+}
+
+// ensureQuotaTable cria (uma vez por pool) a tabela de travas de cota
+func ensureQuotaTable(db *sql.DB, projectCode string) (string, error) {
+	table := quotaTableName(projectCode)
+
+	quotaTablesMu.Lock()
+	ready := quotaTables[db][table]
+	quotaTablesMu.Unlock()
+	if ready {
+		return table, nil
+	}
+
+	d := config.DialectOf(db)
+	statements, err := d.CreateTable(table, true, []string{
+		"id_instancia " + d.BigIntType() + " NOT NULL PRIMARY KEY",
+		"locked_at " + d.DateTimeType() + " NULL",
+	}, nil)
+	if err != nil {
+		return "", err
+	}
+	for _, stmt := range statements {
+		if _, err := db.Exec(stmt); err != nil {
+			return "", fmt.Errorf("erro ao criar tabela de cotas: %w", err)
+		}
+	}
+
+	quotaTablesMu.Lock()
+	if quotaTables[db] == nil {
+		quotaTables[db] = map[string]bool{}
+	}
+	quotaTables[db][table] = true
+	quotaTablesMu.Unlock()
+	return table, nil
+}
+
+// countInstanceRows conta as linhas da instância na tabela (na transação)
+func (h *historyWriter) countInstanceRows(table string) (int64, error) {
+	var count int64
+	sqlQuery := dialect.Rebind(h.dialect, fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE id_instancia = ?", query.QuoteIdent(table)))
+	if err := h.tx.QueryRowContext(h.ctx, sqlQuery, h.instanceID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("erro ao contar linhas de %s: %w", table, err)
+	}
+	return count, nil
+}
+
+// listQuotaTables lista as tabelas de dados do projeto. Tabelas internas
+// ({code}__history, {code}__events, {code}__quota) e resumos materializados
+// ({code}_mv_*) não contam para a cota.
+func listQuotaTables(q dialect.Queryer, d dialect.Dialect, projectCode string) ([]string, error) {
+	all, err := d.Tables(q, projectCode+"_")
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar tabelas do projeto: %w", err)
+	}
+
+	var tables []string
+	for _, table := range all {
+		name := strings.TrimPrefix(table, projectCode+"_")
+		if strings.HasPrefix(name, "_") || strings.HasPrefix(name, models.SummaryPrefix) {
+			continue
+		}
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
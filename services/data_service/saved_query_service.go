@@ -0,0 +1,52 @@
+package services
+
+import (
+	"context"
+
+	"meu-provedor/models"
+	projectService "meu-provedor/services/project"
+)
+
+// ============================================================================
+// SAVED QUERY SERVICE - Execução de consultas nomeadas
+// ============================================================================
+
+// ExecuteSavedQuery executa a consulta nomeada com os parâmetros informados.
+// O id_instancia é sempre aplicado à tabela base e a todos os JOINs. Com
+// approvedOnly (chamadas com API key), só versões aprovadas são executadas.
+func ExecuteSavedQuery(ctx context.Context, name string, req models.RunSavedQueryRequest, approvedOnly bool) ([]map[string]interface{}, *models.SavedQuery, error) {
+	if req.ProjectID <= 0 {
+		return nil, nil, models.ErrInvalidProjectID
+	}
+	if req.InstanceID <= 0 {
+		return nil, nil, models.ErrInvalidInstanceID
+	}
+
+	saved, err := projectService.GetSavedQuery(req.ProjectID, name, req.Version, approvedOnly)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	joinReq, err := projectService.BindSavedQuery(saved, req.Params)
+	if err != nil {
+		return nil, nil, err
+	}
+	joinReq.ProjectID = req.ProjectID
+	joinReq.InstanceID = req.InstanceID
+	joinReq.Consistency = req.Consistency
+	if req.Limit > 0 {
+		joinReq.Limit = req.Limit
+	}
+	if req.Offset > 0 {
+		joinReq.Offset = req.Offset
+	}
+
+	// O SQL livre da consulta vem da definição cadastrada, não do chamador
+	ctx = context.WithValue(ctx, apiKeyCallerKey{}, false)
+
+	result, err := executeAdvancedJoinSelect(ctx, joinReq, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	return result, saved, nil
+}
@@ -0,0 +1,498 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"meu-provedor/models"
+	tableService "meu-provedor/services/table"
+)
+
+// ============================================================================
+// SCHEMA VALIDATION - Validação e coerção de valores antes da escrita
+// ============================================================================
+
+// columnSpec é a forma interpretada de COLUMN_TYPE
+type columnSpec struct {
+	detail   models.ColumnDetail
+	base     string   // int, varchar, enum, ...
+	length   int      // tamanho máximo (varchar/char/text)
+	unsigned bool
+	enum     []string // valores permitidos (enum)
+	// precisão e escala (decimal/numeric); precision 0 = sem limite declarado
+	precision int
+	scale     int
+}
+
+// tableSchema é o conjunto de colunas de uma tabela física
+type tableSchema map[string]columnSpec
+
+// serviceManagedColumns são preenchidas pelo próprio service
+var serviceManagedColumns = map[string]bool{
+	"id_instancia": true,
+}
+
+var (
+	reColumnType = regexp.MustCompile(`^(\w+)(?:\((.*)\))?(.*)$`)
+	reDecimal    = regexp.MustCompile(`^[+-]?(\d+\.?\d*|\.\d+)([eE][+-]?\d{1,3})?$`)
+)
+
+// loadTableSchema carrega (do cache) as colunas da tabela física
+func loadTableSchema(db *sql.DB, fullTable string) (tableSchema, error) {
+	columns, err := tableService.CachedColumns(db, fullTable)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao carregar schema de %s: %w", fullTable, err)
+	}
+	if len(columns) == 0 {
+		return nil, models.ErrTableNotFound.WithDetails(
+			fmt.Sprintf("tabela '%s' não existe", fullTable), nil)
+	}
+
+	schema := tableSchema{}
+	for _, col := range columns {
+		schema[col.Name] = parseColumnSpec(col)
+	}
+	return schema, nil
+}
+
+// validateInsertRow valida uma linha de INSERT, exigindo colunas NOT NULL sem default.
+// Retorna os valores convertidos na mesma ordem de cols.
+func (s tableSchema) validateInsertRow(cols []models.Column, row *int) ([]interface{}, []models.FieldError) {
+	values, errs := s.validateColumns(cols, row)
+
+	provided := map[string]bool{}
+	for _, col := range cols {
+		provided[col.Name] = true
+	}
+
+	for name, spec := range s {
+		if provided[name] || serviceManagedColumns[name] || !spec.required() {
+			continue
+		}
+		errs = append(errs, models.FieldError{
+			Column:  name,
+			Code:    "REQUIRED",
+			Message: fmt.Sprintf("coluna '%s' é obrigatória", name),
+			Row:     row,
+		})
+	}
+
+	return values, errs
+}
+
+// validateUpdateData valida os valores de um UPDATE (apenas colunas informadas)
+func (s tableSchema) validateUpdateData(data map[string]interface{}) (map[string]interface{}, []models.FieldError) {
+	cols := make([]models.Column, 0, len(data))
+	for name, value := range data {
+		cols = append(cols, models.Column{Name: name, Value: value})
+	}
+
+	values, errs := s.validateColumns(cols, nil)
+
+	coerced := make(map[string]interface{}, len(cols))
+	for i, col := range cols {
+		coerced[col.Name] = values[i]
+	}
+	return coerced, errs
+}
+
+func (s tableSchema) validateColumns(cols []models.Column, row *int) ([]interface{}, []models.FieldError) {
+	values := make([]interface{}, len(cols))
+	var errs []models.FieldError
+
+	for i, col := range cols {
+		spec, ok := s[col.Name]
+		if !ok {
+			errs = append(errs, models.FieldError{
+				Column:  col.Name,
+				Code:    "UNKNOWN_COLUMN",
+				Message: fmt.Sprintf("coluna '%s' não existe", col.Name),
+				Row:     row,
+			})
+			continue
+		}
+
+		value, fieldErr := spec.coerce(col.Value)
+		if fieldErr != nil {
+			fieldErr.Column = col.Name
+			fieldErr.Row = row
+			if fieldErr.Code != "NOT_NULL_VIOLATION" {
+				fieldErr.Value = col.Value
+			}
+			errs = append(errs, *fieldErr)
+			continue
+		}
+		values[i] = value
+	}
+
+	return values, errs
+}
+
+// schemaValidationError agrupa todos os erros de campo em um único AppError
+func schemaValidationError(errs []models.FieldError) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return models.ErrSchemaValidation.WithDetails(
+		fmt.Sprintf("%s (%d erro(s))", models.ErrSchemaValidation.Message, len(errs)),
+		map[string]interface{}{"errors": errs},
+	)
+}
+
+// ============================================================================
+// COLUMN SPEC
+// ============================================================================
+
+func parseColumnSpec(col models.ColumnDetail) columnSpec {
+	spec := columnSpec{detail: col}
+
+	m := reColumnType.FindStringSubmatch(strings.TrimSpace(col.Type))
+	if m == nil {
+		spec.base = strings.ToLower(col.Type)
+		return spec
+	}
+
+	spec.base = strings.ToLower(m[1])
+	spec.unsigned = strings.Contains(strings.ToLower(m[3]), "unsigned")
+
+	switch spec.base {
+	case "enum", "set":
+		spec.enum = parseEnumValues(m[2])
+	case "decimal", "numeric":
+		params := strings.SplitN(m[2], ",", 2)
+		spec.precision, _ = strconv.Atoi(strings.TrimSpace(params[0]))
+		if len(params) == 2 {
+			spec.scale, _ = strconv.Atoi(strings.TrimSpace(params[1]))
+		}
+	case "varchar", "char", "varbinary", "binary":
+		spec.length, _ = strconv.Atoi(m[2])
+	case "tinytext", "tinyblob":
+		spec.length = 255
+	case "text", "blob":
+		spec.length = 65535
+	case "mediumtext", "mediumblob":
+		spec.length = 16777215
+	}
+
+	return spec
+}
+
+// required indica coluna NOT NULL sem default e sem valor automático
+func (c columnSpec) required() bool {
+	extra := strings.ToLower(c.detail.Extra)
+	return !c.detail.Nullable &&
+		c.detail.Default == nil &&
+		!strings.Contains(extra, "auto_increment") &&
+		!strings.Contains(extra, "generated")
+}
+
+// integer indica coluna de tipo inteiro
+func (c columnSpec) integer() bool {
+	switch c.base {
+	case "tinyint", "smallint", "mediumint", "int", "integer", "bigint", "year":
+		return true
+	}
+	return false
+}
+
+// numeric indica coluna numérica (inteira ou decimal)
+func (c columnSpec) numeric() bool {
+	switch c.base {
+	case "decimal", "numeric", "float", "double", "real":
+		return true
+	}
+	return c.integer()
+}
+
+// temporal indica coluna de data/hora
+func (c columnSpec) temporal() bool {
+	switch c.base {
+	case "date", "datetime", "timestamp", "time":
+		return true
+	}
+	return false
+}
+
+// coerce valida o valor e converte para o tipo esperado pela coluna
+func (c columnSpec) coerce(value interface{}) (interface{}, *models.FieldError) {
+	if value == nil {
+		if !c.detail.Nullable {
+			return nil, &models.FieldError{
+				Code:    "NOT_NULL_VIOLATION",
+				Message: "coluna não aceita valor nulo",
+			}
+		}
+		return nil, nil
+	}
+
+	switch c.base {
+	case "tinyint", "smallint", "mediumint", "int", "integer", "bigint", "year":
+		n, ok := toInt64(value)
+		if !ok {
+			return nil, c.mismatch("inteiro")
+		}
+		if c.unsigned && n < 0 {
+			return nil, &models.FieldError{Code: "VALUE_OUT_OF_RANGE", Message: "valor não pode ser negativo"}
+		}
+		return n, nil
+
+	case "decimal", "numeric":
+		return c.decimal(value)
+
+	case "float", "double", "real":
+		f, ok := toFloat64(value)
+		if !ok {
+			return nil, c.mismatch("número")
+		}
+		return f, nil
+
+	case "bit", "bool", "boolean":
+		if b, ok := value.(bool); ok {
+			if b {
+				return 1, nil
+			}
+			return 0, nil
+		}
+		n, ok := toInt64(value)
+		if !ok {
+			return nil, c.mismatch("booleano")
+		}
+		return n, nil
+
+	case "date", "datetime", "timestamp", "time":
+		str, ok := value.(string)
+		if !ok {
+			return nil, c.mismatch("data (string)")
+		}
+		t, ok := parseTemporal(str, c.base)
+		if !ok {
+			return nil, c.mismatch("data válida")
+		}
+		return t, nil
+
+	case "json":
+		if str, ok := value.(string); ok && json.Valid([]byte(str)) {
+			return str, nil
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil, c.mismatch("JSON")
+		}
+		return string(encoded), nil
+
+	case "enum":
+		str := fmt.Sprint(value)
+		for _, allowed := range c.enum {
+			if str == allowed {
+				return str, nil
+			}
+		}
+		return nil, &models.FieldError{
+			Code:    "INVALID_ENUM_VALUE",
+			Message: fmt.Sprintf("valor deve ser um de: %s", strings.Join(c.enum, ", ")),
+		}
+
+	case "char", "varchar", "tinytext", "text", "mediumtext", "longtext":
+		str, ok := toText(value)
+		if !ok {
+			return nil, c.mismatch("texto")
+		}
+		if c.length > 0 && c.textLength(str) > c.length {
+			return nil, &models.FieldError{
+				Code:    "VALUE_TOO_LONG",
+				Message: fmt.Sprintf("valor excede o tamanho máximo de %d", c.length),
+			}
+		}
+		return str, nil
+	}
+
+	// Demais tipos (blob, set, spatial...) seguem sem conversão
+	return value, nil
+}
+
+// decimal valida o valor contra a precisão e a escala da coluna e o retorna
+// como texto exato (sem passar por float64)
+func (c columnSpec) decimal(value interface{}) (string, *models.FieldError) {
+	r, ok := toRat(value)
+	if !ok {
+		return "", c.mismatch("número decimal")
+	}
+	if c.unsigned && r.Sign() < 0 {
+		return "", &models.FieldError{Code: "VALUE_OUT_OF_RANGE", Message: "valor não pode ser negativo"}
+	}
+	if c.precision <= 0 {
+		return decimalText(r), nil
+	}
+
+	// Casas decimais além da escala seriam arredondadas pelo banco
+	shifted := new(big.Rat).Mul(r, new(big.Rat).SetInt(pow10(c.scale)))
+	if !shifted.IsInt() {
+		return "", &models.FieldError{
+			Code:    "VALUE_OUT_OF_RANGE",
+			Message: fmt.Sprintf("valor excede %d casa(s) decimal(is)", c.scale),
+		}
+	}
+
+	limit := new(big.Rat).SetInt(pow10(c.precision - c.scale))
+	if new(big.Rat).Abs(r).Cmp(limit) >= 0 {
+		return "", &models.FieldError{
+			Code:    "VALUE_OUT_OF_RANGE",
+			Message: fmt.Sprintf("valor excede %d dígito(s) inteiro(s)", c.precision-c.scale),
+		}
+	}
+	return r.FloatString(c.scale), nil
+}
+
+// textLength mede em caracteres (char/varchar) ou bytes (text)
+func (c columnSpec) textLength(s string) int {
+	if c.base == "char" || c.base == "varchar" {
+		return utf8.RuneCountInString(s)
+	}
+	return len(s)
+}
+
+func (c columnSpec) mismatch(expected string) *models.FieldError {
+	return &models.FieldError{
+		Code:    "TYPE_MISMATCH",
+		Message: fmt.Sprintf("valor deve ser %s (coluna %s)", expected, c.detail.Type),
+	}
+}
+
+// ============================================================================
+// INTERNAL HELPERS
+// ============================================================================
+
+func parseEnumValues(def string) []string {
+	var values []string
+	for _, part := range strings.Split(def, "','") {
+		part = strings.Trim(part, "'")
+		values = append(values, strings.ReplaceAll(part, "''", "'"))
+	}
+	return values
+}
+
+func toInt64(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case float64:
+		if v != math.Trunc(v) {
+			return 0, false
+		}
+		return int64(v), true
+	case int:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint64:
+		return int64(v), v <= math.MaxInt64
+	case json.Number:
+		n, err := v.Int64()
+		return n, err == nil
+	case string:
+		n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+		return n, err == nil
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+func toFloat64(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// toRat interpreta o valor como número decimal exato. Valores float64 (JSON
+// decodificado sem UseNumber) usam a menor representação que volta ao mesmo float.
+func toRat(value interface{}) (*big.Rat, bool) {
+	var text string
+	switch v := value.(type) {
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, false
+		}
+		text = strconv.FormatFloat(v, 'f', -1, 64)
+	case int:
+		text = strconv.Itoa(v)
+	case int64:
+		text = strconv.FormatInt(v, 10)
+	case json.Number:
+		text = v.String()
+	case string:
+		text = strings.TrimSpace(v)
+	default:
+		return nil, false
+	}
+
+	// Só notação decimal (sem frações, prefixos de base ou expoentes enormes)
+	if !reDecimal.MatchString(text) {
+		return nil, false
+	}
+	return new(big.Rat).SetString(text)
+}
+
+// decimalText formata o número com as casas decimais necessárias (exato)
+func decimalText(r *big.Rat) string {
+	for scale := 0; ; scale++ {
+		shifted := new(big.Rat).Mul(r, new(big.Rat).SetInt(pow10(scale)))
+		if shifted.IsInt() {
+			return r.FloatString(scale)
+		}
+	}
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+func toText(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case int, int64, bool, json.Number:
+		return fmt.Sprint(v), true
+	}
+	return "", false
+}
+
+func parseTemporal(value, base string) (interface{}, bool) {
+	if base == "time" {
+		if _, err := time.Parse("15:04:05", value); err == nil {
+			return value, true
+		}
+		return nil, false
+	}
+
+	layouts := []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02"}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+
+	"meu-provedor/models"
+)
+
+func TestCoerceDecimal(t *testing.T) {
+	spec := parseColumnSpec(models.ColumnDetail{Name: "preco", Type: "decimal(10,2) unsigned", Nullable: true})
+
+	tests := []struct {
+		name    string
+		value   interface{}
+		want    string
+		wantErr string
+	}{
+		{"texto exato", "12345678.91", "12345678.91", ""},
+		{"json.Number", json.Number("0.10"), "0.10", ""},
+		{"float64 curto", 19.9, "19.90", ""},
+		{"inteiro", int64(7), "7.00", ""},
+		{"expoente", "1.5e2", "150.00", ""},
+		{"escala excedida", "1.005", "", "VALUE_OUT_OF_RANGE"},
+		{"precisão excedida", "123456789.00", "", "VALUE_OUT_OF_RANGE"},
+		{"negativo em unsigned", "-1", "", "VALUE_OUT_OF_RANGE"},
+		{"fração", "1/3", "", "TYPE_MISMATCH"},
+		{"hexadecimal", "0x1p-2", "", "TYPE_MISMATCH"},
+		{"texto", "abc", "", "TYPE_MISMATCH"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, fieldErr := spec.coerce(tt.value)
+			if tt.wantErr != "" {
+				if fieldErr == nil || fieldErr.Code != tt.wantErr {
+					t.Fatalf("coerce(%v) = %v, %v; esperava %s", tt.value, got, fieldErr, tt.wantErr)
+				}
+				return
+			}
+			if fieldErr != nil {
+				t.Fatalf("coerce(%v) erro inesperado: %v", tt.value, fieldErr.Message)
+			}
+			if got != tt.want {
+				t.Errorf("coerce(%v) = %#v, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCoerceDecimalWithoutPrecision(t *testing.T) {
+	spec := parseColumnSpec(models.ColumnDetail{Name: "valor", Type: "numeric"})
+
+	got, fieldErr := spec.coerce("123456789012345678901234567890.123456789")
+	if fieldErr != nil {
+		t.Fatalf("erro inesperado: %v", fieldErr.Message)
+	}
+	if got != "123456789012345678901234567890.123456789" {
+		t.Errorf("valor alterado: %v", got)
+	}
+}
@@ -0,0 +1,206 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+
+	"meu-provedor/config"
+	"meu-provedor/engine/query"
+	"meu-provedor/models"
+)
+
+// ============================================================================
+// SEARCH SERVICE - Busca full-text (MATCH ... AGAINST)
+// ============================================================================
+
+// SearchResult é o resultado paginado de uma busca full-text
+type SearchResult struct {
+	Rows  []map[string]interface{}
+	Total int64
+	Limit int
+}
+
+// scoreColumn é o nome da coluna de relevância no resultado
+const scoreColumn = "_score"
+
+// highlightsField é o campo com os trechos destacados de cada linha
+const highlightsField = "_highlights"
+
+// ExecuteSearch executa uma busca full-text sobre colunas com índice FULLTEXT
+func ExecuteSearch(ctx context.Context, req models.SearchRequest) (result *SearchResult, err error) {
+	// Validar requisição
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	// Timeout e cancelamento da consulta
+	ctx, guard, finish := guardQuery(ctx, req.ProjectID, req.InstanceID)
+	defer func() { err = finish(err) }()
+
+	// Obter código do projeto
+	projectCode, err := GetProjectCodeByID(req.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	db, err := readDB(req.ProjectID, req.Consistency)
+	if err != nil {
+		return nil, err
+	}
+
+	// Construir nome físico da tabela
+	table, err := BuildTableName(projectCode, req.Table)
+	if err != nil {
+		return nil, err
+	}
+
+	limits, err := GetInstanceLimits(req.InstanceID)
+	if err != nil {
+		return nil, err
+	}
+
+	scope := newAliasScope(db)
+	if err := scope.add(table, table, req.Table); err != nil {
+		return nil, err
+	}
+
+	// Colunas do MATCH (devem corresponder a um índice FULLTEXT)
+	matchCols := make([]string, len(req.Columns))
+	for i, col := range req.Columns {
+		if matchCols[i], err = scope.column(models.ColumnRef{Column: col}); err != nil {
+			return nil, err
+		}
+	}
+	match, err := query.MatchAgainst(matchCols, req.Mode)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", models.ErrInvalidSearchMode, req.Mode)
+	}
+
+	// Total de resultados (para paginação)
+	countBuilder := query.NewSelect(table, table).SetColumns([]string{"COUNT(*)"}).
+		SetDialect(config.DialectOf(db)).
+		SetMaxExecutionTime(guard.executionMs())
+	if err := applySearchFilters(countBuilder, scope, table, match, req); err != nil {
+		return nil, err
+	}
+	var total int64
+	if err := scanRow(ctx, db, &total, countBuilder.Build(), countBuilder.GetValues()...); err != nil {
+		return nil, wrapDBError(models.ErrQueryFailed, err, projectCode)
+	}
+
+	// SELECT com relevância
+	cols := []string{table + ".*"}
+	if len(req.Projection) > 0 {
+		if cols, err = scope.projection(req.Projection); err != nil {
+			return nil, err
+		}
+	}
+	cols = append(cols, match+" AS "+scoreColumn)
+
+	builder := query.NewSelect(table, table).SetColumns(cols).
+		SetDialect(config.DialectOf(db)).
+		SetMaxExecutionTime(guard.executionMs())
+	if err := applySearchFilters(builder, scope, table, match, req); err != nil {
+		return nil, err
+	}
+	builder.SetOrderBy(scoreColumn + " DESC")
+
+	limit := guard.capRows(ClampLimit(req.Limit, limits))
+	if limit > 0 {
+		builder.SetLimitOffset(limit, req.Offset)
+	}
+
+	// O placeholder do score (no SELECT) vem antes dos filtros
+	values := append([]interface{}{req.Query}, builder.GetValues()...)
+	found, err := queryMaps(ctx, db, builder.Build(), values...)
+	if err != nil {
+		return nil, wrapDBError(models.ErrQueryFailed, err, projectCode)
+	}
+
+	if req.Highlight {
+		highlightRows(found, req.Columns, searchTerms(req.Query, req.Mode))
+	}
+
+	return &SearchResult{Rows: found, Total: total, Limit: limit}, nil
+}
+
+// applySearchFilters aplica id_instancia, o MATCH e os filtros simples
+func applySearchFilters(builder *query.SelectBuilder, scope *aliasScope, table, match string, req models.SearchRequest) error {
+	builder.AddWhereEq(table+".id_instancia", req.InstanceID)
+	builder.AddWhere(match, req.Query)
+
+	for col, val := range req.Where {
+		expr, err := scope.column(models.ColumnRef{Column: col})
+		if err != nil {
+			return err
+		}
+		builder.AddWhereEq(expr, val)
+	}
+	return nil
+}
+
+// ============================================================================
+// HIGHLIGHTS
+// ============================================================================
+
+// reBooleanOperators remove os operadores do modo boolean (+ - < > ~ * " ( ))
+var reBooleanOperators = regexp.MustCompile(`[+\-<>~*"()@]`)
+
+// searchTerms extrai os termos da busca para destaque
+func searchTerms(q, mode string) []string {
+	if strings.EqualFold(strings.TrimSpace(mode), "boolean") {
+		q = reBooleanOperators.ReplaceAllString(q, " ")
+	}
+	var terms []string
+	for _, term := range strings.Fields(q) {
+		if len([]rune(term)) > 1 {
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// highlightRows adiciona _highlights com os termos envoltos em <mark>. O texto
+// é escapado (html.EscapeString) antes de receber as marcações: o resultado
+// pode ser inserido direto como HTML.
+func highlightRows(rows []map[string]interface{}, columns, terms []string) {
+	if len(terms) == 0 {
+		return
+	}
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		quoted[i] = regexp.QuoteMeta(term)
+	}
+	re := regexp.MustCompile(`(?i)(` + strings.Join(quoted, "|") + `)`)
+
+	for _, row := range rows {
+		highlights := map[string]string{}
+		for _, col := range columns {
+			text, ok := row[col].(string)
+			if !ok || !re.MatchString(text) {
+				continue
+			}
+			highlights[col] = markMatches(text, re)
+		}
+		row[highlightsField] = highlights
+	}
+}
+
+// markMatches escapa o texto e envolve cada ocorrência em <mark>. As posições
+// são buscadas no texto original, para que termos como "&" ou "<" também sejam
+// encontrados.
+func markMatches(text string, re *regexp.Regexp) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range re.FindAllStringIndex(text, -1) {
+		b.WriteString(html.EscapeString(text[last:loc[0]]))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(text[loc[0]:loc[1]]))
+		b.WriteString("</mark>")
+		last = loc[1]
+	}
+	b.WriteString(html.EscapeString(text[last:]))
+	return b.String()
+}
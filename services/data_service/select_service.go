@@ -1,105 +1,200 @@
-package services
-
-import (
-	"fmt"
-	"meu-provedor/config"
-	"meu-provedor/engine/query"
-	"meu-provedor/models"
-)
-
-// ============================================================================
-// SELECT SERVICE
-// ============================================================================
-
-// ExecuteAdvancedSelect executa um SELECT avançado com suporte a JOINs
-func ExecuteAdvancedSelect(req models.AdvancedSelectRequest) ([]map[string]interface{}, error) {
-	// Validar requisição
-	if err := req.Validate(); err != nil {
-		return nil, err
-	}
-
-	// Obter código do projeto
-	projectCode, err := GetProjectCodeByID(req.ProjectID)
-	if err != nil {
-		return nil, err
-	}
-
-	// Construir nome físico da tabela
-	mainTable, err := BuildTableName(projectCode, req.Table)
-	if err != nil {
-		return nil, err
-	}
-
-	mainAlias := req.Alias
-	if mainAlias == "" {
-		mainAlias = mainTable
-	}
-
-	// Criar SelectBuilder
-	builder := query.NewSelect(mainTable, mainAlias)
-
-	// Definir colunas
-	if len(req.Select) > 0 {
-		builder.SetColumns(req.Select)
-	}
-
-	// Adicionar JOINs
-	for _, j := range req.Joins {
-		joinTable, err := BuildTableName(projectCode, j.Table)
-		if err != nil {
-			return nil, err
-		}
-		builder.AddJoin(j.Type, joinTable, j.Alias, j.On)
-	}
-
-	// Filtro obrigatório: id_instancia
-	builder.AddWhere(fmt.Sprintf("%s.id_instancia = ?", mainAlias), req.InstanceID)
-
-	// Filtros simples (WHERE)
-	for k, v := range req.Where {
-		if !query.IsValidColumnName(k) {
-			return nil, fmt.Errorf("%w: %s", models.ErrInvalidColumn, k)
-		}
-		builder.AddWhere(k+" = ?", v)
-	}
-
-	// Filtro raw (WHERE customizado)
-	if req.WhereRaw != "" {
-		builder.AddWhere("(" + req.WhereRaw + ")")
-	}
-
-	// GROUP BY
-	if req.GroupBy != "" {
-		builder.SetGroupBy(req.GroupBy)
-	}
-
-	// HAVING
-	if req.Having != "" {
-		builder.SetHaving(req.Having)
-	}
-
-	// ORDER BY
-	if req.OrderBy != "" {
-		builder.SetOrderBy(req.OrderBy)
-	}
-
-	// LIMIT e OFFSET
-	if req.Limit > 0 {
-		builder.SetLimitOffset(req.Limit, req.Offset)
-	}
-
-	// Executar query
-	rows, err := config.MasterDB.Query(builder.Build(), builder.GetValues()...)
-	if err != nil {
-		return nil, fmt.Errorf("%w: %v", models.ErrQueryFailed, err)
-	}
-	defer rows.Close()
-
-	// Converter para map
-	result, err := RowsToMap(rows)
-	if err != nil {
-		return nil, err
-	}
-
-	return result, nil
-}
+package services
+
+import (
+	"context"
+
+	"meu-provedor/config"
+	"meu-provedor/engine/query"
+	"meu-provedor/models"
+)
+
+// ============================================================================
+// SELECT SERVICE
+// ============================================================================
+
+// ExecuteAdvancedSelect executa um SELECT avançado com suporte a JOINs
+func ExecuteAdvancedSelect(ctx context.Context, req models.AdvancedSelectRequest) (result []map[string]interface{}, err error) {
+	// Timeout e cancelamento da consulta
+	ctx, guard, finish := guardQuery(ctx, req.ProjectID, req.InstanceID)
+	defer func() { err = finish(err) }()
+
+	prepared, err := prepareAdvancedSelect(req, guard)
+	if err != nil {
+		return nil, err
+	}
+
+	// Executar query
+	result, err = queryMaps(ctx, prepared.db, prepared.sql, prepared.args...)
+	if err != nil {
+		return nil, wrapDBError(models.ErrQueryFailed, err, prepared.projectCode)
+	}
+
+	// Embutir relacionamentos (include)
+	if err := resolveIncludes(ctx, prepared.db, guard, prepared.projectCode, req.InstanceID, result, req.Include, 1); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// prepareAdvancedSelect valida a requisição e monta o SQL (usado também pelo EXPLAIN)
+func prepareAdvancedSelect(req models.AdvancedSelectRequest, guard queryGuard) (*preparedQuery, error) {
+	// Validar requisição
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	// Rejeitar SQL livre quando não permitido
+	if err := guard.checkRawSQL(
+		rawField{"select", len(req.Select) > 0},
+		rawField{"where_raw", req.WhereRaw != ""},
+		rawField{"group_by", req.GroupBy != ""},
+		rawField{"having", req.Having != ""},
+		rawField{"order_by", req.OrderBy != ""},
+	); err != nil {
+		return nil, err
+	}
+	for _, j := range req.Joins {
+		if err := guard.checkRawSQL(rawField{"joins.on", j.On != ""}); err != nil {
+			return nil, err
+		}
+	}
+
+	// Obter código do projeto
+	projectCode, err := GetProjectCodeByID(req.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	db, err := readDB(req.ProjectID, req.Consistency)
+	if err != nil {
+		return nil, err
+	}
+
+	// Construir nome físico da tabela
+	mainTable, err := BuildTableName(projectCode, req.Table)
+	if err != nil {
+		return nil, err
+	}
+
+	// Carregar limites da instância
+	limits, err := GetInstanceLimits(req.InstanceID)
+	if err != nil {
+		return nil, err
+	}
+
+	mainAlias := req.Alias
+	if mainAlias == "" {
+		mainAlias = mainTable
+	}
+
+	// Criar SelectBuilder
+	builder := query.NewSelect(mainTable, mainAlias).
+		SetDialect(config.DialectOf(db)).
+		SetMaxExecutionTime(guard.executionMs())
+
+	// Escopo de aliases para validar os campos estruturados
+	scope := newAliasScope(db)
+	if err := scope.add(mainAlias, mainTable, req.Table); err != nil {
+		return nil, err
+	}
+
+	// Adicionar JOINs
+	for _, j := range req.Joins {
+		joinTable, err := BuildTableName(projectCode, j.Table)
+		if err != nil {
+			return nil, err
+		}
+		joinAlias := j.Alias
+		if joinAlias == "" {
+			joinAlias = joinTable
+		}
+		if err := scope.add(joinAlias, joinTable, j.Table); err != nil {
+			return nil, err
+		}
+
+		on := j.On
+		if len(j.Conditions) > 0 {
+			if on, err = scope.joinOn(j.Conditions); err != nil {
+				return nil, err
+			}
+		}
+		builder.AddJoin(j.Type, joinTable, joinAlias, on)
+	}
+
+	// Definir colunas (projeção estruturada tem prioridade)
+	if len(req.Projection) > 0 {
+		cols, err := scope.projection(req.Projection)
+		if err != nil {
+			return nil, err
+		}
+		builder.SetColumns(cols)
+	} else if len(req.Select) > 0 {
+		builder.SetColumns(req.Select)
+	}
+
+	// Filtro obrigatório: id_instancia
+	builder.AddWhereEq(mainAlias+".id_instancia", req.InstanceID)
+
+	// Filtros simples (WHERE)
+	for k, v := range req.Where {
+		col, err := scope.filterKey(k)
+		if err != nil {
+			return nil, err
+		}
+		builder.AddWhereEq(col, v)
+	}
+
+	// Filtros estruturados (comparações e caminhos JSON)
+	for _, f := range req.Filters {
+		expr, args, err := scope.filter(f)
+		if err != nil {
+			return nil, err
+		}
+		builder.AddWhere(expr, args...)
+	}
+
+	// Filtro raw (WHERE customizado)
+	if req.WhereRaw != "" {
+		builder.AddWhere("(" + req.WhereRaw + ")")
+	}
+
+	// GROUP BY
+	if len(req.Group) > 0 {
+		group, err := scope.group(req.Group)
+		if err != nil {
+			return nil, err
+		}
+		builder.SetGroupBy(group)
+	} else if req.GroupBy != "" {
+		builder.SetGroupBy(req.GroupBy)
+	}
+
+	// HAVING
+	if req.Having != "" {
+		builder.SetHaving(req.Having)
+	}
+
+	// ORDER BY
+	if len(req.Sort) > 0 {
+		order, err := scope.sort(req.Sort)
+		if err != nil {
+			return nil, err
+		}
+		builder.SetOrderBy(order)
+	} else if req.OrderBy != "" {
+		builder.SetOrderBy(req.OrderBy)
+	}
+
+	// LIMIT e OFFSET (limitado ao tamanho máximo de resultado e ao teto do servidor)
+	if limit := guard.capRows(ClampLimit(req.Limit, limits)); limit > 0 {
+		builder.SetLimitOffset(limit, req.Offset)
+	}
+
+	return &preparedQuery{
+		db:          db,
+		projectCode: projectCode,
+		sql:         builder.Build(),
+		args:        builder.GetValues(),
+		scope:       scope,
+	}, nil
+}
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	"modernc.org/sqlite"
+
+	"meu-provedor/config"
+	"meu-provedor/models"
+)
+
+// ============================================================================
+// SLOW QUERY LOG - Consultas lentas em memória (por processo)
+// ============================================================================
+//
+// Consultas acima de SLOW_QUERY_MS (padrão 500ms) ficam num buffer circular
+// de SLOW_QUERY_LOG_SIZE entradas (padrão 200). Os argumentos são gravados só
+// com o tipo, os literais do SQL (entre aspas simples e numéricos) são
+// mascarados e do erro fica só o código, para não expor dados dos clientes.
+
+type queryTraceKey struct{}
+
+// queryTrace identifica de quem é a consulta executada sob o contexto
+type queryTrace struct {
+	projectID  int64
+	instanceID int64
+}
+
+type slowQueryLog struct {
+	mu      sync.Mutex
+	entries []models.SlowQuery
+	next    int
+	full    bool
+}
+
+var slowQueries = &slowQueryLog{}
+
+var (
+	// quotedLiteral reconhece literais SQL entre aspas simples
+	quotedLiteral = regexp.MustCompile(`'(?:[^'\\]|\\.|'')*'`)
+	// numericLiteral reconhece números fora de identificadores; placeholders
+	// do PostgreSQL ($1) são preservados
+	numericLiteral = regexp.MustCompile(`\$?\b(?:0[xX][0-9a-fA-F]+|\d+(?:\.\d+)?(?:[eE][+-]?\d+)?)\b`)
+)
+
+// withQueryTrace marca o contexto com o projeto e a instância da requisição
+func withQueryTrace(ctx context.Context, projectID, instanceID int64) context.Context {
+	return context.WithValue(ctx, queryTraceKey{}, queryTrace{projectID: projectID, instanceID: instanceID})
+}
+
+// observeQuery registra a consulta no slow query log quando passa do limite
+func observeQuery(ctx context.Context, sqlQuery string, args []interface{}, start time.Time, err error) {
+	elapsed := time.Since(start)
+	threshold := time.Duration(config.EnvInt("SLOW_QUERY_MS", 500)) * time.Millisecond
+	if threshold <= 0 || elapsed < threshold {
+		return
+	}
+
+	trace, _ := ctx.Value(queryTraceKey{}).(queryTrace)
+	entry := models.SlowQuery{
+		SQL:        maskLiterals(sqlQuery),
+		Args:       redactArgs(args),
+		DurationMs: float64(elapsed.Microseconds()) / 1000,
+		ProjectID:  trace.projectID,
+		InstanceID: trace.instanceID,
+		At:         start,
+	}
+	if err != nil {
+		entry.Error = queryErrorCode(err)
+	}
+	slowQueries.add(entry)
+}
+
+// ListSlowQueries retorna as consultas lentas mais recentes primeiro
+func ListSlowQueries(filter models.SlowQueryFilter) []models.SlowQuery {
+	return slowQueries.list(filter)
+}
+
+// ============================================================================
+// QUERIES INSTRUMENTADAS
+// ============================================================================
+
+// rowQueryer é satisfeito por *sql.DB e *sql.Tx
+type rowQueryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// queryMaps executa um SELECT, converte as linhas e registra a duração
+// (incluindo a leitura das linhas) no slow query log
+func queryMaps(ctx context.Context, q rowQueryer, sqlQuery string, args ...interface{}) (result []map[string]interface{}, err error) {
+	defer func(start time.Time) { observeQuery(ctx, sqlQuery, args, start, err) }(time.Now())
+
+	rows, err := q.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return RowsToMap(rows)
+}
+
+// scanRow executa uma consulta de uma linha e uma coluna, registrando a duração
+func scanRow(ctx context.Context, db *sql.DB, dest interface{}, sqlQuery string, args ...interface{}) (err error) {
+	defer func(start time.Time) { observeQuery(ctx, sqlQuery, args, start, err) }(time.Now())
+	return db.QueryRowContext(ctx, sqlQuery, args...).Scan(dest)
+}
+
+// ============================================================================
+// INTERNAL HELPERS
+// ============================================================================
+
+func (l *slowQueryLog) add(entry models.SlowQuery) {
+	size := config.EnvInt("SLOW_QUERY_LOG_SIZE", 200)
+	if size <= 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.entries) != size {
+		// tamanho alterado em tempo de execução: recomeça o buffer
+		if len(l.entries) > 0 {
+			l.entries, l.next, l.full = nil, 0, false
+		}
+		l.entries = make([]models.SlowQuery, size)
+	}
+	l.entries[l.next] = entry
+	l.next = (l.next + 1) % size
+	if l.next == 0 {
+		l.full = true
+	}
+}
+
+func (l *slowQueryLog) list(filter models.SlowQueryFilter) []models.SlowQuery {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	count := l.next
+	if l.full {
+		count = len(l.entries)
+	}
+
+	out := []models.SlowQuery{}
+	for i := 1; i <= count; i++ {
+		entry := l.entries[(l.next-i+len(l.entries))%len(l.entries)]
+		if filter.ProjectID > 0 && entry.ProjectID != filter.ProjectID {
+			continue
+		}
+		if filter.InstanceID > 0 && entry.InstanceID != filter.InstanceID {
+			continue
+		}
+		out = append(out, entry)
+		if filter.Limit > 0 && len(out) >= filter.Limit {
+			break
+		}
+	}
+	return out
+}
+
+// maskLiterals troca os literais do SQL por marcadores
+func maskLiterals(sqlQuery string) string {
+	masked := quotedLiteral.ReplaceAllString(sqlQuery, "'?'")
+	return numericLiteral.ReplaceAllStringFunc(masked, func(literal string) string {
+		if literal[0] == '$' {
+			return literal
+		}
+		return "?"
+	})
+}
+
+// queryErrorCode reduz o erro ao código do banco (a mensagem pode conter
+// valores dos clientes)
+func queryErrorCode(err error) string {
+	var mysqlErr *mysql.MySQLError
+	var pqErr *pq.Error
+	var sqliteErr *sqlite.Error
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.As(err, &mysqlErr):
+		return "mysql:" + strconv.Itoa(int(mysqlErr.Number))
+	case errors.As(err, &pqErr):
+		return "postgres:" + string(pqErr.Code)
+	case errors.As(err, &sqliteErr):
+		return "sqlite:" + strconv.Itoa(sqliteErr.Code())
+	}
+	return "error"
+}
+
+// redactArgs troca os valores dos argumentos pelos seus tipos
+func redactArgs(args []interface{}) []interface{} {
+	out := make([]interface{}, len(args))
+	for i, arg := range args {
+		if arg == nil {
+			continue
+		}
+		out[i] = fmt.Sprintf("<%T>", arg)
+	}
+	return out
+}
@@ -0,0 +1,44 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestMaskLiterals(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want string
+	}{
+		{"SELECT * FROM `ab_t1` WHERE `cpf` = '123.456' AND `idade` > 42", "SELECT * FROM `ab_t1` WHERE `cpf` = '?' AND `idade` > ?"},
+		{"SELECT * FROM t WHERE v = -3.5e2 OR h = 0x1F", "SELECT * FROM t WHERE v = -? OR h = ?"},
+		{`SELECT * FROM "ab_t2" WHERE "c" = $1 LIMIT 10`, `SELECT * FROM "ab_t2" WHERE "c" = $1 LIMIT ?`},
+		{"SELECT * FROM t WHERE nome = 'O''Brien 7'", "SELECT * FROM t WHERE nome = '?'"},
+	}
+	for _, tt := range tests {
+		if got := maskLiterals(tt.sql); got != tt.want {
+			t.Errorf("maskLiterals(%q) = %q, want %q", tt.sql, got, tt.want)
+		}
+	}
+}
+
+func TestQueryErrorCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{&mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'joao@x.com' for key 'email'"}, "mysql:1062"},
+		{fmt.Errorf("consulta: %w", context.DeadlineExceeded), "timeout"},
+		{context.Canceled, "canceled"},
+		{errors.New("valor secreto"), "error"},
+	}
+	for _, tt := range tests {
+		if got := queryErrorCode(tt.err); got != tt.want {
+			t.Errorf("queryErrorCode(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
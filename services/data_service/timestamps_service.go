@@ -0,0 +1,116 @@
+package services
+
+import (
+	"meu-provedor/engine/query"
+	"meu-provedor/models"
+	tableService "meu-provedor/services/table"
+)
+
+// ============================================================================
+// TIMESTAMPS SERVICE - created_at/updated_at e created_by/updated_by
+// ============================================================================
+
+// Colunas de auditoria preenchidas pelo engine nas tabelas criadas com
+// timestamps/track_user (registro em table_managed_columns)
+const (
+	createdAtColumn = "created_at"
+	updatedAtColumn = "updated_at"
+	createdByColumn = "created_by"
+	updatedByColumn = "updated_by"
+)
+
+// auditColumns são as colunas de auditoria gerenciadas na tabela física
+type auditColumns struct {
+	columns  map[string]bool
+	override bool // audit_override: valores do cliente prevalecem
+}
+
+// loadAuditColumns retorna as colunas de auditoria que a tabela pediu ao
+// criar e que ainda existem no schema
+func loadAuditColumns(schema tableSchema, fullTable string) (auditColumns, error) {
+	managed, err := tableService.GetManagedColumns(fullTable)
+	if err != nil {
+		return auditColumns{}, err
+	}
+
+	var candidates []string
+	if managed.Timestamps {
+		candidates = append(candidates, createdAtColumn, updatedAtColumn)
+	}
+	if managed.TrackUser {
+		candidates = append(candidates, createdByColumn, updatedByColumn)
+	}
+
+	audit := auditColumns{columns: map[string]bool{}, override: managed.AuditOverride}
+	for _, col := range candidates {
+		if _, exists := schema[col]; exists {
+			audit.columns[col] = true
+		}
+	}
+	return audit, nil
+}
+
+// manages indica se a coluna é de auditoria gerenciada
+func (a auditColumns) manages(col string) bool {
+	return a.columns[col]
+}
+
+// checkNotProvided rejeita valores do cliente nas colunas de auditoria
+// (exceto nas tabelas com audit_override)
+func (a auditColumns) checkNotProvided(columns ...string) error {
+	if a.override {
+		return nil
+	}
+	for _, col := range columns {
+		if a.columns[col] {
+			return models.ErrAuditManaged.WithDetails(
+				models.ErrAuditManaged.Message+": "+col,
+				map[string]interface{}{"column": col},
+			)
+		}
+	}
+	return nil
+}
+
+// insertValues retorna as colunas de auditoria (e valores) a acrescentar no
+// INSERT. created_at/updated_at ficam de fora: o DEFAULT CURRENT_TIMESTAMP da
+// coluna usa o relógio do banco. provided são as colunas enviadas pelo
+// cliente (só chegam aqui com audit_override) e não são sobrescritas.
+func (a auditColumns) insertValues(actor string, provided ...string) ([]string, []interface{}) {
+	var cols []string
+	var vals []interface{}
+	for _, col := range []string{createdByColumn, updatedByColumn} {
+		if a.columns[col] && !containsColumn(provided, col) {
+			cols = append(cols, col)
+			vals = append(vals, nullableActor(actor))
+		}
+	}
+	return cols, vals
+}
+
+// applyUpdate preenche updated_at (CURRENT_TIMESTAMP do banco) e updated_by
+// no UPDATE, exceto nas colunas enviadas pelo cliente (provided)
+func (a auditColumns) applyUpdate(builder *query.UpdateBuilder, actor string, provided ...string) {
+	if a.columns[updatedAtColumn] && !containsColumn(provided, updatedAtColumn) {
+		builder.SetOp(updatedAtColumn, "now", nil)
+	}
+	if a.columns[updatedByColumn] && !containsColumn(provided, updatedByColumn) {
+		builder.Set(updatedByColumn, nullableActor(actor))
+	}
+}
+
+func containsColumn(columns []string, col string) bool {
+	for _, c := range columns {
+		if c == col {
+			return true
+		}
+	}
+	return false
+}
+
+func nullableActor(actor string) interface{} {
+	if actor == "" {
+		return nil
+	}
+	return actor
+}
@@ -1,141 +1,477 @@
-package services
-
-import (
-	"fmt"
-	"meu-provedor/config"
-	"meu-provedor/engine/query"
-	"meu-provedor/models"
-)
-
-// ============================================================================
-// UPDATE SERVICE
-// ============================================================================
-
-// ExecuteUpdate executa um UPDATE
-func ExecuteUpdate(req models.UpdateRequest) (int64, error) {
-	// Validar requisição
-	if err := req.Validate(); err != nil {
-		return 0, err
-	}
-
-	// Obter código do projeto
-	projectCode, err := GetProjectCodeByID(req.ProjectID)
-	if err != nil {
-		return 0, err
-	}
-
-	// Construir nome da tabela
-	table, err := BuildTableName(projectCode, req.Table)
-	if err != nil {
-		return 0, err
-	}
-
-	// Criar UpdateBuilder
-	builder := query.NewUpdate(table)
-
-	// Adicionar campos a atualizar
-	for col, val := range req.Data {
-		if !query.IsValidColumnName(col) {
-			return 0, fmt.Errorf("%w: %s", models.ErrInvalidColumn, col)
-		}
-		builder.Set(col, val)
-	}
-
-	// Filtro obrigatório: id_instancia
-	builder.Where("id_instancia = ?", req.InstanceID)
-
-	// Adicionar filtros simples
-	for col, val := range req.Where {
-		if !query.IsValidColumnName(col) {
-			return 0, fmt.Errorf("%w: %s", models.ErrInvalidColumn, col)
-		}
-		builder.Where(col+" = ?", val)
-	}
-
-	// Filtro raw opcional
-	if req.WhereRaw != "" {
-		builder.WhereRaw(req.WhereRaw)
-	}
-
-	// Executar UPDATE
-	sqlQuery, args := builder.Build()
-	result, err := config.MasterDB.Exec(sqlQuery, args...)
-	if err != nil {
-		return 0, fmt.Errorf("%w: %v", models.ErrUpdateFailed, err)
-	}
-
-	// Retornar quantidade de linhas afetadas
-	count, err := result.RowsAffected()
-	if err != nil {
-		return 0, err
-	}
-
-	return count, nil
-}
-
-// ExecuteBatchUpdate executa múltiplos UPDATEs
-func ExecuteBatchUpdate(req models.BatchUpdateRequest) (int64, error) {
-	// Validar requisição básica
-	if req.ProjectID <= 0 {
-		return 0, models.ErrInvalidProjectID
-	}
-	if req.InstanceID <= 0 {
-		return 0, models.ErrInvalidInstanceID
-	}
-	if req.Table == "" {
-		return 0, models.ErrTableRequired
-	}
-	if len(req.Updates) == 0 {
-		return 0, models.ErrNoDataProvided
-	}
-
-	// Obter código do projeto
-	projectCode, err := GetProjectCodeByID(req.ProjectID)
-	if err != nil {
-		return 0, err
-	}
-
-	// Construir nome da tabela
-	table, err := BuildTableName(projectCode, req.Table)
-	if err != nil {
-		return 0, err
-	}
-
-	var totalAffected int64
-
-	// Executar cada update individualmente
-	for _, update := range req.Updates {
-		builder := query.NewUpdate(table)
-
-		// Adicionar campos a atualizar
-		for col, val := range update.Data {
-			if !query.IsValidColumnName(col) {
-				return 0, fmt.Errorf("%w: %s", models.ErrInvalidColumn, col)
-			}
-			builder.Set(col, val)
-		}
-
-		// Filtro obrigatório: id_instancia
-		builder.Where("id_instancia = ?", req.InstanceID)
-
-		// Adicionar filtros do update
-		for col, val := range update.Where {
-			if !query.IsValidColumnName(col) {
-				return 0, fmt.Errorf("%w: %s", models.ErrInvalidColumn, col)
-			}
-			builder.Where(col+" = ?", val)
-		}
-
-		// Executar
-		sqlQuery, args := builder.Build()
-		result, err := config.MasterDB.Exec(sqlQuery, args...)
-		if err != nil {
-			return totalAffected, fmt.Errorf("%w: %v", models.ErrUpdateFailed, err)
-		}
-
-		affected, _ := result.RowsAffected()
-		totalAffected += affected
-	}
-
-	return totalAffected, nil
-}
\ No newline at end of file
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"meu-provedor/engine/query"
+	"meu-provedor/models"
+)
+
+// ============================================================================
+// UPDATE SERVICE
+// ============================================================================
+
+// ExecuteUpdate executa um UPDATE
+func ExecuteUpdate(ctx context.Context, req models.UpdateRequest) (affected int64, err error) {
+	// Validar requisição
+	if err := req.Validate(); err != nil {
+		return 0, err
+	}
+
+	// Timeout e cancelamento da escrita
+	ctx, guard, finish := guardQuery(ctx, req.ProjectID, req.InstanceID)
+	defer func() { err = finish(err) }()
+
+	// Rejeitar SQL livre quando não permitido
+	if err := guard.checkRawSQL(rawField{"where_raw", req.WhereRaw != ""}); err != nil {
+		return 0, err
+	}
+
+	// Obter código do projeto
+	projectCode, err := GetProjectCodeByID(req.ProjectID)
+	if err != nil {
+		return 0, err
+	}
+	db, done, err := writeDB(req.ProjectID)
+	if err != nil {
+		return 0, err
+	}
+	defer done()
+
+	// Construir nome da tabela
+	table, err := BuildTableName(projectCode, req.Table)
+	if err != nil {
+		return 0, err
+	}
+
+	// Validar valores contra o schema da tabela
+	schema, err := loadTableSchema(db, table)
+	if err != nil {
+		return 0, err
+	}
+	versioned, err := loadVersioning(schema, table)
+	if err != nil {
+		return 0, err
+	}
+	audit, err := loadAuditColumns(schema, table)
+	if err != nil {
+		return 0, err
+	}
+	data, fieldErrs := schema.validateUpdateData(req.Data)
+	if err := schemaValidationError(fieldErrs); err != nil {
+		return 0, err
+	}
+	if err := versioned.checkNotProvided(updatedColumns(req.Data, req.Ops)...); err != nil {
+		return 0, err
+	}
+	if err := audit.checkNotProvided(updatedColumns(req.Data, req.Ops)...); err != nil {
+		return 0, err
+	}
+
+	// Criar UpdateBuilder
+	builder := query.NewUpdate(table)
+
+	// Adicionar campos a atualizar
+	for col, val := range data {
+		if !query.IsValidColumnName(col) {
+			return 0, fmt.Errorf("%w: %s", models.ErrInvalidColumn, col)
+		}
+		builder.Set(col, val)
+	}
+
+	// Atualizações parciais em colunas JSON
+	if err := applyJSONUpdates(builder, schema, req.JSON); err != nil {
+		return 0, err
+	}
+
+	// Operadores atômicos (increment, now, ...)
+	if err := applyUpdateOps(builder, schema, req.Ops); err != nil {
+		return 0, err
+	}
+
+	// Colunas de auditoria (updated_at, updated_by)
+	audit.applyUpdate(builder, req.Actor, updatedColumns(req.Data, req.Ops)...)
+
+	// Filtro obrigatório: id_instancia
+	builder.WhereEq("id_instancia", req.InstanceID)
+
+	// Adicionar filtros simples
+	for key, val := range req.Where {
+		col, err := updateWhereExpr(schema, key)
+		if err != nil {
+			return 0, err
+		}
+		builder.WhereEq(col, val)
+	}
+
+	// Filtro raw opcional
+	if req.WhereRaw != "" {
+		builder.WhereRaw(req.WhereRaw)
+	}
+
+	// Concorrência otimista (tabelas versionadas)
+	if err := applyVersionCheck(builder, versioned, req.ExpectedVersion); err != nil {
+		return 0, err
+	}
+
+	// Histórico: travar e capturar as linhas antes do UPDATE
+	hist, err := beginHistory(ctx, db, req.ProjectID, projectCode, req.Table, table, req.InstanceID, req.Actor)
+	if err != nil {
+		return 0, err
+	}
+	defer hist.rollback()
+
+	before, err := hist.snapshot(builder.WhereClauses, builder.WhereValues)
+	if err != nil {
+		return 0, err
+	}
+
+	// Executar UPDATE
+	sqlQuery, args := builder.Build()
+	result, err := hist.exec(sqlQuery, args...)
+	if err != nil {
+		return 0, wrapDBError(models.ErrUpdateFailed, err, projectCode)
+	}
+
+	// Retornar quantidade de linhas afetadas
+	count, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	// Nenhuma linha afetada em tabela versionada: verificar conflito
+	if count == 0 && versioned && req.WhereRaw == "" {
+		if err := versionConflict(ctx, db, projectCode, table, schema, req.InstanceID, req.Where, *req.ExpectedVersion); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := hist.recordChanges(models.HistoryUpdate, before); err != nil {
+		return 0, err
+	}
+	if err := hist.commitChange(models.HistoryUpdate, snapshotIDs(before), count, req.Where); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// ExecuteBatchUpdate executa múltiplos UPDATEs
+func ExecuteBatchUpdate(ctx context.Context, req models.BatchUpdateRequest) (total int64, err error) {
+	// Validar requisição básica
+	if req.ProjectID <= 0 {
+		return 0, models.ErrInvalidProjectID
+	}
+	if req.InstanceID <= 0 {
+		return 0, models.ErrInvalidInstanceID
+	}
+	if req.Table == "" {
+		return 0, models.ErrTableRequired
+	}
+	if len(req.Updates) == 0 {
+		return 0, models.ErrNoDataProvided
+	}
+
+	// Timeout e cancelamento da escrita
+	ctx, _, finish := guardQuery(ctx, req.ProjectID, req.InstanceID)
+	defer func() { err = finish(err) }()
+
+	// Obter código do projeto
+	projectCode, err := GetProjectCodeByID(req.ProjectID)
+	if err != nil {
+		return 0, err
+	}
+	db, done, err := writeDB(req.ProjectID)
+	if err != nil {
+		return 0, err
+	}
+	defer done()
+
+	// Construir nome da tabela
+	table, err := BuildTableName(projectCode, req.Table)
+	if err != nil {
+		return 0, err
+	}
+
+	// Verificar tamanho do lote nos limites da instância
+	limits, err := GetInstanceLimits(req.InstanceID)
+	if err != nil {
+		return 0, err
+	}
+	if err := CheckBatchSize(len(req.Updates), limits); err != nil {
+		return 0, err
+	}
+
+	// Validar todos os updates contra o schema da tabela
+	schema, err := loadTableSchema(db, table)
+	if err != nil {
+		return 0, err
+	}
+	versioned, err := loadVersioning(schema, table)
+	if err != nil {
+		return 0, err
+	}
+	audit, err := loadAuditColumns(schema, table)
+	if err != nil {
+		return 0, err
+	}
+
+	var fieldErrs []models.FieldError
+	updatesData := make([]map[string]interface{}, len(req.Updates))
+	for i, update := range req.Updates {
+		if err := versioned.checkNotProvided(updatedColumns(update.Data, update.Ops)...); err != nil {
+			return 0, err
+		}
+		if err := audit.checkNotProvided(updatedColumns(update.Data, update.Ops)...); err != nil {
+			return 0, err
+		}
+		data, errs := schema.validateUpdateData(update.Data)
+		for j := range errs {
+			index := i
+			errs[j].Row = &index
+		}
+		fieldErrs = append(fieldErrs, errs...)
+		updatesData[i] = data
+	}
+	if err := schemaValidationError(fieldErrs); err != nil {
+		return 0, err
+	}
+
+	// Histórico: todos os updates do lote na mesma transação
+	hist, err := beginHistory(ctx, db, req.ProjectID, projectCode, req.Table, table, req.InstanceID, req.Actor)
+	if err != nil {
+		return 0, err
+	}
+	defer hist.rollback()
+
+	var totalAffected int64
+	var changedIDs []int64
+
+	// Executar cada update individualmente
+	for i, update := range req.Updates {
+		builder := query.NewUpdate(table)
+
+		// Adicionar campos a atualizar
+		for col, val := range updatesData[i] {
+			if !query.IsValidColumnName(col) {
+				return 0, fmt.Errorf("%w: %s", models.ErrInvalidColumn, col)
+			}
+			builder.Set(col, val)
+		}
+
+		// Atualizações parciais em colunas JSON
+		if err := applyJSONUpdates(builder, schema, update.JSON); err != nil {
+			return totalAffected, err
+		}
+
+		// Operadores atômicos (increment, now, ...)
+		if err := applyUpdateOps(builder, schema, update.Ops); err != nil {
+			return totalAffected, err
+		}
+
+		// Colunas de auditoria (updated_at, updated_by)
+		audit.applyUpdate(builder, req.Actor, updatedColumns(update.Data, update.Ops)...)
+
+		// Filtro obrigatório: id_instancia
+		builder.WhereEq("id_instancia", req.InstanceID)
+
+		// Adicionar filtros do update
+		for key, val := range update.Where {
+			col, err := updateWhereExpr(schema, key)
+			if err != nil {
+				return totalAffected, err
+			}
+			builder.WhereEq(col, val)
+		}
+
+		// Concorrência otimista (tabelas versionadas)
+		if err := applyVersionCheck(builder, versioned, update.ExpectedVersion); err != nil {
+			return totalAffected, err
+		}
+
+		before, err := hist.snapshot(builder.WhereClauses, builder.WhereValues)
+		if err != nil {
+			return totalAffected, err
+		}
+
+		// Executar
+		sqlQuery, args := builder.Build()
+		result, err := hist.exec(sqlQuery, args...)
+		if err != nil {
+			return totalAffected, wrapDBError(models.ErrUpdateFailed, err, projectCode)
+		}
+
+		affected, _ := result.RowsAffected()
+		if affected == 0 && versioned {
+			if err := versionConflict(ctx, db, projectCode, table, schema, req.InstanceID, update.Where, *update.ExpectedVersion); err != nil {
+				return totalAffected, err
+			}
+		}
+		totalAffected += affected
+
+		if err := hist.recordChanges(models.HistoryUpdate, before); err != nil {
+			return totalAffected, err
+		}
+		changedIDs = append(changedIDs, snapshotIDs(before)...)
+	}
+
+	if err := hist.commitChange(models.HistoryUpdate, changedIDs, totalAffected, nil); err != nil {
+		return 0, err
+	}
+
+	return totalAffected, nil
+}
+
+// ============================================================================
+// JSON UPDATES
+// ============================================================================
+
+// updateWhereExpr resolve uma chave do where do UPDATE ("coluna" ou "coluna->'$.caminho'")
+func updateWhereExpr(schema tableSchema, key string) (string, error) {
+	column, path, ok := query.ParseJSONPathKey(key)
+	if !ok {
+		if !query.IsValidColumnName(key) {
+			return "", fmt.Errorf("%w: %s", models.ErrInvalidColumn, key)
+		}
+		return query.QuoteIdent(key), nil
+	}
+
+	spec, exists := schema[column]
+	if !exists {
+		return "", fmt.Errorf("%w: %s", models.ErrUnknownColumn, column)
+	}
+	return jsonPathExpr(spec, query.QuoteIdent(column), path)
+}
+
+// applyJSONUpdates adiciona ao UPDATE as operações set/remove/merge em colunas JSON
+func applyJSONUpdates(builder *query.UpdateBuilder, schema tableSchema, ops []models.JSONUpdate) error {
+	for _, op := range ops {
+		spec, exists := schema[op.Column]
+		if !exists {
+			return fmt.Errorf("%w: %s", models.ErrUnknownColumn, op.Column)
+		}
+		if spec.base != "json" {
+			return fmt.Errorf("%w: coluna '%s' não é JSON", models.ErrInvalidJSONPath, op.Column)
+		}
+
+		switch strings.ToLower(op.Op) {
+		case "set":
+			if !query.IsValidJSONPath(op.Path) || op.Path == "$" {
+				return fmt.Errorf("%w: %s", models.ErrInvalidJSONPath, op.Path)
+			}
+			doc, err := json.Marshal(op.Value)
+			if err != nil {
+				return models.NewValidationError(fmt.Sprintf("valor inválido para %s: %v", op.Path, err))
+			}
+			builder.SetJSON(op.Column, op.Path, string(doc))
+
+		case "remove":
+			if !query.IsValidJSONPath(op.Path) || op.Path == "$" {
+				return fmt.Errorf("%w: %s", models.ErrInvalidJSONPath, op.Path)
+			}
+			builder.RemoveJSON(op.Column, op.Path)
+
+		case "merge":
+			patch, ok := op.Value.(map[string]interface{})
+			if !ok {
+				return models.NewValidationError("merge exige um objeto em value")
+			}
+			doc, _ := json.Marshal(patch)
+			builder.MergeJSON(op.Column, string(doc))
+
+		default:
+			return models.NewValidationError(fmt.Sprintf("operação JSON inválida: %s (use set, remove ou merge)", op.Op))
+		}
+	}
+	return nil
+}
+
+// ============================================================================
+// UPDATE OPERATORS
+// ============================================================================
+
+// applyUpdateOps valida os operadores contra o schema e os adiciona ao UPDATE
+func applyUpdateOps(builder *query.UpdateBuilder, schema tableSchema, ops []models.UpdateOperation) error {
+	for _, op := range ops {
+		spec, exists := schema[op.Column]
+		if !exists {
+			return fmt.Errorf("%w: %s", models.ErrUnknownColumn, op.Column)
+		}
+		if serviceManagedColumns[op.Column] {
+			return fmt.Errorf("%w: coluna '%s' não pode ser alterada", models.ErrInvalidUpdateOp, op.Column)
+		}
+
+		name := strings.ToLower(strings.TrimSpace(op.Op))
+		var value interface{}
+
+		switch name {
+		case "increment", "decrement", "multiply":
+			if !spec.numeric() {
+				return fmt.Errorf("%w: %s exige coluna numérica (%s)", models.ErrInvalidUpdateOp, name, op.Column)
+			}
+			switch {
+			case spec.integer():
+				n, ok := toInt64(op.Value)
+				if !ok {
+					return fmt.Errorf("%w: %s exige valor inteiro em '%s'", models.ErrInvalidUpdateOp, name, op.Column)
+				}
+				value = n
+
+			case spec.base == "decimal" || spec.base == "numeric":
+				// DECIMAL é exato: o valor segue como texto e o banco converte
+				if name == "multiply" {
+					r, ok := toRat(op.Value)
+					if !ok {
+						return fmt.Errorf("%w: %s exige valor numérico", models.ErrInvalidUpdateOp, name)
+					}
+					value = query.Decimal{Value: decimalText(r)}
+					break
+				}
+				// O incremento precisa caber na precisão/escala da coluna
+				delta := spec
+				delta.unsigned = false
+				text, fieldErr := delta.decimal(op.Value)
+				if fieldErr != nil {
+					return fmt.Errorf("%w: %s (%s)", models.ErrInvalidUpdateOp, fieldErr.Message, op.Column)
+				}
+				value = query.Decimal{Value: text, Precision: spec.precision, Scale: spec.scale}
+
+			default:
+				n, ok := toFloat64(op.Value)
+				if !ok {
+					return fmt.Errorf("%w: %s exige valor numérico", models.ErrInvalidUpdateOp, name)
+				}
+				value = n
+			}
+
+		case "coalesce", "least", "greatest":
+			coerced, fieldErr := spec.coerce(op.Value)
+			if fieldErr != nil {
+				return fmt.Errorf("%w: %s (%s)", models.ErrInvalidUpdateOp, fieldErr.Message, op.Column)
+			}
+			value = coerced
+			if text, ok := coerced.(string); ok && (spec.base == "decimal" || spec.base == "numeric") {
+				// Comparação numérica, não textual
+				value = query.Decimal{Value: text, Precision: spec.precision, Scale: spec.scale}
+			}
+
+		case "set_null":
+			if !spec.detail.Nullable {
+				return fmt.Errorf("%w: coluna '%s' não aceita nulo", models.ErrInvalidUpdateOp, op.Column)
+			}
+
+		case "now":
+			if !spec.temporal() {
+				return fmt.Errorf("%w: now exige coluna de data (%s)", models.ErrInvalidUpdateOp, op.Column)
+			}
+		}
+
+		if err := builder.SetOp(op.Column, name, value); err != nil {
+			return fmt.Errorf("%w: %v", models.ErrInvalidUpdateOp, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"meu-provedor/config"
+	"meu-provedor/engine/query"
+	"meu-provedor/models"
+	tableService "meu-provedor/services/table"
+)
+
+// ============================================================================
+// VERSION SERVICE - Concorrência otimista em tabelas criadas com versioned
+// ============================================================================
+
+// versioning indica se a versão é gerenciada pelo engine: a tabela foi criada
+// com versioned (registro em table_managed_columns) e ainda tem a coluna
+type versioning bool
+
+// loadVersioning lê o opt-in de versão da tabela física
+func loadVersioning(schema tableSchema, fullTable string) (versioning, error) {
+	managed, err := tableService.GetManagedColumns(fullTable)
+	if err != nil {
+		return false, err
+	}
+	_, exists := schema[models.VersionColumn]
+	return versioning(managed.Versioned && exists), nil
+}
+
+// checkNotProvided rejeita escrita direta na coluna de versão
+func (v versioning) checkNotProvided(columns ...string) error {
+	if !v {
+		return nil
+	}
+	for _, col := range columns {
+		if col == models.VersionColumn {
+			return models.ErrVersionManaged
+		}
+	}
+	return nil
+}
+
+// updatedColumns lista as colunas alteradas por data e ops
+func updatedColumns(data map[string]interface{}, ops []models.UpdateOperation) []string {
+	cols := make([]string, 0, len(data)+len(ops))
+	for col := range data {
+		cols = append(cols, col)
+	}
+	for _, op := range ops {
+		cols = append(cols, op.Column)
+	}
+	return cols
+}
+
+// applyVersionCheck adiciona a verificação de versão ao UPDATE de tabelas versionadas
+func applyVersionCheck(builder *query.UpdateBuilder, versioned versioning, expected *int64) error {
+	if !versioned {
+		return nil
+	}
+	if expected == nil {
+		return models.ErrVersionRequired
+	}
+	builder.WithVersion(models.VersionColumn, *expected)
+	return nil
+}
+
+// versionConflict monta o erro 409 com a linha atual. Se a linha não existe,
+// retorna nil (o UPDATE simplesmente não afetou nenhum registro).
+func versionConflict(ctx context.Context, db *sql.DB, projectCode, table string, schema tableSchema, instanceID int64, where map[string]interface{}, expected int64) error {
+	builder := query.NewSelect(table, table).SetDialect(config.DialectOf(db))
+	builder.AddWhereEq("id_instancia", instanceID)
+	for key, val := range where {
+		col, err := updateWhereExpr(schema, key)
+		if err != nil {
+			return err
+		}
+		builder.AddWhereEq(col, val)
+	}
+	builder.SetLimitOffset(1, 0)
+
+	current, err := queryMaps(ctx, db, builder.Build(), builder.GetValues()...)
+	if err != nil {
+		return wrapDBError(models.ErrQueryFailed, err, projectCode)
+	}
+	if len(current) == 0 {
+		return nil
+	}
+
+	return models.ErrVersionConflict.WithDetails(
+		fmt.Sprintf("%s (versão esperada %d, atual %v)", models.ErrVersionConflict.Message, expected, current[0][models.VersionColumn]),
+		map[string]interface{}{
+			"expected_version": expected,
+			"current":          current[0],
+		},
+	)
+}
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+
+	"meu-provedor/engine/query"
+	"meu-provedor/models"
+	tableService "meu-provedor/services/table"
+)
+
+// ============================================================================
+// VIEW SERVICE - Views do projeto a partir do spec estruturado do join-select
+// ============================================================================
+//
+// A view expõe sempre o id_instancia da tabela base, e cada JOIN exige o mesmo
+// id_instancia: consultada por /data/select, o filtro da instância continua
+// isolando os dados como em qualquer tabela.
+
+// CreateProjectView valida a definição e cria a view {code}_{view_name}
+func CreateProjectView(req models.CreateViewRequest) (string, error) {
+	if req.ProjectID <= 0 {
+		return "", models.ErrInvalidProjectID
+	}
+	return tableService.CreateView(req.ProjectID, req.ViewName, req.Definition, renderViewSelect)
+}
+
+// DropProjectView remove a view do projeto
+func DropProjectView(projectID int64, viewName string) error {
+	if projectID <= 0 {
+		return models.ErrInvalidProjectID
+	}
+	return tableService.DropView(projectID, viewName)
+}
+
+// ============================================================================
+// INTERNAL HELPERS
+// ============================================================================
+
+// renderViewSelect monta o SELECT da view (sem filtro de instância nem LIMIT)
+func renderViewSelect(db *sql.DB, projectCode string, def models.AdvancedJoinSelectRequest) (string, []interface{}, error) {
+	if err := checkViewDefinition(def); err != nil {
+		return "", nil, err
+	}
+
+	baseTable, err := BuildTableName(projectCode, def.Base.Table)
+	if err != nil {
+		return "", nil, err
+	}
+	baseAlias := def.Base.Alias
+	if baseAlias == "" {
+		baseAlias = baseTable
+	}
+
+	scope := newAliasScope(db)
+	if err := scope.add(baseAlias, baseTable, def.Base.Table); err != nil {
+		return "", nil, err
+	}
+	builder := query.NewJoinSelect(baseTable, baseAlias)
+
+	// id_instancia da base, a menos que a projeção já o exponha
+	if !exposesInstance(def.Base.Projection, baseAlias, def.Base.Table) {
+		builder.AddColumns(query.ColumnExpr(baseAlias, "id_instancia"))
+	}
+	cols, err := scope.projection(withDefaultAlias(def.Base.Projection, baseAlias))
+	if err != nil {
+		return "", nil, err
+	}
+	builder.AddColumns(cols...)
+
+	for _, j := range def.Joins {
+		joinTable, err := BuildTableName(projectCode, j.Table)
+		if err != nil {
+			return "", nil, err
+		}
+		joinAlias := j.Alias
+		if joinAlias == "" {
+			joinAlias = joinTable
+		}
+		if err := scope.add(joinAlias, joinTable, j.Table); err != nil {
+			return "", nil, err
+		}
+
+		on, err := scope.joinOn(j.Conditions)
+		if err != nil {
+			return "", nil, err
+		}
+		on = "(" + on + ") AND " + query.EqualityOn([][2]string{{
+			query.ColumnExpr(joinAlias, "id_instancia"),
+			query.ColumnExpr(baseAlias, "id_instancia"),
+		}})
+		builder.AddJoin(query.JoinConfig{Type: j.Type, Table: joinTable, Alias: joinAlias, On: on})
+
+		cols, err := scope.projection(withDefaultAlias(j.Projection, joinAlias))
+		if err != nil {
+			return "", nil, err
+		}
+		builder.AddColumns(cols...)
+	}
+
+	for k, v := range def.Where {
+		if !isValidColumnRef(k) {
+			return "", nil, fmt.Errorf("%w: %s", models.ErrInvalidColumn, k)
+		}
+		builder.AddWhereEq(k, v)
+	}
+
+	// agrupamentos incluem o id_instancia, senão as instâncias se misturariam
+	if len(def.Group) > 0 {
+		group, err := scope.group(append([]models.ColumnRef{{TableAlias: baseAlias, Column: "id_instancia"}}, def.Group...))
+		if err != nil {
+			return "", nil, err
+		}
+		builder.SetGroupBy(group)
+	}
+	if len(def.Sort) > 0 {
+		order, err := scope.sort(def.Sort)
+		if err != nil {
+			return "", nil, err
+		}
+		builder.SetOrderBy(order)
+	}
+
+	sqlQuery, args := builder.Build()
+	return sqlQuery, args, nil
+}
+
+// checkViewDefinition aceita apenas os campos estruturados do join-select
+func checkViewDefinition(def models.AdvancedJoinSelectRequest) error {
+	invalid := func(field string) error {
+		return models.ErrInvalidView.WithDetails(
+			fmt.Sprintf("campo não suportado em views: %s (use os campos estruturados)", field), nil,
+		)
+	}
+
+	if def.Base.Table == "" {
+		return models.ErrInvalidView.WithDetails("definition.base.table é obrigatório", nil)
+	}
+	if len(def.Base.Columns) > 0 {
+		return invalid("base.columns")
+	}
+	if len(def.WhereRaw) > 0 {
+		return invalid("where_raw")
+	}
+	if def.GroupBy != "" {
+		return invalid("group_by")
+	}
+	if def.Having != "" {
+		return invalid("having")
+	}
+	if def.OrderBy != "" {
+		return invalid("order_by")
+	}
+	if len(def.Base.Projection) == 0 {
+		return models.ErrInvalidView.WithDetails("definition.base.projection é obrigatório", nil)
+	}
+	for _, j := range def.Joins {
+		if j.On != "" {
+			return invalid("joins.on")
+		}
+		if len(j.Columns) > 0 {
+			return invalid("joins.columns")
+		}
+		if len(j.Conditions) == 0 {
+			return models.ErrInvalidView.WithDetails("joins.conditions é obrigatório", nil)
+		}
+	}
+	return nil
+}
+
+// exposesInstance indica se a projeção da base já inclui a coluna id_instancia
+func exposesInstance(specs []models.ProjectionSpec, baseAlias, baseTable string) bool {
+	for _, spec := range specs {
+		if spec.TableAlias != "" && spec.TableAlias != baseAlias && spec.TableAlias != baseTable {
+			continue
+		}
+		if spec.Column == "*" {
+			return true
+		}
+		if spec.Column == "id_instancia" && spec.Path == "" && (spec.Alias == "" || spec.Alias == "id_instancia") {
+			return true
+		}
+	}
+	return false
+}
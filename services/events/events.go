@@ -0,0 +1,72 @@
+package events
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"sync"
+	"time"
+
+	"meu-provedor/models"
+)
+
+// ============================================================================
+// EVENT HUB - Distribui as alterações de dados para os consumidores
+// (webhooks, assinaturas em tempo real, ...)
+// ============================================================================
+
+// Listener recebe os eventos emitidos. É chamado de forma síncrona, no fluxo
+// da requisição, então deve ser rápido (ou repassar para uma goroutine).
+type Listener func(event models.ChangeEvent)
+
+var (
+	listenersMu sync.RWMutex
+	listeners   []Listener
+)
+
+// Subscribe registra um consumidor de eventos
+func Subscribe(listener Listener) {
+	listenersMu.Lock()
+	listeners = append(listeners, listener)
+	listenersMu.Unlock()
+}
+
+// Stamp preenche id e horário do evento (quando ausentes). Usado por quem
+// precisa gravar o evento antes de emiti-lo.
+func Stamp(event *models.ChangeEvent) {
+	if event.ID == "" {
+		event.ID = newEventID()
+	}
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now().UTC()
+	}
+}
+
+// Emit preenche id e horário do evento e o entrega a todos os consumidores.
+// Um consumidor com panic não impede a entrega aos demais.
+func Emit(event models.ChangeEvent) {
+	Stamp(&event)
+
+	listenersMu.RLock()
+	current := listeners
+	listenersMu.RUnlock()
+
+	for _, listener := range current {
+		deliver(listener, event)
+	}
+}
+
+func deliver(listener Listener, event models.ChangeEvent) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("❌ Erro no consumidor de eventos: %v", r)
+		}
+	}()
+	listener(event)
+}
+
+func newEventID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,111 @@
+package instance
+
+import (
+	"database/sql"
+	"fmt"
+
+	"meu-provedor/config"
+	"meu-provedor/engine/dialect"
+)
+
+// ============================================================================
+// MIRROR - instancias_projetion nos bancos dedicados
+// ============================================================================
+//
+// O cadastro de instâncias continua no banco master. Nos bancos dedicados
+// existe apenas uma cópia mínima (id, project_id) para que as FKs das tabelas
+// {code}_* (id_instancia -> instancias_projetion.id ON DELETE CASCADE) funcionem.
+
+// EnsureMirror cria a tabela espelho de instâncias no banco dedicado
+func EnsureMirror(db *sql.DB) error {
+	d := config.DialectOf(db)
+	statements, err := d.CreateTable("instancias_projetion", true, []string{
+		"id " + d.BigIntType() + " NOT NULL PRIMARY KEY",
+		"project_id " + d.BigIntType() + " NOT NULL",
+		d.TimestampColumn("created_at", 0, false),
+	}, []dialect.Index{
+		{Name: "idx_instancias_project", Columns: []string{"project_id"}},
+	})
+	if err != nil {
+		return fmt.Errorf("erro ao criar espelho de instâncias: %w", err)
+	}
+	for _, stmt := range statements {
+		if _, err := db.Exec(stmt); err != nil {
+			return fmt.Errorf("erro ao criar espelho de instâncias: %w", err)
+		}
+	}
+	return nil
+}
+
+// SyncMirror copia para o banco dedicado as instâncias do projeto
+func SyncMirror(db *sql.DB, projectID int64) error {
+	if err := EnsureMirror(db); err != nil {
+		return err
+	}
+
+	rows, err := config.MasterDB.Query(
+		`SELECT id FROM instancias_projetion WHERE project_id = ?`, projectID)
+	if err != nil {
+		return fmt.Errorf("erro ao listar instâncias do projeto: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	insertMirror := config.DialectOf(db).InsertIgnore("instancias_projetion", []string{"id", "project_id"})
+	for _, id := range ids {
+		if _, err := db.Exec(insertMirror, id, projectID); err != nil {
+			return fmt.Errorf("erro ao copiar instância %d: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// mirrorInstance registra a instância no banco dedicado do projeto (se houver)
+func mirrorInstance(projectID, instanceID int64) error {
+	db, dedicated, err := dedicatedDB(projectID)
+	if err != nil || !dedicated {
+		return err
+	}
+	if err := EnsureMirror(db); err != nil {
+		return err
+	}
+	_, err = db.Exec(
+		config.DialectOf(db).InsertIgnore("instancias_projetion", []string{"id", "project_id"}),
+		instanceID, projectID,
+	)
+	return err
+}
+
+// unmirrorInstance remove a instância do banco dedicado; o CASCADE apaga as
+// linhas dela nas tabelas do projeto, como acontece no banco master
+func unmirrorInstance(projectID, instanceID int64) error {
+	db, dedicated, err := dedicatedDB(projectID)
+	if err != nil || !dedicated {
+		return err
+	}
+	_, err = db.Exec(dialect.Rebind(config.DialectOf(db), `DELETE FROM instancias_projetion WHERE id = ?`), instanceID)
+	return err
+}
+
+func dedicatedDB(projectID int64) (*sql.DB, bool, error) {
+	dedicated, err := config.IsDedicated(projectID)
+	if err != nil || !dedicated {
+		return nil, false, err
+	}
+	db, err := config.ProjectDB(projectID)
+	if err != nil {
+		return nil, false, err
+	}
+	return db, true, nil
+}
@@ -0,0 +1,94 @@
+package project
+
+import (
+	"fmt"
+	"strings"
+
+	"meu-provedor/config"
+	"meu-provedor/models"
+	"meu-provedor/services/instance"
+	tableService "meu-provedor/services/table"
+)
+
+// ============================================================================
+// BANCO DEDICADO POR PROJETO
+// ============================================================================
+
+// GetDatabase informa onde ficam as tabelas do projeto
+func GetDatabase(projectID int64) (*models.ProjectDatabase, error) {
+	if _, err := config.GetProjectCodeByID(int(projectID)); err != nil {
+		return nil, err
+	}
+
+	info := &models.ProjectDatabase{ProjectID: projectID, Mode: models.TenancyShared}
+
+	dsn, err := config.ProjectDSNInfo(projectID)
+	if err != nil {
+		return nil, err
+	}
+	if dsn != nil {
+		info.Mode = models.TenancyDedicated
+		info.Dialect = dsn.Dialect
+		info.Host = dsn.Host
+		info.Database = dsn.Database
+		info.User = dsn.User
+	}
+	return info, nil
+}
+
+// SetDatabase aponta o projeto para um banco dedicado. Só é permitido enquanto
+// o projeto não tem tabelas no banco atual; para mover dados use StartMigration.
+func SetDatabase(projectID int64, req models.ProjectDatabaseRequest) error {
+	if projectID <= 0 {
+		return models.ErrInvalidProjectID
+	}
+	if strings.TrimSpace(req.DSN) == "" {
+		return models.NewValidationError("dsn is required")
+	}
+	if err := ensureNoTables(projectID); err != nil {
+		return err
+	}
+
+	// Testar a conexão e preparar o espelho de instâncias antes de gravar
+	db, err := config.OpenProjectDB(req.DSN)
+	if err != nil {
+		return fmt.Errorf("%w: %v", models.ErrInvalidDSN, err)
+	}
+	defer db.Close()
+
+	if err := instance.SyncMirror(db, projectID); err != nil {
+		return err
+	}
+
+	return config.SetProjectDSN(projectID, req.DSN)
+}
+
+// RemoveDatabase volta o projeto para o banco master
+func RemoveDatabase(projectID int64) error {
+	if projectID <= 0 {
+		return models.ErrInvalidProjectID
+	}
+	dedicated, err := config.IsDedicated(projectID)
+	if err != nil || !dedicated {
+		return err
+	}
+	if err := ensureNoTables(projectID); err != nil {
+		return err
+	}
+	return config.RemoveProjectDSN(projectID)
+}
+
+// ensureNoTables impede trocar o banco de um projeto que já tem tabelas
+func ensureNoTables(projectID int64) error {
+	tables, err := tableService.List(projectID)
+	if err != nil {
+		return err
+	}
+	if len(tables) > 0 {
+		return models.ErrProjectHasTables.WithDetails(
+			fmt.Sprintf("o projeto possui %d tabela(s) no banco atual; use /database/migrate", len(tables)),
+			map[string]interface{}{"tables": tables},
+		)
+	}
+	return nil
+}
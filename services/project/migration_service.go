@@ -0,0 +1,916 @@
+package project
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"meu-provedor/config"
+	"meu-provedor/engine/dialect"
+	"meu-provedor/models"
+	"meu-provedor/services/instance"
+	tableService "meu-provedor/services/table"
+)
+
+// ============================================================================
+// MIGRAÇÃO DE TENANCY - Banco master <-> banco dedicado
+// ============================================================================
+//
+// Move as tabelas {code}_* do projeto (inclusive {code}__history) para outro
+// banco sem parar o projeto. Resumos materializados ({code}_mv_*) não têm id
+// para os chunks: só a estrutura é criada no destino, e as linhas são
+// reconstruídas lá a partir das tabelas de origem já copiadas.
+//
+//   1. copy:    cópia online em chunks de id, com as escritas liberadas e
+//               marcadas por tabela no catálogo master; rodadas de
+//               recuperação recopiam as tabelas marcadas e uma verificação
+//               completa ainda online fecha a fase
+//   2. freeze:  escritas congeladas em todos os processos; só as tabelas
+//               marcadas desde a verificação online são ressincronizadas
+//   3. verify:  contagem de linhas e checksum completo dessas tabelas
+//   4. switch:  troca do roteamento (os demais processos a veem na próxima
+//               escrita) e descongelamento das escritas
+//   5. cleanup: remoção das tabelas de origem (apenas com drop_source), após
+//               o tempo de cache do roteamento
+//
+// As instâncias do projeto são copiadas para o espelho instancias_projetion
+// do banco dedicado; no banco master o cadastro já existe.
+
+var (
+	migrationsMu sync.Mutex
+	migrations   = map[int64]*models.TenancyMigration{}
+)
+
+// migrationPlan reúne os bancos e tabelas de uma migração
+type migrationPlan struct {
+	projectID   int64
+	code        string
+	req         models.TenancyMigrationRequest
+	source      *sql.DB
+	target      *sql.DB
+	closeTarget bool
+	status      *models.TenancyMigration
+}
+
+// sqlRunner é implementado por *sql.DB e *sql.Conn
+type sqlRunner interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// StartMigration valida o pedido e inicia a migração em segundo plano
+func StartMigration(projectID int64, req models.TenancyMigrationRequest) (*models.TenancyMigration, error) {
+	if projectID <= 0 {
+		return nil, models.ErrInvalidProjectID
+	}
+	req.Mode = strings.ToLower(strings.TrimSpace(req.Mode))
+
+	code, err := config.GetProjectCodeByID(int(projectID))
+	if err != nil {
+		return nil, err
+	}
+	if err := checkPrefixNotShared(code); err != nil {
+		return nil, err
+	}
+	dedicated, err := config.IsDedicated(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	from := models.TenancyShared
+	if dedicated {
+		from = models.TenancyDedicated
+	}
+
+	plan := &migrationPlan{projectID: projectID, code: code, req: req}
+	if plan.source, err = config.ProjectDB(projectID); err != nil {
+		return nil, fmt.Errorf("%w: %v", models.ErrDatabaseConnection, err)
+	}
+
+	switch req.Mode {
+	case models.TenancyDedicated:
+		if strings.TrimSpace(req.DSN) == "" {
+			return nil, models.NewValidationError("dsn is required for mode dedicated")
+		}
+		if err := checkNotCurrentDSN(projectID, req.DSN); err != nil {
+			return nil, err
+		}
+		if plan.target, err = config.OpenProjectDB(req.DSN); err != nil {
+			return nil, fmt.Errorf("%w: %v", models.ErrInvalidDSN, err)
+		}
+		plan.closeTarget = true
+
+	case models.TenancyShared:
+		if !dedicated {
+			return nil, models.NewValidationError("o projeto já usa o banco master")
+		}
+		plan.target = config.MasterDB
+
+	default:
+		return nil, models.ErrInvalidTenancyMode
+	}
+
+	// A cópia depende de SHOW CREATE TABLE e do information_schema do MySQL
+	if config.DialectOf(plan.source) != dialect.MySQL || config.DialectOf(plan.target) != dialect.MySQL {
+		if plan.closeTarget {
+			plan.target.Close()
+		}
+		return nil, models.NewValidationError("a migração de tenancy só é suportada entre bancos MySQL")
+	}
+
+	migrationsMu.Lock()
+	if current, ok := migrations[projectID]; ok && current.Status == models.MigrationRunning {
+		migrationsMu.Unlock()
+		if plan.closeTarget {
+			plan.target.Close()
+		}
+		return nil, models.ErrMigrationRunning
+	}
+	plan.status = &models.TenancyMigration{
+		ProjectID: projectID,
+		From:      from,
+		To:        req.Mode,
+		Status:    models.MigrationRunning,
+		Phase:     models.MigrationPhaseCopy,
+		Tables:    []models.TableMigrationStatus{},
+		StartedAt: time.Now(),
+	}
+	migrations[projectID] = plan.status
+	snapshot := snapshotMigration(plan.status)
+	migrationsMu.Unlock()
+
+	go plan.run()
+	return snapshot, nil
+}
+
+// GetMigration retorna o andamento da última migração do projeto
+func GetMigration(projectID int64) (*models.TenancyMigration, error) {
+	migrationsMu.Lock()
+	defer migrationsMu.Unlock()
+
+	m, ok := migrations[projectID]
+	if !ok {
+		return nil, models.ErrMigrationNotFound
+	}
+	return snapshotMigration(m), nil
+}
+
+// ============================================================================
+// EXECUÇÃO
+// ============================================================================
+
+func (p *migrationPlan) run() {
+	if p.closeTarget {
+		defer p.target.Close()
+	}
+
+	err := p.execute()
+
+	migrationsMu.Lock()
+	now := time.Now()
+	p.status.FinishedAt = &now
+	if err != nil {
+		p.status.Status = models.MigrationFailed
+		p.status.Error = err.Error()
+	} else {
+		p.status.Status = models.MigrationCompleted
+		p.status.Phase = models.MigrationPhaseDone
+	}
+	migrationsMu.Unlock()
+
+	if err != nil {
+		log.Printf("❌ Migração do projeto %d falhou: %v", p.projectID, err)
+	} else {
+		log.Printf("✅ Projeto %d migrado para %s", p.projectID, p.req.Mode)
+	}
+}
+
+func (p *migrationPlan) execute() error {
+	ctx := context.Background()
+
+	// A partir daqui as escritas marcam as tabelas alteradas (em todos os processos)
+	if err := config.TrackProjectWrites(p.projectID, true); err != nil {
+		return err
+	}
+	defer config.TrackProjectWrites(p.projectID, false)
+	// Processos que decidem pelo gate em cache passam a marcar as escritas
+	time.Sleep(config.WriteGateStaleness())
+
+	tables, err := listPhysicalTables(ctx, p.source, p.code)
+	if err != nil {
+		return err
+	}
+	p.update(func(m *models.TenancyMigration) {
+		for _, t := range tables {
+			m.Tables = append(m.Tables, models.TableMigrationStatus{Table: t})
+		}
+	})
+
+	// Conexão única no destino, sem checagem de FK durante a cópia
+	conn, err := p.target.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("erro ao conectar no destino: %w", err)
+	}
+	defer func() {
+		conn.ExecContext(ctx, "SET FOREIGN_KEY_CHECKS = 1")
+		conn.Close()
+	}()
+	if _, err := conn.ExecContext(ctx, "SET FOREIGN_KEY_CHECKS = 0"); err != nil {
+		return err
+	}
+
+	if p.req.Mode == models.TenancyDedicated {
+		if err := instance.SyncMirror(p.target, p.projectID); err != nil {
+			return err
+		}
+	}
+
+	// Criar as tabelas no destino (que não pode tê-las)
+	columns := make(map[string][]string, len(tables))
+	for _, t := range tables {
+		if exists, err := tableExists(ctx, conn, t); err != nil {
+			return err
+		} else if exists {
+			return models.ErrTableExists.WithDetails(
+				fmt.Sprintf("a tabela %s já existe no banco de destino", t),
+				map[string]interface{}{"table": t},
+			)
+		}
+		ddl, err := showCreateTable(ctx, p.source, t)
+		if err != nil {
+			return err
+		}
+		if _, err := conn.ExecContext(ctx, ddl); err != nil {
+			return fmt.Errorf("erro ao criar %s no destino: %w", t, err)
+		}
+		if columns[t], err = tableColumns(ctx, p.source, t); err != nil {
+			return err
+		}
+	}
+
+	// 1. Cópia online (resumos materializados são reconstruídos, não copiados)
+	for _, t := range tables {
+		if summary, err := tableService.IsSummary(t); err != nil {
+			return err
+		} else if summary {
+			continue
+		}
+		if err := p.syncTable(ctx, conn, t, columns[t]); err != nil {
+			return err
+		}
+	}
+
+	// Rodadas de recuperação: recopia as tabelas escritas durante a rodada anterior
+	for round := 0; round < migrationCatchUpRounds(); round++ {
+		dirty, err := config.ConsumeTableWrites(p.projectID)
+		if err != nil {
+			return err
+		}
+		if len(dirty) == 0 {
+			break
+		}
+		if err := p.syncDirty(ctx, conn, dirty, columns); err != nil {
+			return err
+		}
+	}
+
+	// Verificação completa ainda online: o que for escrito a partir daqui
+	// fica marcado e é reverificado com as escritas congeladas
+	if _, err := config.ConsumeTableWrites(p.projectID); err != nil {
+		return err
+	}
+	p.setPhase(models.MigrationPhaseVerify)
+	for _, t := range sortedTables(columns) {
+		if summary, err := tableService.IsSummary(t); err != nil {
+			return err
+		} else if summary {
+			continue
+		}
+		if err := p.verifyTable(ctx, conn, t, columns[t], true); err != nil {
+			return err
+		}
+	}
+	if err := p.rebuildSummaries(columns, nil); err != nil {
+		return err
+	}
+
+	// 2. Congelar escritas e reaplicar apenas as tabelas alteradas
+	p.setPhase(models.MigrationPhaseFreeze)
+	if err := config.FreezeProjectWrites(p.projectID, migrationFreezeTimeout()); err != nil {
+		return err
+	}
+	frozenAt := time.Now()
+	unfreeze := func() {
+		config.UnfreezeProjectWrites(p.projectID)
+		p.update(func(m *models.TenancyMigration) {
+			m.FrozenMs = time.Since(frozenAt).Milliseconds()
+		})
+	}
+
+	if err := p.finalize(ctx, conn, columns); err != nil {
+		unfreeze()
+		return err
+	}
+	unfreeze()
+
+	// 5. Remover a origem (opcional), depois que nenhum processo lê mais por
+	// um roteamento em cache anterior à troca
+	if p.req.DropSource {
+		p.setPhase(models.MigrationPhaseCleanup)
+		time.Sleep(config.RoutingCacheTTL())
+		if err := p.dropSource(ctx, sortedTables(columns)); err != nil {
+			return fmt.Errorf("roteamento já trocado, mas a limpeza da origem falhou: %w", err)
+		}
+	}
+	return nil
+}
+
+// finalize roda com as escritas congeladas: sincroniza e verifica só as
+// tabelas alteradas desde a verificação online e troca o roteamento
+func (p *migrationPlan) finalize(ctx context.Context, conn *sql.Conn, columns map[string][]string) error {
+	if p.req.Mode == models.TenancyDedicated {
+		// Instâncias criadas durante a cópia
+		if err := instance.SyncMirror(p.target, p.projectID); err != nil {
+			return err
+		}
+	}
+
+	dirty, err := config.ConsumeTableWrites(p.projectID)
+	if err != nil {
+		return err
+	}
+	// Tabelas criadas durante a cópia também são marcadas; a listagem é só
+	// uma garantia barata
+	current, err := listPhysicalTables(ctx, p.source, p.code)
+	if err != nil {
+		return err
+	}
+	marked := make(map[string]bool, len(dirty))
+	for _, t := range dirty {
+		marked[t] = true
+	}
+	for _, t := range current {
+		if _, ok := columns[t]; !ok && !marked[t] {
+			dirty = append(dirty, t)
+		}
+	}
+	if err := p.syncDirty(ctx, conn, dirty, columns); err != nil {
+		return err
+	}
+
+	// 3. Verificação das tabelas alteradas (as demais já foram verificadas)
+	// e reconstrução dos resumos que dependem delas
+	p.setPhase(models.MigrationPhaseVerify)
+	for _, t := range dirty {
+		cols, ok := columns[t]
+		if !ok {
+			continue
+		}
+		if summary, err := tableService.IsSummary(t); err != nil {
+			return err
+		} else if summary {
+			continue
+		}
+		if err := p.verifyTable(ctx, conn, t, cols, false); err != nil {
+			return err
+		}
+	}
+	changed := make(map[string]bool, len(dirty))
+	for _, t := range dirty {
+		changed[t] = true
+	}
+	if err := p.rebuildSummaries(columns, changed); err != nil {
+		return err
+	}
+
+	// 4. Troca do roteamento, se o congelamento ainda vale em todos os processos
+	p.setPhase(models.MigrationPhaseSwitch)
+	if err := config.RenewProjectFreeze(p.projectID); err != nil {
+		return err
+	}
+	if p.req.Mode == models.TenancyDedicated {
+		err = config.SetProjectDSN(p.projectID, p.req.DSN)
+	} else {
+		err = config.RemoveProjectDSN(p.projectID)
+	}
+	if err != nil {
+		return err
+	}
+	for t := range columns {
+		tableService.InvalidateColumns(t)
+	}
+	return nil
+}
+
+// syncDirty recria no destino as tabelas alteradas por DDL (ou removidas da
+// origem) e recopia os chunks divergentes das demais
+func (p *migrationPlan) syncDirty(ctx context.Context, conn *sql.Conn, dirty []string, columns map[string][]string) error {
+	sort.Strings(dirty)
+	for _, t := range dirty {
+		cols, exists, err := p.reconcileTable(ctx, conn, t)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			delete(columns, t)
+			p.removeTableStatus(t)
+			continue
+		}
+		columns[t] = cols
+		if summary, err := tableService.IsSummary(t); err != nil {
+			return err
+		} else if summary {
+			continue
+		}
+		if err := p.syncTable(ctx, conn, t, cols); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rebuildSummaries recalcula no destino os resumos materializados a partir
+// das tabelas já copiadas. Com changed, apenas os resumos alterados ou cuja
+// origem foi alterada.
+func (p *migrationPlan) rebuildSummaries(columns map[string][]string, changed map[string]bool) error {
+	for _, t := range sortedTables(columns) {
+		summary, err := tableService.GetSummary(t)
+		if err != nil {
+			return err
+		}
+		if summary == nil {
+			continue
+		}
+		source := fmt.Sprintf("%s_%s", p.code, summary.Source)
+		if changed != nil && !changed[t] && !changed[source] {
+			continue
+		}
+		if err := tableService.RebuildSummary(p.target, p.code, t); err != nil {
+			return fmt.Errorf("erro ao reconstruir o resumo %s no destino: %w", t, err)
+		}
+		p.updateTableStatus(t, func(s *models.TableMigrationStatus) {
+			s.Verified = true
+		})
+	}
+	return nil
+}
+
+// reconcileTable alinha a estrutura da tabela no destino com a da origem
+func (p *migrationPlan) reconcileTable(ctx context.Context, conn *sql.Conn, table string) ([]string, bool, error) {
+	exists, err := tableExists(ctx, p.source, table)
+	if err != nil {
+		return nil, false, err
+	}
+	if !exists {
+		_, err := conn.ExecContext(ctx, "DROP TABLE IF EXISTS "+quoteIdent(table))
+		return nil, false, err
+	}
+
+	ddl, err := showCreateTable(ctx, p.source, table)
+	if err != nil {
+		return nil, false, err
+	}
+	if exists, err := tableExists(ctx, conn, table); err != nil {
+		return nil, false, err
+	} else if exists {
+		current, err := showCreateTable(ctx, conn, table)
+		if err != nil {
+			return nil, false, err
+		}
+		if withoutAutoIncrement(current) == withoutAutoIncrement(ddl) {
+			columns, err := tableColumns(ctx, p.source, table)
+			return columns, true, err
+		}
+		// Estrutura alterada durante a cópia: recria e copia de novo
+		if _, err := conn.ExecContext(ctx, "DROP TABLE "+quoteIdent(table)); err != nil {
+			return nil, false, err
+		}
+	}
+	if _, err := conn.ExecContext(ctx, ddl); err != nil {
+		return nil, false, fmt.Errorf("erro ao criar %s no destino: %w", table, err)
+	}
+	columns, err := tableColumns(ctx, p.source, table)
+	return columns, true, err
+}
+
+// verifyTable compara contagem e checksum completos da tabela e alinha o
+// AUTO_INCREMENT. Com resync, uma divergência é recopiada e verificada de novo.
+func (p *migrationPlan) verifyTable(ctx context.Context, conn *sql.Conn, table string, columns []string, resync bool) error {
+	srcCount, srcSum, err := rangeChecksum(ctx, p.source, table, columns, -1, -1)
+	if err != nil {
+		return err
+	}
+	dstCount, dstSum, err := rangeChecksum(ctx, conn, table, columns, -1, -1)
+	if err != nil {
+		return err
+	}
+	if srcCount != dstCount || srcSum != dstSum {
+		if resync {
+			if err := p.syncTable(ctx, conn, table, columns); err != nil {
+				return err
+			}
+			return p.verifyTable(ctx, conn, table, columns, false)
+		}
+		return fmt.Errorf("verificação falhou em %s: origem %d linhas (checksum %d), destino %d linhas (checksum %d)",
+			table, srcCount, srcSum, dstCount, dstSum)
+	}
+	if err := alignAutoIncrement(ctx, p.source, conn, table); err != nil {
+		return err
+	}
+	p.updateTableStatus(table, func(s *models.TableMigrationStatus) {
+		s.Rows = srcCount
+		s.Checksum = strconv.FormatUint(srcSum, 16)
+		s.Verified = true
+	})
+	return nil
+}
+
+// syncTable percorre a origem em chunks de id e recopia os chunks cujo
+// checksum difere do destino (na primeira passada, todos)
+func (p *migrationPlan) syncTable(ctx context.Context, conn *sql.Conn, table string, columns []string) error {
+	size := migrationChunkSize()
+	var last int64
+
+	for {
+		end, ok, err := chunkEnd(ctx, p.source, table, last, size)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			// Linhas removidas da origem após o último chunk
+			_, err := conn.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE id > ?", quoteIdent(table)), last)
+			return err
+		}
+
+		srcCount, srcSum, err := rangeChecksum(ctx, p.source, table, columns, last, end)
+		if err != nil {
+			return err
+		}
+		dstCount, dstSum, err := rangeChecksum(ctx, conn, table, columns, last, end)
+		if err != nil {
+			return err
+		}
+		if srcCount != dstCount || srcSum != dstSum {
+			if err := copyRange(ctx, p.source, conn, table, columns, last, end); err != nil {
+				return err
+			}
+			p.updateTableStatus(table, func(s *models.TableMigrationStatus) {
+				s.ChunksCopied++
+				s.Verified = false
+			})
+		}
+		last = end
+	}
+}
+
+// dropSource remove as tabelas (e o espelho de instâncias) do banco de origem
+func (p *migrationPlan) dropSource(ctx context.Context, tables []string) error {
+	conn, err := p.source.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		conn.ExecContext(ctx, "SET FOREIGN_KEY_CHECKS = 1")
+		conn.Close()
+	}()
+	if _, err := conn.ExecContext(ctx, "SET FOREIGN_KEY_CHECKS = 0"); err != nil {
+		return err
+	}
+
+	for _, t := range tables {
+		if _, err := conn.ExecContext(ctx, "DROP TABLE "+quoteIdent(t)); err != nil {
+			return fmt.Errorf("erro ao remover %s: %w", t, err)
+		}
+	}
+	if p.req.Mode == models.TenancyShared {
+		// A origem era o banco dedicado: o espelho das instâncias não é mais usado
+		if _, err := conn.ExecContext(ctx, "DELETE FROM instancias_projetion WHERE project_id = ?", p.projectID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *migrationPlan) setPhase(phase string) {
+	p.update(func(m *models.TenancyMigration) { m.Phase = phase })
+}
+
+func (p *migrationPlan) update(fn func(m *models.TenancyMigration)) {
+	migrationsMu.Lock()
+	fn(p.status)
+	migrationsMu.Unlock()
+}
+
+// updateTableStatus altera o estado da tabela, incluindo-a se foi criada
+// durante a migração
+func (p *migrationPlan) updateTableStatus(table string, fn func(s *models.TableMigrationStatus)) {
+	p.update(func(m *models.TenancyMigration) {
+		for i := range m.Tables {
+			if m.Tables[i].Table == table {
+				fn(&m.Tables[i])
+				return
+			}
+		}
+		m.Tables = append(m.Tables, models.TableMigrationStatus{Table: table})
+		fn(&m.Tables[len(m.Tables)-1])
+	})
+}
+
+// removeTableStatus retira do estado uma tabela removida durante a migração
+func (p *migrationPlan) removeTableStatus(table string) {
+	p.update(func(m *models.TenancyMigration) {
+		for i := range m.Tables {
+			if m.Tables[i].Table == table {
+				m.Tables = append(m.Tables[:i], m.Tables[i+1:]...)
+				return
+			}
+		}
+	})
+}
+
+// ============================================================================
+// INTERNAL HELPERS
+// ============================================================================
+
+// checkNotCurrentDSN impede migrar para o banco que o projeto já usa
+func checkNotCurrentDSN(projectID int64, dsn string) error {
+	same, err := config.UsesDSN(projectID, dsn)
+	if err != nil {
+		return fmt.Errorf("%w: %v", models.ErrInvalidDSN, err)
+	}
+	if same {
+		return models.NewValidationError("o projeto já usa este banco")
+	}
+	return nil
+}
+
+// checkPrefixNotShared recusa a migração quando o prefixo {code}_ também é o
+// início do prefixo de outro projeto (codes antigos com "_"): as tabelas dos
+// dois projetos não podem ser distinguidas pelo nome
+func checkPrefixNotShared(code string) error {
+	rows, err := config.MasterDB.Query(`SELECT code FROM projects WHERE code <> ?`, code)
+	if err != nil {
+		return fmt.Errorf("erro ao listar projetos: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var other string
+		if err := rows.Scan(&other); err != nil {
+			return err
+		}
+		if strings.HasPrefix(other, code+"_") || strings.HasPrefix(code, other+"_") {
+			return models.NewValidationError(fmt.Sprintf(
+				"o prefixo de tabelas do projeto se sobrepõe ao do projeto %q; a migração não pode separar as tabelas", other))
+		}
+	}
+	return rows.Err()
+}
+
+// listPhysicalTables lista as tabelas {code}_* do banco
+func listPhysicalTables(ctx context.Context, db sqlRunner, code string) ([]string, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT table_name
+		FROM information_schema.tables
+		WHERE table_schema = DATABASE()
+		AND table_type = 'BASE TABLE'
+		AND table_name LIKE ?`, code+"_%",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar tabelas do projeto: %w", err)
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(name, code+"_") {
+			tables = append(tables, name)
+		}
+	}
+	sort.Strings(tables)
+	return tables, rows.Err()
+}
+
+func tableExists(ctx context.Context, db sqlRunner, table string) (bool, error) {
+	var count int
+	err := db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM information_schema.tables
+		WHERE table_schema = DATABASE() AND table_name = ?`, table,
+	).Scan(&count)
+	return count > 0, err
+}
+
+func showCreateTable(ctx context.Context, db sqlRunner, table string) (string, error) {
+	var name, ddl string
+	if err := db.QueryRowContext(ctx, "SHOW CREATE TABLE "+quoteIdent(table)).Scan(&name, &ddl); err != nil {
+		return "", fmt.Errorf("erro ao ler DDL de %s: %w", table, err)
+	}
+	return ddl, nil
+}
+
+func tableColumns(ctx context.Context, db sqlRunner, table string) ([]string, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT COLUMN_NAME FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?
+		ORDER BY ORDINAL_POSITION`, table,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		columns = append(columns, name)
+	}
+	return columns, rows.Err()
+}
+
+// chunkEnd retorna o id que fecha o próximo chunk após "after"
+func chunkEnd(ctx context.Context, db sqlRunner, table string, after int64, size int) (int64, bool, error) {
+	var end sql.NullInt64
+	err := db.QueryRowContext(ctx,
+		fmt.Sprintf("SELECT id FROM %s WHERE id > ? ORDER BY id LIMIT 1 OFFSET ?", quoteIdent(table)),
+		after, size-1,
+	).Scan(&end)
+	if err == sql.ErrNoRows {
+		// Último chunk (menor que size)
+		err = db.QueryRowContext(ctx,
+			fmt.Sprintf("SELECT MAX(id) FROM %s WHERE id > ?", quoteIdent(table)), after,
+		).Scan(&end)
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("erro ao delimitar chunk de %s: %w", table, err)
+	}
+	return end.Int64, end.Valid, nil
+}
+
+// rangeChecksum calcula COUNT e BIT_XOR(CRC32) das linhas com lo < id <= hi
+// (lo e hi negativos = tabela inteira). Cada coluna entra como "isnull#valor".
+func rangeChecksum(ctx context.Context, db sqlRunner, table string, columns []string, lo, hi int64) (int64, uint64, error) {
+	parts := make([]string, len(columns))
+	for i, col := range columns {
+		c := quoteIdent(col)
+		parts[i] = fmt.Sprintf("ISNULL(%s), IFNULL(CAST(%s AS CHAR), '')", c, c)
+	}
+	sqlQuery := fmt.Sprintf("SELECT COUNT(*), COALESCE(BIT_XOR(CRC32(CONCAT_WS('#', %s))), 0) FROM %s",
+		strings.Join(parts, ", "), quoteIdent(table))
+
+	var args []interface{}
+	if lo >= 0 && hi >= 0 {
+		sqlQuery += " WHERE id > ? AND id <= ?"
+		args = append(args, lo, hi)
+	}
+
+	var count int64
+	var sum uint64
+	if err := db.QueryRowContext(ctx, sqlQuery, args...).Scan(&count, &sum); err != nil {
+		return 0, 0, fmt.Errorf("erro ao calcular checksum de %s: %w", table, err)
+	}
+	return count, sum, nil
+}
+
+// copyRange substitui no destino as linhas com lo < id <= hi pelas da origem
+func copyRange(ctx context.Context, src, dst sqlRunner, table string, columns []string, lo, hi int64) error {
+	quoted := make([]string, len(columns))
+	for i, col := range columns {
+		quoted[i] = quoteIdent(col)
+	}
+	colList := strings.Join(quoted, ", ")
+
+	if _, err := dst.ExecContext(ctx,
+		fmt.Sprintf("DELETE FROM %s WHERE id > ? AND id <= ?", quoteIdent(table)), lo, hi,
+	); err != nil {
+		return fmt.Errorf("erro ao limpar chunk de %s: %w", table, err)
+	}
+
+	rows, err := src.QueryContext(ctx,
+		fmt.Sprintf("SELECT %s FROM %s WHERE id > ? AND id <= ? ORDER BY id", colList, quoteIdent(table)), lo, hi,
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao ler chunk de %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	// Respeita o limite de 65535 placeholders do MySQL
+	perInsert := 60000 / len(columns)
+	if perInsert < 1 {
+		perInsert = 1
+	}
+	rowPlaceholder := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ") + ")"
+
+	var batch []interface{}
+	pending := 0
+	flush := func() error {
+		if pending == 0 {
+			return nil
+		}
+		placeholders := strings.TrimSuffix(strings.Repeat(rowPlaceholder+", ", pending), ", ")
+		_, err := dst.ExecContext(ctx,
+			fmt.Sprintf("INSERT INTO %s (%s) VALUES %s", quoteIdent(table), colList, placeholders), batch...)
+		batch, pending = batch[:0], 0
+		if err != nil {
+			return fmt.Errorf("erro ao copiar chunk de %s: %w", table, err)
+		}
+		return nil
+	}
+
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		ptrs := make([]interface{}, len(columns))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return err
+		}
+		batch = append(batch, values...)
+		pending++
+		if pending >= perInsert {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return flush()
+}
+
+var (
+	autoIncrementRe       = regexp.MustCompile(`AUTO_INCREMENT=(\d+)`)
+	autoIncrementOptionRe = regexp.MustCompile(` AUTO_INCREMENT=\d+`)
+)
+
+// withoutAutoIncrement remove do DDL o contador, que difere entre os bancos
+func withoutAutoIncrement(ddl string) string {
+	return autoIncrementOptionRe.ReplaceAllString(ddl, "")
+}
+
+// alignAutoIncrement leva o contador AUTO_INCREMENT da origem para o destino
+// (ids de linhas removidas no fim da tabela não são reutilizados)
+func alignAutoIncrement(ctx context.Context, src, dst sqlRunner, table string) error {
+	ddl, err := showCreateTable(ctx, src, table)
+	if err != nil {
+		return err
+	}
+	match := autoIncrementRe.FindStringSubmatch(ddl)
+	if match == nil {
+		return nil
+	}
+	_, err = dst.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s AUTO_INCREMENT = %s", quoteIdent(table), match[1]))
+	return err
+}
+
+func quoteIdent(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+func snapshotMigration(m *models.TenancyMigration) *models.TenancyMigration {
+	copied := *m
+	copied.Tables = append([]models.TableMigrationStatus{}, m.Tables...)
+	return &copied
+}
+
+func migrationChunkSize() int {
+	return config.EnvPositiveInt("MIGRATION_CHUNK_SIZE", 1000)
+}
+
+// sortedTables retorna as tabelas migradas em ordem
+func sortedTables(columns map[string][]string) []string {
+	tables := make([]string, 0, len(columns))
+	for t := range columns {
+		tables = append(tables, t)
+	}
+	sort.Strings(tables)
+	return tables
+}
+
+// migrationCatchUpRounds aceita 0 (congela logo após a cópia inicial)
+func migrationCatchUpRounds() int {
+	if rounds := config.EnvInt("MIGRATION_CATCHUP_ROUNDS", 3); rounds >= 0 {
+		return rounds
+	}
+	return 3
+}
+
+func migrationFreezeTimeout() time.Duration {
+	return time.Duration(config.EnvPositiveInt("MIGRATION_FREEZE_TIMEOUT_SECONDS", 30)) * time.Second
+}
@@ -1,103 +1,114 @@
-package project
-
-import (
-	"database/sql"
-	"errors"
-	"meu-provedor/config"
-	"meu-provedor/models"
-)
-
-// Create insere um novo projeto
-func Create(req models.ProjectRequest) error {
-	if req.Code == "" {
-		return errors.New("code is required")
-	}
-
-	exists, err := CodeExists(req.Code)
-	if err != nil {
-		return err
-	}
-	if exists {
-		return models.ErrProjectCodeExists
-	}
-
-	_, err = config.MasterDB.Exec(`
-		INSERT INTO projects (name, code, api_key, type, version, status)
-		VALUES (?, ?, ?, ?, ?, ?)`,
-		req.Name,
-		req.Code,
-		req.ApiKey,
-		req.Type,
-		req.Version,
-		req.Status,
-	)
-	return err
-}
-
-// List retorna todos os projetos
-func List() ([]models.Project, error) {
-	rows, err := config.MasterDB.Query(`
-		SELECT id, name, code, api_key, type, version, status, created_at
-		FROM projects`,
-	)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var projects []models.Project
-	for rows.Next() {
-		var p models.Project
-		err := rows.Scan(
-			&p.ID,
-			&p.Name,
-			&p.Code,
-			&p.ApiKey,
-			&p.Type,
-			&p.Version,
-			&p.Status,
-			&p.CreatedAt,
-		)
-		if err != nil {
-			continue
-		}
-		projects = append(projects, p)
-	}
-	return projects, nil
-}
-
-// Update atualiza um projeto existente (SEM alterar o code)
-func Update(id int64, req models.ProjectUpdateRequest) error {
-	_, err := config.MasterDB.Exec(`
-		UPDATE projects
-		SET name=?, api_key=?, type=?, version=?, status=?
-		WHERE id=?`,
-		req.Name,
-		req.ApiKey,
-		req.Type,
-		req.Version,
-		req.Status,
-		id,
-	)
-	return err
-}
-
-// Delete remove um projeto
-func Delete(id int64) error {
-	_, err := config.MasterDB.Exec(`DELETE FROM projects WHERE id=?`, id)
-	return err
-}
-
-// CodeExists verifica se um código de projeto já existe
-func CodeExists(code string) (bool, error) {
-	var exists int
-	err := config.MasterDB.QueryRow(
-		`SELECT 1 FROM projects WHERE code=? LIMIT 1`,
-		code,
-	).Scan(&exists)
-
-	if err == sql.ErrNoRows {
-		return false, nil
-	}
-	return err == nil, err
-}
+package project
+
+import (
+	"database/sql"
+	"regexp"
+	"meu-provedor/config"
+	"meu-provedor/models"
+)
+
+// projectCodeRe: o code prefixa as tabelas ({code}_tabela); com "_" o
+// prefixo de um projeto poderia ser o início do prefixo de outro
+var projectCodeRe = regexp.MustCompile(`^[A-Za-z0-9]{1,32}$`)
+
+// Create insere um novo projeto
+func Create(req models.ProjectRequest) error {
+	if req.Code == "" {
+		return models.NewValidationError("code is required")
+	}
+	if !projectCodeRe.MatchString(req.Code) {
+		return models.ErrInvalidProjectCode
+	}
+
+	exists, err := CodeExists(req.Code)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return models.ErrProjectCodeExists
+	}
+
+	_, err = config.MasterDB.Exec(`
+		INSERT INTO projects (name, code, api_key, type, version, status)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		req.Name,
+		req.Code,
+		req.ApiKey,
+		req.Type,
+		req.Version,
+		req.Status,
+	)
+	return err
+}
+
+// List retorna todos os projetos
+func List() ([]models.Project, error) {
+	rows, err := config.MasterDB.Query(`
+		SELECT id, name, code, api_key, type, version, status, created_at
+		FROM projects`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var projects []models.Project
+	for rows.Next() {
+		var p models.Project
+		err := rows.Scan(
+			&p.ID,
+			&p.Name,
+			&p.Code,
+			&p.ApiKey,
+			&p.Type,
+			&p.Version,
+			&p.Status,
+			&p.CreatedAt,
+		)
+		if err != nil {
+			continue
+		}
+		projects = append(projects, p)
+	}
+	return projects, nil
+}
+
+// Update atualiza um projeto existente (SEM alterar o code)
+func Update(id int64, req models.ProjectUpdateRequest) error {
+	_, err := config.MasterDB.Exec(`
+		UPDATE projects
+		SET name=?, api_key=?, type=?, version=?, status=?
+		WHERE id=?`,
+		req.Name,
+		req.ApiKey,
+		req.Type,
+		req.Version,
+		req.Status,
+		id,
+	)
+	return err
+}
+
+// Delete remove um projeto
+func Delete(id int64) error {
+	_, err := config.MasterDB.Exec(`DELETE FROM projects WHERE id=?`, id)
+	if err != nil {
+		return err
+	}
+	// Remove o roteamento para o banco dedicado (o banco em si não é apagado)
+	return config.RemoveProjectDSN(id)
+}
+
+// CodeExists verifica se um código de projeto já existe
+func CodeExists(code string) (bool, error) {
+	var exists int
+	err := config.MasterDB.QueryRow(
+		`SELECT 1 FROM projects WHERE code=? LIMIT 1`,
+		code,
+	).Scan(&exists)
+
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
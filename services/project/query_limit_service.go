@@ -0,0 +1,94 @@
+package project
+
+import (
+	"database/sql"
+	"fmt"
+
+	"meu-provedor/config"
+	"meu-provedor/models"
+)
+
+// ============================================================================
+// LIMITES DE CONSULTA POR PROJETO
+// ============================================================================
+
+// EnsureQueryLimitTable garante que a tabela project_query_limits existe
+func EnsureQueryLimitTable() error {
+	_, err := config.MasterDB.Exec(`
+		CREATE TABLE IF NOT EXISTS project_query_limits (
+			project_id BIGINT UNSIGNED NOT NULL PRIMARY KEY,
+			statement_timeout_ms INT NOT NULL DEFAULT 0,
+			max_rows INT NOT NULL DEFAULT 0,
+			api_key_raw_sql TINYINT(1) NOT NULL DEFAULT 0,
+			updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+		)`)
+	if err != nil {
+		return fmt.Errorf("erro ao criar tabela project_query_limits: %w", err)
+	}
+
+	// Tabelas criadas antes da coluna api_key_raw_sql
+	columns, err := config.DialectOf(config.MasterDB).Columns(config.MasterDB, "project_query_limits")
+	if err != nil {
+		return fmt.Errorf("erro ao verificar colunas de project_query_limits: %w", err)
+	}
+	for _, col := range columns {
+		if col.Name == "api_key_raw_sql" {
+			return nil
+		}
+	}
+	if _, err := config.MasterDB.Exec(
+		`ALTER TABLE project_query_limits ADD COLUMN api_key_raw_sql TINYINT(1) NOT NULL DEFAULT 0`,
+	); err != nil {
+		return fmt.Errorf("erro ao criar coluna api_key_raw_sql: %w", err)
+	}
+	return nil
+}
+
+// GetQueryLimits retorna os limites configurados para o projeto (nil se não houver)
+func GetQueryLimits(projectID int64) (*models.ProjectQueryLimits, error) {
+	if err := EnsureQueryLimitTable(); err != nil {
+		return nil, err
+	}
+
+	limits := models.ProjectQueryLimits{ProjectID: projectID}
+	err := config.MasterDB.QueryRow(
+		`SELECT statement_timeout_ms, max_rows, api_key_raw_sql FROM project_query_limits WHERE project_id=? LIMIT 1`,
+		projectID,
+	).Scan(&limits.StatementTimeoutMs, &limits.MaxRows, &limits.APIKeyRawSQL)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &limits, nil
+}
+
+// SetQueryLimits cria ou atualiza o timeout de statement, o teto de linhas e
+// a liberação de SQL livre para API keys do projeto
+func SetQueryLimits(projectID int64, req models.ProjectQueryLimits) error {
+	if projectID <= 0 {
+		return models.ErrInvalidProjectID
+	}
+	if req.StatementTimeoutMs < 0 || req.MaxRows < 0 {
+		return models.ErrInvalidQueryLimit.WithDetails(
+			"statement_timeout_ms e max_rows devem ser >= 0", nil,
+		)
+	}
+	if err := EnsureQueryLimitTable(); err != nil {
+		return err
+	}
+
+	_, err := config.MasterDB.Exec(`
+		INSERT INTO project_query_limits (project_id, statement_timeout_ms, max_rows, api_key_raw_sql)
+		VALUES (?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE statement_timeout_ms=VALUES(statement_timeout_ms), max_rows=VALUES(max_rows),
+			api_key_raw_sql=VALUES(api_key_raw_sql)`,
+		projectID,
+		req.StatementTimeoutMs,
+		req.MaxRows,
+		req.APIKeyRawSQL,
+	)
+	return err
+}
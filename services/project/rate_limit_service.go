@@ -0,0 +1,75 @@
+package project
+
+import (
+	"database/sql"
+	"fmt"
+
+	"meu-provedor/config"
+	"meu-provedor/models"
+)
+
+// ============================================================================
+// RATE LIMIT POR PROJETO
+// ============================================================================
+
+// EnsureRateLimitTable garante que a tabela project_rate_limits existe
+func EnsureRateLimitTable() error {
+	_, err := config.MasterDB.Exec(`
+		CREATE TABLE IF NOT EXISTS project_rate_limits (
+			project_id BIGINT UNSIGNED NOT NULL PRIMARY KEY,
+			requests_per_minute INT NOT NULL,
+			burst INT NOT NULL DEFAULT 0,
+			updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+		)`)
+	if err != nil {
+		return fmt.Errorf("erro ao criar tabela project_rate_limits: %w", err)
+	}
+	return nil
+}
+
+// GetRateLimit retorna o limite configurado para o projeto (nil se não houver)
+func GetRateLimit(projectID int64) (*models.ProjectRateLimit, error) {
+	if err := EnsureRateLimitTable(); err != nil {
+		return nil, err
+	}
+
+	limit := models.ProjectRateLimit{ProjectID: projectID}
+	err := config.MasterDB.QueryRow(
+		`SELECT requests_per_minute, burst FROM project_rate_limits WHERE project_id=? LIMIT 1`,
+		projectID,
+	).Scan(&limit.RequestsPerMinute, &limit.Burst)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &limit, nil
+}
+
+// SetRateLimit cria ou atualiza o limite de requisições do projeto
+func SetRateLimit(projectID int64, req models.ProjectRateLimit) error {
+	if projectID <= 0 {
+		return models.ErrInvalidProjectID
+	}
+	if req.RequestsPerMinute <= 0 {
+		return models.NewValidationError("requests_per_minute must be > 0")
+	}
+	if req.Burst < 0 {
+		return models.NewValidationError("burst must be >= 0")
+	}
+	if err := EnsureRateLimitTable(); err != nil {
+		return err
+	}
+
+	_, err := config.MasterDB.Exec(`
+		INSERT INTO project_rate_limits (project_id, requests_per_minute, burst)
+		VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE requests_per_minute=VALUES(requests_per_minute), burst=VALUES(burst)`,
+		projectID,
+		req.RequestsPerMinute,
+		req.Burst,
+	)
+	return err
+}
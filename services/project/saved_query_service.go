@@ -0,0 +1,409 @@
+package project
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"meu-provedor/config"
+	"meu-provedor/engine/query"
+	"meu-provedor/models"
+)
+
+// ============================================================================
+// CONSULTAS NOMEADAS - Templates de join-select com parâmetros tipados
+// ============================================================================
+//
+// Cada alteração grava uma nova versão (as anteriores são mantidas). Chamadas
+// com API key só executam versões aprovadas.
+
+// EnsureSavedQueryTable garante que a tabela project_saved_queries existe
+func EnsureSavedQueryTable() error {
+	_, err := config.MasterDB.Exec(`
+		CREATE TABLE IF NOT EXISTS project_saved_queries (
+			id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+			project_id BIGINT UNSIGNED NOT NULL,
+			name VARCHAR(64) NOT NULL,
+			version INT NOT NULL,
+			description VARCHAR(512) NOT NULL DEFAULT '',
+			template JSON NOT NULL,
+			params JSON NOT NULL,
+			approved TINYINT(1) NOT NULL DEFAULT 0,
+			created_by VARCHAR(191) NOT NULL DEFAULT '',
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE KEY uq_saved_query_version (project_id, name, version)
+		)`)
+	if err != nil {
+		return fmt.Errorf("erro ao criar tabela project_saved_queries: %w", err)
+	}
+	return nil
+}
+
+// SaveQuery valida a definição e grava uma nova versão da consulta nomeada
+func SaveQuery(projectID int64, name string, req models.SavedQueryRequest, actor string) (*models.SavedQuery, error) {
+	if projectID <= 0 {
+		return nil, models.ErrInvalidProjectID
+	}
+	if err := validateSavedQuery(name, req); err != nil {
+		return nil, err
+	}
+	if err := EnsureSavedQueryTable(); err != nil {
+		return nil, err
+	}
+
+	// projeto e instância vêm sempre da execução
+	req.Template.ProjectID = 0
+	req.Template.InstanceID = 0
+	if req.Params == nil {
+		req.Params = []models.QueryParam{}
+	}
+	template, err := json.Marshal(req.Template)
+	if err != nil {
+		return nil, err
+	}
+	params, err := json.Marshal(req.Params)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := config.MasterDB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var version int
+	err = tx.QueryRow(
+		`SELECT COALESCE(MAX(version), 0) FROM project_saved_queries WHERE project_id=? AND name=? FOR UPDATE`,
+		projectID, name,
+	).Scan(&version)
+	if err != nil {
+		return nil, err
+	}
+	version++
+
+	_, err = tx.Exec(`
+		INSERT INTO project_saved_queries (project_id, name, version, description, template, params, created_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		projectID, name, version, req.Description, template, params, actor,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &models.SavedQuery{
+		ProjectID:   projectID,
+		Name:        name,
+		Version:     version,
+		Description: req.Description,
+		Template:    req.Template,
+		Params:      req.Params,
+		CreatedBy:   actor,
+		CreatedAt:   time.Now(),
+	}, nil
+}
+
+// GetSavedQuery retorna uma versão da consulta (0 = mais recente). Com
+// approvedOnly, considera apenas versões aprovadas.
+func GetSavedQuery(projectID int64, name string, version int, approvedOnly bool) (*models.SavedQuery, error) {
+	if err := EnsureSavedQueryTable(); err != nil {
+		return nil, err
+	}
+
+	sqlQuery := savedQueryColumns + ` WHERE project_id=? AND name=?`
+	args := []interface{}{projectID, name}
+	if version > 0 {
+		sqlQuery += ` AND version=?`
+		args = append(args, version)
+	}
+	if approvedOnly {
+		sqlQuery += ` AND approved=1`
+	}
+	sqlQuery += ` ORDER BY version DESC LIMIT 1`
+
+	queries, err := scanSavedQueries(config.MasterDB.Query(sqlQuery, args...))
+	if err != nil {
+		return nil, err
+	}
+	if len(queries) == 0 {
+		if approvedOnly {
+			if _, err := GetSavedQuery(projectID, name, version, false); err == nil {
+				return nil, models.ErrQueryNotApproved
+			}
+		}
+		return nil, models.ErrSavedQueryNotFound
+	}
+	return &queries[0], nil
+}
+
+// ListSavedQueries retorna a versão mais recente de cada consulta do projeto
+func ListSavedQueries(projectID int64) ([]models.SavedQuery, error) {
+	if err := EnsureSavedQueryTable(); err != nil {
+		return nil, err
+	}
+	return scanSavedQueries(config.MasterDB.Query(savedQueryColumns+`
+		WHERE project_id=? AND version = (
+			SELECT MAX(v.version) FROM project_saved_queries v
+			WHERE v.project_id = project_saved_queries.project_id AND v.name = project_saved_queries.name
+		)
+		ORDER BY name`, projectID))
+}
+
+// ListSavedQueryVersions retorna todas as versões de uma consulta (mais recente primeiro)
+func ListSavedQueryVersions(projectID int64, name string) ([]models.SavedQuery, error) {
+	if err := EnsureSavedQueryTable(); err != nil {
+		return nil, err
+	}
+	queries, err := scanSavedQueries(config.MasterDB.Query(
+		savedQueryColumns+` WHERE project_id=? AND name=? ORDER BY version DESC`, projectID, name,
+	))
+	if err != nil {
+		return nil, err
+	}
+	if len(queries) == 0 {
+		return nil, models.ErrSavedQueryNotFound
+	}
+	return queries, nil
+}
+
+// ApproveSavedQuery aprova ou revoga uma versão para chamadas com API key
+func ApproveSavedQuery(projectID int64, name string, req models.SavedQueryApproval) error {
+	if req.Version <= 0 {
+		return models.ErrInvalidSavedQuery.WithDetails("version é obrigatório", nil)
+	}
+	if err := EnsureSavedQueryTable(); err != nil {
+		return err
+	}
+
+	result, err := config.MasterDB.Exec(
+		`UPDATE project_saved_queries SET approved=? WHERE project_id=? AND name=? AND version=?`,
+		req.Approved, projectID, name, req.Version,
+	)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		// RowsAffected é 0 também quando o valor não mudou
+		if _, err := GetSavedQuery(projectID, name, req.Version, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteSavedQuery remove a consulta com todas as suas versões
+func DeleteSavedQuery(projectID int64, name string) error {
+	if err := EnsureSavedQueryTable(); err != nil {
+		return err
+	}
+
+	result, err := config.MasterDB.Exec(
+		`DELETE FROM project_saved_queries WHERE project_id=? AND name=?`, projectID, name,
+	)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return models.ErrSavedQueryNotFound
+	}
+	return nil
+}
+
+// BindSavedQuery valida os valores informados contra os parâmetros declarados
+// e devolve o join-select pronto para executar
+func BindSavedQuery(saved *models.SavedQuery, values map[string]interface{}) (models.AdvancedJoinSelectRequest, error) {
+	req := saved.Template
+
+	declared := map[string]bool{}
+	omitted := map[string]bool{}
+	bound := map[string]interface{}{}
+	for _, p := range saved.Params {
+		declared[p.Name] = true
+
+		value, ok := values[p.Name]
+		if !ok || value == nil {
+			if p.Default == nil {
+				if p.Required {
+					return req, fmt.Errorf("%w: '%s' é obrigatório", models.ErrInvalidQueryParam, p.Name)
+				}
+				omitted[p.Name] = true
+				continue
+			}
+			value = p.Default
+		}
+
+		coerced, err := CoerceParam(p, value)
+		if err != nil {
+			return req, err
+		}
+		bound[p.Name] = coerced
+	}
+	for name := range values {
+		if !declared[name] {
+			return req, fmt.Errorf("%w: '%s' não declarado", models.ErrInvalidQueryParam, name)
+		}
+	}
+
+	// filtros com parâmetro opcional não informado são removidos
+	where := make(map[string]interface{}, len(req.Where))
+	for key, value := range req.Where {
+		if name, ok := paramRef(value); ok {
+			if omitted[name] {
+				continue
+			}
+			value = bound[name]
+		}
+		where[key] = value
+	}
+	req.Where = where
+	return req, nil
+}
+
+// CoerceParam converte o valor JSON recebido para o tipo do parâmetro
+func CoerceParam(p models.QueryParam, value interface{}) (interface{}, error) {
+	invalid := func() error {
+		return fmt.Errorf("%w: '%s' deve ser %s", models.ErrInvalidQueryParam, p.Name, p.Type)
+	}
+
+	switch p.Type {
+	case models.ParamString:
+		if s, ok := value.(string); ok {
+			return s, nil
+		}
+	case models.ParamInt:
+		switch v := value.(type) {
+		case float64:
+			if v == math.Trunc(v) {
+				return int64(v), nil
+			}
+		case string:
+			if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+				return n, nil
+			}
+		}
+	case models.ParamFloat:
+		switch v := value.(type) {
+		case float64:
+			return v, nil
+		case string:
+			if n, err := strconv.ParseFloat(v, 64); err == nil {
+				return n, nil
+			}
+		}
+	case models.ParamBool:
+		if b, ok := value.(bool); ok {
+			return b, nil
+		}
+	case models.ParamDatetime:
+		if s, ok := value.(string); ok {
+			for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"} {
+				if t, err := time.Parse(layout, s); err == nil {
+					return t.UTC(), nil
+				}
+			}
+		}
+	}
+	return nil, invalid()
+}
+
+// ============================================================================
+// INTERNAL HELPERS
+// ============================================================================
+
+const savedQueryColumns = `
+	SELECT project_id, name, version, description, template, params, approved, created_by, created_at
+	FROM project_saved_queries`
+
+func scanSavedQueries(rows *sql.Rows, err error) ([]models.SavedQuery, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	queries := []models.SavedQuery{}
+	for rows.Next() {
+		var q models.SavedQuery
+		var template, params []byte
+		if err := rows.Scan(
+			&q.ProjectID, &q.Name, &q.Version, &q.Description,
+			&template, &params, &q.Approved, &q.CreatedBy, &q.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(template, &q.Template); err != nil {
+			return nil, fmt.Errorf("template inválido em %s v%d: %w", q.Name, q.Version, err)
+		}
+		if err := json.Unmarshal(params, &q.Params); err != nil {
+			return nil, fmt.Errorf("params inválidos em %s v%d: %w", q.Name, q.Version, err)
+		}
+		queries = append(queries, q)
+	}
+	return queries, rows.Err()
+}
+
+// validateSavedQuery confere nome, parâmetros e referências do template
+func validateSavedQuery(name string, req models.SavedQueryRequest) error {
+	if !query.IsValidIdentifier(name) || len(name) > 64 {
+		return models.ErrInvalidSavedQuery.WithDetails("nome inválido: "+name, nil)
+	}
+	if req.Template.Base.Table == "" {
+		return models.ErrInvalidSavedQuery.WithDetails("template.base.table é obrigatório", nil)
+	}
+
+	declared := map[string]bool{}
+	for _, p := range req.Params {
+		if !query.IsValidIdentifier(p.Name) {
+			return models.ErrInvalidSavedQuery.WithDetails("nome de parâmetro inválido: "+p.Name, nil)
+		}
+		if declared[p.Name] {
+			return models.ErrInvalidSavedQuery.WithDetails("parâmetro duplicado: "+p.Name, nil)
+		}
+		switch p.Type {
+		case models.ParamString, models.ParamInt, models.ParamFloat, models.ParamBool, models.ParamDatetime:
+		default:
+			return models.ErrInvalidSavedQuery.WithDetails(
+				fmt.Sprintf("tipo inválido para '%s': %s", p.Name, p.Type), nil,
+			)
+		}
+		if p.Default != nil {
+			if _, err := CoerceParam(p, p.Default); err != nil {
+				return err
+			}
+		}
+		declared[p.Name] = true
+	}
+
+	for key, value := range req.Template.Where {
+		name, ok := paramRef(value)
+		if !ok {
+			if _, isObject := value.(map[string]interface{}); isObject {
+				return models.ErrInvalidSavedQuery.WithDetails(
+					fmt.Sprintf("where.%s: use {\"param\": \"nome\"} para referenciar um parâmetro", key), nil,
+				)
+			}
+			continue
+		}
+		if !declared[name] {
+			return models.ErrInvalidSavedQuery.WithDetails(
+				fmt.Sprintf("where.%s referencia parâmetro não declarado: %s", key, name), nil,
+			)
+		}
+	}
+	return nil
+}
+
+// paramRef reconhece a referência {"param": "nome"} em um valor do template
+func paramRef(value interface{}) (string, bool) {
+	ref, ok := value.(map[string]interface{})
+	if !ok || len(ref) != 1 {
+		return "", false
+	}
+	name, ok := ref["param"].(string)
+	return name, ok
+}
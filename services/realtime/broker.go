@@ -0,0 +1,214 @@
+package realtime
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"sync"
+
+	"meu-provedor/models"
+)
+
+// ============================================================================
+// BROKER - Distribuição de eventos para assinaturas em tempo real
+// ============================================================================
+
+// Message é um evento numerado pelo broker. O id de retomada é "epoch-seq":
+// o epoch identifica o processo (a numeração recomeça a cada início).
+type Message struct {
+	Epoch string
+	Seq   uint64
+	Event models.ChangeEvent
+	// Reset indica que eventos foram perdidos (id de retomada fora do buffer
+	// ou assinante lento): o cliente deve recarregar os dados
+	Reset bool
+}
+
+// ID retorna o id de retomada da mensagem
+func (m Message) ID() string {
+	return m.Epoch + "-" + strconv.FormatUint(m.Seq, 10)
+}
+
+// ParseMessageID separa epoch e seq de um id de retomada
+func ParseMessageID(id string) (string, uint64, bool) {
+	i := strings.LastIndex(id, "-")
+	if i <= 0 {
+		return "", 0, false
+	}
+	seq, err := strconv.ParseUint(id[i+1:], 10, 64)
+	if err != nil {
+		return "", 0, false
+	}
+	return id[:i], seq, true
+}
+
+// Filter seleciona os eventos de uma assinatura
+type Filter struct {
+	ProjectID  int64
+	InstanceID int64
+	Table      string
+	Operations []string // vazio = todas
+}
+
+// Matches indica se o evento pertence à assinatura
+func (f Filter) Matches(event models.ChangeEvent) bool {
+	if event.ProjectID != f.ProjectID || event.InstanceID != f.InstanceID || event.Table != f.Table {
+		return false
+	}
+	if len(f.Operations) == 0 {
+		return true
+	}
+	for _, op := range f.Operations {
+		if strings.EqualFold(op, event.Operation) {
+			return true
+		}
+	}
+	return false
+}
+
+// Subscription recebe as mensagens em C. C é fechado quando a assinatura termina
+// (inclusive quando o assinante não acompanha o ritmo dos eventos).
+type Subscription struct {
+	C      chan Message
+	filter Filter
+}
+
+// Broker publica eventos e mantém assinaturas. A implementação padrão é em
+// memória (um processo); outra pode ser instalada com SetBroker.
+type Broker interface {
+	Publish(event models.ChangeEvent)
+	// Subscribe cria a assinatura, reenviando antes os eventos posteriores a
+	// lastID ainda disponíveis (lastID vazio não reenvia nada). Um id de outro
+	// epoch recebe um Reset.
+	Subscribe(filter Filter, lastID string) *Subscription
+	Unsubscribe(sub *Subscription)
+}
+
+// subscriberBuffer é a quantidade de mensagens pendentes por assinante
+const subscriberBuffer = 64
+
+// MemoryBroker mantém os últimos eventos em um buffer circular
+type MemoryBroker struct {
+	mu     sync.Mutex
+	epoch  string
+	seq    uint64
+	buffer []Message
+	next   int
+	full   bool
+	subs   map[*Subscription]struct{}
+}
+
+// NewMemoryBroker cria um broker que guarda os últimos size eventos
+func NewMemoryBroker(size int) *MemoryBroker {
+	if size <= 0 {
+		size = 1000
+	}
+	return &MemoryBroker{
+		epoch:  newEpoch(),
+		buffer: make([]Message, size),
+		subs:   map[*Subscription]struct{}{},
+	}
+}
+
+// Publish numera o evento, guarda no buffer e entrega aos assinantes
+func (b *MemoryBroker) Publish(event models.ChangeEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.seq++
+	msg := Message{Epoch: b.epoch, Seq: b.seq, Event: event}
+
+	b.buffer[b.next] = msg
+	b.next = (b.next + 1) % len(b.buffer)
+	if b.next == 0 {
+		b.full = true
+	}
+
+	for sub := range b.subs {
+		if !sub.filter.Matches(event) {
+			continue
+		}
+		select {
+		case sub.C <- msg:
+		default:
+			// Assinante lento: encerra; o cliente retoma pelo último id recebido
+			b.drop(sub)
+		}
+	}
+}
+
+// Subscribe registra a assinatura e reenvia os eventos posteriores a lastID
+func (b *MemoryBroker) Subscribe(filter Filter, lastID string) *Subscription {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sub := &Subscription{C: make(chan Message, subscriberBuffer), filter: filter}
+	if lastID == "" {
+		b.subs[sub] = struct{}{}
+		return sub
+	}
+
+	epoch, lastSeq, ok := ParseMessageID(lastID)
+	if !ok || epoch != b.epoch || lastSeq > b.seq {
+		// Id de outro processo (ex.: após reinício): não há como retomar
+		sub.C <- Message{Epoch: b.epoch, Seq: b.seq, Reset: true}
+	} else if lastSeq < b.seq {
+		replay := b.since(lastSeq)
+		if len(replay) > 0 && replay[0].Seq > lastSeq+1 {
+			// Parte dos eventos já saiu do buffer
+			sub.C <- Message{Epoch: b.epoch, Seq: lastSeq, Reset: true}
+		}
+		for _, msg := range replay {
+			if !filter.Matches(msg.Event) {
+				continue
+			}
+			select {
+			case sub.C <- msg:
+			default:
+				close(sub.C)
+				return sub
+			}
+		}
+	}
+
+	b.subs[sub] = struct{}{}
+	return sub
+}
+
+// Unsubscribe encerra a assinatura
+func (b *MemoryBroker) Unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.drop(sub)
+}
+
+func (b *MemoryBroker) drop(sub *Subscription) {
+	if _, ok := b.subs[sub]; ok {
+		delete(b.subs, sub)
+		close(sub.C)
+	}
+}
+
+// newEpoch gera o identificador da numeração deste broker
+func newEpoch() string {
+	b := make([]byte, 6)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// since retorna, em ordem, as mensagens do buffer com Seq > lastSeq
+func (b *MemoryBroker) since(lastSeq uint64) []Message {
+	var ordered []Message
+	if b.full {
+		ordered = append(ordered, b.buffer[b.next:]...)
+	}
+	ordered = append(ordered, b.buffer[:b.next]...)
+
+	for i, msg := range ordered {
+		if msg.Seq > lastSeq {
+			return ordered[i:]
+		}
+	}
+	return nil
+}
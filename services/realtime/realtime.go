@@ -0,0 +1,50 @@
+package realtime
+
+import (
+	"sync"
+
+	"meu-provedor/config"
+	"meu-provedor/models"
+	"meu-provedor/services/events"
+)
+
+// ============================================================================
+// REALTIME - Assinaturas de alterações via Server-Sent Events
+// ============================================================================
+
+var (
+	brokerMu sync.RWMutex
+	broker   Broker = NewMemoryBroker(0)
+)
+
+// Start instala o broker em memória e assina os eventos dos data services
+func Start() {
+	SetBroker(NewMemoryBroker(config.EnvPositiveInt("REALTIME_BUFFER_SIZE", 1000)))
+
+	events.Subscribe(func(event models.ChangeEvent) {
+		current().Publish(event)
+	})
+}
+
+// SetBroker troca o broker usado pelas assinaturas
+func SetBroker(b Broker) {
+	brokerMu.Lock()
+	broker = b
+	brokerMu.Unlock()
+}
+
+// Subscribe cria uma assinatura no broker atual
+func Subscribe(filter Filter, lastID string) *Subscription {
+	return current().Subscribe(filter, lastID)
+}
+
+// Unsubscribe encerra uma assinatura no broker atual
+func Unsubscribe(sub *Subscription) {
+	current().Unsubscribe(sub)
+}
+
+func current() Broker {
+	brokerMu.RLock()
+	defer brokerMu.RUnlock()
+	return broker
+}
@@ -0,0 +1,256 @@
+package summary
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"meu-provedor/config"
+	"meu-provedor/models"
+	"meu-provedor/services/events"
+	tableService "meu-provedor/services/table"
+)
+
+// ============================================================================
+// REFRESHER - Atualização agendada e incremental dos resumos materializados
+// ============================================================================
+//
+// Resumos on_write são recalculados apenas para a instância alterada, depois
+// de um pequeno intervalo que agrupa escritas em sequência. A marca de
+// alteração também fica no catálogo: se o processo parar antes do recálculo,
+// o ciclo de verificação de qualquer processo recalcula o resumo inteiro.
+// Resumos scheduled são recalculados por completo quando o intervalo
+// configurado expira. Falhas repetidas esperam cada vez mais (até
+// maxRetryDelay) antes de uma nova tentativa.
+
+// maxRetryDelay limita a espera entre tentativas de um resumo com falha
+const maxRetryDelay = 5 * time.Minute
+
+var (
+	dirtyMu sync.Mutex
+	// dirty guarda, por resumo, as instâncias a recalcular (0 = todas)
+	dirty = map[string]map[int64]bool{}
+	// retries guarda as falhas seguidas de cada resumo
+	retries = map[string]retryState{}
+)
+
+type retryState struct {
+	failures int
+	next     time.Time
+}
+
+// Start assina os eventos de dados e inicia os ciclos de atualização
+func Start() error {
+	if err := tableService.EnsureSummaryCatalogTable(); err != nil {
+		return err
+	}
+	events.Subscribe(markDirty)
+
+	go func() {
+		ticker := time.NewTicker(debounce())
+		defer ticker.Stop()
+		for range ticker.C {
+			flushDirty()
+		}
+	}()
+
+	poll := time.Duration(config.EnvPositiveInt("SUMMARY_POLL_SECONDS", 5)) * time.Second
+	go func() {
+		ticker := time.NewTicker(poll)
+		defer ticker.Stop()
+		for range ticker.C {
+			refreshDue()
+			refreshOrphans()
+		}
+	}()
+
+	log.Println("✅ Atualização de resumos materializados iniciada")
+	return nil
+}
+
+// markDirty marca os resumos on_write da tabela alterada (roda na goroutine
+// de quem emitiu o evento: apenas consulta o cache e registra a instância)
+func markDirty(event models.ChangeEvent) {
+	summaries, err := tableService.OnWriteSummaries(event.ProjectID, event.Table)
+	if err != nil {
+		log.Printf("❌ Resumos: erro ao buscar resumos de %s: %v", event.Table, err)
+		return
+	}
+	if len(summaries) == 0 {
+		return
+	}
+
+	for _, fullTable := range summaries {
+		if markInstance(fullTable, event.InstanceID) {
+			// Primeira marca do ciclo: grava no catálogo fora da requisição
+			go persistDirty(fullTable)
+		}
+	}
+}
+
+// flushDirty recalcula as instâncias marcadas desde o último ciclo
+func flushDirty() {
+	dirtyMu.Lock()
+	pending := dirty
+	dirty = map[string]map[int64]bool{}
+	dirtyMu.Unlock()
+
+	for fullTable, instances := range pending {
+		if waiting(fullTable) {
+			requeue(fullTable, instances)
+			continue
+		}
+
+		// evento sem instância: recalcula o resumo inteiro uma única vez
+		if instances[0] {
+			instances = map[int64]bool{0: true}
+		}
+		refreshMarked(fullTable, instances)
+	}
+}
+
+// refreshMarked recalcula as instâncias do resumo e, se todas foram
+// atualizadas, apaga a marca do catálogo lida antes do recálculo
+func refreshMarked(fullTable string, instances map[int64]bool) {
+	since, err := tableService.SummaryDirtySince(fullTable)
+	if err != nil {
+		log.Printf("❌ Resumos: erro ao ler marca de %s: %v", fullTable, err)
+	}
+
+	failed := map[int64]bool{}
+	for instanceID := range instances {
+		err := tableService.RefreshSummary(fullTable, instanceID)
+		if err == nil {
+			continue
+		}
+		log.Printf("❌ Resumos: erro ao atualizar %s (instância %d): %v", fullTable, instanceID, err)
+		// Resumo removido não volta; as demais falhas esperam o próximo intervalo
+		if errors.Is(err, models.ErrSummaryNotFound) {
+			forgetRetries(fullTable)
+			return
+		}
+		failed[instanceID] = true
+	}
+
+	if len(failed) > 0 {
+		recordFailure(fullTable)
+		requeue(fullTable, failed)
+		return
+	}
+	forgetRetries(fullTable)
+	if err := tableService.ClearSummaryDirty(fullTable, since); err != nil {
+		log.Printf("❌ Resumos: erro ao limpar marca de %s: %v", fullTable, err)
+	}
+}
+
+// markInstance marca a instância do resumo para o próximo ciclo; retorna
+// true quando o resumo não tinha marcas pendentes
+func markInstance(fullTable string, instanceID int64) bool {
+	dirtyMu.Lock()
+	defer dirtyMu.Unlock()
+	first := dirty[fullTable] == nil
+	if first {
+		dirty[fullTable] = map[int64]bool{}
+	}
+	dirty[fullTable][instanceID] = true
+	return first
+}
+
+// requeue devolve instâncias não recalculadas ao próximo ciclo (a marca no
+// catálogo continua gravada)
+func requeue(fullTable string, instances map[int64]bool) {
+	dirtyMu.Lock()
+	defer dirtyMu.Unlock()
+	if dirty[fullTable] == nil {
+		dirty[fullTable] = map[int64]bool{}
+	}
+	for instanceID := range instances {
+		dirty[fullTable][instanceID] = true
+	}
+}
+
+func persistDirty(fullTable string) {
+	if err := tableService.MarkSummaryDirty(fullTable); err != nil {
+		log.Printf("❌ Resumos: erro ao marcar %s no catálogo: %v", fullTable, err)
+	}
+}
+
+// refreshDue recalcula por completo os resumos agendados vencidos
+func refreshDue() {
+	due, err := tableService.DueSummaries()
+	if err != nil {
+		log.Printf("❌ Resumos: erro ao buscar resumos agendados: %v", err)
+		return
+	}
+	for _, fullTable := range due {
+		if err := tableService.RefreshSummary(fullTable, 0); err != nil {
+			log.Printf("❌ Resumos: erro ao atualizar %s: %v", fullTable, err)
+		}
+	}
+}
+
+// refreshOrphans recalcula por completo os resumos on_write com marca antiga
+// no catálogo: o processo que marcou parou ou não consegue atualizar. O
+// próprio last_attempt_at limita as tentativas a uma por orphanGrace.
+func refreshOrphans() {
+	orphans, err := tableService.OrphanDirtySummaries(orphanGrace())
+	if err != nil {
+		log.Printf("❌ Resumos: erro ao buscar marcas pendentes: %v", err)
+		return
+	}
+	for _, fullTable := range orphans {
+		log.Printf("⚠️ Resumos: recalculando %s (alterações pendentes sem atualização)", fullTable)
+		refreshMarked(fullTable, map[int64]bool{0: true})
+	}
+}
+
+// ============================================================================
+// RETRIES
+// ============================================================================
+
+// waiting indica se o resumo ainda está no intervalo após uma falha
+func waiting(fullTable string) bool {
+	dirtyMu.Lock()
+	defer dirtyMu.Unlock()
+	state, ok := retries[fullTable]
+	return ok && time.Now().Before(state.next)
+}
+
+// recordFailure dobra a espera do resumo a cada falha seguida
+func recordFailure(fullTable string) {
+	dirtyMu.Lock()
+	defer dirtyMu.Unlock()
+	state := retries[fullTable]
+	state.failures++
+	state.next = time.Now().Add(retryDelay(state.failures))
+	retries[fullTable] = state
+}
+
+func forgetRetries(fullTable string) {
+	dirtyMu.Lock()
+	delete(retries, fullTable)
+	dirtyMu.Unlock()
+}
+
+// retryDelay é a espera após failures falhas seguidas (debounce, 2x, 4x, ...)
+func retryDelay(failures int) time.Duration {
+	delay := debounce()
+	for i := 1; i < failures && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		return maxRetryDelay
+	}
+	return delay
+}
+
+func debounce() time.Duration {
+	return time.Duration(config.EnvPositiveInt("SUMMARY_DEBOUNCE_MS", 1000)) * time.Millisecond
+}
+
+// orphanGrace é a idade a partir da qual uma marca no catálogo é tratada como
+// abandonada (bem acima do debounce: processos ativos limpam antes)
+func orphanGrace() time.Duration {
+	return time.Duration(config.EnvPositiveInt("SUMMARY_ORPHAN_SECONDS", 60)) * time.Second
+}
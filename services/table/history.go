@@ -0,0 +1,153 @@
+package table
+
+import (
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"meu-provedor/config"
+	"meu-provedor/engine/dialect"
+	"meu-provedor/models"
+)
+
+// ============================================================================
+// ROW HISTORY - Opt-in por tabela e tabela de histórico por projeto
+// ============================================================================
+
+const historyCacheTTL = time.Minute
+
+var (
+	historyMu    sync.RWMutex
+	historyCache = map[string]historyEntry{}
+)
+
+type historyEntry struct {
+	enabled bool
+	loaded  time.Time
+}
+
+// HistoryTableName retorna a tabela de histórico do projeto. O prefixo "__"
+// não colide com tabelas do cliente (nomes não podem começar com "_").
+func HistoryTableName(projectCode string) string {
+	return projectCode + "__history"
+}
+
+// EnsureHistorySettingsTable garante que a tabela table_history_settings existe
+func EnsureHistorySettingsTable() error {
+	_, err := config.MasterDB.Exec(`
+		CREATE TABLE IF NOT EXISTS table_history_settings (
+			table_name VARCHAR(128) NOT NULL PRIMARY KEY,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`)
+	if err != nil {
+		return fmt.Errorf("erro ao criar tabela table_history_settings: %w", err)
+	}
+	return nil
+}
+
+// EnsureHistoryTable garante que a tabela de histórico do projeto existe
+func EnsureHistoryTable(db *sql.DB, projectCode string) error {
+	d := config.DialectOf(db)
+	table := HistoryTableName(projectCode)
+
+	statements, err := d.CreateTable(table, true, []string{
+		d.AutoIncrementPK("id"),
+		"table_name VARCHAR(64) NOT NULL",
+		"row_id " + d.BigIntType() + " NOT NULL",
+		"id_instancia " + d.BigIntType() + " NOT NULL",
+		"operation VARCHAR(16) NOT NULL",
+		"before_data " + d.JSONType() + " NULL",
+		"after_data " + d.JSONType() + " NULL",
+		"diff " + d.JSONType() + " NULL",
+		"actor VARCHAR(191) NULL",
+		d.TimestampColumn("created_at", 6, false),
+	}, []dialect.Index{
+		{Name: table + "_row", Columns: []string{"table_name", "row_id"}},
+		{Name: table + "_instance", Columns: []string{"id_instancia"}},
+	})
+	if err == nil {
+		err = execAll(db, statements)
+	}
+	if err != nil {
+		return fmt.Errorf("erro ao criar tabela de histórico: %w", err)
+	}
+	return nil
+}
+
+// SetHistory liga ou desliga o histórico de uma tabela do projeto (usando project_id)
+func SetHistory(projectID int64, tableName string, enabled bool) error {
+	projectCode, db, done, err := projectWriteDatabase(projectID)
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	fullTable := fmt.Sprintf("%s_%s", projectCode, tableName)
+	columns, err := CachedColumns(db, fullTable)
+	if err != nil {
+		return err
+	}
+	if len(columns) == 0 {
+		return models.ErrTableNotFound
+	}
+
+	return SetHistoryEnabled(db, projectCode, fullTable, enabled)
+}
+
+// SetHistoryEnabled liga ou desliga o histórico de uma tabela física
+// (db é o banco do projeto, onde fica a tabela de histórico)
+func SetHistoryEnabled(db *sql.DB, projectCode, fullTable string, enabled bool) error {
+	if err := EnsureHistorySettingsTable(); err != nil {
+		return err
+	}
+
+	var err error
+	if enabled {
+		if err = EnsureHistoryTable(db, projectCode); err != nil {
+			return err
+		}
+		_, err = config.MasterDB.Exec(
+			`INSERT IGNORE INTO table_history_settings (table_name) VALUES (?)`, fullTable)
+	} else {
+		_, err = config.MasterDB.Exec(
+			`DELETE FROM table_history_settings WHERE table_name = ?`, fullTable)
+	}
+	if err != nil {
+		return err
+	}
+
+	historyMu.Lock()
+	delete(historyCache, fullTable)
+	historyMu.Unlock()
+	return nil
+}
+
+// HistoryEnabled indica se a tabela física grava histórico (cache de 1 minuto)
+func HistoryEnabled(fullTable string) (bool, error) {
+	historyMu.RLock()
+	entry, ok := historyCache[fullTable]
+	historyMu.RUnlock()
+
+	if ok && time.Since(entry.loaded) < historyCacheTTL {
+		return entry.enabled, nil
+	}
+
+	if err := EnsureHistorySettingsTable(); err != nil {
+		return false, err
+	}
+
+	var count int
+	err := config.MasterDB.QueryRow(
+		`SELECT COUNT(*) FROM table_history_settings WHERE table_name = ?`, fullTable,
+	).Scan(&count)
+	if err != nil {
+		return false, err
+	}
+
+	historyMu.Lock()
+	historyCache[fullTable] = historyEntry{enabled: count > 0, loaded: time.Now()}
+	historyMu.Unlock()
+
+	return count > 0, nil
+}
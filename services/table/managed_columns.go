@@ -0,0 +1,133 @@
+package table
+
+import (
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"meu-provedor/config"
+)
+
+// ============================================================================
+// MANAGED COLUMNS - Colunas preenchidas pelo engine (opt-in na criação)
+// ============================================================================
+//
+// Uma tabela criada com timestamps/track_user/versioned fica registrada aqui;
+// só nela o engine preenche created_at/updated_at, created_by/updated_by e
+// version e rejeita valores do cliente. Colunas com o mesmo nome em outras
+// tabelas são dados comuns. Com audit_override, valores de auditoria enviados
+// pelo cliente (importações, backfill) são aceitos e prevalecem sobre os do
+// engine.
+
+const managedCacheTTL = time.Minute
+
+// ManagedColumns indica quais grupos de colunas o engine gerencia na tabela
+type ManagedColumns struct {
+	Timestamps bool // created_at, updated_at
+	TrackUser  bool // created_by, updated_by
+	Versioned  bool // version (concorrência otimista)
+
+	AuditOverride bool // aceita created_*/updated_* enviados pelo cliente
+}
+
+var (
+	managedMu    sync.RWMutex
+	managedCache = map[string]managedEntry{}
+)
+
+type managedEntry struct {
+	columns ManagedColumns
+	loaded  time.Time
+}
+
+// EnsureManagedColumnsTable garante que a tabela table_managed_columns existe
+func EnsureManagedColumnsTable() error {
+	_, err := config.MasterDB.Exec(`
+		CREATE TABLE IF NOT EXISTS table_managed_columns (
+			table_name VARCHAR(128) NOT NULL PRIMARY KEY,
+			timestamps TINYINT(1) NOT NULL DEFAULT 0,
+			track_user TINYINT(1) NOT NULL DEFAULT 0,
+			versioned TINYINT(1) NOT NULL DEFAULT 0,
+			audit_override TINYINT(1) NOT NULL DEFAULT 0,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`)
+	if err != nil {
+		return fmt.Errorf("erro ao criar tabela table_managed_columns: %w", err)
+	}
+
+	// Tabelas criadas antes da coluna audit_override
+	columns, err := config.DialectOf(config.MasterDB).Columns(config.MasterDB, "table_managed_columns")
+	if err != nil {
+		return fmt.Errorf("erro ao verificar colunas de table_managed_columns: %w", err)
+	}
+	for _, col := range columns {
+		if col.Name == "audit_override" {
+			return nil
+		}
+	}
+	if _, err := config.MasterDB.Exec(
+		`ALTER TABLE table_managed_columns ADD COLUMN audit_override TINYINT(1) NOT NULL DEFAULT 0`,
+	); err != nil {
+		return fmt.Errorf("erro ao criar coluna audit_override: %w", err)
+	}
+	return nil
+}
+
+// SetManagedColumns registra as colunas gerenciadas da tabela física
+// (sem nenhuma, o registro é removido)
+func SetManagedColumns(fullTable string, managed ManagedColumns) error {
+	if err := EnsureManagedColumnsTable(); err != nil {
+		return err
+	}
+
+	var err error
+	if managed == (ManagedColumns{}) {
+		_, err = config.MasterDB.Exec(`DELETE FROM table_managed_columns WHERE table_name = ?`, fullTable)
+	} else {
+		_, err = config.MasterDB.Exec(`
+			INSERT INTO table_managed_columns (table_name, timestamps, track_user, versioned, audit_override)
+			VALUES (?, ?, ?, ?, ?)
+			ON DUPLICATE KEY UPDATE timestamps = VALUES(timestamps), track_user = VALUES(track_user),
+				versioned = VALUES(versioned), audit_override = VALUES(audit_override)`,
+			fullTable, managed.Timestamps, managed.TrackUser, managed.Versioned, managed.AuditOverride,
+		)
+	}
+	if err != nil {
+		return fmt.Errorf("erro ao registrar colunas gerenciadas de %s: %w", fullTable, err)
+	}
+
+	managedMu.Lock()
+	delete(managedCache, fullTable)
+	managedMu.Unlock()
+	return nil
+}
+
+// GetManagedColumns retorna as colunas gerenciadas da tabela física (cache de 1 minuto)
+func GetManagedColumns(fullTable string) (ManagedColumns, error) {
+	managedMu.RLock()
+	entry, ok := managedCache[fullTable]
+	managedMu.RUnlock()
+
+	if ok && time.Since(entry.loaded) < managedCacheTTL {
+		return entry.columns, nil
+	}
+
+	if err := EnsureManagedColumnsTable(); err != nil {
+		return ManagedColumns{}, err
+	}
+
+	var managed ManagedColumns
+	err := config.MasterDB.QueryRow(
+		`SELECT timestamps, track_user, versioned, audit_override FROM table_managed_columns WHERE table_name = ?`, fullTable,
+	).Scan(&managed.Timestamps, &managed.TrackUser, &managed.Versioned, &managed.AuditOverride)
+	if err != nil && err != sql.ErrNoRows {
+		return ManagedColumns{}, err
+	}
+
+	managedMu.Lock()
+	managedCache[fullTable] = managedEntry{columns: managed, loaded: time.Now()}
+	managedMu.Unlock()
+
+	return managed, nil
+}
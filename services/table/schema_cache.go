@@ -0,0 +1,56 @@
+package table
+
+import (
+	"database/sql"
+	"sync"
+	"time"
+
+	"meu-provedor/models"
+)
+
+// ============================================================================
+// SCHEMA CACHE - Colunas das tabelas físicas (invalidado em alterações DDL)
+// ============================================================================
+
+const columnsCacheTTL = 5 * time.Minute
+
+type columnsEntry struct {
+	columns []models.ColumnDetail
+	loaded  time.Time
+}
+
+var (
+	columnsMu    sync.RWMutex
+	columnsCache = map[string]columnsEntry{}
+)
+
+// CachedColumns retorna as colunas da tabela física, consultando o
+// information_schema do banco do projeto apenas quando o cache expirou ou
+// foi invalidado. O cache é indexado pelo nome físico, único entre projetos.
+func CachedColumns(db *sql.DB, fullTable string) ([]models.ColumnDetail, error) {
+	columnsMu.RLock()
+	entry, ok := columnsCache[fullTable]
+	columnsMu.RUnlock()
+
+	if ok && time.Since(entry.loaded) < columnsCacheTTL {
+		return entry.columns, nil
+	}
+
+	columns, err := getColumns(db, fullTable)
+	if err != nil {
+		return nil, err
+	}
+
+	columnsMu.Lock()
+	columnsCache[fullTable] = columnsEntry{columns: columns, loaded: time.Now()}
+	columnsMu.Unlock()
+
+	return columns, nil
+}
+
+// InvalidateColumns descarta as colunas em cache da tabela física
+func InvalidateColumns(fullTable string) {
+	columnsMu.Lock()
+	delete(columnsCache, fullTable)
+	columnsMu.Unlock()
+}
@@ -0,0 +1,725 @@
+package table
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"meu-provedor/config"
+	"meu-provedor/engine/dialect"
+	"meu-provedor/engine/query"
+	"meu-provedor/models"
+)
+
+// ============================================================================
+// SUMMARIES - Resumos materializados ({code}_mv_nome)
+// ============================================================================
+//
+// Um resumo é uma agregação agrupada sempre por id_instancia, gravada em uma
+// tabela comum do projeto: é consultada por /data/select como qualquer
+// tabela, mas só o engine escreve nela. A definição e o estado da última
+// atualização ficam no catálogo (project_summaries, no banco master).
+
+const (
+	summaryCacheTTL = time.Minute
+	// minSummaryInterval evita recálculos completos em sequência
+	minSummaryInterval = 10
+	// defaultSummaryInterval é usado quando interval_seconds não é informado
+	defaultSummaryInterval = 300
+)
+
+var (
+	summaryMu    sync.RWMutex
+	summaryCache = map[string]summaryEntry{}
+	onWriteCache = map[int64]onWriteEntry{}
+
+	// refreshLocks serializa as atualizações de cada resumo neste processo
+	refreshLocksMu sync.Mutex
+	refreshLocks   = map[string]*sync.Mutex{}
+)
+
+type summaryEntry struct {
+	isSummary bool
+	loaded    time.Time
+}
+
+// onWriteEntry são os resumos on_write do projeto indexados pela tabela de origem
+type onWriteEntry struct {
+	bySource map[string][]string
+	loaded   time.Time
+}
+
+var summaryFunctions = map[string]bool{"COUNT": true, "SUM": true, "AVG": true, "MIN": true, "MAX": true}
+
+// EnsureSummaryCatalogTable garante que a tabela project_summaries existe
+func EnsureSummaryCatalogTable() error {
+	_, err := config.MasterDB.Exec(`
+		CREATE TABLE IF NOT EXISTS project_summaries (
+			table_name VARCHAR(128) NOT NULL PRIMARY KEY,
+			project_id BIGINT UNSIGNED NOT NULL,
+			name VARCHAR(64) NOT NULL,
+			definition JSON NOT NULL,
+			refresh_mode VARCHAR(16) NOT NULL,
+			interval_seconds INT NOT NULL DEFAULT 0,
+			status VARCHAR(16) NOT NULL,
+			last_refresh_at DATETIME(6) NULL,
+			last_attempt_at DATETIME(6) NULL,
+			last_duration_ms BIGINT NOT NULL DEFAULT 0,
+			last_error TEXT NULL,
+			dirty_since DATETIME(6) NULL,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			INDEX idx_summaries_project (project_id),
+			INDEX idx_summaries_mode (refresh_mode)
+		)`)
+	if err != nil {
+		return fmt.Errorf("erro ao criar tabela project_summaries: %w", err)
+	}
+
+	// Catálogos criados antes da coluna dirty_since
+	columns, err := config.DialectOf(config.MasterDB).Columns(config.MasterDB, "project_summaries")
+	if err != nil {
+		return fmt.Errorf("erro ao verificar colunas de project_summaries: %w", err)
+	}
+	for _, col := range columns {
+		if col.Name == "dirty_since" {
+			return nil
+		}
+	}
+	if _, err := config.MasterDB.Exec(
+		`ALTER TABLE project_summaries ADD COLUMN dirty_since DATETIME(6) NULL`,
+	); err != nil {
+		return fmt.Errorf("erro ao criar coluna dirty_since: %w", err)
+	}
+	return nil
+}
+
+// CreateSummary cria a tabela {code}_mv_{name}, registra a definição e faz a
+// primeira atualização
+func CreateSummary(req models.CreateSummaryRequest) (*models.MaterializedSummary, error) {
+	def, err := normalizeSummaryDefinition(req.SummaryDefinition)
+	if err != nil {
+		return nil, err
+	}
+	if !query.IsValidTableName(req.Name) || strings.HasPrefix(req.Name, "_") {
+		return nil, fmt.Errorf("%w: %s", models.ErrInvalidTableName, req.Name)
+	}
+	if err := EnsureSummaryCatalogTable(); err != nil {
+		return nil, err
+	}
+
+	projectCode, db, done, err := projectWriteDatabase(req.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	fullSource := fmt.Sprintf("%s_%s", projectCode, def.Source)
+	logical := models.SummaryPrefix + req.Name
+	fullTable := fmt.Sprintf("%s_%s", projectCode, logical)
+	d := config.DialectOf(db)
+
+	if err := checkSummarySource(db, fullSource, def); err != nil {
+		done()
+		return nil, err
+	}
+
+	// Estrutura herdada do SELECT de agregação, sem linhas
+	selectSQL, _ := summarySelect(fullSource, def, 0, true)
+	if _, err := db.Exec(dialect.Rebind(d, "CREATE TABLE "+query.QuoteIdent(fullTable)+" AS "+selectSQL)); err != nil {
+		done()
+		return nil, fmt.Errorf("%w: %v", models.ErrInvalidSummary, err)
+	}
+	InvalidateColumns(fullTable)
+
+	err = func() error {
+		stmt, err := d.CreateIndex(fullTable, dialect.Index{
+			Name:    "idx_" + fullTable + "_instancia",
+			Type:    "INDEX",
+			Columns: []string{"id_instancia"},
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := db.Exec(stmt); err != nil {
+			return err
+		}
+
+		definition, err := json.Marshal(def)
+		if err != nil {
+			return err
+		}
+		_, err = config.MasterDB.Exec(
+			`INSERT INTO project_summaries (table_name, project_id, name, definition, refresh_mode, interval_seconds, status)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			fullTable, req.ProjectID, req.Name, definition, def.RefreshMode, def.IntervalSeconds, models.SummaryPending,
+		)
+		return err
+	}()
+	if err != nil {
+		// sem registro no catálogo a tabela seria gravável: desfaz
+		db.Exec(dialect.Rebind(d, "DROP TABLE IF EXISTS "+query.QuoteIdent(fullTable)))
+		InvalidateColumns(fullTable)
+		done()
+		return nil, err
+	}
+	forgetSummary(req.ProjectID, fullTable)
+	done()
+
+	// Falhas da primeira carga ficam registradas no estado do resumo
+	RefreshSummary(fullTable, 0)
+	return GetSummary(fullTable)
+}
+
+// DropSummary remove a tabela do resumo e sua definição
+func DropSummary(projectID int64, name string) error {
+	if err := EnsureSummaryCatalogTable(); err != nil {
+		return err
+	}
+
+	projectCode, db, done, err := projectWriteDatabase(projectID)
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	fullTable := fmt.Sprintf("%s_%s%s", projectCode, models.SummaryPrefix, name)
+	isSummary, err := IsSummary(fullTable)
+	if err != nil {
+		return err
+	}
+	if !isSummary {
+		return models.ErrSummaryNotFound
+	}
+
+	marked, err := trackWrite(projectID, fullTable)
+	if err != nil {
+		return err
+	}
+	defer marked()
+
+	lock := refreshLock(fullTable)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if _, err := db.Exec(dialect.Rebind(config.DialectOf(db), "DROP TABLE IF EXISTS "+query.QuoteIdent(fullTable))); err != nil {
+		return err
+	}
+	InvalidateColumns(fullTable)
+
+	return unregisterSummary(projectID, fullTable)
+}
+
+// RefreshSummary recalcula o resumo: apenas a instância informada ou, com
+// instanceID 0, todas. O estado da atualização é gravado no catálogo.
+func RefreshSummary(fullTable string, instanceID int64) error {
+	summary, err := GetSummary(fullTable)
+	if err != nil {
+		return err
+	}
+	if summary == nil {
+		return models.ErrSummaryNotFound
+	}
+
+	lock := refreshLock(fullTable)
+	lock.Lock()
+	defer lock.Unlock()
+
+	projectCode, db, done, err := projectWriteDatabase(summary.ProjectID)
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	marked, err := trackWrite(summary.ProjectID, fullTable)
+	if err != nil {
+		return err
+	}
+	defer marked()
+
+	markSummaryRefreshing(fullTable)
+	start := time.Now()
+	err = refreshSummaryData(db, fmt.Sprintf("%s_%s", projectCode, summary.Source), fullTable, summary.SummaryDefinition, instanceID)
+	recordSummaryRefresh(fullTable, start, err)
+	return err
+}
+
+// RebuildSummary recalcula por completo o resumo em outro banco do projeto.
+// Usado pela migração de tenancy: resumos não são copiados (não têm id para
+// os chunks), e sim reconstruídos no destino a partir das tabelas já copiadas.
+func RebuildSummary(db *sql.DB, projectCode, fullTable string) error {
+	summary, err := GetSummary(fullTable)
+	if err != nil {
+		return err
+	}
+	if summary == nil {
+		return models.ErrSummaryNotFound
+	}
+
+	lock := refreshLock(fullTable)
+	lock.Lock()
+	defer lock.Unlock()
+
+	return refreshSummaryData(db, fmt.Sprintf("%s_%s", projectCode, summary.Source), fullTable, summary.SummaryDefinition, 0)
+}
+
+// RefreshProjectSummary recalcula por completo o resumo do projeto (manual)
+func RefreshProjectSummary(projectID int64, name string) (*models.MaterializedSummary, error) {
+	projectCode, err := config.GetProjectCodeByID(int(projectID))
+	if err != nil {
+		return nil, fmt.Errorf("projeto não encontrado: %w", err)
+	}
+
+	fullTable := fmt.Sprintf("%s_%s%s", projectCode, models.SummaryPrefix, name)
+	if err := RefreshSummary(fullTable, 0); err != nil {
+		return nil, err
+	}
+	return GetSummary(fullTable)
+}
+
+// GetSummary retorna o resumo e o estado da última atualização (nil se a
+// tabela não for um resumo)
+func GetSummary(fullTable string) (*models.MaterializedSummary, error) {
+	if err := EnsureSummaryCatalogTable(); err != nil {
+		return nil, err
+	}
+
+	var (
+		s           models.MaterializedSummary
+		raw         []byte
+		lastRefresh sql.NullTime
+		lastError   sql.NullString
+	)
+	err := config.MasterDB.QueryRow(`
+		SELECT project_id, name, definition, status, last_refresh_at, last_duration_ms, last_error
+		FROM project_summaries WHERE table_name = ?`, fullTable,
+	).Scan(&s.ProjectID, &s.Name, &raw, &s.Status, &lastRefresh, &s.LastDurationMs, &lastError)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(raw, &s.SummaryDefinition); err != nil {
+		return nil, fmt.Errorf("definição inválida do resumo %s: %w", fullTable, err)
+	}
+	s.Table = models.SummaryPrefix + s.Name
+	if lastRefresh.Valid {
+		s.LastRefreshAt = &lastRefresh.Time
+	}
+	s.LastError = lastError.String
+	return &s, nil
+}
+
+// IsSummary indica se a tabela física é um resumo materializado (cache de 1 minuto)
+func IsSummary(fullTable string) (bool, error) {
+	summaryMu.RLock()
+	entry, ok := summaryCache[fullTable]
+	summaryMu.RUnlock()
+
+	if ok && time.Since(entry.loaded) < summaryCacheTTL {
+		return entry.isSummary, nil
+	}
+
+	if err := EnsureSummaryCatalogTable(); err != nil {
+		return false, err
+	}
+
+	var count int
+	err := config.MasterDB.QueryRow(
+		`SELECT COUNT(*) FROM project_summaries WHERE table_name = ?`, fullTable,
+	).Scan(&count)
+	if err != nil {
+		return false, err
+	}
+
+	summaryMu.Lock()
+	summaryCache[fullTable] = summaryEntry{isSummary: count > 0, loaded: time.Now()}
+	summaryMu.Unlock()
+
+	return count > 0, nil
+}
+
+// OnWriteSummaries retorna os resumos on_write calculados a partir da tabela
+// lógica source (cache de 1 minuto por projeto)
+func OnWriteSummaries(projectID int64, source string) ([]string, error) {
+	summaryMu.RLock()
+	entry, ok := onWriteCache[projectID]
+	summaryMu.RUnlock()
+
+	if ok && time.Since(entry.loaded) < summaryCacheTTL {
+		return entry.bySource[source], nil
+	}
+
+	if err := EnsureSummaryCatalogTable(); err != nil {
+		return nil, err
+	}
+
+	rows, err := config.MasterDB.Query(`
+		SELECT table_name, JSON_UNQUOTE(JSON_EXTRACT(definition, '$.source'))
+		FROM project_summaries WHERE project_id = ? AND refresh_mode = ?`,
+		projectID, models.RefreshOnWrite,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	bySource := map[string][]string{}
+	for rows.Next() {
+		var fullTable, src string
+		if err := rows.Scan(&fullTable, &src); err != nil {
+			return nil, err
+		}
+		bySource[src] = append(bySource[src], fullTable)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	summaryMu.Lock()
+	onWriteCache[projectID] = onWriteEntry{bySource: bySource, loaded: time.Now()}
+	summaryMu.Unlock()
+
+	return bySource[source], nil
+}
+
+// DueSummaries retorna os resumos agendados cujo intervalo já passou (contado
+// da última tentativa: um resumo com falha não é recalculado a cada ciclo)
+func DueSummaries() ([]string, error) {
+	if err := EnsureSummaryCatalogTable(); err != nil {
+		return nil, err
+	}
+
+	rows, err := config.MasterDB.Query(`
+		SELECT table_name FROM project_summaries
+		WHERE refresh_mode = ?
+		  AND (last_attempt_at IS NULL OR last_attempt_at + INTERVAL interval_seconds SECOND <= NOW(6))
+		ORDER BY last_attempt_at`, models.RefreshScheduled,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var due []string
+	for rows.Next() {
+		var fullTable string
+		if err := rows.Scan(&fullTable); err != nil {
+			return nil, err
+		}
+		due = append(due, fullTable)
+	}
+	return due, rows.Err()
+}
+
+// ============================================================================
+// DIRTY MARKS - Alterações pendentes dos resumos on_write
+// ============================================================================
+//
+// O refresher acumula as instâncias alteradas em memória; a marca no catálogo
+// (dirty_since = última marcação) sobrevive ao processo. Ela só é apagada se
+// não mudou durante o recálculo, e uma marca antiga sem tentativa recente
+// (processo que parou antes de recalcular) leva a um recálculo completo.
+
+// MarkSummaryDirty registra no catálogo que o resumo tem alterações pendentes
+func MarkSummaryDirty(fullTable string) error {
+	_, err := config.MasterDB.Exec(
+		`UPDATE project_summaries SET dirty_since = NOW(6) WHERE table_name = ?`, fullTable)
+	return err
+}
+
+// SummaryDirtySince retorna a marca atual do resumo (inválida se não houver)
+func SummaryDirtySince(fullTable string) (sql.NullTime, error) {
+	var since sql.NullTime
+	err := config.MasterDB.QueryRow(
+		`SELECT dirty_since FROM project_summaries WHERE table_name = ?`, fullTable,
+	).Scan(&since)
+	if err == sql.ErrNoRows {
+		return sql.NullTime{}, nil
+	}
+	return since, err
+}
+
+// ClearSummaryDirty apaga a marca lida antes do recálculo; uma marcação
+// feita durante o recálculo troca o valor e é preservada
+func ClearSummaryDirty(fullTable string, since sql.NullTime) error {
+	if !since.Valid {
+		return nil
+	}
+	_, err := config.MasterDB.Exec(
+		`UPDATE project_summaries SET dirty_since = NULL WHERE table_name = ? AND dirty_since = ?`,
+		fullTable, since.Time)
+	return err
+}
+
+// OrphanDirtySummaries retorna os resumos on_write marcados há mais de grace
+// e sem tentativa de atualização nesse período
+func OrphanDirtySummaries(grace time.Duration) ([]string, error) {
+	if err := EnsureSummaryCatalogTable(); err != nil {
+		return nil, err
+	}
+
+	seconds := int(grace / time.Second)
+	rows, err := config.MasterDB.Query(`
+		SELECT table_name FROM project_summaries
+		WHERE refresh_mode = ?
+		  AND dirty_since <= NOW(6) - INTERVAL ? SECOND
+		  AND (last_attempt_at IS NULL OR last_attempt_at <= NOW(6) - INTERVAL ? SECOND)
+		ORDER BY dirty_since`, models.RefreshOnWrite, seconds, seconds,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orphans []string
+	for rows.Next() {
+		var fullTable string
+		if err := rows.Scan(&fullTable); err != nil {
+			return nil, err
+		}
+		orphans = append(orphans, fullTable)
+	}
+	return orphans, rows.Err()
+}
+
+// ============================================================================
+// INTERNAL HELPERS
+// ============================================================================
+
+// normalizeSummaryDefinition valida a definição e aplica os padrões
+func normalizeSummaryDefinition(def models.SummaryDefinition) (models.SummaryDefinition, error) {
+	invalid := func(msg string) error {
+		return models.ErrInvalidSummary.WithDetails(msg, nil)
+	}
+
+	if !query.IsValidTableName(def.Source) {
+		return def, invalid("source é obrigatório e deve ser um nome de tabela válido")
+	}
+	if strings.HasPrefix(def.Source, models.SummaryPrefix) || strings.HasPrefix(def.Source, "_") {
+		return def, invalid("source não pode ser um resumo materializado nem tabela interna")
+	}
+	if len(def.Aggregates) == 0 {
+		return def, invalid("aggregates é obrigatório")
+	}
+
+	seen := map[string]bool{"id_instancia": true}
+	for _, col := range def.GroupBy {
+		if !query.IsValidColumnName(col) {
+			return def, fmt.Errorf("%w: %s", models.ErrInvalidColumn, col)
+		}
+		if seen[col] {
+			return def, invalid("coluna repetida no resumo: " + col)
+		}
+		seen[col] = true
+	}
+
+	for i, agg := range def.Aggregates {
+		agg.Function = strings.ToUpper(strings.TrimSpace(agg.Function))
+		if !summaryFunctions[agg.Function] {
+			return def, invalid("função de agregação não suportada: " + agg.Function)
+		}
+		if agg.Column == "*" {
+			agg.Column = ""
+		}
+		if agg.Column == "" && agg.Function != "COUNT" {
+			return def, invalid(agg.Function + " exige column")
+		}
+		if agg.Column != "" && !query.IsValidColumnName(agg.Column) {
+			return def, fmt.Errorf("%w: %s", models.ErrInvalidColumn, agg.Column)
+		}
+		if !query.IsValidColumnName(agg.As) {
+			return def, invalid("aggregates.as é obrigatório e deve ser um nome de coluna válido")
+		}
+		if seen[agg.As] {
+			return def, invalid("coluna repetida no resumo: " + agg.As)
+		}
+		seen[agg.As] = true
+		def.Aggregates[i] = agg
+	}
+
+	for col := range def.Where {
+		if !query.IsValidColumnName(col) {
+			return def, fmt.Errorf("%w: %s", models.ErrInvalidColumn, col)
+		}
+	}
+
+	switch def.RefreshMode {
+	case "", models.RefreshScheduled:
+		def.RefreshMode = models.RefreshScheduled
+		if def.IntervalSeconds == 0 {
+			def.IntervalSeconds = defaultSummaryInterval
+		}
+		if def.IntervalSeconds < minSummaryInterval {
+			return def, invalid(fmt.Sprintf("interval_seconds deve ser no mínimo %d", minSummaryInterval))
+		}
+	case models.RefreshOnWrite:
+		def.IntervalSeconds = 0
+	default:
+		return def, invalid("refresh_mode deve ser scheduled ou on_write")
+	}
+	return def, nil
+}
+
+// checkSummarySource confere se a origem existe e tem as colunas usadas
+func checkSummarySource(db *sql.DB, fullSource string, def models.SummaryDefinition) error {
+	columns, err := CachedColumns(db, fullSource)
+	if err != nil {
+		return err
+	}
+	if len(columns) == 0 {
+		return fmt.Errorf("%w: %s", models.ErrTableNotFound, def.Source)
+	}
+
+	known := make(map[string]bool, len(columns))
+	for _, col := range columns {
+		known[col.Name] = true
+	}
+	used := append([]string{}, def.GroupBy...)
+	for _, agg := range def.Aggregates {
+		if agg.Column != "" {
+			used = append(used, agg.Column)
+		}
+	}
+	for col := range def.Where {
+		used = append(used, col)
+	}
+	for _, col := range used {
+		if !known[col] {
+			return fmt.Errorf("%w: %s", models.ErrInvalidColumn, col)
+		}
+	}
+	return nil
+}
+
+// summaryColumns lista as colunas do resumo na ordem da tabela
+func summaryColumns(def models.SummaryDefinition) []string {
+	cols := append([]string{"id_instancia"}, def.GroupBy...)
+	for _, agg := range def.Aggregates {
+		cols = append(cols, agg.As)
+	}
+	return cols
+}
+
+// summarySelect monta, na forma do MySQL, o SELECT agrupado do resumo.
+// Com empty o SELECT não retorna linhas (estrutura do CREATE TABLE ... AS).
+func summarySelect(fullSource string, def models.SummaryDefinition, instanceID int64, empty bool) (string, []interface{}) {
+	group := append([]string{"id_instancia"}, def.GroupBy...)
+
+	cols := make([]string, 0, len(group)+len(def.Aggregates))
+	cols = append(cols, group...)
+	for _, agg := range def.Aggregates {
+		arg := "*"
+		if agg.Column != "" {
+			arg = query.QuoteIdent(agg.Column)
+		}
+		cols = append(cols, fmt.Sprintf("%s(%s) AS %s", agg.Function, arg, query.QuoteIdent(agg.As)))
+	}
+
+	builder := query.NewSelect(fullSource, "").SetColumns(cols)
+	if empty {
+		builder.AddWhere("1 = 0")
+	} else {
+		if instanceID > 0 {
+			builder.AddWhereEq("id_instancia", instanceID)
+		}
+		for col, value := range def.Where {
+			builder.AddWhereEq(col, value)
+		}
+	}
+
+	quoted := make([]string, len(group))
+	for i, col := range group {
+		quoted[i] = query.QuoteIdent(col)
+	}
+	builder.SetGroupBy(strings.Join(quoted, ", "))
+
+	return builder.Build(), builder.GetValues()
+}
+
+// refreshSummaryData substitui, em uma transação, as linhas do resumo pelo
+// resultado atual da agregação
+func refreshSummaryData(db *sql.DB, fullSource, fullTable string, def models.SummaryDefinition, instanceID int64) error {
+	d := config.DialectOf(db)
+
+	del := query.NewDelete(fullTable).SetDialect(d)
+	if instanceID > 0 {
+		del.WhereEq("id_instancia", instanceID)
+	}
+	deleteSQL, deleteArgs := del.Build()
+
+	cols := summaryColumns(def)
+	quoted := make([]string, len(cols))
+	for i, col := range cols {
+		quoted[i] = query.QuoteIdent(col)
+	}
+	selectSQL, selectArgs := summarySelect(fullSource, def, instanceID, false)
+	insertSQL := dialect.Rebind(d, fmt.Sprintf("INSERT INTO %s (%s) %s",
+		query.QuoteIdent(fullTable), strings.Join(quoted, ", "), selectSQL))
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(deleteSQL, deleteArgs...); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(insertSQL, selectArgs...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// markSummaryRefreshing registra o início de uma tentativa de atualização
+func markSummaryRefreshing(fullTable string) {
+	config.MasterDB.Exec(`
+		UPDATE project_summaries SET status = ?, last_attempt_at = NOW(6)
+		WHERE table_name = ?`, models.SummaryRefreshing, fullTable)
+}
+
+// recordSummaryRefresh grava o resultado da atualização no catálogo
+func recordSummaryRefresh(fullTable string, start time.Time, refreshErr error) {
+	elapsed := time.Since(start).Milliseconds()
+	if refreshErr != nil {
+		config.MasterDB.Exec(`
+			UPDATE project_summaries SET status = ?, last_duration_ms = ?, last_error = ?
+			WHERE table_name = ?`, models.SummaryFailed, elapsed, refreshErr.Error(), fullTable)
+		return
+	}
+	config.MasterDB.Exec(`
+		UPDATE project_summaries SET status = ?, last_refresh_at = NOW(6), last_duration_ms = ?, last_error = NULL
+		WHERE table_name = ?`, models.SummaryReady, elapsed, fullTable)
+}
+
+// unregisterSummary remove o resumo do catálogo (tabela já removida)
+func unregisterSummary(projectID int64, fullTable string) error {
+	if err := EnsureSummaryCatalogTable(); err != nil {
+		return err
+	}
+	_, err := config.MasterDB.Exec(`DELETE FROM project_summaries WHERE table_name = ?`, fullTable)
+	forgetSummary(projectID, fullTable)
+	return err
+}
+
+func forgetSummary(projectID int64, fullTable string) {
+	summaryMu.Lock()
+	delete(summaryCache, fullTable)
+	delete(onWriteCache, projectID)
+	summaryMu.Unlock()
+}
+
+func refreshLock(fullTable string) *sync.Mutex {
+	refreshLocksMu.Lock()
+	defer refreshLocksMu.Unlock()
+	lock, ok := refreshLocks[fullTable]
+	if !ok {
+		lock = &sync.Mutex{}
+		refreshLocks[fullTable] = lock
+	}
+	return lock
+}
@@ -1,309 +1,433 @@
-package table
-
-import (
-	"database/sql"
-	"fmt"
-	"strings"
-	"meu-provedor/config"
-	"meu-provedor/models"
-)
-
-// ============================================================================
-// TABLE OPERATIONS
-// ============================================================================
-
-// Create cria uma nova tabela para o projeto (usando project_id)
-func Create(projectID int64, req models.CreateTableRequest) (string, error) {
-	// Busca o code do projeto pelo ID
-	projectCode, err := config.GetProjectCodeByID(int(projectID))
-	if err != nil {
-		return "", fmt.Errorf("projeto não encontrado: %w", err)
-	}
-
-	fullTableName := fmt.Sprintf("%s_%s", projectCode, req.TableName)
-
-	columns := []string{
-		"id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY",
-		`id_instancia BIGINT UNSIGNED NOT NULL,
-		FOREIGN KEY (id_instancia)
-		REFERENCES instancias_projetion(id)
-		ON DELETE CASCADE`,
-	}
-
-	for _, col := range req.Columns {
-		def := col.Name + " " + col.Type
-		if !col.Nullable {
-			def += " NOT NULL"
-		}
-		if col.Unique {
-			def += " UNIQUE"
-		}
-		columns = append(columns, def)
-	}
-
-	for _, idx := range req.Indexes {
-		idxDef := ""
-		if idx.Type == "UNIQUE" {
-			idxDef = fmt.Sprintf("UNIQUE KEY %s (%s)", idx.Name, strings.Join(idx.Columns, ","))
-		} else {
-			idxDef = fmt.Sprintf("INDEX %s (%s)", idx.Name, strings.Join(idx.Columns, ","))
-		}
-		columns = append(columns, idxDef)
-	}
-
-	createSQL := fmt.Sprintf("CREATE TABLE %s (%s)", fullTableName, strings.Join(columns, ","))
-
-	_, err = config.MasterDB.Exec(createSQL)
-	return fullTableName, err
-}
-
-// List retorna todas as tabelas de um projeto (usando project_id)
-func List(projectID int64) ([]string, error) {
-	projectCode, err := config.GetProjectCodeByID(int(projectID))
-	if err != nil {
-		return nil, fmt.Errorf("projeto não encontrado: %w", err)
-	}
-
-	rows, err := config.MasterDB.Query(`
-		SELECT table_name
-		FROM information_schema.tables
-		WHERE table_schema = DATABASE()
-		AND table_name LIKE ?`, projectCode+"_%",
-	)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var tables []string
-	for rows.Next() {
-		var fullName string
-		rows.Scan(&fullName)
-		displayName := strings.TrimPrefix(fullName, projectCode+"_")
-		tables = append(tables, displayName)
-	}
-	return tables, nil
-}
-
-// Delete remove uma tabela (usando project_id)
-func Delete(projectID int64, table string) error {
-	projectCode, err := config.GetProjectCodeByID(int(projectID))
-	if err != nil {
-		return fmt.Errorf("projeto não encontrado: %w", err)
-	}
-
-	fullTable := fmt.Sprintf("%s_%s", projectCode, table)
-	_, err = config.MasterDB.Exec("DROP TABLE " + fullTable)
-	return err
-}
-
-// GetDetails retorna detalhes completos de uma tabela (usando project_id)
-func GetDetails(projectID int64, tableName string) (*models.TableDetail, error) {
-	projectCode, err := config.GetProjectCodeByID(int(projectID))
-	if err != nil {
-		return nil, fmt.Errorf("projeto não encontrado: %w", err)
-	}
-
-	fullTable := fmt.Sprintf("%s_%s", projectCode, tableName)
-
-	columns, err := getColumns(fullTable)
-	if err != nil {
-		return nil, err
-	}
-
-	indexes, err := getIndexes(fullTable)
-	if err != nil {
-		return nil, err
-	}
-
-	return &models.TableDetail{
-		Name:    tableName,
-		Columns: columns,
-		Indexes: indexes,
-	}, nil
-}
-
-// ============================================================================
-// COLUMN OPERATIONS
-// ============================================================================
-
-type ColumnRequest struct {
-	Name     string `json:"name"`
-	Type     string `json:"type"`
-	Nullable bool   `json:"nullable"`
-	Unique   bool   `json:"unique"`
-}
-
-// AddColumn adiciona uma nova coluna à tabela (usando project_id)
-func AddColumn(projectID int64, tableName string, col ColumnRequest) error {
-	projectCode, err := config.GetProjectCodeByID(int(projectID))
-	if err != nil {
-		return fmt.Errorf("projeto não encontrado: %w", err)
-	}
-
-	fullTable := fmt.Sprintf("%s_%s", projectCode, tableName)
-	def := col.Name + " " + col.Type
-	if !col.Nullable {
-		def += " NOT NULL"
-	}
-	if col.Unique {
-		def += " UNIQUE"
-	}
-	query := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", fullTable, def)
-	_, err = config.MasterDB.Exec(query)
-	return err
-}
-
-// ModifyColumn modifica uma coluna existente (usando project_id)
-func ModifyColumn(projectID int64, tableName string, col ColumnRequest) error {
-	projectCode, err := config.GetProjectCodeByID(int(projectID))
-	if err != nil {
-		return fmt.Errorf("projeto não encontrado: %w", err)
-	}
-
-	fullTable := fmt.Sprintf("%s_%s", projectCode, tableName)
-	def := col.Name + " " + col.Type
-	if !col.Nullable {
-		def += " NOT NULL"
-	}
-	query := fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s", fullTable, def)
-	_, err = config.MasterDB.Exec(query)
-	return err
-}
-
-// DropColumn remove uma coluna (usando project_id)
-func DropColumn(projectID int64, tableName, columnName string) error {
-	projectCode, err := config.GetProjectCodeByID(int(projectID))
-	if err != nil {
-		return fmt.Errorf("projeto não encontrado: %w", err)
-	}
-
-	fullTable := fmt.Sprintf("%s_%s", projectCode, tableName)
-	query := fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", fullTable, columnName)
-	_, err = config.MasterDB.Exec(query)
-	return err
-}
-
-// ============================================================================
-// INDEX OPERATIONS
-// ============================================================================
-
-type IndexRequest struct {
-	Name    string   `json:"name"`
-	Columns []string `json:"columns"`
-	Type    string   `json:"type"`
-}
-
-// AddIndex adiciona um novo índice (usando project_id)
-func AddIndex(projectID int64, tableName string, idx IndexRequest) error {
-	projectCode, err := config.GetProjectCodeByID(int(projectID))
-	if err != nil {
-		return fmt.Errorf("projeto não encontrado: %w", err)
-	}
-
-	fullTable := fmt.Sprintf("%s_%s", projectCode, tableName)
-	var query string
-	if idx.Type == "UNIQUE" {
-		query = fmt.Sprintf("ALTER TABLE %s ADD UNIQUE INDEX %s (%s)",
-			fullTable, idx.Name, strings.Join(idx.Columns, ","))
-	} else {
-		query = fmt.Sprintf("ALTER TABLE %s ADD INDEX %s (%s)",
-			fullTable, idx.Name, strings.Join(idx.Columns, ","))
-	}
-	_, err = config.MasterDB.Exec(query)
-	return err
-}
-
-// DropIndex remove um índice (usando project_id)
-func DropIndex(projectID int64, tableName, indexName string) error {
-	projectCode, err := config.GetProjectCodeByID(int(projectID))
-	if err != nil {
-		return fmt.Errorf("projeto não encontrado: %w", err)
-	}
-
-	fullTable := fmt.Sprintf("%s_%s", projectCode, tableName)
-	query := fmt.Sprintf("ALTER TABLE %s DROP INDEX %s", fullTable, indexName)
-	_, err = config.MasterDB.Exec(query)
-	return err
-}
-
-// ============================================================================
-// INTERNAL HELPERS
-// ============================================================================
-
-func getColumns(fullTable string) ([]models.ColumnDetail, error) {
-	rows, err := config.MasterDB.Query(`
-		SELECT COLUMN_NAME, COLUMN_TYPE, IS_NULLABLE, COLUMN_DEFAULT, COLUMN_KEY, EXTRA
-		FROM information_schema.COLUMNS
-		WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?
-		ORDER BY ORDINAL_POSITION`, fullTable,
-	)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var columns []models.ColumnDetail
-	for rows.Next() {
-		var col models.ColumnDetail
-		var isNullable, colDefault, colKey, extra sql.NullString
-
-		err := rows.Scan(&col.Name, &col.Type, &isNullable, &colDefault, &colKey, &extra)
-		if err != nil {
-			continue
-		}
-
-		col.Nullable = (isNullable.String == "YES")
-		if colDefault.Valid {
-			col.Default = colDefault.String
-		}
-		col.Key = colKey.String
-		col.Extra = extra.String
-
-		columns = append(columns, col)
-	}
-	return columns, nil
-}
-
-func getIndexes(fullTable string) ([]models.IndexDetail, error) {
-	rows, err := config.MasterDB.Query(`
-		SELECT INDEX_NAME, COLUMN_NAME, NON_UNIQUE
-		FROM information_schema.STATISTICS
-		WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?
-		ORDER BY INDEX_NAME, SEQ_IN_INDEX`, fullTable,
-	)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	indexMap := make(map[string]*models.IndexDetail)
-	for rows.Next() {
-		var name, column string
-		var nonUnique int
-
-		err := rows.Scan(&name, &column, &nonUnique)
-		if err != nil {
-			continue
-		}
-
-		if _, exists := indexMap[name]; !exists {
-			idxType := "INDEX"
-			if nonUnique == 0 {
-				idxType = "UNIQUE"
-			}
-			indexMap[name] = &models.IndexDetail{
-				Name:    name,
-				Columns: []string{},
-				Type:    idxType,
-			}
-		}
-		indexMap[name].Columns = append(indexMap[name].Columns, column)
-	}
-
-	var indexes []models.IndexDetail
-	for _, idx := range indexMap {
-		indexes = append(indexes, *idx)
-	}
-	return indexes, nil
-}
+package table
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"meu-provedor/config"
+	"meu-provedor/engine/dialect"
+	"meu-provedor/models"
+)
+
+// ============================================================================
+// TABLE OPERATIONS
+// ============================================================================
+
+// Create cria uma nova tabela para o projeto (usando project_id)
+func Create(projectID int64, req models.CreateTableRequest) (string, error) {
+	// Busca o code do projeto pelo ID
+	projectCode, db, done, err := projectWriteDatabase(projectID)
+	if err != nil {
+		return "", err
+	}
+	defer done()
+
+	// Nomes iniciados por "_" são reservados (ex.: {code}__history)
+	if strings.HasPrefix(req.TableName, "_") {
+		return "", fmt.Errorf("%w: nomes iniciados por '_' são reservados", models.ErrInvalidTableName)
+	}
+	// "mv_" é reservado aos resumos materializados (POST /schema/summary)
+	if strings.HasPrefix(req.TableName, models.SummaryPrefix) {
+		return "", fmt.Errorf("%w: nomes iniciados por '%s' são reservados", models.ErrInvalidTableName, models.SummaryPrefix)
+	}
+
+	fullTableName := fmt.Sprintf("%s_%s", projectCode, req.TableName)
+	marked, err := trackWrite(projectID, fullTableName)
+	if err != nil {
+		return "", err
+	}
+	defer marked()
+	d := config.DialectOf(db)
+
+	columns := []string{
+		d.AutoIncrementPK("id"),
+		"id_instancia " + d.BigIntType() + " NOT NULL",
+	}
+
+	for _, col := range req.Columns {
+		def := col.Name + " " + col.Type
+		if !col.Nullable {
+			def += " NOT NULL"
+		}
+		if col.Unique {
+			def += " UNIQUE"
+		}
+		columns = append(columns, def)
+	}
+
+	if req.Versioned {
+		columns = append(columns, models.VersionColumn+" "+d.BigIntType()+" NOT NULL DEFAULT 1")
+	}
+	if req.Timestamps {
+		columns = append(columns,
+			d.TimestampColumn("created_at", 0, false),
+			d.TimestampColumn("updated_at", 0, true),
+		)
+	}
+	if req.TrackUser {
+		columns = append(columns, "created_by VARCHAR(191) NULL", "updated_by VARCHAR(191) NULL")
+	}
+
+	// Constraints depois das colunas (exigência do SQLite)
+	columns = append(columns, d.ForeignKeyCascade("id_instancia", "instancias_projetion", "id"))
+
+	indexes := make([]dialect.Index, len(req.Indexes))
+	for i, idx := range req.Indexes {
+		indexes[i] = dialect.Index{Name: idx.Name, Type: idx.Type, Columns: idx.Columns}
+	}
+
+	statements, err := d.CreateTable(fullTableName, false, columns, indexes)
+	if err != nil {
+		return fullTableName, err
+	}
+
+	err = execAll(db, statements)
+	InvalidateColumns(fullTableName)
+	if err != nil {
+		if len(statements) > 1 {
+			// Índices criados fora do CREATE TABLE: não deixar a tabela pela metade
+			db.Exec("DROP TABLE IF EXISTS " + fullTableName)
+		}
+		return fullTableName, err
+	}
+
+	// Auditoria e versão só são gerenciadas nas tabelas que pediram: sem o
+	// registro, a tabela fica sem o controle
+	managed := ManagedColumns{
+		Timestamps:    req.Timestamps,
+		TrackUser:     req.TrackUser,
+		Versioned:     req.Versioned,
+		AuditOverride: req.AuditOverride && (req.Timestamps || req.TrackUser),
+	}
+	if err := SetManagedColumns(fullTableName, managed); err != nil {
+		db.Exec("DROP TABLE IF EXISTS " + fullTableName)
+		InvalidateColumns(fullTableName)
+		return fullTableName, err
+	}
+
+	if req.History {
+		if err := SetHistoryEnabled(db, projectCode, fullTableName, true); err != nil {
+			return fullTableName, err
+		}
+	}
+	return fullTableName, nil
+}
+
+// List retorna todas as tabelas, views e resumos de um projeto (usando project_id)
+func List(projectID int64) ([]models.TableSummary, error) {
+	projectCode, db, err := projectDatabase(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	d := config.DialectOf(db)
+	fullNames, err := d.Tables(db, projectCode+"_")
+	if err != nil {
+		return nil, err
+	}
+	viewNames, err := d.Views(db, projectCode+"_")
+	if err != nil {
+		return nil, err
+	}
+
+	var tables []models.TableSummary
+	add := func(fullName, tableType string) {
+		displayName := strings.TrimPrefix(fullName, projectCode+"_")
+		// Tabelas internas (ex.: __history) não são listadas
+		if strings.HasPrefix(displayName, "_") {
+			return
+		}
+		tables = append(tables, models.TableSummary{Name: displayName, Type: tableType})
+	}
+	for _, fullName := range fullNames {
+		tableType := models.TableTypeTable
+		if strings.HasPrefix(fullName, projectCode+"_"+models.SummaryPrefix) {
+			isSummary, err := IsSummary(fullName)
+			if err != nil {
+				return nil, err
+			}
+			if isSummary {
+				tableType = models.TableTypeSummary
+			}
+		}
+		add(fullName, tableType)
+	}
+	for _, fullName := range viewNames {
+		add(fullName, models.TableTypeView)
+	}
+	return tables, nil
+}
+
+// Delete remove uma tabela (usando project_id)
+func Delete(projectID int64, table string) error {
+	projectCode, db, done, err := projectWriteDatabase(projectID)
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	fullTable := fmt.Sprintf("%s_%s", projectCode, table)
+	marked, err := trackWrite(projectID, fullTable)
+	if err != nil {
+		return err
+	}
+	defer marked()
+	_, err = db.Exec("DROP TABLE " + fullTable)
+	InvalidateColumns(fullTable)
+	if err != nil {
+		return err
+	}
+
+	// O histórico já gravado é mantido; apenas o opt-in é removido
+	if err := SetHistoryEnabled(db, projectCode, fullTable, false); err != nil {
+		return err
+	}
+	if err := SetManagedColumns(fullTable, ManagedColumns{}); err != nil {
+		return err
+	}
+	if strings.HasPrefix(table, models.SummaryPrefix) {
+		return unregisterSummary(projectID, fullTable)
+	}
+	return nil
+}
+
+// GetDetails retorna detalhes completos de uma tabela (usando project_id)
+func GetDetails(projectID int64, tableName string) (*models.TableDetail, error) {
+	projectCode, db, err := projectDatabase(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	fullTable := fmt.Sprintf("%s_%s", projectCode, tableName)
+
+	columns, err := getColumns(db, fullTable)
+	if err != nil {
+		return nil, err
+	}
+
+	indexes, err := getIndexes(db, fullTable)
+	if err != nil {
+		return nil, err
+	}
+
+	definition, err := ViewDefinition(fullTable)
+	if err != nil {
+		return nil, err
+	}
+	summary, err := GetSummary(fullTable)
+	if err != nil {
+		return nil, err
+	}
+	tableType := models.TableTypeTable
+	if definition != nil {
+		tableType = models.TableTypeView
+	}
+	if summary != nil {
+		tableType = models.TableTypeSummary
+	}
+
+	return &models.TableDetail{
+		Name:       tableName,
+		Type:       tableType,
+		Columns:    columns,
+		Indexes:    indexes,
+		Definition: definition,
+		Summary:    summary,
+	}, nil
+}
+
+// ============================================================================
+// COLUMN OPERATIONS
+// ============================================================================
+
+type ColumnRequest struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Nullable bool   `json:"nullable"`
+	Unique   bool   `json:"unique"`
+}
+
+// AddColumn adiciona uma nova coluna à tabela (usando project_id)
+func AddColumn(projectID int64, tableName string, col ColumnRequest) error {
+	projectCode, db, done, err := projectWriteDatabase(projectID)
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	fullTable := fmt.Sprintf("%s_%s", projectCode, tableName)
+	marked, err := trackWrite(projectID, fullTable)
+	if err != nil {
+		return err
+	}
+	defer marked()
+	def := col.Name + " " + col.Type
+	if !col.Nullable {
+		def += " NOT NULL"
+	}
+	if col.Unique {
+		def += " UNIQUE"
+	}
+	query := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", fullTable, def)
+	_, err = db.Exec(query)
+	InvalidateColumns(fullTable)
+	return err
+}
+
+// ModifyColumn modifica uma coluna existente (usando project_id)
+func ModifyColumn(projectID int64, tableName string, col ColumnRequest) error {
+	projectCode, db, done, err := projectWriteDatabase(projectID)
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	fullTable := fmt.Sprintf("%s_%s", projectCode, tableName)
+	marked, err := trackWrite(projectID, fullTable)
+	if err != nil {
+		return err
+	}
+	defer marked()
+	statements, err := config.DialectOf(db).ModifyColumn(fullTable, col.Name, col.Type, col.Nullable)
+	if err != nil {
+		return err
+	}
+	err = execAll(db, statements)
+	InvalidateColumns(fullTable)
+	return err
+}
+
+// DropColumn remove uma coluna (usando project_id)
+func DropColumn(projectID int64, tableName, columnName string) error {
+	projectCode, db, done, err := projectWriteDatabase(projectID)
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	fullTable := fmt.Sprintf("%s_%s", projectCode, tableName)
+	marked, err := trackWrite(projectID, fullTable)
+	if err != nil {
+		return err
+	}
+	defer marked()
+	query := fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", fullTable, columnName)
+	_, err = db.Exec(query)
+	InvalidateColumns(fullTable)
+	return err
+}
+
+// ============================================================================
+// INDEX OPERATIONS
+// ============================================================================
+
+type IndexRequest struct {
+	Name    string   `json:"name"`
+	Columns []string `json:"columns"`
+	Type    string   `json:"type"`
+}
+
+// AddIndex adiciona um novo índice (usando project_id)
+func AddIndex(projectID int64, tableName string, idx IndexRequest) error {
+	projectCode, db, done, err := projectWriteDatabase(projectID)
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	fullTable := fmt.Sprintf("%s_%s", projectCode, tableName)
+	marked, err := trackWrite(projectID, fullTable)
+	if err != nil {
+		return err
+	}
+	defer marked()
+	query, err := config.DialectOf(db).CreateIndex(fullTable,
+		dialect.Index{Name: idx.Name, Type: idx.Type, Columns: idx.Columns})
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(query)
+	return err
+}
+
+// DropIndex remove um índice (usando project_id)
+func DropIndex(projectID int64, tableName, indexName string) error {
+	projectCode, db, done, err := projectWriteDatabase(projectID)
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	fullTable := fmt.Sprintf("%s_%s", projectCode, tableName)
+	marked, err := trackWrite(projectID, fullTable)
+	if err != nil {
+		return err
+	}
+	defer marked()
+	_, err = db.Exec(config.DialectOf(db).DropIndex(fullTable, indexName))
+	return err
+}
+
+// ============================================================================
+// INTERNAL HELPERS
+// ============================================================================
+
+// projectDatabase resolve o code do projeto e o banco onde ficam suas tabelas
+func projectDatabase(projectID int64) (string, *sql.DB, error) {
+	projectCode, err := config.GetProjectCodeByID(int(projectID))
+	if err != nil {
+		return "", nil, fmt.Errorf("projeto não encontrado: %w", err)
+	}
+	db, err := config.ProjectDB(projectID)
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: %v", models.ErrDatabaseConnection, err)
+	}
+	return projectCode, db, nil
+}
+
+// projectWriteDatabase é projectDatabase para DDL: registra a escrita no gate
+// do projeto (bloqueada durante migrações). done deve ser chamada ao final.
+func projectWriteDatabase(projectID int64) (string, *sql.DB, func(), error) {
+	done, err := config.BeginProjectWrite(projectID)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	projectCode, db, err := projectDatabase(projectID)
+	if err != nil {
+		done()
+		return "", nil, nil, err
+	}
+	return projectCode, db, done, nil
+}
+
+// trackWrite marca a tabela física como escrita (durante uma migração); a
+// função retornada marca de novo ao fim da operação
+func trackWrite(projectID int64, fullTable string) (func(), error) {
+	if err := config.MarkTableWrite(projectID, fullTable); err != nil {
+		return nil, err
+	}
+	return func() {
+		if err := config.MarkTableWrite(projectID, fullTable); err != nil {
+			log.Printf("⚠️ %v", err)
+		}
+	}, nil
+}
+
+// execAll executa em ordem os comandos DDL gerados pelo dialeto
+func execAll(db *sql.DB, statements []string) error {
+	for _, stmt := range statements {
+		if _, err := db.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func getColumns(db *sql.DB, fullTable string) ([]models.ColumnDetail, error) {
+	return config.DialectOf(db).Columns(db, fullTable)
+}
+
+func getIndexes(db *sql.DB, fullTable string) ([]models.IndexDetail, error) {
+	return config.DialectOf(db).Indexes(db, fullTable)
+}
@@ -0,0 +1,195 @@
+package table
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"meu-provedor/config"
+	"meu-provedor/engine/dialect"
+	"meu-provedor/engine/query"
+	"meu-provedor/models"
+)
+
+// ============================================================================
+// VIEWS - Views do projeto ({code}_nome) descritas pelo spec do join-select
+// ============================================================================
+//
+// A definição estruturada fica no catálogo (project_views, no banco master);
+// o SELECT é renderizado pelo serviço de dados, que conhece os aliases e o
+// schema das tabelas. Views são somente leitura.
+
+const viewCacheTTL = time.Minute
+
+var (
+	viewMu    sync.RWMutex
+	viewCache = map[string]viewEntry{}
+)
+
+type viewEntry struct {
+	isView bool
+	loaded time.Time
+}
+
+// ViewRenderer renderiza, na forma do MySQL e com placeholders "?", o SELECT
+// de uma view a partir da definição
+type ViewRenderer func(db *sql.DB, projectCode string, def models.AdvancedJoinSelectRequest) (string, []interface{}, error)
+
+// EnsureViewCatalogTable garante que a tabela project_views existe
+func EnsureViewCatalogTable() error {
+	_, err := config.MasterDB.Exec(`
+		CREATE TABLE IF NOT EXISTS project_views (
+			view_name VARCHAR(128) NOT NULL PRIMARY KEY,
+			project_id BIGINT UNSIGNED NOT NULL,
+			definition JSON NOT NULL,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			INDEX idx_views_project (project_id)
+		)`)
+	if err != nil {
+		return fmt.Errorf("erro ao criar tabela project_views: %w", err)
+	}
+	return nil
+}
+
+// CreateView cria a view {code}_viewName com o SELECT renderizado da definição
+func CreateView(projectID int64, viewName string, def models.AdvancedJoinSelectRequest, render ViewRenderer) (string, error) {
+	if !query.IsValidTableName(viewName) || strings.HasPrefix(viewName, "_") || strings.HasPrefix(viewName, models.SummaryPrefix) {
+		return "", fmt.Errorf("%w: %s", models.ErrInvalidTableName, viewName)
+	}
+	if err := EnsureViewCatalogTable(); err != nil {
+		return "", err
+	}
+
+	projectCode, db, done, err := projectWriteDatabase(projectID)
+	if err != nil {
+		return "", err
+	}
+	defer done()
+
+	fullView := fmt.Sprintf("%s_%s", projectCode, viewName)
+	d := config.DialectOf(db)
+
+	selectSQL, args, err := render(db, projectCode, def)
+	if err != nil {
+		return fullView, err
+	}
+	// DDL não aceita parâmetros: os valores do where entram como literais
+	stmt, err := dialect.Inline(d, "CREATE VIEW "+query.QuoteIdent(fullView)+" AS "+selectSQL, args)
+	if err != nil {
+		return fullView, fmt.Errorf("%w: %v", models.ErrInvalidView, err)
+	}
+	if _, err := db.Exec(dialect.Rebind(d, stmt)); err != nil {
+		return fullView, fmt.Errorf("%w: %v", models.ErrInvalidView, err)
+	}
+	InvalidateColumns(fullView)
+
+	definition, err := json.Marshal(def)
+	if err == nil {
+		_, err = config.MasterDB.Exec(
+			`INSERT INTO project_views (view_name, project_id, definition) VALUES (?, ?, ?)
+			ON DUPLICATE KEY UPDATE project_id=VALUES(project_id), definition=VALUES(definition)`,
+			fullView, projectID, definition,
+		)
+	}
+	if err != nil {
+		// sem registro no catálogo a view seria gravável: desfaz
+		db.Exec(dialect.Rebind(d, "DROP VIEW "+query.QuoteIdent(fullView)))
+		return fullView, err
+	}
+
+	forgetView(fullView)
+	return fullView, nil
+}
+
+// DropView remove a view do projeto e sua definição
+func DropView(projectID int64, viewName string) error {
+	if err := EnsureViewCatalogTable(); err != nil {
+		return err
+	}
+
+	projectCode, db, done, err := projectWriteDatabase(projectID)
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	fullView := fmt.Sprintf("%s_%s", projectCode, viewName)
+	isView, err := IsView(fullView)
+	if err != nil {
+		return err
+	}
+	if !isView {
+		return models.ErrViewNotFound
+	}
+
+	if _, err := db.Exec(dialect.Rebind(config.DialectOf(db), "DROP VIEW IF EXISTS "+query.QuoteIdent(fullView))); err != nil {
+		return err
+	}
+	InvalidateColumns(fullView)
+
+	_, err = config.MasterDB.Exec(`DELETE FROM project_views WHERE view_name = ?`, fullView)
+	forgetView(fullView)
+	return err
+}
+
+// ViewDefinition retorna a definição da view (nil se a tabela não for view)
+func ViewDefinition(fullTable string) (*models.AdvancedJoinSelectRequest, error) {
+	if err := EnsureViewCatalogTable(); err != nil {
+		return nil, err
+	}
+
+	var raw []byte
+	err := config.MasterDB.QueryRow(
+		`SELECT definition FROM project_views WHERE view_name = ?`, fullTable,
+	).Scan(&raw)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var def models.AdvancedJoinSelectRequest
+	if err := json.Unmarshal(raw, &def); err != nil {
+		return nil, fmt.Errorf("definição inválida da view %s: %w", fullTable, err)
+	}
+	return &def, nil
+}
+
+// IsView indica se a tabela física é uma view do projeto (cache de 1 minuto)
+func IsView(fullTable string) (bool, error) {
+	viewMu.RLock()
+	entry, ok := viewCache[fullTable]
+	viewMu.RUnlock()
+
+	if ok && time.Since(entry.loaded) < viewCacheTTL {
+		return entry.isView, nil
+	}
+
+	if err := EnsureViewCatalogTable(); err != nil {
+		return false, err
+	}
+
+	var count int
+	err := config.MasterDB.QueryRow(
+		`SELECT COUNT(*) FROM project_views WHERE view_name = ?`, fullTable,
+	).Scan(&count)
+	if err != nil {
+		return false, err
+	}
+
+	viewMu.Lock()
+	viewCache[fullTable] = viewEntry{isView: count > 0, loaded: time.Now()}
+	viewMu.Unlock()
+
+	return count > 0, nil
+}
+
+func forgetView(fullView string) {
+	viewMu.Lock()
+	delete(viewCache, fullView)
+	viewMu.Unlock()
+}
@@ -0,0 +1,117 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+
+	"meu-provedor/models"
+)
+
+// ============================================================================
+// ADDRESS - Bloqueio de destinos internos (SSRF)
+// ============================================================================
+//
+// O host do webhook é resolvido no cadastro e o endereço é conferido de novo
+// a cada conexão: o DNS pode mudar depois do cadastro e redirecionamentos
+// levam a outros hosts. Loopback, redes privadas, link-local (inclusive o
+// metadata 169.254.169.254) e faixas reservadas são recusados.
+
+// blockedNets complementa os métodos de net.IP com faixas reservadas
+var blockedNets = parseCIDRs(
+	"0.0.0.0/8",     // "esta" rede
+	"100.64.0.0/10", // CGNAT
+	"192.0.0.0/24",  // atribuições do IETF
+	"198.18.0.0/15", // benchmark
+	"240.0.0.0/4",   // reservado (inclui broadcast)
+	"64:ff9b::/96",  // NAT64 (mapeia IPv4 internos)
+)
+
+// resolveTimeout limita a resolução do host no cadastro
+const resolveTimeout = 5 * time.Second
+
+// blockedIP indica se o endereço é interno
+func blockedIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return true
+	}
+	for _, n := range blockedNets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateURL confere esquema e host e recusa hosts que resolvem para
+// endereços internos
+func validateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Hostname() == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return models.ErrInvalidWebhookURL
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), resolveTimeout)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, u.Hostname())
+	if err != nil || len(addrs) == 0 {
+		return models.ErrInvalidWebhookURL.WithDetails(
+			"host do webhook não encontrado: "+u.Hostname(),
+			map[string]interface{}{"host": u.Hostname()},
+		)
+	}
+	for _, addr := range addrs {
+		if blockedIP(addr.IP) {
+			return models.ErrWebhookURLBlocked.WithDetails(
+				fmt.Sprintf("%s: %s resolve para %s", models.ErrWebhookURLBlocked.Message, u.Hostname(), addr.IP),
+				map[string]interface{}{"host": u.Hostname()},
+			)
+		}
+	}
+	return nil
+}
+
+// newHTTPClient cria o cliente de entregas: sem proxy e com a checagem do
+// endereço feita na conexão, depois da resolução de DNS
+func newHTTPClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || blockedIP(ip) {
+				return fmt.Errorf("destino interno bloqueado: %s", address)
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   5 * time.Second,
+			ResponseHeaderTimeout: 10 * time.Second,
+			MaxIdleConnsPerHost:   dispatchWorkers,
+		},
+	}
+}
+
+func parseCIDRs(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets[i] = n
+	}
+	return nets
+}
@@ -0,0 +1,249 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"meu-provedor/config"
+	"meu-provedor/models"
+	"meu-provedor/services/events"
+)
+
+// ============================================================================
+// DISPATCHER - Envio das entregas com assinatura HMAC, retentativas e backoff
+// ============================================================================
+
+const (
+	// dispatchBatchSize é o máximo de entregas buscadas por ciclo
+	dispatchBatchSize = 50
+	// dispatchWorkers é o número de envios simultâneos
+	dispatchWorkers = 4
+	// claimSeconds reserva a entrega enquanto ela é enviada
+	claimSeconds = 120
+	// maxBackoff limita o intervalo entre tentativas
+	maxBackoff = time.Hour
+)
+
+// httpClient recusa destinos internos na conexão (ver address.go)
+var httpClient = newHTTPClient()
+
+// dueDelivery é uma entrega pronta para envio
+type dueDelivery struct {
+	id       int64
+	eventID  string
+	payload  []byte
+	attempts int
+	url      string
+	secret   string
+}
+
+// Start cria as tabelas e inicia o relay de eventos e o dispatcher
+func Start() error {
+	if err := EnsureWebhookTables(); err != nil {
+		return err
+	}
+	events.Subscribe(wakeRelay)
+
+	interval := time.Duration(config.EnvPositiveInt("WEBHOOK_POLL_SECONDS", 2)) * time.Second
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case projectID := <-relayWake:
+				if err := relayProject(projectID); err != nil {
+					log.Printf("❌ Webhooks: erro ao repassar eventos do projeto %d: %v", projectID, err)
+				}
+			case <-ticker.C:
+				relayAll()
+			}
+		}
+	}()
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			dispatchDue()
+		}
+	}()
+
+	log.Println("✅ Dispatcher de webhooks iniciado")
+	return nil
+}
+
+// dispatchDue envia as entregas pendentes cujo horário já chegou
+func dispatchDue() {
+	rows, err := config.MasterDB.Query(`
+		SELECT o.id, o.event_id, o.payload, o.attempts, w.url, w.secret
+		FROM webhook_outbox o
+		JOIN project_webhooks w ON w.id = o.webhook_id
+		WHERE o.status = ? AND o.next_attempt_at <= NOW()
+		ORDER BY o.id
+		LIMIT ?`, models.DeliveryPending, dispatchBatchSize)
+	if err != nil {
+		log.Printf("❌ Webhooks: erro ao buscar entregas: %v", err)
+		return
+	}
+
+	var due []dueDelivery
+	for rows.Next() {
+		var d dueDelivery
+		var payload string
+		if err := rows.Scan(&d.id, &d.eventID, &payload, &d.attempts, &d.url, &d.secret); err != nil {
+			log.Printf("❌ Webhooks: erro ao ler entrega: %v", err)
+			continue
+		}
+		d.payload = []byte(payload)
+		due = append(due, d)
+	}
+	rows.Close()
+
+	sem := make(chan struct{}, dispatchWorkers)
+	var wg sync.WaitGroup
+	for _, d := range due {
+		if !claim(d.id) {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(d dueDelivery) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			send(d)
+		}(d)
+	}
+	wg.Wait()
+}
+
+// claim reserva a entrega adiando next_attempt_at (evita envio duplicado
+// por outro ciclo ou processo enquanto a requisição está em andamento)
+func claim(id int64) bool {
+	result, err := config.MasterDB.Exec(`
+		UPDATE webhook_outbox
+		SET next_attempt_at = NOW() + INTERVAL ? SECOND
+		WHERE id = ? AND status = ? AND next_attempt_at <= NOW()`,
+		claimSeconds, id, models.DeliveryPending)
+	if err != nil {
+		log.Printf("❌ Webhooks: erro ao reservar entrega %d: %v", id, err)
+		return false
+	}
+	n, _ := result.RowsAffected()
+	return n == 1
+}
+
+// send faz o POST assinado e registra o resultado
+func send(d dueDelivery) {
+	status, err := post(d)
+	if err != nil {
+		markFailed(d, status, err.Error())
+		return
+	}
+	markDelivered(d, status)
+}
+
+// post envia a entrega assinada. Respostas fora de 2xx são erro (com o status).
+func post(d dueDelivery) (int, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequest(http.MethodPost, d.url, bytes.NewReader(d.payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Delivery", strconv.FormatInt(d.id, 10))
+	req.Header.Set("X-Webhook-Event-ID", d.eventID)
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", "sha256="+Sign(d.secret, timestamp, d.payload))
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("status HTTP %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign calcula a assinatura HMAC-SHA256 de "timestamp.payload"
+func Sign(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func markDelivered(d dueDelivery, status int) {
+	_, err := config.MasterDB.Exec(`
+		UPDATE webhook_outbox
+		SET status = ?, attempts = attempts + 1, last_status = ?, last_error = NULL, delivered_at = NOW()
+		WHERE id = ?`, models.DeliveryDelivered, status, d.id)
+	if err != nil {
+		log.Printf("❌ Webhooks: erro ao marcar entrega %d: %v", d.id, err)
+	}
+}
+
+// markFailed agenda nova tentativa com backoff exponencial ou envia para a dead-letter
+func markFailed(d dueDelivery, status int, reason string) {
+	attempts := d.attempts + 1
+	if len(reason) > 1024 {
+		reason = reason[:1024]
+	}
+
+	var lastStatus interface{}
+	if status > 0 {
+		lastStatus = status
+	}
+
+	var err error
+	dead, wait := planRetry(attempts, config.EnvPositiveInt("WEBHOOK_MAX_ATTEMPTS", 8))
+	if dead {
+		_, err = config.MasterDB.Exec(`
+			UPDATE webhook_outbox
+			SET status = ?, attempts = ?, last_status = ?, last_error = ?
+			WHERE id = ?`, models.DeliveryDead, attempts, lastStatus, reason, d.id)
+		log.Printf("⚠️ Webhooks: entrega %d enviada para dead-letter após %d tentativas", d.id, attempts)
+	} else {
+		_, err = config.MasterDB.Exec(`
+			UPDATE webhook_outbox
+			SET attempts = ?, last_status = ?, last_error = ?, next_attempt_at = NOW() + INTERVAL ? SECOND
+			WHERE id = ?`, attempts, lastStatus, reason, int(wait.Seconds()), d.id)
+	}
+	if err != nil {
+		log.Printf("❌ Webhooks: erro ao registrar falha da entrega %d: %v", d.id, err)
+	}
+}
+
+// planRetry decide o destino de uma entrega após a falha de número attempts:
+// dead-letter ao atingir maxAttempts, senão nova tentativa após o backoff
+func planRetry(attempts, maxAttempts int) (dead bool, wait time.Duration) {
+	if attempts >= maxAttempts {
+		return true, 0
+	}
+	return false, backoff(attempts)
+}
+
+// backoff: 10s, 20s, 40s, ... até maxBackoff
+func backoff(attempts int) time.Duration {
+	delay := 10 * time.Second
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return delay
+}
@@ -0,0 +1,201 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"meu-provedor/models"
+)
+
+// useTestClient troca o cliente de entregas pelo do servidor de teste (o
+// cliente real recusa 127.0.0.1)
+func useTestClient(t *testing.T, srv *httptest.Server) {
+	t.Helper()
+	previous := httpClient
+	httpClient = srv.Client()
+	t.Cleanup(func() { httpClient = previous })
+}
+
+func TestPostSignsPayload(t *testing.T) {
+	const secret = "s3cr3t"
+	payload := []byte(`{"id":"evt-1","operation":"insert"}`)
+
+	var verified atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		timestamp := r.Header.Get("X-Webhook-Timestamp")
+
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(timestamp + "." + string(body)))
+		expected := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+		if r.Header.Get("X-Webhook-Signature") != expected {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get("X-Webhook-Event-ID") != "evt-1" || r.Header.Get("X-Webhook-Delivery") != "7" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		verified.Store(true)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+	useTestClient(t, srv)
+
+	status, err := post(dueDelivery{id: 7, eventID: "evt-1", payload: payload, url: srv.URL, secret: secret})
+	if err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	if status != http.StatusNoContent || !verified.Load() {
+		t.Fatalf("status %d, assinatura verificada = %v", status, verified.Load())
+	}
+
+	// Secret errado: o receptor recusa
+	status, err = post(dueDelivery{id: 7, eventID: "evt-1", payload: payload, url: srv.URL, secret: "outro"})
+	if err == nil || status != http.StatusUnauthorized {
+		t.Fatalf("esperava 401 com secret errado, veio status %d, err %v", status, err)
+	}
+}
+
+func TestRetryWithBackoffUntilDeadLetter(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	useTestClient(t, srv)
+
+	const maxAttempts = 5
+	d := dueDelivery{id: 1, eventID: "evt-2", payload: []byte(`{}`), url: srv.URL, secret: "x"}
+	expectedWaits := []time.Duration{10 * time.Second, 20 * time.Second, 40 * time.Second, 80 * time.Second}
+
+	for attempt := 1; ; attempt++ {
+		status, err := post(d)
+		if err == nil || status != http.StatusServiceUnavailable {
+			t.Fatalf("tentativa %d: esperava falha 503, veio status %d, err %v", attempt, status, err)
+		}
+
+		dead, wait := planRetry(attempt, maxAttempts)
+		if attempt == maxAttempts {
+			if !dead {
+				t.Fatalf("tentativa %d deveria ir para a dead-letter", attempt)
+			}
+			break
+		}
+		if dead {
+			t.Fatalf("tentativa %d foi para a dead-letter antes do limite", attempt)
+		}
+		if wait != expectedWaits[attempt-1] {
+			t.Fatalf("tentativa %d: backoff %v, esperado %v", attempt, wait, expectedWaits[attempt-1])
+		}
+	}
+	if calls.Load() != maxAttempts {
+		t.Fatalf("receptor chamado %d vezes, esperado %d", calls.Load(), maxAttempts)
+	}
+}
+
+func TestRetrySucceedsAfterTransientFailure(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+	useTestClient(t, srv)
+
+	d := dueDelivery{id: 2, eventID: "evt-3", payload: []byte(`{}`), url: srv.URL, secret: "x"}
+	for attempt := 1; attempt <= 3; attempt++ {
+		status, err := post(d)
+		if attempt < 3 {
+			if err == nil {
+				t.Fatalf("tentativa %d deveria falhar", attempt)
+			}
+			if dead, _ := planRetry(attempt, 8); dead {
+				t.Fatalf("tentativa %d não deveria ir para a dead-letter", attempt)
+			}
+			continue
+		}
+		if err != nil || status != http.StatusOK {
+			t.Fatalf("tentativa %d: status %d, err %v", attempt, status, err)
+		}
+	}
+}
+
+func TestBackoffIsCapped(t *testing.T) {
+	if got := backoff(30); got != maxBackoff {
+		t.Fatalf("backoff(30) = %v, esperado %v", got, maxBackoff)
+	}
+}
+
+func TestDeliveryClientRefusesInternalAddress(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("o receptor interno não deveria ser chamado")
+	}))
+	defer srv.Close()
+
+	// httpClient padrão: a checagem acontece na conexão
+	_, err := post(dueDelivery{id: 3, eventID: "evt-4", payload: []byte(`{}`), url: srv.URL, secret: "x"})
+	if err == nil || !strings.Contains(err.Error(), "bloqueado") {
+		t.Fatalf("esperava conexão bloqueada, veio %v", err)
+	}
+}
+
+func TestValidateURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want *models.AppError
+	}{
+		{"ftp://93.184.216.34/hook", models.ErrInvalidWebhookURL},
+		{"http:///sem-host", models.ErrInvalidWebhookURL},
+		{"http://127.0.0.1:8080/hook", models.ErrWebhookURLBlocked},
+		{"http://10.0.0.5/hook", models.ErrWebhookURLBlocked},
+		{"http://192.168.1.10/hook", models.ErrWebhookURLBlocked},
+		{"http://169.254.169.254/latest/meta-data", models.ErrWebhookURLBlocked},
+		{"http://[::1]/hook", models.ErrWebhookURLBlocked},
+		{"http://[fd00::1]/hook", models.ErrWebhookURLBlocked},
+		{"http://100.64.0.1/hook", models.ErrWebhookURLBlocked},
+		{"https://93.184.216.34/hook", nil},
+	}
+	for _, tt := range tests {
+		err := validateURL(tt.url)
+		if tt.want == nil {
+			if err != nil {
+				t.Errorf("%s: erro inesperado %v", tt.url, err)
+			}
+			continue
+		}
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: esperava %s, veio %v", tt.url, tt.want.Code, err)
+		}
+	}
+}
+
+func TestBlockedIP(t *testing.T) {
+	blocked := []string{"127.0.0.1", "10.1.2.3", "172.16.0.1", "192.168.0.1", "169.254.169.254",
+		"0.0.0.0", "::1", "fe80::1", "fc00::1", "::ffff:127.0.0.1", "224.0.0.1"}
+	for _, addr := range blocked {
+		if !blockedIP(net.ParseIP(addr)) {
+			t.Errorf("%s deveria ser bloqueado", addr)
+		}
+	}
+	for _, addr := range []string{"93.184.216.34", "2606:2800:220:1:248:1893:25c8:1946"} {
+		if blockedIP(net.ParseIP(addr)) {
+			t.Errorf("%s não deveria ser bloqueado", addr)
+		}
+	}
+}
@@ -0,0 +1,299 @@
+package webhook
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+
+	"meu-provedor/config"
+	"meu-provedor/engine/dialect"
+	"meu-provedor/engine/query"
+	"meu-provedor/models"
+)
+
+// ============================================================================
+// OUTBOX - Entregas persistidas antes do envio
+// ============================================================================
+//
+// A escrita de dados grava o evento em {code}__events, no banco do projeto e
+// na mesma transação: se os dados foram confirmados, o evento também foi. O
+// relay move os eventos para webhook_outbox (no banco master), de onde o
+// dispatcher faz os envios.
+
+// relayBatchSize é o máximo de eventos repassados por projeto e ciclo
+const relayBatchSize = 100
+
+var (
+	eventTablesMu sync.Mutex
+	// eventTables guarda, por pool, as tabelas de eventos já criadas
+	eventTables = map[*sql.DB]map[string]bool{}
+	// relayWake recebe os projetos com eventos novos neste processo
+	relayWake = make(chan int64, 64)
+)
+
+// EventTableName retorna a tabela de eventos pendentes do projeto. O prefixo
+// "__" não colide com tabelas do cliente (nomes não podem começar com "_").
+func EventTableName(projectCode string) string {
+	return projectCode + "__events"
+}
+
+// PrepareEvents indica se as escritas do projeto devem gravar o evento na
+// própria transação (há webhooks ativos) e garante a tabela de eventos no
+// banco do projeto. Deve ser chamada antes de abrir a transação: DDL no
+// MySQL confirma a transação em andamento.
+func PrepareEvents(db *sql.DB, projectID int64, projectCode string) (bool, error) {
+	hooks, err := activeWebhooks(projectID)
+	if err != nil {
+		return false, fmt.Errorf("erro ao carregar webhooks do projeto %d: %w", projectID, err)
+	}
+	if len(hooks) == 0 {
+		return false, nil
+	}
+	return true, ensureEventTable(db, projectCode)
+}
+
+// ensureEventTable cria (uma vez por pool) a tabela de eventos do projeto
+func ensureEventTable(db *sql.DB, projectCode string) error {
+	table := EventTableName(projectCode)
+
+	eventTablesMu.Lock()
+	ready := eventTables[db][table]
+	eventTablesMu.Unlock()
+	if ready {
+		return nil
+	}
+
+	d := config.DialectOf(db)
+	statements, err := d.CreateTable(table, true, []string{
+		d.AutoIncrementPK("id"),
+		"event_id VARCHAR(64) NOT NULL",
+		"payload " + d.JSONType() + " NOT NULL",
+		d.TimestampColumn("created_at", 0, false),
+	}, nil)
+	if err != nil {
+		return err
+	}
+	for _, stmt := range statements {
+		if _, err := db.Exec(stmt); err != nil {
+			return fmt.Errorf("erro ao criar tabela de eventos: %w", err)
+		}
+	}
+
+	eventTablesMu.Lock()
+	if eventTables[db] == nil {
+		eventTables[db] = map[string]bool{}
+	}
+	eventTables[db][table] = true
+	eventTablesMu.Unlock()
+	return nil
+}
+
+// relayProject copia os eventos pendentes do banco do projeto para
+// webhook_outbox (uma entrega por webhook compatível) e os remove da origem.
+// A entrega é única por (webhook, evento): um relay interrompido entre a
+// cópia e a remoção não duplica envios.
+func relayProject(projectID int64) error {
+	projectCode, err := config.GetProjectCodeByID(int(projectID))
+	if err != nil {
+		return err
+	}
+
+	// A remoção é uma escrita no banco do projeto: respeita o congelamento
+	done, err := config.BeginProjectWrite(projectID)
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	db, err := config.ProjectDB(projectID)
+	if err != nil {
+		return err
+	}
+	if err := ensureEventTable(db, projectCode); err != nil {
+		return err
+	}
+	table := EventTableName(projectCode)
+	d := config.DialectOf(db)
+
+	rows, err := db.Query(dialect.Rebind(d, fmt.Sprintf(
+		"SELECT id, event_id, payload FROM %s ORDER BY id%s", query.QuoteIdent(table), d.LimitOffset(relayBatchSize, 0))))
+	if err != nil {
+		return fmt.Errorf("erro ao ler eventos pendentes: %w", err)
+	}
+	type pendingEvent struct {
+		id      int64
+		eventID string
+		payload []byte
+	}
+	var pending []pendingEvent
+	for rows.Next() {
+		var e pendingEvent
+		if err := rows.Scan(&e.id, &e.eventID, &e.payload); err != nil {
+			rows.Close()
+			return err
+		}
+		pending = append(pending, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil || len(pending) == 0 {
+		return err
+	}
+
+	hooks, err := activeWebhooks(projectID)
+	if err != nil {
+		return err
+	}
+	if err := config.MarkTableWrite(projectID, table); err != nil {
+		return err
+	}
+	defer config.MarkTableWrite(projectID, table)
+
+	deleteSQL := dialect.Rebind(d, fmt.Sprintf("DELETE FROM %s WHERE id = ?", query.QuoteIdent(table)))
+	for _, e := range pending {
+		var event models.ChangeEvent
+		if err := json.Unmarshal(e.payload, &event); err != nil {
+			log.Printf("❌ Webhooks: evento %s com payload inválido descartado: %v", e.eventID, err)
+		} else {
+			for _, hook := range hooks {
+				if !matches(hook, event) {
+					continue
+				}
+				_, err := config.MasterDB.Exec(`
+					INSERT IGNORE INTO webhook_outbox (webhook_id, project_id, event_id, payload)
+					VALUES (?, ?, ?, ?)`,
+					hook.ID, projectID, e.eventID, string(e.payload),
+				)
+				if err != nil {
+					return fmt.Errorf("erro ao gravar entrega do evento %s: %w", e.eventID, err)
+				}
+			}
+		}
+		if _, err := db.Exec(deleteSQL, e.id); err != nil {
+			return fmt.Errorf("erro ao remover evento %s: %w", e.eventID, err)
+		}
+	}
+	return nil
+}
+
+// relayAll percorre os projetos com webhooks cadastrados
+func relayAll() {
+	rows, err := config.MasterDB.Query(`SELECT DISTINCT project_id FROM project_webhooks`)
+	if err != nil {
+		log.Printf("❌ Webhooks: erro ao listar projetos: %v", err)
+		return
+	}
+	var projects []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err == nil {
+			projects = append(projects, id)
+		}
+	}
+	rows.Close()
+
+	for _, projectID := range projects {
+		if err := relayProject(projectID); err != nil {
+			log.Printf("❌ Webhooks: erro ao repassar eventos do projeto %d: %v", projectID, err)
+		}
+	}
+}
+
+// wakeRelay antecipa o relay do projeto que acabou de gravar um evento
+// (roda no fluxo da requisição: nunca bloqueia)
+func wakeRelay(event models.ChangeEvent) {
+	select {
+	case relayWake <- event.ProjectID:
+	default:
+	}
+}
+
+// ListDeliveries lista as entregas de um webhook (status vazio = todas;
+// "dead" é a lista de entregas que esgotaram as tentativas)
+func ListDeliveries(projectID, webhookID int64, status string, limit, offset int) ([]models.WebhookDelivery, error) {
+	if err := checkOwnership(projectID, webhookID); err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+
+	query := `
+		SELECT id, webhook_id, event_id, payload, status, attempts, last_status, last_error,
+			next_attempt_at, created_at, delivered_at
+		FROM webhook_outbox
+		WHERE webhook_id = ?`
+	args := []interface{}{webhookID}
+	if status != "" {
+		query += " AND status = ?"
+		args = append(args, status)
+	}
+	query += " ORDER BY id DESC LIMIT ? OFFSET ?"
+	args = append(args, limit, offset)
+
+	rows, err := config.MasterDB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []models.WebhookDelivery{}
+	for rows.Next() {
+		var d models.WebhookDelivery
+		var payload string
+		var lastStatus sql.NullInt64
+		var lastError sql.NullString
+		var deliveredAt sql.NullTime
+
+		if err := rows.Scan(&d.ID, &d.WebhookID, &d.EventID, &payload, &d.Status, &d.Attempts,
+			&lastStatus, &lastError, &d.NextAttemptAt, &d.CreatedAt, &deliveredAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(payload), &d.Event); err != nil {
+			return nil, fmt.Errorf("entrega %d com payload inválido: %w", d.ID, err)
+		}
+		d.LastStatus = int(lastStatus.Int64)
+		d.LastError = lastError.String
+		if deliveredAt.Valid {
+			d.DeliveredAt = &deliveredAt.Time
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+// RetryDelivery recoloca uma entrega (normalmente da dead-letter) na fila
+func RetryDelivery(projectID, webhookID, deliveryID int64) error {
+	if err := checkOwnership(projectID, webhookID); err != nil {
+		return err
+	}
+
+	result, err := config.MasterDB.Exec(`
+		UPDATE webhook_outbox
+		SET status = ?, attempts = 0, next_attempt_at = NOW(), last_error = NULL
+		WHERE id = ? AND webhook_id = ? AND status <> ?`,
+		models.DeliveryPending, deliveryID, webhookID, models.DeliveryDelivered,
+	)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return models.ErrDeliveryNotFound
+	}
+	return nil
+}
+
+func checkOwnership(projectID, webhookID int64) error {
+	var count int
+	err := config.MasterDB.QueryRow(
+		`SELECT COUNT(*) FROM project_webhooks WHERE id = ? AND project_id = ?`, webhookID, projectID,
+	).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return models.ErrWebhookNotFound
+	}
+	return nil
+}
@@ -0,0 +1,279 @@
+package webhook
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"meu-provedor/config"
+	"meu-provedor/models"
+)
+
+// ============================================================================
+// WEBHOOK SERVICE - Cadastro de webhooks por projeto
+// ============================================================================
+
+const webhooksCacheTTL = 30 * time.Second
+
+type webhooksEntry struct {
+	hooks  []models.Webhook
+	loaded time.Time
+}
+
+var (
+	webhooksMu    sync.RWMutex
+	webhooksCache = map[int64]webhooksEntry{}
+)
+
+// EnsureWebhookTables garante que as tabelas project_webhooks e webhook_outbox existem
+func EnsureWebhookTables() error {
+	_, err := config.MasterDB.Exec(`
+		CREATE TABLE IF NOT EXISTS project_webhooks (
+			id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+			project_id BIGINT UNSIGNED NOT NULL,
+			url VARCHAR(2048) NOT NULL,
+			secret VARCHAR(128) NOT NULL,
+			tables JSON NULL,
+			operations JSON NULL,
+			id_instancia BIGINT UNSIGNED NULL,
+			active TINYINT(1) NOT NULL DEFAULT 1,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			INDEX idx_webhooks_project (project_id)
+		)`)
+	if err != nil {
+		return fmt.Errorf("erro ao criar tabela project_webhooks: %w", err)
+	}
+
+	_, err = config.MasterDB.Exec(`
+		CREATE TABLE IF NOT EXISTS webhook_outbox (
+			id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+			webhook_id BIGINT UNSIGNED NOT NULL,
+			project_id BIGINT UNSIGNED NOT NULL,
+			event_id VARCHAR(64) NOT NULL,
+			payload JSON NOT NULL,
+			status VARCHAR(16) NOT NULL DEFAULT 'pending',
+			attempts INT NOT NULL DEFAULT 0,
+			last_status INT NULL,
+			last_error VARCHAR(1024) NULL,
+			next_attempt_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			delivered_at DATETIME NULL,
+			UNIQUE KEY uq_outbox_event (webhook_id, event_id),
+			INDEX idx_outbox_due (status, next_attempt_at),
+			INDEX idx_outbox_webhook (webhook_id, status)
+		)`)
+	if err != nil {
+		return fmt.Errorf("erro ao criar tabela webhook_outbox: %w", err)
+	}
+	return nil
+}
+
+// Create cadastra um webhook. O secret (gerado se ausente) só é retornado aqui.
+func Create(projectID int64, req models.WebhookRequest) (*models.Webhook, error) {
+	if projectID <= 0 {
+		return nil, models.ErrInvalidProjectID
+	}
+	if err := validateURL(req.URL); err != nil {
+		return nil, err
+	}
+	for _, op := range req.Operations {
+		if !isKnownOperation(op) {
+			return nil, models.NewValidationError("operação inválida: " + op)
+		}
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			return nil, fmt.Errorf("erro ao gerar secret: %w", err)
+		}
+		secret = hex.EncodeToString(b)
+	}
+
+	result, err := config.MasterDB.Exec(`
+		INSERT INTO project_webhooks (project_id, url, secret, tables, operations, id_instancia)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		projectID, req.URL, secret, jsonList(req.Tables), jsonList(req.Operations), req.InstanceID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	forgetWebhooks(projectID)
+
+	return &models.Webhook{
+		ID:         id,
+		ProjectID:  projectID,
+		URL:        req.URL,
+		Secret:     secret,
+		Tables:     req.Tables,
+		Operations: req.Operations,
+		InstanceID: req.InstanceID,
+		Active:     true,
+		CreatedAt:  time.Now(),
+	}, nil
+}
+
+// List retorna os webhooks do projeto (sem o secret)
+func List(projectID int64) ([]models.Webhook, error) {
+	rows, err := config.MasterDB.Query(`
+		SELECT id, project_id, url, tables, operations, id_instancia, active, created_at
+		FROM project_webhooks
+		WHERE project_id = ?
+		ORDER BY id`, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hooks := []models.Webhook{}
+	for rows.Next() {
+		var hook models.Webhook
+		var tables, operations sql.NullString
+		var instanceID sql.NullInt64
+
+		if err := rows.Scan(&hook.ID, &hook.ProjectID, &hook.URL, &tables, &operations,
+			&instanceID, &hook.Active, &hook.CreatedAt); err != nil {
+			return nil, err
+		}
+		hook.Tables = parseList(tables)
+		hook.Operations = parseList(operations)
+		if instanceID.Valid {
+			hook.InstanceID = &instanceID.Int64
+		}
+		hooks = append(hooks, hook)
+	}
+	return hooks, rows.Err()
+}
+
+// Delete remove o webhook e as entregas ainda pendentes
+func Delete(projectID, webhookID int64) error {
+	result, err := config.MasterDB.Exec(
+		`DELETE FROM project_webhooks WHERE id = ? AND project_id = ?`, webhookID, projectID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return models.ErrWebhookNotFound
+	}
+
+	_, err = config.MasterDB.Exec(
+		`DELETE FROM webhook_outbox WHERE webhook_id = ? AND status = ?`, webhookID, models.DeliveryPending)
+	forgetWebhooks(projectID)
+	return err
+}
+
+// ============================================================================
+// MATCHING
+// ============================================================================
+
+// activeWebhooks retorna os webhooks ativos do projeto (com secret), via cache
+func activeWebhooks(projectID int64) ([]models.Webhook, error) {
+	webhooksMu.RLock()
+	entry, ok := webhooksCache[projectID]
+	webhooksMu.RUnlock()
+
+	if ok && time.Since(entry.loaded) < webhooksCacheTTL {
+		return entry.hooks, nil
+	}
+
+	rows, err := config.MasterDB.Query(`
+		SELECT id, url, secret, tables, operations, id_instancia
+		FROM project_webhooks
+		WHERE project_id = ? AND active = 1`, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hooks []models.Webhook
+	for rows.Next() {
+		hook := models.Webhook{ProjectID: projectID, Active: true}
+		var tables, operations sql.NullString
+		var instanceID sql.NullInt64
+
+		if err := rows.Scan(&hook.ID, &hook.URL, &hook.Secret, &tables, &operations, &instanceID); err != nil {
+			return nil, err
+		}
+		hook.Tables = parseList(tables)
+		hook.Operations = parseList(operations)
+		if instanceID.Valid {
+			hook.InstanceID = &instanceID.Int64
+		}
+		hooks = append(hooks, hook)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	webhooksMu.Lock()
+	webhooksCache[projectID] = webhooksEntry{hooks: hooks, loaded: time.Now()}
+	webhooksMu.Unlock()
+
+	return hooks, nil
+}
+
+func forgetWebhooks(projectID int64) {
+	webhooksMu.Lock()
+	delete(webhooksCache, projectID)
+	webhooksMu.Unlock()
+}
+
+// matches aplica os filtros de tabela, operação e instância do webhook
+func matches(hook models.Webhook, event models.ChangeEvent) bool {
+	if hook.InstanceID != nil && *hook.InstanceID != event.InstanceID {
+		return false
+	}
+	return containsOrEmpty(hook.Tables, event.Table) && containsOrEmpty(hook.Operations, event.Operation)
+}
+
+// ============================================================================
+// INTERNAL HELPERS
+// ============================================================================
+
+func isKnownOperation(op string) bool {
+	switch op {
+	case models.HistoryInsert, models.HistoryUpdate, models.HistoryDelete,
+		models.HistorySoftDelete, models.HistoryRestore:
+		return true
+	}
+	return false
+}
+
+func containsOrEmpty(list []string, value string) bool {
+	if len(list) == 0 {
+		return true
+	}
+	for _, item := range list {
+		if strings.EqualFold(item, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func jsonList(list []string) interface{} {
+	if len(list) == 0 {
+		return nil
+	}
+	encoded, _ := json.Marshal(list)
+	return string(encoded)
+}
+
+func parseList(value sql.NullString) []string {
+	if !value.Valid || value.String == "" {
+		return nil
+	}
+	var list []string
+	json.Unmarshal([]byte(value.String), &list)
+	return list
+}